@@ -122,6 +122,7 @@ func TestCreate(t *testing.T) {
 			delete(getJSON, "status")
 			delete(getJSON, "uid")
 			delete(getJSON, "updatedAt")
+			delete(getJSON, "version")
 
 			outData, _ := os.ReadFile(tc.out)
 
@@ -144,7 +145,7 @@ func TestCreatePaperWithImages(t *testing.T) {
 		"Plain": {
 			urlFormat: "%s/lpas/%s",
 			pathRe: func(lpaUID string, filename string) string {
-				return fmt.Sprintf("^%s/scans/%s.png$", lpaUID, filename)
+				return fmt.Sprintf("^%s/scans/[0-9a-f-]{36}/%s.png$", lpaUID, filename)
 			},
 		},
 		"Presigned": {
@@ -155,7 +156,7 @@ func TestCreatePaperWithImages(t *testing.T) {
 					hostBucket = "https://s3.eu-west-1.amazonaws.com/[a-z0-9\\-]+/"
 				}
 
-				return hostBucket + lpaUID + "/scans/" + filename + ".png\\?X-Amz-Algorithm=AWS4-HMAC-SHA256&.+$"
+				return hostBucket + lpaUID + "/scans/[0-9a-f-]{36}/" + filename + ".png\\?X-Amz-Algorithm=AWS4-HMAC-SHA256&.+$"
 			},
 		},
 	}
@@ -192,6 +193,7 @@ func TestCreatePaperWithImages(t *testing.T) {
 			delete(getJSON, "status")
 			delete(getJSON, "uid")
 			delete(getJSON, "updatedAt")
+			delete(getJSON, "version")
 			delete(getJSON, "restrictionsAndConditionsImages")
 			delete(getJSON, "howAttorneysMakeDecisionsDetailsImages")
 
@@ -280,7 +282,7 @@ func TestGetListWithImages(t *testing.T) {
 		"Plain": {
 			urlFormat: "%s/lpas",
 			pathRe: func(lpaUID string, filename string) string {
-				return fmt.Sprintf("^%s/scans/%s.png$", lpaUID, filename)
+				return fmt.Sprintf("^%s/scans/[0-9a-f-]{36}/%s.png$", lpaUID, filename)
 			},
 		},
 		"Presigned": {
@@ -291,7 +293,7 @@ func TestGetListWithImages(t *testing.T) {
 					hostBucket = "https://s3.eu-west-1.amazonaws.com/[a-z0-9\\-]+/"
 				}
 
-				return hostBucket + lpaUID + "/scans/" + filename + ".png\\?X-Amz-Algorithm=AWS4-HMAC-SHA256&.+$"
+				return hostBucket + lpaUID + "/scans/[0-9a-f-]{36}/" + filename + ".png\\?X-Amz-Algorithm=AWS4-HMAC-SHA256&.+$"
 			},
 		},
 	}
@@ -473,6 +475,14 @@ func TestUpdatesEnd(t *testing.T) {
 			path:      "docs/donor-withdraw-lpa.json",
 			authorUID: authorUID,
 		},
+		"DonorDeceased": {
+			path:      "docs/donor-deceased.json",
+			authorUID: authorUID,
+		},
+		"AttorneyDeceased": {
+			path:      "docs/attorney-deceased.json",
+			authorUID: authorUID,
+		},
 	}
 
 	for scenario, tc := range testcases {
@@ -0,0 +1,102 @@
+package ddb
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/tracing"
+)
+
+// Backfill writes the actor mappings and searchKey attribute of each LPA in
+// the deeds table. These are otherwise only written when an LPA is changed, so
+// an LPA saved before they were introduced cannot be found by actor or by
+// donor until it is next changed. It returns the number of LPAs backfilled.
+//
+// It is safe to run more than once, and while LPAs are being changed.
+func (c *Client) Backfill(ctx context.Context) (count int, err error) {
+	ctx, span := tracing.StartSpan(ctx, "ddb.Backfill")
+	defer tracing.EndSpan(span, &err)
+
+	input := &dynamodb.ScanInput{
+		TableName: aws.String(c.tableName),
+	}
+
+	for {
+		output, err := c.svc.Scan(ctx, input)
+		if err != nil {
+			return count, err
+		}
+
+		var lpas []shared.Lpa
+		if err := attributevalue.UnmarshalListOfMapsWithOptions(output.Items, &lpas, decoderOptions); err != nil {
+			return count, err
+		}
+
+		for _, lpa := range lpas {
+			if err := c.backfillLpa(ctx, lpa); err != nil {
+				return count, err
+			}
+			count++
+		}
+
+		if len(output.LastEvaluatedKey) == 0 {
+			return count, nil
+		}
+		input.ExclusiveStartKey = output.LastEvaluatedKey
+	}
+}
+
+func (c *Client) backfillLpa(ctx context.Context, lpa shared.Lpa) error {
+	for _, actorUID := range lpa.ActorUIDs() {
+		item, _ := attributevalue.MarshalMap(actorItem{ActorUid: actorUID, LpaUid: lpa.Uid})
+
+		if _, err := c.svc.PutItem(ctx, &dynamodb.PutItemInput{
+			TableName: aws.String(c.actorsTableName),
+			Item:      item,
+		}); err != nil {
+			return err
+		}
+	}
+
+	searchKey := lpa.SearchKey()
+	if searchKey == "" {
+		return nil
+	}
+
+	// a change made since the LPA was read will have written the searchKey, and
+	// possibly a different one, so it is left alone
+	condition := expression.Name("version").Equal(expression.Value(lpa.Version))
+	if lpa.Version == 0 {
+		condition = expression.Name("version").AttributeNotExists()
+	}
+
+	expr, err := expression.NewBuilder().
+		WithUpdate(expression.Set(expression.Name("searchKey"), expression.Value(searchKey))).
+		WithCondition(condition).
+		Build()
+	if err != nil {
+		return err
+	}
+
+	_, err = c.svc.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(c.tableName),
+		Key:                       map[string]types.AttributeValue{"uid": &types.AttributeValueMemberS{Value: lpa.Uid}},
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	})
+
+	var conditionFailed *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionFailed) {
+		return nil
+	}
+
+	return err
+}
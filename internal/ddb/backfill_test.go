@@ -0,0 +1,183 @@
+package ddb
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+	"github.com/stretchr/testify/assert"
+	mock "github.com/stretchr/testify/mock"
+)
+
+func backfillLpa(uid string, version int) shared.Lpa {
+	lpa := shared.Lpa{Uid: uid, Version: version}
+	lpa.Donor.UID = "a-donor"
+	lpa.Donor.LastName = "Smith"
+	lpa.Donor.DateOfBirth = newDate("1990-01-02")
+	lpa.Donor.Address.Postcode = "B14 7ED"
+	lpa.CertificateProvider.UID = "a-certificate-provider"
+
+	return lpa
+}
+
+func newDate(s string) shared.Date {
+	d := shared.Date{}
+	_ = d.UnmarshalText([]byte(s))
+	return d
+}
+
+func actorPut(actorUID, lpaUID string) *dynamodb.PutItemInput {
+	item, _ := attributevalue.MarshalMap(actorItem{ActorUid: actorUID, LpaUid: lpaUID})
+
+	return &dynamodb.PutItemInput{TableName: aws.String(actorsTableName), Item: item}
+}
+
+func TestClientBackfill(t *testing.T) {
+	first := backfillLpa("M-1111-1111-1111", 3)
+	second := backfillLpa("M-2222-2222-2222", 0)
+	second.Donor.Address.Postcode = ""
+
+	firstItem, _ := marshalLpa(first)
+	secondItem, _ := marshalLpa(second)
+	lastKey := map[string]types.AttributeValue{"uid": &types.AttributeValueMemberS{Value: first.Uid}}
+
+	dynamodbClient := newMockDynamodbClient(t)
+	dynamodbClient.EXPECT().
+		Scan(spanCtx, &dynamodb.ScanInput{TableName: aws.String(tableName)}).
+		Return(&dynamodb.ScanOutput{Items: []map[string]types.AttributeValue{firstItem}, LastEvaluatedKey: lastKey}, nil).
+		Once()
+	dynamodbClient.EXPECT().
+		Scan(spanCtx, &dynamodb.ScanInput{TableName: aws.String(tableName), ExclusiveStartKey: lastKey}).
+		Return(&dynamodb.ScanOutput{Items: []map[string]types.AttributeValue{secondItem}}, nil).
+		Once()
+	dynamodbClient.EXPECT().
+		PutItem(spanCtx, actorPut("a-donor", first.Uid)).
+		Return(&dynamodb.PutItemOutput{}, nil)
+	dynamodbClient.EXPECT().
+		PutItem(spanCtx, actorPut("a-certificate-provider", first.Uid)).
+		Return(&dynamodb.PutItemOutput{}, nil)
+	dynamodbClient.EXPECT().
+		UpdateItem(spanCtx, mock.MatchedBy(func(input *dynamodb.UpdateItemInput) bool {
+			return assert.Equal(t, tableName, *input.TableName) &&
+				assert.Equal(t, map[string]types.AttributeValue{"uid": &types.AttributeValueMemberS{Value: first.Uid}}, input.Key) &&
+				assert.Equal(t, "SET #1 = :1\n", *input.UpdateExpression) &&
+				assert.Equal(t, "#0 = :0", *input.ConditionExpression) &&
+				assert.Equal(t, map[string]string{"#0": "version", "#1": "searchKey"}, input.ExpressionAttributeNames) &&
+				assert.Equal(t, map[string]types.AttributeValue{
+					":0": &types.AttributeValueMemberN{Value: "3"},
+					":1": &types.AttributeValueMemberS{Value: first.SearchKey()},
+				}, input.ExpressionAttributeValues)
+		})).
+		Return(&dynamodb.UpdateItemOutput{}, nil)
+	dynamodbClient.EXPECT().
+		PutItem(spanCtx, actorPut("a-donor", second.Uid)).
+		Return(&dynamodb.PutItemOutput{}, nil)
+	dynamodbClient.EXPECT().
+		PutItem(spanCtx, actorPut("a-certificate-provider", second.Uid)).
+		Return(&dynamodb.PutItemOutput{}, nil)
+
+	client := &Client{svc: dynamodbClient, tableName: tableName, actorsTableName: actorsTableName}
+
+	count, err := client.Backfill(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func TestClientBackfillWhenUnversioned(t *testing.T) {
+	lpa := backfillLpa("M-1111-1111-1111", 0)
+	item, _ := marshalLpa(lpa)
+
+	dynamodbClient := newMockDynamodbClient(t)
+	dynamodbClient.EXPECT().
+		Scan(spanCtx, mock.Anything).
+		Return(&dynamodb.ScanOutput{Items: []map[string]types.AttributeValue{item}}, nil)
+	dynamodbClient.EXPECT().
+		PutItem(spanCtx, mock.Anything).
+		Return(&dynamodb.PutItemOutput{}, nil)
+	dynamodbClient.EXPECT().
+		UpdateItem(spanCtx, mock.MatchedBy(func(input *dynamodb.UpdateItemInput) bool {
+			return assert.Equal(t, "attribute_not_exists (#0)", *input.ConditionExpression) &&
+				assert.Equal(t, "version", input.ExpressionAttributeNames["#0"])
+		})).
+		Return(&dynamodb.UpdateItemOutput{}, nil)
+
+	client := &Client{svc: dynamodbClient, tableName: tableName, actorsTableName: actorsTableName}
+
+	count, err := client.Backfill(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestClientBackfillWhenChangedSinceRead(t *testing.T) {
+	item, _ := marshalLpa(backfillLpa("M-1111-1111-1111", 3))
+
+	dynamodbClient := newMockDynamodbClient(t)
+	dynamodbClient.EXPECT().
+		Scan(spanCtx, mock.Anything).
+		Return(&dynamodb.ScanOutput{Items: []map[string]types.AttributeValue{item}}, nil)
+	dynamodbClient.EXPECT().
+		PutItem(spanCtx, mock.Anything).
+		Return(&dynamodb.PutItemOutput{}, nil)
+	dynamodbClient.EXPECT().
+		UpdateItem(spanCtx, mock.Anything).
+		Return(nil, &types.ConditionalCheckFailedException{})
+
+	client := &Client{svc: dynamodbClient, tableName: tableName, actorsTableName: actorsTableName}
+
+	count, err := client.Backfill(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestClientBackfillWhenScanErrors(t *testing.T) {
+	dynamodbClient := newMockDynamodbClient(t)
+	dynamodbClient.EXPECT().
+		Scan(spanCtx, mock.Anything).
+		Return(nil, errExpected)
+
+	client := &Client{svc: dynamodbClient, tableName: tableName}
+
+	_, err := client.Backfill(ctx)
+	assert.Equal(t, errExpected, err)
+}
+
+func TestClientBackfillWhenPutItemErrors(t *testing.T) {
+	item, _ := marshalLpa(backfillLpa("M-1111-1111-1111", 3))
+
+	dynamodbClient := newMockDynamodbClient(t)
+	dynamodbClient.EXPECT().
+		Scan(spanCtx, mock.Anything).
+		Return(&dynamodb.ScanOutput{Items: []map[string]types.AttributeValue{item}}, nil)
+	dynamodbClient.EXPECT().
+		PutItem(spanCtx, mock.Anything).
+		Return(nil, errExpected)
+
+	client := &Client{svc: dynamodbClient, tableName: tableName, actorsTableName: actorsTableName}
+
+	count, err := client.Backfill(ctx)
+	assert.Equal(t, errExpected, err)
+	assert.Equal(t, 0, count)
+}
+
+func TestClientBackfillWhenUpdateItemErrors(t *testing.T) {
+	item, _ := marshalLpa(backfillLpa("M-1111-1111-1111", 3))
+
+	dynamodbClient := newMockDynamodbClient(t)
+	dynamodbClient.EXPECT().
+		Scan(spanCtx, mock.Anything).
+		Return(&dynamodb.ScanOutput{Items: []map[string]types.AttributeValue{item}}, nil)
+	dynamodbClient.EXPECT().
+		PutItem(spanCtx, mock.Anything).
+		Return(&dynamodb.PutItemOutput{}, nil)
+	dynamodbClient.EXPECT().
+		UpdateItem(spanCtx, mock.Anything).
+		Return(nil, errExpected)
+
+	client := &Client{svc: dynamodbClient, tableName: tableName, actorsTableName: actorsTableName}
+
+	_, err := client.Backfill(ctx)
+	assert.Equal(t, errExpected, err)
+}
@@ -2,21 +2,58 @@ package ddb
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/event"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/idempotency"
 	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/tracing"
+	"golang.org/x/sync/errgroup"
 )
 
+const (
+	// batchGetLimit is the maximum number of keys DynamoDB accepts in a single
+	// BatchGetItem request
+	batchGetLimit       = 100
+	batchGetConcurrency = 4
+	batchGetMaxAttempts = 5
+	batchGetBackoff     = 50 * time.Millisecond
+
+	// searchIndexName is the deeds table index on searchKey
+	searchIndexName = "searchKey"
+
+	// maxTransactItems is the maximum number of items DynamoDB accepts in a
+	// single TransactWriteItems request
+	maxTransactItems = 100
+)
+
+// ErrConditionFailed is returned when a write is rejected because the stored
+// item is not in the expected state, for example because another request has
+// changed the LPA since it was read.
+var ErrConditionFailed = errors.New("condition failed")
+
+// ErrTooManyItems is returned, and nothing is written, when a write would need
+// more items than DynamoDB accepts in a single transaction.
+var ErrTooManyItems = errors.New("too many items for one transaction")
+
 type dynamodbClient interface {
 	TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error)
 	PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
 	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
 	BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error)
 	Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+	Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
+	DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
 }
 
 type QueryPaginator interface {
@@ -51,34 +88,68 @@ func (f *awsPaginatorFactory) NewQueryPaginator(input *dynamodb.QueryInput) Quer
 }
 
 type Client struct {
-	svc              dynamodbClient
-	tableName        string
-	changesTableName string
-	paginatorFactory PaginatorFactory
+	svc                  dynamodbClient
+	tableName            string
+	changesTableName     string
+	actorsTableName      string
+	idempotencyTableName string
+	outboxTableName      string
+	region               string
+	paginatorFactory     PaginatorFactory
 }
 
-func New(cfg aws.Config, tableName, changesTableName string) *Client {
+func New(cfg aws.Config, tableName, changesTableName, actorsTableName, idempotencyTableName, outboxTableName string) *Client {
 	svc := dynamodb.NewFromConfig(cfg)
 
 	return &Client{
-		svc:              svc,
-		tableName:        tableName,
-		changesTableName: changesTableName,
-		paginatorFactory: &awsPaginatorFactory{svc: svc},
+		svc:                  svc,
+		tableName:            tableName,
+		changesTableName:     changesTableName,
+		actorsTableName:      actorsTableName,
+		idempotencyTableName: idempotencyTableName,
+		outboxTableName:      outboxTableName,
+		region:               cfg.Region,
+		paginatorFactory:     &awsPaginatorFactory{svc: svc},
 	}
 }
 
-func (c *Client) PutChanges(ctx context.Context, data any, update shared.Update) error {
-	changesItem, _ := attributevalue.MarshalMap(map[string]interface{}{
-		"id":      update.Id,
-		"uid":     update.Uid,
-		"applied": update.Applied,
-		"author":  update.Author,
-		"type":    update.Type,
-		"changes": update.Changes,
-	})
+// PutChanges writes lpa to the deeds table and records each of updates in the
+// changes table. The write only succeeds if the stored LPA is still at the version
+// immediately before lpa.Version, otherwise ErrConditionFailed is returned.
+//
+// The actors table is updated to map each actor on lpa to it, and to remove
+// the mappings for any of previousActorUIDs that are no longer on it.
+//
+// If record is not nil it is saved in the same transaction, and
+// ErrConditionFailed is returned if a live record with its key already exists.
+//
+// Each of outbox is written to the outbox table in the same transaction, so
+// that the events are only sent if the changes are saved.
+//
+// The transaction holds the LPA, an item for each update, an item for each
+// actor mapping written or removed, an item for each outbox entry, and the
+// record. If that is more than DynamoDB allows then ErrTooManyItems is returned.
+func (c *Client) PutChanges(ctx context.Context, lpa shared.Lpa, updates []shared.Update, previousActorUIDs []string, record *idempotency.Record, outbox []event.OutboxEntry) (err error) {
+	updateTypes := make([]string, len(updates))
+	for i, update := range updates {
+		updateTypes[i] = update.Type
+	}
+
+	ctx, span := tracing.StartSpan(ctx, "ddb.PutChanges", tracing.LpaUID(lpa.Uid), tracing.UpdateTypes(updateTypes...))
+	defer tracing.EndSpan(span, &err)
 
-	item, err := attributevalue.MarshalMapWithOptions(data, encoderOptions)
+	item, err := marshalLpa(lpa)
+	if err != nil {
+		return err
+	}
+
+	// LPAs written before versioning was introduced have no version attribute
+	condition := expression.Name("version").Equal(expression.Value(lpa.Version - 1))
+	if lpa.Version <= 1 {
+		condition = expression.Name("version").AttributeNotExists().Or(condition)
+	}
+
+	expr, err := expression.NewBuilder().WithCondition(condition).Build()
 	if err != nil {
 		return err
 	}
@@ -88,54 +159,167 @@ func (c *Client) PutChanges(ctx context.Context, data any, update shared.Update)
 			// write the LPA to the deeds table
 			{
 				Put: &types.Put{
-					TableName: aws.String(c.tableName),
-					Item:      item,
+					TableName:                 aws.String(c.tableName),
+					Item:                      item,
+					ConditionExpression:       expr.Condition(),
+					ExpressionAttributeNames:  expr.Names(),
+					ExpressionAttributeValues: expr.Values(),
 				},
 			},
+		},
+	}
 
-			// record the change
-			{
-				Put: &types.Put{
-					TableName: aws.String(c.changesTableName),
-					Item:      changesItem,
-				},
+	// record the changes
+	for _, update := range updates {
+		transactInput.TransactItems = append(transactInput.TransactItems, types.TransactWriteItem{
+			Put: &types.Put{
+				TableName: aws.String(c.changesTableName),
+				Item:      marshalUpdate(update),
 			},
-		},
+		})
+	}
+
+	transactInput.TransactItems = append(transactInput.TransactItems, c.actorItems(lpa, previousActorUIDs)...)
+
+	for _, entry := range outbox {
+		item, err := c.outboxItem(entry)
+		if err != nil {
+			return err
+		}
+
+		transactInput.TransactItems = append(transactInput.TransactItems, item)
+	}
+
+	if record != nil {
+		item, err := c.idempotencyItem(*record)
+		if err != nil {
+			return err
+		}
+
+		transactInput.TransactItems = append(transactInput.TransactItems, item)
+	}
+
+	if len(transactInput.TransactItems) > maxTransactItems {
+		return ErrTooManyItems
 	}
 
 	_, err = c.svc.TransactWriteItems(ctx, transactInput)
+	if isConditionalCheckFailed(err) {
+		return ErrConditionFailed
+	}
 
 	return err
 }
 
-func (c *Client) Put(ctx context.Context, data any) error {
-	item, err := attributevalue.MarshalMapWithOptions(data, encoderOptions)
+// Create writes lpa to the deeds table and records update as its first change.
+// If an LPA with the same UID already exists then nothing is written and
+// ErrConditionFailed is returned. A non-nil record, and the outbox entry, are
+// saved as in PutChanges, and ErrTooManyItems is returned when the transaction
+// would be too large in the same way.
+func (c *Client) Create(ctx context.Context, lpa shared.Lpa, update shared.Update, record *idempotency.Record, outbox event.OutboxEntry) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "ddb.Create", tracing.LpaUID(lpa.Uid), tracing.UpdateTypes(update.Type))
+	defer tracing.EndSpan(span, &err)
+
+	changesItem := marshalUpdate(update)
+
+	item, err := marshalLpa(lpa)
 	if err != nil {
 		return err
 	}
 
-	_, err = c.svc.PutItem(ctx, &dynamodb.PutItemInput{
-		TableName: aws.String(c.tableName),
-		Item:      item,
+	expr, err := expression.NewBuilder().
+		WithCondition(expression.Name("uid").AttributeNotExists()).
+		Build()
+	if err != nil {
+		return err
+	}
+
+	transactItems := []types.TransactWriteItem{
+		// write the LPA to the deeds table, unless it already exists
+		{
+			Put: &types.Put{
+				TableName:                aws.String(c.tableName),
+				Item:                     item,
+				ConditionExpression:      expr.Condition(),
+				ExpressionAttributeNames: expr.Names(),
+			},
+		},
+
+		// record the creation as the first change
+		{
+			Put: &types.Put{
+				TableName: aws.String(c.changesTableName),
+				Item:      changesItem,
+			},
+		},
+	}
+
+	transactItems = append(transactItems, c.actorItems(lpa, nil)...)
+
+	outboxItem, err := c.outboxItem(outbox)
+	if err != nil {
+		return err
+	}
+
+	transactItems = append(transactItems, outboxItem)
+
+	if record != nil {
+		item, err := c.idempotencyItem(*record)
+		if err != nil {
+			return err
+		}
+
+		transactItems = append(transactItems, item)
+	}
+
+	if len(transactItems) > maxTransactItems {
+		return ErrTooManyItems
+	}
+
+	_, err = c.svc.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
 	})
+	if isConditionalCheckFailed(err) {
+		return ErrConditionFailed
+	}
 
 	return err
 }
 
-func (c *Client) Get(ctx context.Context, uid string) (shared.Lpa, error) {
-	lpa := shared.Lpa{}
+func (c *Client) Get(ctx context.Context, uid string) (lpa shared.Lpa, err error) {
+	ctx, span := tracing.StartSpan(ctx, "ddb.Get", tracing.LpaUID(uid))
+	defer tracing.EndSpan(span, &err)
+
+	return c.get(ctx, uid, false)
+}
+
+// GetForUpdate returns the LPA using a strongly consistent read, so that a
+// request retrying after losing a race to change the LPA sees the change it
+// lost to.
+func (c *Client) GetForUpdate(ctx context.Context, uid string) (lpa shared.Lpa, err error) {
+	ctx, span := tracing.StartSpan(ctx, "ddb.GetForUpdate", tracing.LpaUID(uid))
+	defer tracing.EndSpan(span, &err)
 
+	return c.get(ctx, uid, true)
+}
+
+func (c *Client) get(ctx context.Context, uid string, consistentRead bool) (lpa shared.Lpa, err error) {
 	marshalledUid, err := attributevalue.Marshal(uid)
 	if err != nil {
 		return lpa, err
 	}
 
-	getItemOutput, err := c.svc.GetItem(ctx, &dynamodb.GetItemInput{
+	input := &dynamodb.GetItemInput{
 		TableName: aws.String(c.tableName),
 		Key: map[string]types.AttributeValue{
 			"uid": marshalledUid,
 		},
-	})
+	}
+	if consistentRead {
+		input.ConsistentRead = aws.Bool(true)
+	}
+
+	getItemOutput, err := c.svc.GetItem(ctx, input)
 
 	if err != nil {
 		return lpa, err
@@ -146,9 +330,11 @@ func (c *Client) Get(ctx context.Context, uid string) (shared.Lpa, error) {
 	return lpa, err
 }
 
-func (c *Client) GetChanges(ctx context.Context, uid string) ([]shared.Update, error) {
+func (c *Client) GetChanges(ctx context.Context, uid string) (updates []shared.Update, err error) {
+	ctx, span := tracing.StartSpan(ctx, "ddb.GetChanges", tracing.LpaUID(uid))
+	defer tracing.EndSpan(span, &err)
+
 	var response *dynamodb.QueryOutput
-	var updates []shared.Update
 
 	keyEx := expression.Key("uid").Equal(expression.Value(uid))
 	expr, err := expression.NewBuilder().WithKeyCondition(keyEx).Build()
@@ -183,7 +369,92 @@ func (c *Client) GetChanges(ctx context.Context, uid string) ([]shared.Update, e
 	return updates, nil
 }
 
-func (c *Client) GetList(ctx context.Context, uids []string) ([]shared.Lpa, error) {
+// GetUpdate fetches the update with the given ID that was made to the LPA. An
+// empty update is returned if there is no such update.
+func (c *Client) GetUpdate(ctx context.Context, uid, id string) (_ shared.Update, err error) {
+	ctx, span := tracing.StartSpan(ctx, "ddb.GetUpdate", tracing.LpaUID(uid))
+	defer tracing.EndSpan(span, &err)
+
+	expr, err := expression.NewBuilder().
+		WithKeyCondition(expression.Key("uid").Equal(expression.Value(uid))).
+		WithFilter(expression.Name("id").Equal(expression.Value(id))).
+		Build()
+	if err != nil {
+		return shared.Update{}, err
+	}
+
+	queryPaginator := c.paginatorFactory.NewQueryPaginator(&dynamodb.QueryInput{
+		TableName:                 aws.String(c.changesTableName),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+		FilterExpression:          expr.Filter(),
+	})
+
+	for queryPaginator.HasMorePages() {
+		response, err := queryPaginator.NextPage(ctx)
+		if err != nil {
+			return shared.Update{}, err
+		}
+
+		if len(response.Items) > 0 {
+			var update shared.Update
+			err := attributevalue.UnmarshalMap(response.Items[0], &update)
+			return update, err
+		}
+	}
+
+	return shared.Update{}, nil
+}
+
+// GetList fetches the LPAs with the given UIDs, in the order requested. Any
+// UIDs that do not exist are omitted from the result.
+func (c *Client) GetList(ctx context.Context, uids []string) (_ []shared.Lpa, err error) {
+	ctx, span := tracing.StartSpan(ctx, "ddb.GetList")
+	defer tracing.EndSpan(span, &err)
+
+	uids = unique(uids)
+
+	var mu sync.Mutex
+	found := make(map[string]shared.Lpa, len(uids))
+
+	group, ctx := errgroup.WithContext(ctx)
+	group.SetLimit(batchGetConcurrency)
+
+	for chunk := range slices.Chunk(uids, batchGetLimit) {
+		group.Go(func() error {
+			lpas, err := c.batchGet(ctx, chunk)
+			if err != nil {
+				return err
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			for _, lpa := range lpas {
+				found[lpa.Uid] = lpa
+			}
+
+			return nil
+		})
+	}
+
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+
+	lpas := make([]shared.Lpa, 0, len(found))
+	for _, uid := range uids {
+		if lpa, ok := found[uid]; ok {
+			lpas = append(lpas, lpa)
+		}
+	}
+
+	return lpas, nil
+}
+
+// batchGet fetches up to batchGetLimit LPAs, retrying any keys that DynamoDB
+// leaves unprocessed.
+func (c *Client) batchGet(ctx context.Context, uids []string) ([]shared.Lpa, error) {
 	keys := make([]map[string]types.AttributeValue, len(uids))
 	for i, uid := range uids {
 		keys[i] = map[string]types.AttributeValue{
@@ -191,23 +462,281 @@ func (c *Client) GetList(ctx context.Context, uids []string) ([]shared.Lpa, erro
 		}
 	}
 
-	output, err := c.svc.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{
-		RequestItems: map[string]types.KeysAndAttributes{
-			c.tableName: {
-				Keys: keys,
+	var lpas []shared.Lpa
+	for attempt := 1; ; attempt++ {
+		output, err := c.svc.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{
+			RequestItems: map[string]types.KeysAndAttributes{
+				c.tableName: {
+					Keys: keys,
+				},
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		var v []shared.Lpa
+		if err := attributevalue.UnmarshalListOfMapsWithOptions(output.Responses[c.tableName], &v, decoderOptions); err != nil {
+			return nil, err
+		}
+		lpas = append(lpas, v...)
+
+		keys = output.UnprocessedKeys[c.tableName].Keys
+		if len(keys) == 0 {
+			return lpas, nil
+		}
+
+		if attempt == batchGetMaxAttempts {
+			return nil, fmt.Errorf("%d keys unprocessed after %d attempts", len(keys), attempt)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(batchGetBackoff << (attempt - 1)):
+		}
+	}
+}
+
+// GetUidsByActor returns the UIDs of the LPAs that the actor is on.
+func (c *Client) GetUidsByActor(ctx context.Context, actorUid string) (_ []string, err error) {
+	ctx, span := tracing.StartSpan(ctx, "ddb.GetUidsByActor")
+	defer tracing.EndSpan(span, &err)
+
+	keyEx := expression.Key("actorUid").Equal(expression.Value(actorUid))
+	expr, err := expression.NewBuilder().WithKeyCondition(keyEx).Build()
+	if err != nil {
+		return nil, err
+	}
+
+	queryPaginator := c.paginatorFactory.NewQueryPaginator(&dynamodb.QueryInput{
+		TableName:                 aws.String(c.actorsTableName),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+	})
+
+	var uids []string
+	for queryPaginator.HasMorePages() {
+		response, err := queryPaginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		var page []actorItem
+		if err := attributevalue.UnmarshalListOfMaps(response.Items, &page); err != nil {
+			return nil, err
+		}
+
+		for _, item := range page {
+			uids = append(uids, item.LpaUid)
+		}
+	}
+
+	return uids, nil
+}
+
+type actorItem struct {
+	ActorUid string `dynamodbav:"actorUid"`
+	LpaUid   string `dynamodbav:"lpaUid"`
+}
+
+// actorItems maps each actor on the LPA to it in the actors table, and removes
+// the mappings for previous actors that are no longer on the LPA. Existing
+// mappings are written again so that LPAs created before the actors table
+// existed are picked up when they are next changed.
+func (c *Client) actorItems(lpa shared.Lpa, previousActorUIDs []string) []types.TransactWriteItem {
+	actorUIDs := lpa.ActorUIDs()
+
+	var items []types.TransactWriteItem
+	for _, actorUID := range actorUIDs {
+		item, _ := attributevalue.MarshalMap(actorItem{ActorUid: actorUID, LpaUid: lpa.Uid})
+
+		items = append(items, types.TransactWriteItem{
+			Put: &types.Put{
+				TableName: aws.String(c.actorsTableName),
+				Item:      item,
+			},
+		})
+	}
+
+	for _, actorUID := range unique(previousActorUIDs) {
+		if actorUID == "" || slices.Contains(actorUIDs, actorUID) {
+			continue
+		}
+
+		key, _ := attributevalue.MarshalMap(actorItem{ActorUid: actorUID, LpaUid: lpa.Uid})
+
+		items = append(items, types.TransactWriteItem{
+			Delete: &types.Delete{
+				TableName: aws.String(c.actorsTableName),
+				Key:       key,
 			},
+		})
+	}
+
+	return items
+}
+
+// GetIdempotencyRecord returns the record stored with key, or an empty record
+// if there is none. The record may have expired but not yet been removed.
+func (c *Client) GetIdempotencyRecord(ctx context.Context, key string) (record idempotency.Record, err error) {
+	ctx, span := tracing.StartSpan(ctx, "ddb.GetIdempotencyRecord")
+	defer tracing.EndSpan(span, &err)
+
+	output, err := c.svc.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(c.idempotencyTableName),
+		Key: map[string]types.AttributeValue{
+			"key": &types.AttributeValueMemberS{Value: key},
 		},
+		ConsistentRead: aws.Bool(true),
 	})
 	if err != nil {
-		return nil, err
+		return record, err
 	}
 
-	var v []shared.Lpa
-	if err := attributevalue.UnmarshalListOfMaps(output.Responses[c.tableName], &v); err != nil {
+	err = attributevalue.UnmarshalMap(output.Item, &record)
+
+	return record, err
+}
+
+// idempotencyItem saves the record, unless there is already a record with the
+// same key that had not expired when this one was created.
+func (c *Client) idempotencyItem(record idempotency.Record) (types.TransactWriteItem, error) {
+	item, err := attributevalue.MarshalMap(record)
+	if err != nil {
+		return types.TransactWriteItem{}, err
+	}
+
+	expr, err := expression.NewBuilder().
+		WithCondition(expression.Or(
+			expression.Name("key").AttributeNotExists(),
+			expression.Name("expiresAt").LessThanEqual(expression.Value(record.CreatedAt)),
+		)).
+		Build()
+	if err != nil {
+		return types.TransactWriteItem{}, err
+	}
+
+	return types.TransactWriteItem{
+		Put: &types.Put{
+			TableName:                 aws.String(c.idempotencyTableName),
+			Item:                      item,
+			ConditionExpression:       expr.Condition(),
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+		},
+	}, nil
+}
+
+// SearchByDonor returns up to limit LPAs with the given shared.DonorSearchKey,
+// in UID order and starting after the UID startAfter if it is set. When there
+// may be more results the UID to start the next search after is also returned.
+func (c *Client) SearchByDonor(ctx context.Context, searchKey string, limit int, startAfter string) (_ []shared.Lpa, _ string, err error) {
+	ctx, span := tracing.StartSpan(ctx, "ddb.SearchByDonor")
+	defer tracing.EndSpan(span, &err)
+
+	keyEx := expression.Key("searchKey").Equal(expression.Value(searchKey))
+	expr, err := expression.NewBuilder().WithKeyCondition(keyEx).Build()
+	if err != nil {
+		return nil, "", err
+	}
+
+	input := &dynamodb.QueryInput{
+		TableName:                 aws.String(c.tableName),
+		IndexName:                 aws.String(searchIndexName),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+		Limit:                     aws.Int32(int32(limit)),
+	}
+
+	if startAfter != "" {
+		input.ExclusiveStartKey = map[string]types.AttributeValue{
+			"searchKey": &types.AttributeValueMemberS{Value: searchKey},
+			"uid":       &types.AttributeValueMemberS{Value: startAfter},
+		}
+	}
+
+	output, err := c.svc.Query(ctx, input)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var lpas []shared.Lpa
+	if err := attributevalue.UnmarshalListOfMapsWithOptions(output.Items, &lpas, decoderOptions); err != nil {
+		return nil, "", err
+	}
+
+	var next string
+	if uid, ok := output.LastEvaluatedKey["uid"].(*types.AttributeValueMemberS); ok {
+		next = uid.Value
+	}
+
+	return lpas, next, nil
+}
+
+func unique(values []string) []string {
+	seen := make(map[string]struct{}, len(values))
+	result := make([]string, 0, len(values))
+
+	for _, v := range values {
+		if _, ok := seen[v]; !ok {
+			seen[v] = struct{}{}
+			result = append(result, v)
+		}
+	}
+
+	return result
+}
+
+// marshalLpa converts the LPA to a deeds table item, adding the searchKey
+// attribute when the donor has enough details to be searched for.
+func marshalLpa(lpa shared.Lpa) (map[string]types.AttributeValue, error) {
+	item, err := attributevalue.MarshalMapWithOptions(lpa, encoderOptions)
+	if err != nil {
 		return nil, err
 	}
 
-	return v, nil
+	if searchKey := lpa.SearchKey(); searchKey != "" {
+		item["searchKey"] = &types.AttributeValueMemberS{Value: searchKey}
+	}
+
+	return item, nil
+}
+
+func marshalUpdate(update shared.Update) map[string]types.AttributeValue {
+	values := map[string]interface{}{
+		"id":      update.Id,
+		"uid":     update.Uid,
+		"applied": update.Applied,
+		"author":  update.Author,
+		"type":    update.Type,
+		"changes": update.Changes,
+	}
+
+	if len(update.Diff) > 0 {
+		values["diff"] = update.Diff
+	}
+
+	item, _ := attributevalue.MarshalMap(values)
+
+	return item
+}
+
+func isConditionalCheckFailed(err error) bool {
+	var canceled *types.TransactionCanceledException
+	if !errors.As(err, &canceled) {
+		return false
+	}
+
+	for _, reason := range canceled.CancellationReasons {
+		if aws.ToString(reason.Code) == "ConditionalCheckFailed" {
+			return true
+		}
+	}
+
+	return false
 }
 
 func decoderOptions(opts *attributevalue.DecoderOptions) {
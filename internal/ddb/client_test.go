@@ -4,11 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/event"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/idempotency"
 	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
 	"github.com/stretchr/testify/assert"
 	mock "github.com/stretchr/testify/mock"
@@ -19,31 +24,292 @@ type ctxValueType string
 const ctxValue ctxValueType = "for"
 
 var (
-	ctx              = context.WithValue(context.Background(), ctxValue, "testing")
-	tableName        = "a-table"
-	changesTableName = "a-change-table"
-	errExpected      = errors.New("hey")
+	ctx                  = context.WithValue(context.Background(), ctxValue, "testing")
+	spanCtx              = mock.MatchedBy(func(c context.Context) bool { return c.Value(ctxValue) == "testing" })
+	tableName            = "a-table"
+	changesTableName     = "a-change-table"
+	actorsTableName      = "an-actor-table"
+	idempotencyTableName = "an-idempotency-table"
+	outboxTableName      = "an-outbox-table"
+	errExpected          = errors.New("hey")
 )
 
 func TestNew(t *testing.T) {
-	client := New(aws.Config{}, tableName, changesTableName)
+	client := New(aws.Config{Region: "eu-west-1"}, tableName, changesTableName, actorsTableName, idempotencyTableName, outboxTableName)
 
 	assert.IsType(t, (*dynamodb.Client)(nil), client.svc)
 	assert.Equal(t, tableName, client.tableName)
 	assert.Equal(t, changesTableName, client.changesTableName)
+	assert.Equal(t, actorsTableName, client.actorsTableName)
+	assert.Equal(t, idempotencyTableName, client.idempotencyTableName)
+	assert.Equal(t, outboxTableName, client.outboxTableName)
+	assert.Equal(t, "eu-west-1", client.region)
 }
 
 func TestClientPutChanges(t *testing.T) {
+	testcases := map[string]struct {
+		version   int
+		condition string
+		values    map[string]types.AttributeValue
+	}{
+		"first version": {
+			version:   1,
+			condition: "(attribute_not_exists (#0)) OR (#0 = :0)",
+			values:    map[string]types.AttributeValue{":0": &types.AttributeValueMemberN{Value: "0"}},
+		},
+		"later version": {
+			version:   5,
+			condition: "#0 = :0",
+			values:    map[string]types.AttributeValue{":0": &types.AttributeValueMemberN{Value: "4"}},
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			lpa := shared.Lpa{Uid: "a-uid", Version: tc.version}
+			item, _ := attributevalue.MarshalMapWithOptions(lpa, encoderOptions)
+
+			dynamodbClient := newMockDynamodbClient(t)
+			dynamodbClient.EXPECT().
+				TransactWriteItems(spanCtx, &dynamodb.TransactWriteItemsInput{
+					TransactItems: []types.TransactWriteItem{{
+						Put: &types.Put{
+							TableName:                 aws.String(tableName),
+							Item:                      item,
+							ConditionExpression:       aws.String(tc.condition),
+							ExpressionAttributeNames:  map[string]string{"#0": "version"},
+							ExpressionAttributeValues: tc.values,
+						},
+					}, {
+						Put: &types.Put{
+							TableName: aws.String(changesTableName),
+							Item: map[string]types.AttributeValue{
+								"id":      &types.AttributeValueMemberS{Value: "123"},
+								"uid":     &types.AttributeValueMemberS{Value: "a-uid"},
+								"applied": &types.AttributeValueMemberS{Value: "2024-01-01Tsomething"},
+								"author":  &types.AttributeValueMemberS{Value: "an-author"},
+								"type":    &types.AttributeValueMemberS{Value: "a-type"},
+								"changes": &types.AttributeValueMemberL{Value: []types.AttributeValue{
+									&types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+										"Key": &types.AttributeValueMemberS{Value: "a-key"},
+										"Old": &types.AttributeValueMemberB{Value: []byte("old")},
+										"New": &types.AttributeValueMemberB{Value: []byte("new")},
+									}},
+								}},
+							},
+						},
+					}},
+				}).
+				Return(nil, errExpected)
+
+			client := &Client{
+				svc:              dynamodbClient,
+				tableName:        tableName,
+				changesTableName: changesTableName,
+			}
+
+			err := client.PutChanges(ctx, lpa, []shared.Update{{
+				Id:      "123",
+				Uid:     "a-uid",
+				Applied: "2024-01-01Tsomething",
+				Author:  "an-author",
+				Type:    "a-type",
+				Changes: []shared.Change{
+					{Key: "a-key", Old: json.RawMessage("old"), New: json.RawMessage("new")},
+				},
+			}}, nil, nil, nil)
+			assert.Equal(t, errExpected, err)
+		})
+	}
+}
+
+func TestClientPutChangesWithSeveralUpdates(t *testing.T) {
 	dynamodbClient := newMockDynamodbClient(t)
 	dynamodbClient.EXPECT().
-		TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
-			TransactItems: []types.TransactWriteItem{{
+		TransactWriteItems(spanCtx, mock.MatchedBy(func(input *dynamodb.TransactWriteItemsInput) bool {
+			return assert.Len(t, input.TransactItems, 3) &&
+				assert.Equal(t, aws.String(changesTableName), input.TransactItems[1].Put.TableName) &&
+				assert.Equal(t, &types.AttributeValueMemberS{Value: "1"}, input.TransactItems[1].Put.Item["id"]) &&
+				assert.Equal(t, aws.String(changesTableName), input.TransactItems[2].Put.TableName) &&
+				assert.Equal(t, &types.AttributeValueMemberS{Value: "2"}, input.TransactItems[2].Put.Item["id"])
+		})).
+		Return(nil, nil)
+
+	client := &Client{
+		svc:              dynamodbClient,
+		tableName:        tableName,
+		changesTableName: changesTableName,
+	}
+
+	err := client.PutChanges(ctx, shared.Lpa{Uid: "a-uid", Version: 2}, []shared.Update{{Id: "1"}, {Id: "2"}}, nil, nil, nil)
+	assert.Nil(t, err)
+}
+
+func TestClientPutChangesWithOutbox(t *testing.T) {
+	dynamodbClient := newMockDynamodbClient(t)
+	dynamodbClient.EXPECT().
+		TransactWriteItems(spanCtx, mock.MatchedBy(func(input *dynamodb.TransactWriteItemsInput) bool {
+			return assert.Len(t, input.TransactItems, 5) &&
+				assert.Equal(t, aws.String(outboxTableName), input.TransactItems[3].Put.TableName) &&
+				assert.Equal(t, &types.AttributeValueMemberS{Value: "1"}, input.TransactItems[3].Put.Item["id"]) &&
+				assert.Equal(t, &types.AttributeValueMemberS{Value: "eu-west-1"}, input.TransactItems[3].Put.Item["region"]) &&
+				assert.Equal(t, aws.String(outboxTableName), input.TransactItems[4].Put.TableName) &&
+				assert.Equal(t, &types.AttributeValueMemberS{Value: "2"}, input.TransactItems[4].Put.Item["id"])
+		})).
+		Return(nil, nil)
+
+	client := &Client{
+		svc:              dynamodbClient,
+		tableName:        tableName,
+		changesTableName: changesTableName,
+		outboxTableName:  outboxTableName,
+		region:           "eu-west-1",
+	}
+
+	err := client.PutChanges(ctx, shared.Lpa{Uid: "a-uid", Version: 2}, []shared.Update{{Id: "1"}, {Id: "2"}}, nil, nil, []event.OutboxEntry{{Id: "1"}, {Id: "2"}})
+	assert.Nil(t, err)
+}
+
+func TestClientPutChangesWhenConditionFails(t *testing.T) {
+	dynamodbClient := newMockDynamodbClient(t)
+	dynamodbClient.EXPECT().
+		TransactWriteItems(spanCtx, mock.Anything).
+		Return(nil, &types.TransactionCanceledException{
+			CancellationReasons: []types.CancellationReason{
+				{Code: aws.String("ConditionalCheckFailed")},
+				{Code: aws.String("None")},
+			},
+		})
+
+	client := &Client{
+		svc:              dynamodbClient,
+		tableName:        tableName,
+		changesTableName: changesTableName,
+	}
+
+	err := client.PutChanges(ctx, shared.Lpa{Uid: "a-uid", Version: 2}, []shared.Update{{}}, nil, nil, nil)
+	assert.Equal(t, ErrConditionFailed, err)
+}
+
+func TestClientPutChangesWhenTooManyItems(t *testing.T) {
+	lpa := shared.Lpa{Uid: "a-uid", Version: 2}
+	for i := range 95 {
+		lpa.Attorneys = append(lpa.Attorneys, shared.Attorney{Person: shared.Person{UID: fmt.Sprint("an-attorney-", i)}})
+	}
+
+	updates := []shared.Update{{Id: "1"}, {Id: "2"}}
+	outbox := []event.OutboxEntry{{Id: "1"}, {Id: "2"}}
+
+	testcases := map[string]struct {
+		record *idempotency.Record
+		err    error
+	}{
+		"at limit": {
+			err: nil,
+		},
+		"over limit": {
+			record: &idempotency.Record{Key: "a-key"},
+			err:    ErrTooManyItems,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			dynamodbClient := newMockDynamodbClient(t)
+			if tc.err == nil {
+				dynamodbClient.EXPECT().
+					TransactWriteItems(spanCtx, mock.MatchedBy(func(input *dynamodb.TransactWriteItemsInput) bool {
+						return assert.Len(t, input.TransactItems, 100)
+					})).
+					Return(nil, nil)
+			}
+
+			client := &Client{
+				svc:                  dynamodbClient,
+				tableName:            tableName,
+				changesTableName:     changesTableName,
+				actorsTableName:      actorsTableName,
+				idempotencyTableName: idempotencyTableName,
+				outboxTableName:      outboxTableName,
+			}
+
+			err := client.PutChanges(ctx, lpa, updates, nil, tc.record, outbox)
+			assert.Equal(t, tc.err, err)
+		})
+	}
+}
+
+func TestClientPutChangesActors(t *testing.T) {
+	lpa := shared.Lpa{
+		Uid:     "a-uid",
+		Version: 2,
+		LpaInit: shared.LpaInit{
+			Donor:     shared.Donor{Person: shared.Person{UID: "donor"}},
+			Attorneys: []shared.Attorney{{Person: shared.Person{UID: "new-attorney"}}},
+		},
+	}
+
+	dynamodbClient := newMockDynamodbClient(t)
+	dynamodbClient.EXPECT().
+		TransactWriteItems(spanCtx, mock.MatchedBy(func(input *dynamodb.TransactWriteItemsInput) bool {
+			return assert.Equal(t, []types.TransactWriteItem{{
 				Put: &types.Put{
-					TableName: aws.String(tableName),
+					TableName: aws.String(actorsTableName),
 					Item: map[string]types.AttributeValue{
-						"hey": &types.AttributeValueMemberS{Value: "hello"},
+						"actorUid": &types.AttributeValueMemberS{Value: "donor"},
+						"lpaUid":   &types.AttributeValueMemberS{Value: "a-uid"},
 					},
 				},
+			}, {
+				Put: &types.Put{
+					TableName: aws.String(actorsTableName),
+					Item: map[string]types.AttributeValue{
+						"actorUid": &types.AttributeValueMemberS{Value: "new-attorney"},
+						"lpaUid":   &types.AttributeValueMemberS{Value: "a-uid"},
+					},
+				},
+			}, {
+				Delete: &types.Delete{
+					TableName: aws.String(actorsTableName),
+					Key: map[string]types.AttributeValue{
+						"actorUid": &types.AttributeValueMemberS{Value: "old-attorney"},
+						"lpaUid":   &types.AttributeValueMemberS{Value: "a-uid"},
+					},
+				},
+			}}, input.TransactItems[2:])
+		})).
+		Return(nil, nil)
+
+	client := &Client{
+		svc:              dynamodbClient,
+		tableName:        tableName,
+		changesTableName: changesTableName,
+		actorsTableName:  actorsTableName,
+	}
+
+	err := client.PutChanges(ctx, lpa, []shared.Update{{}}, []string{"donor", "old-attorney", ""}, nil, nil)
+	assert.Nil(t, err)
+}
+
+func TestClientCreate(t *testing.T) {
+	lpa := shared.Lpa{Uid: "a-uid", Version: 1}
+	item, _ := attributevalue.MarshalMapWithOptions(lpa, encoderOptions)
+	outbox := event.OutboxEntry{
+		Id:        "123",
+		Event:     event.LpaUpdated{Uid: "a-uid", ChangeType: "CREATE"},
+		CreatedAt: time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	dynamodbClient := newMockDynamodbClient(t)
+	dynamodbClient.EXPECT().
+		TransactWriteItems(spanCtx, &dynamodb.TransactWriteItemsInput{
+			TransactItems: []types.TransactWriteItem{{
+				Put: &types.Put{
+					TableName:                aws.String(tableName),
+					Item:                     item,
+					ConditionExpression:      aws.String("attribute_not_exists (#0)"),
+					ExpressionAttributeNames: map[string]string{"#0": "uid"},
+				},
 			}, {
 				Put: &types.Put{
 					TableName: aws.String(changesTableName),
@@ -52,16 +318,30 @@ func TestClientPutChanges(t *testing.T) {
 						"uid":     &types.AttributeValueMemberS{Value: "a-uid"},
 						"applied": &types.AttributeValueMemberS{Value: "2024-01-01Tsomething"},
 						"author":  &types.AttributeValueMemberS{Value: "an-author"},
-						"type":    &types.AttributeValueMemberS{Value: "a-type"},
+						"type":    &types.AttributeValueMemberS{Value: "CREATE"},
 						"changes": &types.AttributeValueMemberL{Value: []types.AttributeValue{
 							&types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
-								"Key": &types.AttributeValueMemberS{Value: "a-key"},
-								"Old": &types.AttributeValueMemberB{Value: []byte("old")},
-								"New": &types.AttributeValueMemberB{Value: []byte("new")},
+								"Key": &types.AttributeValueMemberS{Value: ""},
+								"Old": &types.AttributeValueMemberB{Value: []byte("null")},
+								"New": &types.AttributeValueMemberB{Value: []byte("{}")},
 							}},
 						}},
 					},
 				},
+			}, {
+				Put: &types.Put{
+					TableName: aws.String(outboxTableName),
+					Item: map[string]types.AttributeValue{
+						"id":     &types.AttributeValueMemberS{Value: "123"},
+						"region": &types.AttributeValueMemberS{Value: "eu-west-1"},
+						"event": &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+							"uid":        &types.AttributeValueMemberS{Value: "a-uid"},
+							"changeType": &types.AttributeValueMemberS{Value: "CREATE"},
+						}},
+						"createdAt":     &types.AttributeValueMemberS{Value: "2024-01-01T12:00:00Z"},
+						"pendingRegion": &types.AttributeValueMemberS{Value: "eu-west-1"},
+					},
+				},
 			}},
 		}).
 		Return(nil, errExpected)
@@ -70,45 +350,225 @@ func TestClientPutChanges(t *testing.T) {
 		svc:              dynamodbClient,
 		tableName:        tableName,
 		changesTableName: changesTableName,
+		outboxTableName:  outboxTableName,
+		region:           "eu-west-1",
 	}
 
-	err := client.PutChanges(ctx, map[string]string{"hey": "hello"}, shared.Update{
+	err := client.Create(ctx, lpa, shared.Update{
 		Id:      "123",
 		Uid:     "a-uid",
 		Applied: "2024-01-01Tsomething",
 		Author:  "an-author",
-		Type:    "a-type",
+		Type:    "CREATE",
 		Changes: []shared.Change{
-			{Key: "a-key", Old: json.RawMessage("old"), New: json.RawMessage("new")},
+			{Key: "", Old: json.RawMessage("null"), New: json.RawMessage("{}")},
 		},
-	})
+	}, nil, outbox)
 	assert.Equal(t, errExpected, err)
 }
 
-func TestClientPut(t *testing.T) {
+func TestClientCreateActors(t *testing.T) {
+	lpa := shared.Lpa{
+		Uid: "a-uid",
+		LpaInit: shared.LpaInit{
+			Donor:               shared.Donor{Person: shared.Person{UID: "donor"}},
+			CertificateProvider: shared.CertificateProvider{Person: shared.Person{UID: "certificate-provider"}},
+		},
+	}
+
 	dynamodbClient := newMockDynamodbClient(t)
 	dynamodbClient.EXPECT().
-		PutItem(ctx, &dynamodb.PutItemInput{
-			TableName: aws.String(tableName),
-			Item: map[string]types.AttributeValue{
-				"hey": &types.AttributeValueMemberS{Value: "hello"},
+		TransactWriteItems(spanCtx, mock.MatchedBy(func(input *dynamodb.TransactWriteItemsInput) bool {
+			return assert.Equal(t, []types.TransactWriteItem{{
+				Put: &types.Put{
+					TableName: aws.String(actorsTableName),
+					Item: map[string]types.AttributeValue{
+						"actorUid": &types.AttributeValueMemberS{Value: "donor"},
+						"lpaUid":   &types.AttributeValueMemberS{Value: "a-uid"},
+					},
+				},
+			}, {
+				Put: &types.Put{
+					TableName: aws.String(actorsTableName),
+					Item: map[string]types.AttributeValue{
+						"actorUid": &types.AttributeValueMemberS{Value: "certificate-provider"},
+						"lpaUid":   &types.AttributeValueMemberS{Value: "a-uid"},
+					},
+				},
+			}}, input.TransactItems[2:4])
+		})).
+		Return(nil, nil)
+
+	client := &Client{
+		svc:              dynamodbClient,
+		tableName:        tableName,
+		changesTableName: changesTableName,
+		actorsTableName:  actorsTableName,
+	}
+
+	err := client.Create(ctx, lpa, shared.Update{}, nil, event.OutboxEntry{})
+	assert.Nil(t, err)
+}
+
+func TestClientCreateWhenAlreadyExists(t *testing.T) {
+	dynamodbClient := newMockDynamodbClient(t)
+	dynamodbClient.EXPECT().
+		TransactWriteItems(spanCtx, mock.Anything).
+		Return(nil, &types.TransactionCanceledException{
+			CancellationReasons: []types.CancellationReason{
+				{Code: aws.String("ConditionalCheckFailed")},
+				{Code: aws.String("None")},
 			},
+		})
+
+	client := &Client{
+		svc:              dynamodbClient,
+		tableName:        tableName,
+		changesTableName: changesTableName,
+	}
+
+	err := client.Create(ctx, shared.Lpa{Uid: "a-uid"}, shared.Update{}, nil, event.OutboxEntry{})
+	assert.Equal(t, ErrConditionFailed, err)
+}
+
+func TestClientCreateWhenTooManyItems(t *testing.T) {
+	lpa := shared.Lpa{Uid: "a-uid"}
+	for i := range 98 {
+		lpa.Attorneys = append(lpa.Attorneys, shared.Attorney{Person: shared.Person{UID: fmt.Sprint("an-attorney-", i)}})
+	}
+
+	client := &Client{
+		svc:              newMockDynamodbClient(t),
+		tableName:        tableName,
+		changesTableName: changesTableName,
+		actorsTableName:  actorsTableName,
+		outboxTableName:  outboxTableName,
+	}
+
+	err := client.Create(ctx, lpa, shared.Update{}, nil, event.OutboxEntry{})
+	assert.Equal(t, ErrTooManyItems, err)
+}
+
+func TestClientPutChangesWithIdempotencyRecord(t *testing.T) {
+	record := idempotency.Record{Key: "a-key", UpdateId: "an-id", StatusCode: 201, Body: "{}", CreatedAt: 100, ExpiresAt: 200}
+	item, _ := attributevalue.MarshalMap(record)
+
+	dynamodbClient := newMockDynamodbClient(t)
+	dynamodbClient.EXPECT().
+		TransactWriteItems(spanCtx, mock.MatchedBy(func(input *dynamodb.TransactWriteItemsInput) bool {
+			return assert.Equal(t, types.TransactWriteItem{
+				Put: &types.Put{
+					TableName:                aws.String(idempotencyTableName),
+					Item:                     item,
+					ConditionExpression:      aws.String("(attribute_not_exists (#0)) OR (#1 <= :0)"),
+					ExpressionAttributeNames: map[string]string{"#0": "key", "#1": "expiresAt"},
+					ExpressionAttributeValues: map[string]types.AttributeValue{
+						":0": &types.AttributeValueMemberN{Value: "100"},
+					},
+				},
+			}, input.TransactItems[len(input.TransactItems)-1])
+		})).
+		Return(nil, nil)
+
+	client := &Client{
+		svc:                  dynamodbClient,
+		tableName:            tableName,
+		changesTableName:     changesTableName,
+		idempotencyTableName: idempotencyTableName,
+	}
+
+	err := client.PutChanges(ctx, shared.Lpa{Uid: "a-uid", Version: 2}, []shared.Update{{}}, nil, &record, nil)
+	assert.Nil(t, err)
+}
+
+func TestClientCreateWithIdempotencyRecord(t *testing.T) {
+	record := idempotency.Record{Key: "a-key", CreatedAt: 100, ExpiresAt: 200}
+
+	dynamodbClient := newMockDynamodbClient(t)
+	dynamodbClient.EXPECT().
+		TransactWriteItems(spanCtx, mock.MatchedBy(func(input *dynamodb.TransactWriteItemsInput) bool {
+			last := input.TransactItems[len(input.TransactItems)-1]
+
+			return assert.Equal(t, aws.String(idempotencyTableName), last.Put.TableName) &&
+				assert.Equal(t, &types.AttributeValueMemberS{Value: "a-key"}, last.Put.Item["key"])
+		})).
+		Return(nil, nil)
+
+	client := &Client{
+		svc:                  dynamodbClient,
+		tableName:            tableName,
+		changesTableName:     changesTableName,
+		idempotencyTableName: idempotencyTableName,
+	}
+
+	err := client.Create(ctx, shared.Lpa{Uid: "a-uid"}, shared.Update{}, &record, event.OutboxEntry{})
+	assert.Nil(t, err)
+}
+
+func TestClientGetIdempotencyRecord(t *testing.T) {
+	dynamodbClient := newMockDynamodbClient(t)
+	dynamodbClient.EXPECT().
+		GetItem(spanCtx, &dynamodb.GetItemInput{
+			TableName: aws.String(idempotencyTableName),
+			Key: map[string]types.AttributeValue{
+				"key": &types.AttributeValueMemberS{Value: "a-key"},
+			},
+			ConsistentRead: aws.Bool(true),
 		}).
+		Return(&dynamodb.GetItemOutput{
+			Item: map[string]types.AttributeValue{
+				"key":        &types.AttributeValueMemberS{Value: "a-key"},
+				"updateId":   &types.AttributeValueMemberS{Value: "an-id"},
+				"statusCode": &types.AttributeValueMemberN{Value: "201"},
+				"expiresAt":  &types.AttributeValueMemberN{Value: "200"},
+			},
+		}, nil)
+
+	client := &Client{
+		svc:                  dynamodbClient,
+		idempotencyTableName: idempotencyTableName,
+	}
+
+	record, err := client.GetIdempotencyRecord(ctx, "a-key")
+	assert.Nil(t, err)
+	assert.Equal(t, idempotency.Record{Key: "a-key", UpdateId: "an-id", StatusCode: 201, ExpiresAt: 200}, record)
+}
+
+func TestClientGetIdempotencyRecordWhenNotFound(t *testing.T) {
+	dynamodbClient := newMockDynamodbClient(t)
+	dynamodbClient.EXPECT().
+		GetItem(spanCtx, mock.Anything).
+		Return(&dynamodb.GetItemOutput{}, nil)
+
+	client := &Client{
+		svc:                  dynamodbClient,
+		idempotencyTableName: idempotencyTableName,
+	}
+
+	record, err := client.GetIdempotencyRecord(ctx, "a-key")
+	assert.Nil(t, err)
+	assert.Equal(t, idempotency.Record{}, record)
+}
+
+func TestClientGetIdempotencyRecordWhenClientErrors(t *testing.T) {
+	dynamodbClient := newMockDynamodbClient(t)
+	dynamodbClient.EXPECT().
+		GetItem(spanCtx, mock.Anything).
 		Return(nil, errExpected)
 
 	client := &Client{
-		svc:       dynamodbClient,
-		tableName: tableName,
+		svc:                  dynamodbClient,
+		idempotencyTableName: idempotencyTableName,
 	}
 
-	err := client.Put(ctx, map[string]string{"hey": "hello"})
+	_, err := client.GetIdempotencyRecord(ctx, "a-key")
 	assert.Equal(t, errExpected, err)
 }
 
 func TestClientGet(t *testing.T) {
 	dynamodbClient := newMockDynamodbClient(t)
 	dynamodbClient.EXPECT().
-		GetItem(ctx, &dynamodb.GetItemInput{
+		GetItem(spanCtx, &dynamodb.GetItemInput{
 			TableName: aws.String(tableName),
 			Key: map[string]types.AttributeValue{
 				"uid": &types.AttributeValueMemberS{Value: "my-uid"},
@@ -134,7 +594,7 @@ func TestClientGet(t *testing.T) {
 func TestClientGetWhenClientErrors(t *testing.T) {
 	dynamodbClient := newMockDynamodbClient(t)
 	dynamodbClient.EXPECT().
-		GetItem(ctx, mock.Anything).
+		GetItem(spanCtx, mock.Anything).
 		Return(nil, errExpected)
 
 	client := &Client{svc: dynamodbClient}
@@ -143,16 +603,56 @@ func TestClientGetWhenClientErrors(t *testing.T) {
 	assert.Equal(t, errExpected, err)
 }
 
+func TestClientGetForUpdate(t *testing.T) {
+	dynamodbClient := newMockDynamodbClient(t)
+	dynamodbClient.EXPECT().
+		GetItem(spanCtx, &dynamodb.GetItemInput{
+			TableName: aws.String(tableName),
+			Key: map[string]types.AttributeValue{
+				"uid": &types.AttributeValueMemberS{Value: "my-uid"},
+			},
+			ConsistentRead: aws.Bool(true),
+		}).
+		Return(&dynamodb.GetItemOutput{
+			Item: map[string]types.AttributeValue{
+				"uid": &types.AttributeValueMemberS{Value: "my-uid"},
+			},
+		}, nil)
+
+	client := &Client{
+		svc:       dynamodbClient,
+		tableName: tableName,
+	}
+
+	lpa, err := client.GetForUpdate(ctx, "my-uid")
+	assert.Nil(t, err)
+	assert.Equal(t, shared.Lpa{Uid: "my-uid"}, lpa)
+}
+
+func TestClientGetForUpdateWhenClientErrors(t *testing.T) {
+	dynamodbClient := newMockDynamodbClient(t)
+	dynamodbClient.EXPECT().
+		GetItem(spanCtx, mock.Anything).
+		Return(nil, errExpected)
+
+	client := &Client{svc: dynamodbClient}
+
+	_, err := client.GetForUpdate(ctx, "my-uid")
+	assert.Equal(t, errExpected, err)
+}
+
 func TestClientGetList(t *testing.T) {
 	dynamodbClient := newMockDynamodbClient(t)
 	dynamodbClient.EXPECT().
-		BatchGetItem(ctx, &dynamodb.BatchGetItemInput{
+		BatchGetItem(mock.Anything, &dynamodb.BatchGetItemInput{
 			RequestItems: map[string]types.KeysAndAttributes{
 				tableName: {
 					Keys: []map[string]types.AttributeValue{{
 						"uid": &types.AttributeValueMemberS{Value: "my-uid"},
 					}, {
 						"uid": &types.AttributeValueMemberS{Value: "another-uid"},
+					}, {
+						"uid": &types.AttributeValueMemberS{Value: "missing-uid"},
 					}},
 				},
 			},
@@ -160,11 +660,11 @@ func TestClientGetList(t *testing.T) {
 		Return(&dynamodb.BatchGetItemOutput{
 			Responses: map[string][]map[string]types.AttributeValue{
 				tableName: {{
-					"uid":     &types.AttributeValueMemberS{Value: "my-uid"},
-					"lpaType": &types.AttributeValueMemberS{Value: "property-and-affairs"},
-				}, {
 					"uid":     &types.AttributeValueMemberS{Value: "another-uid"},
 					"lpaType": &types.AttributeValueMemberS{Value: "personal-welfare"},
+				}, {
+					"uid":     &types.AttributeValueMemberS{Value: "my-uid"},
+					"lpaType": &types.AttributeValueMemberS{Value: "property-and-affairs"},
 				}},
 			},
 		}, nil)
@@ -174,7 +674,7 @@ func TestClientGetList(t *testing.T) {
 		tableName: tableName,
 	}
 
-	lpas, err := client.GetList(ctx, []string{"my-uid", "another-uid"})
+	lpas, err := client.GetList(ctx, []string{"my-uid", "another-uid", "missing-uid", "my-uid"})
 	assert.Nil(t, err)
 	assert.Equal(t, []shared.Lpa{
 		{Uid: "my-uid", LpaInit: shared.LpaInit{LpaType: shared.LpaTypePropertyAndAffairs}},
@@ -182,10 +682,124 @@ func TestClientGetList(t *testing.T) {
 	}, lpas)
 }
 
+func TestClientGetListWhenNoUids(t *testing.T) {
+	client := &Client{
+		svc:       newMockDynamodbClient(t),
+		tableName: tableName,
+	}
+
+	lpas, err := client.GetList(ctx, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []shared.Lpa{}, lpas)
+}
+
+func TestClientGetListWhenMoreUidsThanBatchLimit(t *testing.T) {
+	var uids []string
+	var items []map[string]types.AttributeValue
+	var expected []shared.Lpa
+	for i := range 250 {
+		uid := fmt.Sprintf("uid-%d", i)
+		uids = append(uids, uid)
+		items = append(items, map[string]types.AttributeValue{"uid": &types.AttributeValueMemberS{Value: uid}})
+		expected = append(expected, shared.Lpa{Uid: uid})
+	}
+
+	batchOf := func(from, to int) any {
+		return mock.MatchedBy(func(input *dynamodb.BatchGetItemInput) bool {
+			keys := input.RequestItems[tableName].Keys
+			return len(keys) == to-from && keys[0]["uid"].(*types.AttributeValueMemberS).Value == uids[from]
+		})
+	}
+
+	dynamodbClient := newMockDynamodbClient(t)
+	for _, batch := range [][2]int{{0, 100}, {100, 200}, {200, 250}} {
+		dynamodbClient.EXPECT().
+			BatchGetItem(mock.Anything, batchOf(batch[0], batch[1])).
+			Return(&dynamodb.BatchGetItemOutput{
+				Responses: map[string][]map[string]types.AttributeValue{tableName: items[batch[0]:batch[1]]},
+			}, nil).
+			Once()
+	}
+
+	client := &Client{
+		svc:       dynamodbClient,
+		tableName: tableName,
+	}
+
+	lpas, err := client.GetList(ctx, uids)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, lpas)
+}
+
+func TestClientGetListWhenUnprocessedKeys(t *testing.T) {
+	myKey := map[string]types.AttributeValue{"uid": &types.AttributeValueMemberS{Value: "my-uid"}}
+	anotherKey := map[string]types.AttributeValue{"uid": &types.AttributeValueMemberS{Value: "another-uid"}}
+
+	dynamodbClient := newMockDynamodbClient(t)
+	dynamodbClient.EXPECT().
+		BatchGetItem(mock.Anything, &dynamodb.BatchGetItemInput{
+			RequestItems: map[string]types.KeysAndAttributes{
+				tableName: {Keys: []map[string]types.AttributeValue{myKey, anotherKey}},
+			},
+		}).
+		Return(&dynamodb.BatchGetItemOutput{
+			Responses: map[string][]map[string]types.AttributeValue{
+				tableName: {{"uid": &types.AttributeValueMemberS{Value: "another-uid"}}},
+			},
+			UnprocessedKeys: map[string]types.KeysAndAttributes{
+				tableName: {Keys: []map[string]types.AttributeValue{myKey}},
+			},
+		}, nil).
+		Once()
+	dynamodbClient.EXPECT().
+		BatchGetItem(mock.Anything, &dynamodb.BatchGetItemInput{
+			RequestItems: map[string]types.KeysAndAttributes{
+				tableName: {Keys: []map[string]types.AttributeValue{myKey}},
+			},
+		}).
+		Return(&dynamodb.BatchGetItemOutput{
+			Responses: map[string][]map[string]types.AttributeValue{
+				tableName: {{"uid": &types.AttributeValueMemberS{Value: "my-uid"}}},
+			},
+		}, nil).
+		Once()
+
+	client := &Client{
+		svc:       dynamodbClient,
+		tableName: tableName,
+	}
+
+	lpas, err := client.GetList(ctx, []string{"my-uid", "another-uid"})
+	assert.Nil(t, err)
+	assert.Equal(t, []shared.Lpa{{Uid: "my-uid"}, {Uid: "another-uid"}}, lpas)
+}
+
+func TestClientGetListWhenKeysRemainUnprocessed(t *testing.T) {
+	myKey := map[string]types.AttributeValue{"uid": &types.AttributeValueMemberS{Value: "my-uid"}}
+
+	dynamodbClient := newMockDynamodbClient(t)
+	dynamodbClient.EXPECT().
+		BatchGetItem(mock.Anything, mock.Anything).
+		Return(&dynamodb.BatchGetItemOutput{
+			UnprocessedKeys: map[string]types.KeysAndAttributes{
+				tableName: {Keys: []map[string]types.AttributeValue{myKey}},
+			},
+		}, nil).
+		Times(batchGetMaxAttempts)
+
+	client := &Client{
+		svc:       dynamodbClient,
+		tableName: tableName,
+	}
+
+	_, err := client.GetList(ctx, []string{"my-uid"})
+	assert.EqualError(t, err, "1 keys unprocessed after 5 attempts")
+}
+
 func TestClientGetListWhenClientErrors(t *testing.T) {
 	dynamodbClient := newMockDynamodbClient(t)
 	dynamodbClient.EXPECT().
-		BatchGetItem(ctx, mock.Anything).
+		BatchGetItem(mock.Anything, mock.Anything).
 		Return(nil, errExpected)
 
 	client := &Client{svc: dynamodbClient}
@@ -254,9 +868,9 @@ func TestClientGetChanges(t *testing.T) {
 		Return(queryPaginator)
 
 	queryPaginator.EXPECT().HasMorePages().Return(true).Once()
-	queryPaginator.EXPECT().NextPage(ctx).Return(page1, nil).Once()
+	queryPaginator.EXPECT().NextPage(spanCtx).Return(page1, nil).Once()
 	queryPaginator.EXPECT().HasMorePages().Return(true).Once()
-	queryPaginator.EXPECT().NextPage(ctx).Return(page2, nil).Once()
+	queryPaginator.EXPECT().NextPage(spanCtx).Return(page2, nil).Once()
 	queryPaginator.EXPECT().HasMorePages().Return(false).Once()
 
 	client := &Client{
@@ -319,7 +933,7 @@ func TestClientGetChangesErrorOnQuery(t *testing.T) {
 		Return(queryPaginator)
 
 	queryPaginator.EXPECT().HasMorePages().Return(true).Once()
-	queryPaginator.EXPECT().NextPage(ctx).Return(nil, errExpected).Once()
+	queryPaginator.EXPECT().NextPage(spanCtx).Return(nil, errExpected).Once()
 
 	client := &Client{
 		svc:              dynamodbClient,
@@ -331,3 +945,251 @@ func TestClientGetChangesErrorOnQuery(t *testing.T) {
 	assert.Nil(t, updates)
 	assert.Equal(t, errExpected, err)
 }
+
+func TestClientGetUpdate(t *testing.T) {
+	paginatorFactory := newMockPaginatorFactory(t)
+	queryPaginator := newMockQueryPaginator(t)
+
+	paginatorFactory.EXPECT().
+		NewQueryPaginator(mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+			return assert.Equal(t, aws.String(changesTableName), input.TableName) &&
+				assert.Contains(t, input.ExpressionAttributeValues, ":0") &&
+				assert.Contains(t, input.ExpressionAttributeValues, ":1") &&
+				assert.NotNil(t, input.FilterExpression)
+		})).
+		Return(queryPaginator)
+
+	queryPaginator.EXPECT().HasMorePages().Return(true).Twice()
+	queryPaginator.EXPECT().NextPage(spanCtx).Return(&dynamodb.QueryOutput{}, nil).Once()
+	queryPaginator.EXPECT().NextPage(spanCtx).Return(&dynamodb.QueryOutput{
+		Items: []map[string]types.AttributeValue{{
+			"id":   &types.AttributeValueMemberS{Value: "an-id"},
+			"uid":  &types.AttributeValueMemberS{Value: "my-uid"},
+			"type": &types.AttributeValueMemberS{Value: "CORRECTION"},
+		}},
+	}, nil).Once()
+
+	client := &Client{
+		changesTableName: changesTableName,
+		paginatorFactory: paginatorFactory,
+	}
+
+	update, err := client.GetUpdate(ctx, "my-uid", "an-id")
+	assert.Nil(t, err)
+	assert.Equal(t, shared.Update{Id: "an-id", Uid: "my-uid", Type: "CORRECTION"}, update)
+}
+
+func TestClientGetUpdateWhenNotFound(t *testing.T) {
+	paginatorFactory := newMockPaginatorFactory(t)
+	queryPaginator := newMockQueryPaginator(t)
+
+	paginatorFactory.EXPECT().
+		NewQueryPaginator(mock.Anything).
+		Return(queryPaginator)
+
+	queryPaginator.EXPECT().HasMorePages().Return(true).Once()
+	queryPaginator.EXPECT().NextPage(spanCtx).Return(&dynamodb.QueryOutput{}, nil).Once()
+	queryPaginator.EXPECT().HasMorePages().Return(false).Once()
+
+	client := &Client{
+		changesTableName: changesTableName,
+		paginatorFactory: paginatorFactory,
+	}
+
+	update, err := client.GetUpdate(ctx, "my-uid", "an-id")
+	assert.Nil(t, err)
+	assert.Equal(t, shared.Update{}, update)
+}
+
+func TestClientGetUpdateWhenQueryErrors(t *testing.T) {
+	paginatorFactory := newMockPaginatorFactory(t)
+	queryPaginator := newMockQueryPaginator(t)
+
+	paginatorFactory.EXPECT().
+		NewQueryPaginator(mock.Anything).
+		Return(queryPaginator)
+
+	queryPaginator.EXPECT().HasMorePages().Return(true).Once()
+	queryPaginator.EXPECT().NextPage(spanCtx).Return(nil, errExpected).Once()
+
+	client := &Client{
+		changesTableName: changesTableName,
+		paginatorFactory: paginatorFactory,
+	}
+
+	_, err := client.GetUpdate(ctx, "my-uid", "an-id")
+	assert.Equal(t, errExpected, err)
+}
+
+func TestClientGetUidsByActor(t *testing.T) {
+	paginatorFactory := newMockPaginatorFactory(t)
+	queryPaginator := newMockQueryPaginator(t)
+
+	s := "#0 = :0"
+	paginatorFactory.EXPECT().
+		NewQueryPaginator(&dynamodb.QueryInput{
+			TableName:                aws.String(actorsTableName),
+			ExpressionAttributeNames: map[string]string{"#0": "actorUid"},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":0": &types.AttributeValueMemberS{Value: "an-actor"},
+			},
+			KeyConditionExpression: &s,
+		}).
+		Return(queryPaginator)
+
+	queryPaginator.EXPECT().HasMorePages().Return(true).Once()
+	queryPaginator.EXPECT().NextPage(spanCtx).Return(&dynamodb.QueryOutput{
+		Items: []map[string]types.AttributeValue{{
+			"actorUid": &types.AttributeValueMemberS{Value: "an-actor"},
+			"lpaUid":   &types.AttributeValueMemberS{Value: "M-1111-1111-1111"},
+		}},
+	}, nil).Once()
+	queryPaginator.EXPECT().HasMorePages().Return(true).Once()
+	queryPaginator.EXPECT().NextPage(spanCtx).Return(&dynamodb.QueryOutput{
+		Items: []map[string]types.AttributeValue{{
+			"actorUid": &types.AttributeValueMemberS{Value: "an-actor"},
+			"lpaUid":   &types.AttributeValueMemberS{Value: "M-2222-2222-2222"},
+		}},
+	}, nil).Once()
+	queryPaginator.EXPECT().HasMorePages().Return(false).Once()
+
+	client := &Client{
+		actorsTableName:  actorsTableName,
+		paginatorFactory: paginatorFactory,
+	}
+
+	uids, err := client.GetUidsByActor(ctx, "an-actor")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"M-1111-1111-1111", "M-2222-2222-2222"}, uids)
+}
+
+func TestClientGetUidsByActorWhenQueryErrors(t *testing.T) {
+	paginatorFactory := newMockPaginatorFactory(t)
+	queryPaginator := newMockQueryPaginator(t)
+
+	paginatorFactory.EXPECT().
+		NewQueryPaginator(mock.Anything).
+		Return(queryPaginator)
+
+	queryPaginator.EXPECT().HasMorePages().Return(true).Once()
+	queryPaginator.EXPECT().NextPage(spanCtx).Return(nil, errExpected).Once()
+
+	client := &Client{
+		actorsTableName:  actorsTableName,
+		paginatorFactory: paginatorFactory,
+	}
+
+	_, err := client.GetUidsByActor(ctx, "an-actor")
+	assert.Equal(t, errExpected, err)
+}
+
+func TestClientSearchByDonor(t *testing.T) {
+	testcases := map[string]struct {
+		startAfter        string
+		exclusiveStartKey map[string]types.AttributeValue
+		lastEvaluatedKey  map[string]types.AttributeValue
+		next              string
+	}{
+		"first page": {
+			lastEvaluatedKey: map[string]types.AttributeValue{
+				"searchKey": &types.AttributeValueMemberS{Value: "smith#1980-04-05#B147ED"},
+				"uid":       &types.AttributeValueMemberS{Value: "M-1111-1111-1111"},
+			},
+			next: "M-1111-1111-1111",
+		},
+		"last page": {
+			startAfter: "M-1111-1111-1111",
+			exclusiveStartKey: map[string]types.AttributeValue{
+				"searchKey": &types.AttributeValueMemberS{Value: "smith#1980-04-05#B147ED"},
+				"uid":       &types.AttributeValueMemberS{Value: "M-1111-1111-1111"},
+			},
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			dynamodbClient := newMockDynamodbClient(t)
+			dynamodbClient.EXPECT().
+				Query(spanCtx, &dynamodb.QueryInput{
+					TableName:                aws.String(tableName),
+					IndexName:                aws.String("searchKey"),
+					ExpressionAttributeNames: map[string]string{"#0": "searchKey"},
+					ExpressionAttributeValues: map[string]types.AttributeValue{
+						":0": &types.AttributeValueMemberS{Value: "smith#1980-04-05#B147ED"},
+					},
+					KeyConditionExpression: aws.String("#0 = :0"),
+					Limit:                  aws.Int32(10),
+					ExclusiveStartKey:      tc.exclusiveStartKey,
+				}).
+				Return(&dynamodb.QueryOutput{
+					Items: []map[string]types.AttributeValue{{
+						"uid": &types.AttributeValueMemberS{Value: "M-1111-1111-1111"},
+					}},
+					LastEvaluatedKey: tc.lastEvaluatedKey,
+				}, nil)
+
+			client := &Client{
+				svc:       dynamodbClient,
+				tableName: tableName,
+			}
+
+			lpas, next, err := client.SearchByDonor(ctx, "smith#1980-04-05#B147ED", 10, tc.startAfter)
+			assert.Nil(t, err)
+			assert.Equal(t, []shared.Lpa{{Uid: "M-1111-1111-1111"}}, lpas)
+			assert.Equal(t, tc.next, next)
+		})
+	}
+}
+
+func TestClientSearchByDonorWhenQueryErrors(t *testing.T) {
+	dynamodbClient := newMockDynamodbClient(t)
+	dynamodbClient.EXPECT().
+		Query(spanCtx, mock.Anything).
+		Return(nil, errExpected)
+
+	client := &Client{
+		svc:       dynamodbClient,
+		tableName: tableName,
+	}
+
+	_, _, err := client.SearchByDonor(ctx, "smith#1980-04-05#B147ED", 10, "")
+	assert.Equal(t, errExpected, err)
+}
+
+func TestMarshalLpa(t *testing.T) {
+	var donor shared.Donor
+	_ = json.Unmarshal([]byte(`{"lastName":"Smith","dateOfBirth":"1980-04-05","address":{"postcode":"B14 7ED"}}`), &donor)
+
+	item, err := marshalLpa(shared.Lpa{Uid: "M-1111-1111-1111", LpaInit: shared.LpaInit{Donor: donor}})
+	assert.Nil(t, err)
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "smith#1980-04-05#B147ED"}, item["searchKey"])
+}
+
+func TestMarshalLpaWhenNotSearchable(t *testing.T) {
+	item, err := marshalLpa(shared.Lpa{Uid: "M-1111-1111-1111"})
+	assert.Nil(t, err)
+	assert.NotContains(t, item, "searchKey")
+}
+
+func TestMarshalUpdateWithDiff(t *testing.T) {
+	item := marshalUpdate(shared.Update{
+		Changes: []shared.Change{},
+		Diff:    []shared.Change{{Key: "/status", Old: json.RawMessage(`"a"`), New: json.RawMessage(`"b"`)}},
+	})
+
+	assert.Equal(t, &types.AttributeValueMemberL{Value: []types.AttributeValue{
+		&types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+			"Key": &types.AttributeValueMemberS{Value: "/status"},
+			"Old": &types.AttributeValueMemberB{Value: []byte(`"a"`)},
+			"New": &types.AttributeValueMemberB{Value: []byte(`"b"`)},
+		}},
+	}}, item["diff"])
+
+	var update shared.Update
+	_ = attributevalue.UnmarshalMap(item, &update)
+	assert.Equal(t, []shared.Change{{Key: "/status", Old: json.RawMessage(`"a"`), New: json.RawMessage(`"b"`)}}, update.Diff)
+}
+
+func TestMarshalUpdateWithoutDiff(t *testing.T) {
+	assert.NotContains(t, marshalUpdate(shared.Update{}), "diff")
+}
@@ -125,6 +125,93 @@ func (_c *mockDynamodbClient_BatchGetItem_Call) RunAndReturn(run func(ctx contex
 	return _c
 }
 
+// DeleteItem provides a mock function for the type mockDynamodbClient
+func (_mock *mockDynamodbClient) DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	// func(*dynamodb.Options)
+	_va := make([]interface{}, len(optFns))
+	for _i := range optFns {
+		_va[_i] = optFns[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, params)
+	_ca = append(_ca, _va...)
+	ret := _mock.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteItem")
+	}
+
+	var r0 *dynamodb.DeleteItemOutput
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *dynamodb.DeleteItemInput, ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)); ok {
+		return returnFunc(ctx, params, optFns...)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *dynamodb.DeleteItemInput, ...func(*dynamodb.Options)) *dynamodb.DeleteItemOutput); ok {
+		r0 = returnFunc(ctx, params, optFns...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dynamodb.DeleteItemOutput)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, *dynamodb.DeleteItemInput, ...func(*dynamodb.Options)) error); ok {
+		r1 = returnFunc(ctx, params, optFns...)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockDynamodbClient_DeleteItem_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteItem'
+type mockDynamodbClient_DeleteItem_Call struct {
+	*mock.Call
+}
+
+// DeleteItem is a helper method to define mock.On call
+//   - ctx context.Context
+//   - params *dynamodb.DeleteItemInput
+//   - optFns ...func(*dynamodb.Options)
+func (_e *mockDynamodbClient_Expecter) DeleteItem(ctx interface{}, params interface{}, optFns ...interface{}) *mockDynamodbClient_DeleteItem_Call {
+	return &mockDynamodbClient_DeleteItem_Call{Call: _e.mock.On("DeleteItem",
+		append([]interface{}{ctx, params}, optFns...)...)}
+}
+
+func (_c *mockDynamodbClient_DeleteItem_Call) Run(run func(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options))) *mockDynamodbClient_DeleteItem_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 *dynamodb.DeleteItemInput
+		if args[1] != nil {
+			arg1 = args[1].(*dynamodb.DeleteItemInput)
+		}
+		var arg2 []func(*dynamodb.Options)
+		variadicArgs := make([]func(*dynamodb.Options), len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(func(*dynamodb.Options))
+			}
+		}
+		arg2 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2...,
+		)
+	})
+	return _c
+}
+
+func (_c *mockDynamodbClient_DeleteItem_Call) Return(deleteItemOutput *dynamodb.DeleteItemOutput, err error) *mockDynamodbClient_DeleteItem_Call {
+	_c.Call.Return(deleteItemOutput, err)
+	return _c
+}
+
+func (_c *mockDynamodbClient_DeleteItem_Call) RunAndReturn(run func(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)) *mockDynamodbClient_DeleteItem_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetItem provides a mock function for the type mockDynamodbClient
 func (_mock *mockDynamodbClient) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
 	// func(*dynamodb.Options)
@@ -386,6 +473,93 @@ func (_c *mockDynamodbClient_Query_Call) RunAndReturn(run func(ctx context.Conte
 	return _c
 }
 
+// Scan provides a mock function for the type mockDynamodbClient
+func (_mock *mockDynamodbClient) Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	// func(*dynamodb.Options)
+	_va := make([]interface{}, len(optFns))
+	for _i := range optFns {
+		_va[_i] = optFns[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, params)
+	_ca = append(_ca, _va...)
+	ret := _mock.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Scan")
+	}
+
+	var r0 *dynamodb.ScanOutput
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *dynamodb.ScanInput, ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)); ok {
+		return returnFunc(ctx, params, optFns...)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *dynamodb.ScanInput, ...func(*dynamodb.Options)) *dynamodb.ScanOutput); ok {
+		r0 = returnFunc(ctx, params, optFns...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dynamodb.ScanOutput)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, *dynamodb.ScanInput, ...func(*dynamodb.Options)) error); ok {
+		r1 = returnFunc(ctx, params, optFns...)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockDynamodbClient_Scan_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Scan'
+type mockDynamodbClient_Scan_Call struct {
+	*mock.Call
+}
+
+// Scan is a helper method to define mock.On call
+//   - ctx context.Context
+//   - params *dynamodb.ScanInput
+//   - optFns ...func(*dynamodb.Options)
+func (_e *mockDynamodbClient_Expecter) Scan(ctx interface{}, params interface{}, optFns ...interface{}) *mockDynamodbClient_Scan_Call {
+	return &mockDynamodbClient_Scan_Call{Call: _e.mock.On("Scan",
+		append([]interface{}{ctx, params}, optFns...)...)}
+}
+
+func (_c *mockDynamodbClient_Scan_Call) Run(run func(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options))) *mockDynamodbClient_Scan_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 *dynamodb.ScanInput
+		if args[1] != nil {
+			arg1 = args[1].(*dynamodb.ScanInput)
+		}
+		var arg2 []func(*dynamodb.Options)
+		variadicArgs := make([]func(*dynamodb.Options), len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(func(*dynamodb.Options))
+			}
+		}
+		arg2 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2...,
+		)
+	})
+	return _c
+}
+
+func (_c *mockDynamodbClient_Scan_Call) Return(scanOutput *dynamodb.ScanOutput, err error) *mockDynamodbClient_Scan_Call {
+	_c.Call.Return(scanOutput, err)
+	return _c
+}
+
+func (_c *mockDynamodbClient_Scan_Call) RunAndReturn(run func(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)) *mockDynamodbClient_Scan_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // TransactWriteItems provides a mock function for the type mockDynamodbClient
 func (_mock *mockDynamodbClient) TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
 	// func(*dynamodb.Options)
@@ -473,6 +647,93 @@ func (_c *mockDynamodbClient_TransactWriteItems_Call) RunAndReturn(run func(ctx
 	return _c
 }
 
+// UpdateItem provides a mock function for the type mockDynamodbClient
+func (_mock *mockDynamodbClient) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	// func(*dynamodb.Options)
+	_va := make([]interface{}, len(optFns))
+	for _i := range optFns {
+		_va[_i] = optFns[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, params)
+	_ca = append(_ca, _va...)
+	ret := _mock.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateItem")
+	}
+
+	var r0 *dynamodb.UpdateItemOutput
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *dynamodb.UpdateItemInput, ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)); ok {
+		return returnFunc(ctx, params, optFns...)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *dynamodb.UpdateItemInput, ...func(*dynamodb.Options)) *dynamodb.UpdateItemOutput); ok {
+		r0 = returnFunc(ctx, params, optFns...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dynamodb.UpdateItemOutput)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, *dynamodb.UpdateItemInput, ...func(*dynamodb.Options)) error); ok {
+		r1 = returnFunc(ctx, params, optFns...)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockDynamodbClient_UpdateItem_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateItem'
+type mockDynamodbClient_UpdateItem_Call struct {
+	*mock.Call
+}
+
+// UpdateItem is a helper method to define mock.On call
+//   - ctx context.Context
+//   - params *dynamodb.UpdateItemInput
+//   - optFns ...func(*dynamodb.Options)
+func (_e *mockDynamodbClient_Expecter) UpdateItem(ctx interface{}, params interface{}, optFns ...interface{}) *mockDynamodbClient_UpdateItem_Call {
+	return &mockDynamodbClient_UpdateItem_Call{Call: _e.mock.On("UpdateItem",
+		append([]interface{}{ctx, params}, optFns...)...)}
+}
+
+func (_c *mockDynamodbClient_UpdateItem_Call) Run(run func(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options))) *mockDynamodbClient_UpdateItem_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 *dynamodb.UpdateItemInput
+		if args[1] != nil {
+			arg1 = args[1].(*dynamodb.UpdateItemInput)
+		}
+		var arg2 []func(*dynamodb.Options)
+		variadicArgs := make([]func(*dynamodb.Options), len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(func(*dynamodb.Options))
+			}
+		}
+		arg2 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2...,
+		)
+	})
+	return _c
+}
+
+func (_c *mockDynamodbClient_UpdateItem_Call) Return(updateItemOutput *dynamodb.UpdateItemOutput, err error) *mockDynamodbClient_UpdateItem_Call {
+	_c.Call.Return(updateItemOutput, err)
+	return _c
+}
+
+func (_c *mockDynamodbClient_UpdateItem_Call) RunAndReturn(run func(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)) *mockDynamodbClient_UpdateItem_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // newMockQueryPaginator creates a new instance of mockQueryPaginator. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func newMockQueryPaginator(t interface {
@@ -0,0 +1,143 @@
+package ddb
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/event"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/tracing"
+)
+
+const (
+	// outboxRetention is how long an outbox entry is kept after it has been
+	// sent.
+	outboxRetention = 7 * 24 * time.Hour
+
+	// pendingIndexName is the outbox table index on pendingRegion and
+	// createdAt. pendingRegion is removed when an entry is sent, so the index
+	// only holds the entries that are still to be sent.
+	pendingIndexName = "pending"
+
+	// maxPendingOutboxEntries is the most pending entries returned at once
+	maxPendingOutboxEntries = 100
+)
+
+// outboxItem saves the entry as written in this region, so that only the relay
+// in the region that made the change sends it.
+func (c *Client) outboxItem(entry event.OutboxEntry) (types.TransactWriteItem, error) {
+	entry.Region = c.region
+
+	item, err := attributevalue.MarshalMapWithOptions(entry, encoderOptions)
+	if err != nil {
+		return types.TransactWriteItem{}, err
+	}
+
+	if entry.SentAt == nil {
+		item["pendingRegion"] = &types.AttributeValueMemberS{Value: c.region}
+	}
+
+	return types.TransactWriteItem{
+		Put: &types.Put{
+			TableName: aws.String(c.outboxTableName),
+			Item:      item,
+		},
+	}, nil
+}
+
+// GetOutboxEntry returns the outbox entry with the given ID, or an empty entry
+// if there is none.
+func (c *Client) GetOutboxEntry(ctx context.Context, id string) (entry event.OutboxEntry, err error) {
+	ctx, span := tracing.StartSpan(ctx, "ddb.GetOutboxEntry")
+	defer tracing.EndSpan(span, &err)
+
+	output, err := c.svc.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(c.outboxTableName),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: id},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return entry, err
+	}
+
+	err = attributevalue.UnmarshalMapWithOptions(output.Item, &entry, decoderOptions)
+
+	return entry, err
+}
+
+// MarkOutboxEntrySent records that the outbox entry with the given ID has been
+// put on the event bus, removes it from the pending index, and sets it to
+// expire after outboxRetention.
+func (c *Client) MarkOutboxEntrySent(ctx context.Context, id string, sentAt time.Time) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "ddb.MarkOutboxEntrySent")
+	defer tracing.EndSpan(span, &err)
+
+	expr, err := expression.NewBuilder().
+		WithCondition(expression.Name("id").AttributeExists()).
+		WithUpdate(expression.
+			Set(expression.Name("sentAt"), expression.Value(sentAt)).
+			Set(expression.Name("expiresAt"), expression.Value(sentAt.Add(outboxRetention).Unix())).
+			Remove(expression.Name("pendingRegion"))).
+		Build()
+	if err != nil {
+		return err
+	}
+
+	_, err = c.svc.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(c.outboxTableName),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: id},
+		},
+		ConditionExpression:       expr.Condition(),
+		UpdateExpression:          expr.Update(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	})
+
+	return err
+}
+
+// GetPendingOutboxIds returns the IDs of up to maxPendingOutboxEntries entries
+// written in this region that have not been sent and were created at or before
+// createdBefore, oldest first.
+func (c *Client) GetPendingOutboxIds(ctx context.Context, createdBefore time.Time) (ids []string, err error) {
+	ctx, span := tracing.StartSpan(ctx, "ddb.GetPendingOutboxIds")
+	defer tracing.EndSpan(span, &err)
+
+	keyEx := expression.Key("pendingRegion").Equal(expression.Value(c.region)).
+		And(expression.Key("createdAt").LessThanEqual(expression.Value(createdBefore.UTC().Format(time.RFC3339Nano))))
+	expr, err := expression.NewBuilder().WithKeyCondition(keyEx).Build()
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := c.svc.Query(ctx, &dynamodb.QueryInput{
+		TableName:                 aws.String(c.outboxTableName),
+		IndexName:                 aws.String(pendingIndexName),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+		Limit:                     aws.Int32(maxPendingOutboxEntries),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []event.OutboxEntry
+	if err := attributevalue.UnmarshalListOfMapsWithOptions(output.Items, &entries, decoderOptions); err != nil {
+		return nil, err
+	}
+
+	ids = make([]string, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.Id
+	}
+
+	return ids, nil
+}
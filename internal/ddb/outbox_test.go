@@ -0,0 +1,143 @@
+package ddb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/event"
+	"github.com/stretchr/testify/assert"
+	mock "github.com/stretchr/testify/mock"
+)
+
+func TestClientGetOutboxEntry(t *testing.T) {
+	dynamodbClient := newMockDynamodbClient(t)
+	dynamodbClient.EXPECT().
+		GetItem(spanCtx, &dynamodb.GetItemInput{
+			TableName: aws.String(outboxTableName),
+			Key: map[string]types.AttributeValue{
+				"id": &types.AttributeValueMemberS{Value: "an-id"},
+			},
+			ConsistentRead: aws.Bool(true),
+		}).
+		Return(&dynamodb.GetItemOutput{
+			Item: map[string]types.AttributeValue{
+				"id":     &types.AttributeValueMemberS{Value: "an-id"},
+				"region": &types.AttributeValueMemberS{Value: "eu-west-1"},
+				"event": &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+					"uid":        &types.AttributeValueMemberS{Value: "a-uid"},
+					"changeType": &types.AttributeValueMemberS{Value: "CREATE"},
+				}},
+				"createdAt": &types.AttributeValueMemberS{Value: "2024-01-01T12:00:00Z"},
+			},
+		}, nil)
+
+	client := &Client{svc: dynamodbClient, outboxTableName: outboxTableName}
+
+	entry, err := client.GetOutboxEntry(ctx, "an-id")
+	assert.Nil(t, err)
+	assert.Equal(t, event.OutboxEntry{
+		Id:        "an-id",
+		Region:    "eu-west-1",
+		Event:     event.LpaUpdated{Uid: "a-uid", ChangeType: "CREATE"},
+		CreatedAt: time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC),
+	}, entry)
+	assert.False(t, entry.Sent())
+}
+
+func TestClientGetOutboxEntryWhenClientErrors(t *testing.T) {
+	dynamodbClient := newMockDynamodbClient(t)
+	dynamodbClient.EXPECT().
+		GetItem(spanCtx, mock.Anything).
+		Return(nil, errExpected)
+
+	client := &Client{svc: dynamodbClient, outboxTableName: outboxTableName}
+
+	_, err := client.GetOutboxEntry(ctx, "an-id")
+	assert.Equal(t, errExpected, err)
+}
+
+func TestClientMarkOutboxEntrySent(t *testing.T) {
+	sentAt := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+	dynamodbClient := newMockDynamodbClient(t)
+	dynamodbClient.EXPECT().
+		UpdateItem(spanCtx, &dynamodb.UpdateItemInput{
+			TableName: aws.String(outboxTableName),
+			Key: map[string]types.AttributeValue{
+				"id": &types.AttributeValueMemberS{Value: "an-id"},
+			},
+			ConditionExpression:      aws.String("attribute_exists (#0)"),
+			UpdateExpression:         aws.String("REMOVE #1\nSET #2 = :0, #3 = :1\n"),
+			ExpressionAttributeNames: map[string]string{"#0": "id", "#1": "pendingRegion", "#2": "sentAt", "#3": "expiresAt"},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":0": &types.AttributeValueMemberS{Value: "2024-01-01T12:00:00Z"},
+				":1": &types.AttributeValueMemberN{Value: "1704715200"},
+			},
+		}).
+		Return(&dynamodb.UpdateItemOutput{}, nil)
+
+	client := &Client{svc: dynamodbClient, outboxTableName: outboxTableName}
+
+	err := client.MarkOutboxEntrySent(ctx, "an-id", sentAt)
+	assert.Nil(t, err)
+}
+
+func TestClientMarkOutboxEntrySentWhenClientErrors(t *testing.T) {
+	dynamodbClient := newMockDynamodbClient(t)
+	dynamodbClient.EXPECT().
+		UpdateItem(spanCtx, mock.Anything).
+		Return(nil, errExpected)
+
+	client := &Client{svc: dynamodbClient, outboxTableName: outboxTableName}
+
+	err := client.MarkOutboxEntrySent(ctx, "an-id", time.Now())
+	assert.Equal(t, errExpected, err)
+}
+
+func TestClientGetPendingOutboxIds(t *testing.T) {
+	dynamodbClient := newMockDynamodbClient(t)
+	dynamodbClient.EXPECT().
+		Query(spanCtx, &dynamodb.QueryInput{
+			TableName:                aws.String(outboxTableName),
+			IndexName:                aws.String("pending"),
+			ExpressionAttributeNames: map[string]string{"#0": "pendingRegion", "#1": "createdAt"},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":0": &types.AttributeValueMemberS{Value: "eu-west-1"},
+				":1": &types.AttributeValueMemberS{Value: "2024-01-01T12:00:00Z"},
+			},
+			KeyConditionExpression: aws.String("(#0 = :0) AND (#1 <= :1)"),
+			Limit:                  aws.Int32(100),
+		}).
+		Return(&dynamodb.QueryOutput{
+			Items: []map[string]types.AttributeValue{{
+				"id":            &types.AttributeValueMemberS{Value: "an-id"},
+				"pendingRegion": &types.AttributeValueMemberS{Value: "eu-west-1"},
+				"createdAt":     &types.AttributeValueMemberS{Value: "2024-01-01T11:00:00Z"},
+			}, {
+				"id":            &types.AttributeValueMemberS{Value: "another-id"},
+				"pendingRegion": &types.AttributeValueMemberS{Value: "eu-west-1"},
+				"createdAt":     &types.AttributeValueMemberS{Value: "2024-01-01T11:30:00Z"},
+			}},
+		}, nil)
+
+	client := &Client{svc: dynamodbClient, outboxTableName: outboxTableName, region: "eu-west-1"}
+
+	ids, err := client.GetPendingOutboxIds(ctx, time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"an-id", "another-id"}, ids)
+}
+
+func TestClientGetPendingOutboxIdsWhenClientErrors(t *testing.T) {
+	dynamodbClient := newMockDynamodbClient(t)
+	dynamodbClient.EXPECT().
+		Query(spanCtx, mock.Anything).
+		Return(nil, errExpected)
+
+	client := &Client{svc: dynamodbClient, outboxTableName: outboxTableName}
+
+	_, err := client.GetPendingOutboxIds(ctx, time.Now())
+	assert.Equal(t, errExpected, err)
+}
@@ -0,0 +1,91 @@
+package ddb
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+)
+
+// UnmarshalUpdate decodes an item from the changes table, as given in the
+// image of a DynamoDB stream record.
+func UnmarshalUpdate(image map[string]events.DynamoDBAttributeValue) (shared.Update, error) {
+	item, err := fromStreamImage(image)
+	if err != nil {
+		return shared.Update{}, err
+	}
+
+	var update shared.Update
+	if err := attributevalue.UnmarshalMap(item, &update); err != nil {
+		return shared.Update{}, err
+	}
+
+	return update, nil
+}
+
+func fromStreamImage(image map[string]events.DynamoDBAttributeValue) (map[string]types.AttributeValue, error) {
+	item := make(map[string]types.AttributeValue, len(image))
+	for k, v := range image {
+		av, err := fromStreamValue(v)
+		if err != nil {
+			return nil, err
+		}
+
+		item[k] = av
+	}
+
+	return item, nil
+}
+
+func fromStreamValue(v events.DynamoDBAttributeValue) (types.AttributeValue, error) {
+	switch v.DataType() {
+	case events.DataTypeString:
+		return &types.AttributeValueMemberS{Value: v.String()}, nil
+
+	case events.DataTypeNumber:
+		return &types.AttributeValueMemberN{Value: v.Number()}, nil
+
+	case events.DataTypeBinary:
+		return &types.AttributeValueMemberB{Value: v.Binary()}, nil
+
+	case events.DataTypeBoolean:
+		return &types.AttributeValueMemberBOOL{Value: v.Boolean()}, nil
+
+	case events.DataTypeNull:
+		return &types.AttributeValueMemberNULL{Value: true}, nil
+
+	case events.DataTypeStringSet:
+		return &types.AttributeValueMemberSS{Value: v.StringSet()}, nil
+
+	case events.DataTypeNumberSet:
+		return &types.AttributeValueMemberNS{Value: v.NumberSet()}, nil
+
+	case events.DataTypeBinarySet:
+		return &types.AttributeValueMemberBS{Value: v.BinarySet()}, nil
+
+	case events.DataTypeList:
+		list := make([]types.AttributeValue, len(v.List()))
+		for i, item := range v.List() {
+			av, err := fromStreamValue(item)
+			if err != nil {
+				return nil, err
+			}
+
+			list[i] = av
+		}
+
+		return &types.AttributeValueMemberL{Value: list}, nil
+
+	case events.DataTypeMap:
+		m, err := fromStreamImage(v.Map())
+		if err != nil {
+			return nil, err
+		}
+
+		return &types.AttributeValueMemberM{Value: m}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported stream attribute data type %d", v.DataType())
+}
@@ -0,0 +1,47 @@
+package ddb
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnmarshalUpdate(t *testing.T) {
+	// "bnVsbA==" is null and "InNpZ25lZCI=" is "signed", as changes are stored
+	// as binary
+	var image map[string]events.DynamoDBAttributeValue
+	err := json.Unmarshal([]byte(`{
+		"id": {"S": "an-id"},
+		"uid": {"S": "M-1"},
+		"applied": {"S": "2024-01-02T12:13:14.000000015Z"},
+		"author": {"S": "urn:opg:poas:sirius:users:1"},
+		"type": {"S": "ATTORNEY_SIGN"},
+		"changes": {"L": [{"M": {"Key": {"S": "/attorneys/0/signedAt"}, "Old": {"B": "bnVsbA=="}, "New": {"B": "InNpZ25lZCI="}}}]},
+		"diff": {"L": [{"M": {"Key": {"S": "/attorneys/0/signedAt"}, "Old": {"B": "bnVsbA=="}, "New": {"B": "InNpZ25lZCI="}}}]}
+	}`), &image)
+	assert.Nil(t, err)
+
+	change := shared.Change{Key: "/attorneys/0/signedAt", Old: json.RawMessage(`null`), New: json.RawMessage(`"signed"`)}
+
+	update, err := UnmarshalUpdate(image)
+	assert.Nil(t, err)
+	assert.Equal(t, shared.Update{
+		Id:      "an-id",
+		Uid:     "M-1",
+		Applied: "2024-01-02T12:13:14.000000015Z",
+		Author:  "urn:opg:poas:sirius:users:1",
+		Type:    "ATTORNEY_SIGN",
+		Changes: []shared.Change{change},
+		Diff:    []shared.Change{change},
+	}, update)
+}
+
+func TestUnmarshalUpdateWhenInvalid(t *testing.T) {
+	_, err := UnmarshalUpdate(map[string]events.DynamoDBAttributeValue{
+		"changes": events.NewBooleanAttribute(true),
+	})
+	assert.Error(t, err)
+}
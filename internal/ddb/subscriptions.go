@@ -0,0 +1,116 @@
+package ddb
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/tracing"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/webhook"
+)
+
+// deadLetterRetention is how long a failed webhook delivery is kept for, to be
+// investigated or redelivered.
+const deadLetterRetention = 30 * 24 * time.Hour
+
+// SubscriptionClient stores the webhook subscriptions, and the deliveries to
+// them that could not be made.
+type SubscriptionClient struct {
+	svc                  dynamodbClient
+	tableName            string
+	deadLettersTableName string
+}
+
+func NewSubscriptionClient(cfg aws.Config, tableName, deadLettersTableName string) *SubscriptionClient {
+	return &SubscriptionClient{
+		svc:                  dynamodb.NewFromConfig(cfg),
+		tableName:            tableName,
+		deadLettersTableName: deadLettersTableName,
+	}
+}
+
+// PutSubscription creates the subscription, or replaces it if one with the
+// same ID exists.
+func (c *SubscriptionClient) PutSubscription(ctx context.Context, subscription webhook.Subscription) error {
+	if err := subscription.Validate(); err != nil {
+		return err
+	}
+
+	item, err := attributevalue.MarshalMapWithOptions(subscription, encoderOptions)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.svc.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(c.tableName),
+		Item:      item,
+	})
+
+	return err
+}
+
+// DeleteSubscription removes the subscription with id, if it exists.
+func (c *SubscriptionClient) DeleteSubscription(ctx context.Context, id string) error {
+	_, err := c.svc.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(c.tableName),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: id},
+		},
+	})
+
+	return err
+}
+
+// GetSubscriptions returns all of the subscriptions. There are expected to be
+// few enough that reading the whole table is fine.
+func (c *SubscriptionClient) GetSubscriptions(ctx context.Context) (subscriptions []webhook.Subscription, err error) {
+	ctx, span := tracing.StartSpan(ctx, "ddb.GetSubscriptions")
+	defer tracing.EndSpan(span, &err)
+
+	input := &dynamodb.ScanInput{
+		TableName:      aws.String(c.tableName),
+		ConsistentRead: aws.Bool(true),
+	}
+
+	for {
+		output, err := c.svc.Scan(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+
+		var page []webhook.Subscription
+		if err := attributevalue.UnmarshalListOfMapsWithOptions(output.Items, &page, decoderOptions); err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, page...)
+
+		if len(output.LastEvaluatedKey) == 0 {
+			return subscriptions, nil
+		}
+		input.ExclusiveStartKey = output.LastEvaluatedKey
+	}
+}
+
+// PutDeadLetter records a delivery that could not be made, to expire after
+// deadLetterRetention.
+func (c *SubscriptionClient) PutDeadLetter(ctx context.Context, deadLetter webhook.DeadLetter) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "ddb.PutDeadLetter")
+	defer tracing.EndSpan(span, &err)
+
+	item, err := attributevalue.MarshalMapWithOptions(deadLetter, encoderOptions)
+	if err != nil {
+		return err
+	}
+	item["expiresAt"] = &types.AttributeValueMemberN{Value: strconv.FormatInt(deadLetter.FailedAt.Add(deadLetterRetention).Unix(), 10)}
+
+	_, err = c.svc.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(c.deadLettersTableName),
+		Item:      item,
+	})
+
+	return err
+}
@@ -0,0 +1,169 @@
+package ddb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/webhook"
+	"github.com/stretchr/testify/assert"
+	mock "github.com/stretchr/testify/mock"
+)
+
+var testSubscription = webhook.Subscription{
+	Id:          "a-subscription",
+	CallbackURL: "https://example.com/hook",
+	UpdateTypes: []string{"REGISTER"},
+	Statuses:    []shared.LpaStatus{shared.LpaStatusRegistered},
+	SecretARN:   "arn:aws:secretsmanager:eu-west-1:123456789012:secret:webhooks/a-subscription-AbCdEf",
+	CreatedAt:   time.Date(2024, time.January, 2, 12, 13, 14, 0, time.UTC),
+}
+
+func TestNewSubscriptionClient(t *testing.T) {
+	client := NewSubscriptionClient(aws.Config{}, "a-subscriptions-table", "a-dead-letters-table")
+
+	assert.IsType(t, (*dynamodb.Client)(nil), client.svc)
+	assert.Equal(t, "a-subscriptions-table", client.tableName)
+	assert.Equal(t, "a-dead-letters-table", client.deadLettersTableName)
+}
+
+func TestSubscriptionClientPutSubscription(t *testing.T) {
+	dynamodbClient := newMockDynamodbClient(t)
+	dynamodbClient.EXPECT().
+		PutItem(ctx, mock.MatchedBy(func(input *dynamodb.PutItemInput) bool {
+			return assert.Equal(t, "a-subscriptions-table", *input.TableName) &&
+				assert.Equal(t, &types.AttributeValueMemberS{Value: "a-subscription"}, input.Item["id"]) &&
+				assert.Equal(t, &types.AttributeValueMemberS{Value: "https://example.com/hook"}, input.Item["callbackUrl"])
+		})).
+		Return(&dynamodb.PutItemOutput{}, nil)
+
+	client := &SubscriptionClient{svc: dynamodbClient, tableName: "a-subscriptions-table"}
+
+	err := client.PutSubscription(ctx, testSubscription)
+	assert.Nil(t, err)
+}
+
+func TestSubscriptionClientPutSubscriptionWhenInvalid(t *testing.T) {
+	client := &SubscriptionClient{svc: newMockDynamodbClient(t), tableName: "a-subscriptions-table"}
+
+	err := client.PutSubscription(ctx, webhook.Subscription{Id: "a-subscription"})
+	assert.Error(t, err)
+}
+
+func TestSubscriptionClientPutSubscriptionWhenClientErrors(t *testing.T) {
+	dynamodbClient := newMockDynamodbClient(t)
+	dynamodbClient.EXPECT().
+		PutItem(ctx, mock.Anything).
+		Return(nil, errExpected)
+
+	client := &SubscriptionClient{svc: dynamodbClient, tableName: "a-subscriptions-table"}
+
+	err := client.PutSubscription(ctx, testSubscription)
+	assert.Equal(t, errExpected, err)
+}
+
+func TestSubscriptionClientDeleteSubscription(t *testing.T) {
+	dynamodbClient := newMockDynamodbClient(t)
+	dynamodbClient.EXPECT().
+		DeleteItem(ctx, &dynamodb.DeleteItemInput{
+			TableName: aws.String("a-subscriptions-table"),
+			Key: map[string]types.AttributeValue{
+				"id": &types.AttributeValueMemberS{Value: "a-subscription"},
+			},
+		}).
+		Return(&dynamodb.DeleteItemOutput{}, errExpected)
+
+	client := &SubscriptionClient{svc: dynamodbClient, tableName: "a-subscriptions-table"}
+
+	err := client.DeleteSubscription(ctx, "a-subscription")
+	assert.Equal(t, errExpected, err)
+}
+
+func TestSubscriptionClientGetSubscriptions(t *testing.T) {
+	first := testSubscription
+	second := testSubscription
+	second.Id = "another-subscription"
+	second.UpdateTypes = nil
+
+	firstItem, _ := attributevalue.MarshalMapWithOptions(first, encoderOptions)
+	secondItem, _ := attributevalue.MarshalMapWithOptions(second, encoderOptions)
+	lastKey := map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: "a-subscription"}}
+
+	dynamodbClient := newMockDynamodbClient(t)
+	dynamodbClient.EXPECT().
+		Scan(spanCtx, &dynamodb.ScanInput{
+			TableName:      aws.String("a-subscriptions-table"),
+			ConsistentRead: aws.Bool(true),
+		}).
+		Return(&dynamodb.ScanOutput{Items: []map[string]types.AttributeValue{firstItem}, LastEvaluatedKey: lastKey}, nil).
+		Once()
+	dynamodbClient.EXPECT().
+		Scan(spanCtx, &dynamodb.ScanInput{
+			TableName:         aws.String("a-subscriptions-table"),
+			ConsistentRead:    aws.Bool(true),
+			ExclusiveStartKey: lastKey,
+		}).
+		Return(&dynamodb.ScanOutput{Items: []map[string]types.AttributeValue{secondItem}}, nil).
+		Once()
+
+	client := &SubscriptionClient{svc: dynamodbClient, tableName: "a-subscriptions-table"}
+
+	subscriptions, err := client.GetSubscriptions(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, []webhook.Subscription{first, second}, subscriptions)
+}
+
+func TestSubscriptionClientGetSubscriptionsWhenClientErrors(t *testing.T) {
+	dynamodbClient := newMockDynamodbClient(t)
+	dynamodbClient.EXPECT().
+		Scan(spanCtx, mock.Anything).
+		Return(nil, errExpected)
+
+	client := &SubscriptionClient{svc: dynamodbClient, tableName: "a-subscriptions-table"}
+
+	_, err := client.GetSubscriptions(ctx)
+	assert.Equal(t, errExpected, err)
+}
+
+func TestSubscriptionClientPutDeadLetter(t *testing.T) {
+	failedAt := time.Date(2024, time.January, 2, 12, 13, 14, 0, time.UTC)
+
+	dynamodbClient := newMockDynamodbClient(t)
+	dynamodbClient.EXPECT().
+		PutItem(spanCtx, mock.MatchedBy(func(input *dynamodb.PutItemInput) bool {
+			return assert.Equal(t, "a-dead-letters-table", *input.TableName) &&
+				assert.Equal(t, &types.AttributeValueMemberS{Value: "an-update/a-subscription"}, input.Item["deliveryId"]) &&
+				assert.Equal(t, &types.AttributeValueMemberN{Value: "3"}, input.Item["attempts"]) &&
+				assert.Equal(t, &types.AttributeValueMemberN{Value: "1706789594"}, input.Item["expiresAt"])
+		})).
+		Return(&dynamodb.PutItemOutput{}, nil)
+
+	client := &SubscriptionClient{svc: dynamodbClient, deadLettersTableName: "a-dead-letters-table"}
+
+	err := client.PutDeadLetter(ctx, webhook.DeadLetter{
+		DeliveryId:     "an-update/a-subscription",
+		SubscriptionId: "a-subscription",
+		CallbackURL:    "https://example.com/hook",
+		Payload:        []byte(`{}`),
+		Attempts:       3,
+		LastError:      "callback responded 500",
+		FailedAt:       failedAt,
+	})
+	assert.Nil(t, err)
+}
+
+func TestSubscriptionClientPutDeadLetterWhenClientErrors(t *testing.T) {
+	dynamodbClient := newMockDynamodbClient(t)
+	dynamodbClient.EXPECT().
+		PutItem(spanCtx, mock.Anything).
+		Return(nil, errExpected)
+
+	client := &SubscriptionClient{svc: dynamodbClient, deadLettersTableName: "a-dead-letters-table"}
+
+	err := client.PutDeadLetter(ctx, webhook.DeadLetter{})
+	assert.Equal(t, errExpected, err)
+}
@@ -0,0 +1,65 @@
+package ddb
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// TokenClient records the IDs of JWTs that have been used, so that a token
+// cannot be replayed. Items expire from the table when the token does.
+type TokenClient struct {
+	svc       dynamodbClient
+	tableName string
+}
+
+func NewTokenClient(cfg aws.Config, tableName string) *TokenClient {
+	return &TokenClient{
+		svc:       dynamodb.NewFromConfig(cfg),
+		tableName: tableName,
+	}
+}
+
+// PutTokenId records that the token with id has been used, and reports
+// whether it had not been used before. An id recorded with an expiry that has
+// passed is treated as unused, as the item may not have been removed yet.
+func (c *TokenClient) PutTokenId(ctx context.Context, id string, expiresAt time.Time) (bool, error) {
+	now := time.Now().Unix()
+
+	expr, err := expression.NewBuilder().
+		WithCondition(expression.Or(
+			expression.Name("id").AttributeNotExists(),
+			expression.Name("expiresAt").LessThanEqual(expression.Value(now)),
+		)).
+		Build()
+	if err != nil {
+		return false, err
+	}
+
+	_, err = c.svc.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(c.tableName),
+		Item: map[string]types.AttributeValue{
+			"id":        &types.AttributeValueMemberS{Value: id},
+			"expiresAt": &types.AttributeValueMemberN{Value: strconv.FormatInt(expiresAt.Unix(), 10)},
+		},
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	})
+	if err != nil {
+		var conditionFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailed) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,67 @@
+package ddb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	mock "github.com/stretchr/testify/mock"
+)
+
+func TestNewTokenClient(t *testing.T) {
+	client := NewTokenClient(aws.Config{}, "a-tokens-table")
+
+	assert.IsType(t, (*dynamodb.Client)(nil), client.svc)
+	assert.Equal(t, "a-tokens-table", client.tableName)
+}
+
+func TestTokenClientPutTokenId(t *testing.T) {
+	expiresAt := time.Date(2030, time.January, 2, 3, 4, 5, 0, time.UTC)
+
+	dynamodbClient := newMockDynamodbClient(t)
+	dynamodbClient.EXPECT().
+		PutItem(ctx, mock.MatchedBy(func(input *dynamodb.PutItemInput) bool {
+			return assert.Equal(t, "a-tokens-table", *input.TableName) &&
+				assert.Equal(t, map[string]types.AttributeValue{
+					"id":        &types.AttributeValueMemberS{Value: "opg.poas.sirius#a-jti"},
+					"expiresAt": &types.AttributeValueMemberN{Value: "1893553445"},
+				}, input.Item) &&
+				assert.Equal(t, "(attribute_not_exists (#0)) OR (#1 <= :0)", *input.ConditionExpression) &&
+				assert.Equal(t, map[string]string{"#0": "id", "#1": "expiresAt"}, input.ExpressionAttributeNames)
+		})).
+		Return(&dynamodb.PutItemOutput{}, nil)
+
+	client := &TokenClient{svc: dynamodbClient, tableName: "a-tokens-table"}
+
+	unused, err := client.PutTokenId(ctx, "opg.poas.sirius#a-jti", expiresAt)
+	assert.Nil(t, err)
+	assert.True(t, unused)
+}
+
+func TestTokenClientPutTokenIdWhenAlreadyUsed(t *testing.T) {
+	dynamodbClient := newMockDynamodbClient(t)
+	dynamodbClient.EXPECT().
+		PutItem(ctx, mock.Anything).
+		Return(nil, &types.ConditionalCheckFailedException{})
+
+	client := &TokenClient{svc: dynamodbClient, tableName: "a-tokens-table"}
+
+	unused, err := client.PutTokenId(ctx, "opg.poas.sirius#a-jti", time.Now())
+	assert.Nil(t, err)
+	assert.False(t, unused)
+}
+
+func TestTokenClientPutTokenIdWhenClientErrors(t *testing.T) {
+	dynamodbClient := newMockDynamodbClient(t)
+	dynamodbClient.EXPECT().
+		PutItem(ctx, mock.Anything).
+		Return(nil, errExpected)
+
+	client := &TokenClient{svc: dynamodbClient, tableName: "a-tokens-table"}
+
+	_, err := client.PutTokenId(ctx, "opg.poas.sirius#a-jti", time.Now())
+	assert.Equal(t, errExpected, err)
+}
@@ -0,0 +1,60 @@
+package event
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+)
+
+// actorUID returns the UID of the actor whose details the change key points
+// into. Actors in lists may be identified by index or UID.
+func actorUID(lpa shared.Lpa, key string) (string, bool) {
+	parts := strings.SplitN(strings.TrimPrefix(key, "/"), "/", 3)
+
+	switch parts[0] {
+	case "donor":
+		return lpa.Donor.UID, true
+
+	case "certificateProvider":
+		return lpa.CertificateProvider.UID, true
+
+	case "attorneys":
+		if len(parts) > 1 {
+			if idx, ok := lpa.FindAttorneyIndex(parts[1]); ok && idx >= 0 {
+				return lpa.Attorneys[idx].UID, true
+			}
+		}
+
+	case "trustCorporations":
+		if len(parts) > 1 {
+			if idx, ok := lpa.FindTrustCorporationIndex(parts[1]); ok && idx >= 0 {
+				return lpa.TrustCorporations[idx].UID, true
+			}
+		}
+
+	case "peopleToNotify":
+		if len(parts) > 1 {
+			for i, personToNotify := range lpa.PeopleToNotify {
+				if personToNotify.UID == parts[1] || strconv.Itoa(i) == parts[1] {
+					return personToNotify.UID, true
+				}
+			}
+		}
+	}
+
+	return "", false
+}
+
+// uniqueUIDs removes empty and repeated UIDs, keeping the first of each.
+func uniqueUIDs(uids []string) []string {
+	var result []string
+	for _, uid := range uids {
+		if uid != "" && !slices.Contains(result, uid) {
+			result = append(result, uid)
+		}
+	}
+
+	return result
+}
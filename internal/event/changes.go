@@ -0,0 +1,144 @@
+package event
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+)
+
+// Detail types of the events describing what an update changed.
+const (
+	DetailTypeLpaRegistered  = "lpa-registered"
+	DetailTypeAttorneySigned = "attorney-signed"
+	DetailTypeStatusChanged  = "status-changed"
+)
+
+// ChangeEvent is an event describing part of an update made to an LPA. Detail
+// is one of LpaRegistered, AttorneySigned or StatusChanged.
+type ChangeEvent struct {
+	DetailType string
+	Detail     any
+}
+
+// LpaChange holds the details common to all change events. Changes are only
+// those relevant to the event, and ActorUIDs are the actors the event concerns.
+type LpaChange struct {
+	Uid        string          `json:"uid"`
+	UpdateId   string          `json:"updateId"`
+	UpdateType string          `json:"updateType"`
+	Applied    string          `json:"applied"`
+	Author     shared.URN      `json:"author"`
+	Changes    []shared.Change `json:"changes"`
+	ActorUIDs  []string        `json:"actorUids"`
+}
+
+func (c LpaChange) lpaChange() LpaChange {
+	return c
+}
+
+type LpaRegistered struct {
+	LpaChange
+}
+
+type AttorneySigned struct {
+	LpaChange
+	AttorneyUID string `json:"attorneyUid"`
+}
+
+type StatusChanged struct {
+	LpaChange
+	OldStatus shared.LpaStatus `json:"oldStatus"`
+	NewStatus shared.LpaStatus `json:"newStatus"`
+}
+
+// NewChangeEvents lists the events to send for an update recorded in the
+// changes table. The lpa is used to find the UIDs of the actors involved.
+func NewChangeEvents(update shared.Update, lpa shared.Lpa) []ChangeEvent {
+	// updates made before the diff was recorded only have their changes
+	changes := update.Diff
+	if len(changes) == 0 {
+		changes = update.Changes
+	}
+
+	lpaChange := func(changes []shared.Change, actorUIDs []string) LpaChange {
+		return LpaChange{
+			Uid:        update.Uid,
+			UpdateId:   update.Id,
+			UpdateType: update.Type,
+			Applied:    update.Applied,
+			Author:     update.Author,
+			Changes:    changes,
+			ActorUIDs:  actorUIDs,
+		}
+	}
+
+	var events []ChangeEvent
+
+	switch update.Type {
+	case "REGISTER":
+		events = append(events, ChangeEvent{
+			DetailType: DetailTypeLpaRegistered,
+			Detail:     LpaRegistered{LpaChange: lpaChange(changes, lpa.ActorUIDs())},
+		})
+
+	case "ATTORNEY_SIGN":
+		if uid, attorneyChanges, ok := actorChanges(lpa, changes, "/attorneys/"); ok {
+			events = append(events, ChangeEvent{
+				DetailType: DetailTypeAttorneySigned,
+				Detail:     AttorneySigned{LpaChange: lpaChange(attorneyChanges, []string{uid}), AttorneyUID: uid},
+			})
+		}
+
+	case "TRUST_CORPORATION_SIGN":
+		if uid, trustCorporationChanges, ok := actorChanges(lpa, changes, "/trustCorporations/"); ok {
+			events = append(events, ChangeEvent{
+				DetailType: DetailTypeAttorneySigned,
+				Detail:     AttorneySigned{LpaChange: lpaChange(trustCorporationChanges, []string{uid}), AttorneyUID: uid},
+			})
+		}
+	}
+
+	for _, change := range changes {
+		if change.Key != "/status" {
+			continue
+		}
+
+		var oldStatus, newStatus shared.LpaStatus
+		_ = json.Unmarshal(change.Old, &oldStatus)
+		_ = json.Unmarshal(change.New, &newStatus)
+
+		events = append(events, ChangeEvent{
+			DetailType: DetailTypeStatusChanged,
+			Detail: StatusChanged{
+				LpaChange: lpaChange([]shared.Change{change}, lpa.ActorUIDs()),
+				OldStatus: oldStatus,
+				NewStatus: newStatus,
+			},
+		})
+	}
+
+	return events
+}
+
+// actorChanges returns the changes made to an actor in the list at prefix,
+// along with the actor's UID.
+func actorChanges(lpa shared.Lpa, changes []shared.Change, prefix string) (string, []shared.Change, bool) {
+	var (
+		uid    string
+		result []shared.Change
+	)
+
+	for _, change := range changes {
+		if !strings.HasPrefix(change.Key, prefix) {
+			continue
+		}
+
+		if actorUID, ok := actorUID(lpa, change.Key); ok {
+			uid = actorUID
+			result = append(result, change)
+		}
+	}
+
+	return uid, result, len(result) > 0
+}
@@ -0,0 +1,134 @@
+package event
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewChangeEvents(t *testing.T) {
+	lpa := shared.Lpa{
+		LpaInit: shared.LpaInit{
+			Donor:               shared.Donor{Person: shared.Person{UID: "donor"}},
+			CertificateProvider: shared.CertificateProvider{Person: shared.Person{UID: "certificate-provider"}},
+			Attorneys: []shared.Attorney{
+				{Person: shared.Person{UID: "attorney-1"}},
+				{Person: shared.Person{UID: "attorney-2"}},
+			},
+			TrustCorporations: []shared.TrustCorporation{{UID: "trust-corporation"}},
+		},
+	}
+	allActors := []string{"donor", "certificate-provider", "attorney-1", "attorney-2", "trust-corporation"}
+
+	statusChange := shared.Change{Key: "/status", Old: json.RawMessage(`"statutory-waiting-period"`), New: json.RawMessage(`"registered"`)}
+	registrationDateChange := shared.Change{Key: "/registrationDate", Old: json.RawMessage(`null`), New: json.RawMessage(`"2024-01-02T12:13:14Z"`)}
+	signedAtChange := shared.Change{Key: "/attorneys/1/signedAt", Old: json.RawMessage(`null`), New: json.RawMessage(`"2024-01-02T12:13:14Z"`)}
+	versionChange := shared.Change{Key: "/version", Old: json.RawMessage(`3`), New: json.RawMessage(`4`)}
+
+	lpaChange := func(updateType string, changes []shared.Change, actorUIDs []string) LpaChange {
+		return LpaChange{
+			Uid:        "M-1",
+			UpdateId:   "an-id",
+			UpdateType: updateType,
+			Applied:    "2024-01-02T12:13:14.000000015Z",
+			Author:     "urn:opg:poas:sirius:users:1",
+			Changes:    changes,
+			ActorUIDs:  actorUIDs,
+		}
+	}
+
+	testcases := map[string]struct {
+		update   shared.Update
+		expected []ChangeEvent
+	}{
+		"register": {
+			update: shared.Update{
+				Type: "REGISTER",
+				Diff: []shared.Change{registrationDateChange, statusChange, versionChange},
+			},
+			expected: []ChangeEvent{{
+				DetailType: DetailTypeLpaRegistered,
+				Detail:     LpaRegistered{LpaChange: lpaChange("REGISTER", []shared.Change{registrationDateChange, statusChange, versionChange}, allActors)},
+			}, {
+				DetailType: DetailTypeStatusChanged,
+				Detail: StatusChanged{
+					LpaChange: lpaChange("REGISTER", []shared.Change{statusChange}, allActors),
+					OldStatus: shared.LpaStatusStatutoryWaitingPeriod,
+					NewStatus: shared.LpaStatusRegistered,
+				},
+			}},
+		},
+		"attorney sign": {
+			update: shared.Update{
+				Type: "ATTORNEY_SIGN",
+				Diff: []shared.Change{signedAtChange, versionChange},
+			},
+			expected: []ChangeEvent{{
+				DetailType: DetailTypeAttorneySigned,
+				Detail: AttorneySigned{
+					LpaChange:   lpaChange("ATTORNEY_SIGN", []shared.Change{signedAtChange}, []string{"attorney-2"}),
+					AttorneyUID: "attorney-2",
+				},
+			}},
+		},
+		"attorney sign by uid without diff": {
+			update: shared.Update{
+				Type:    "ATTORNEY_SIGN",
+				Changes: []shared.Change{{Key: "/attorneys/attorney-1/signedAt", New: json.RawMessage(`"2024-01-02T12:13:14Z"`)}},
+			},
+			expected: []ChangeEvent{{
+				DetailType: DetailTypeAttorneySigned,
+				Detail: AttorneySigned{
+					LpaChange:   lpaChange("ATTORNEY_SIGN", []shared.Change{{Key: "/attorneys/attorney-1/signedAt", New: json.RawMessage(`"2024-01-02T12:13:14Z"`)}}, []string{"attorney-1"}),
+					AttorneyUID: "attorney-1",
+				},
+			}},
+		},
+		"trust corporation sign": {
+			update: shared.Update{
+				Type: "TRUST_CORPORATION_SIGN",
+				Diff: []shared.Change{{Key: "/trustCorporations/0/signatories/0/signedAt"}, versionChange},
+			},
+			expected: []ChangeEvent{{
+				DetailType: DetailTypeAttorneySigned,
+				Detail: AttorneySigned{
+					LpaChange:   lpaChange("TRUST_CORPORATION_SIGN", []shared.Change{{Key: "/trustCorporations/0/signatories/0/signedAt"}}, []string{"trust-corporation"}),
+					AttorneyUID: "trust-corporation",
+				},
+			}},
+		},
+		"status change": {
+			update: shared.Update{
+				Type: "OPG_STATUS_CHANGE",
+				Diff: []shared.Change{statusChange, versionChange},
+			},
+			expected: []ChangeEvent{{
+				DetailType: DetailTypeStatusChanged,
+				Detail: StatusChanged{
+					LpaChange: lpaChange("OPG_STATUS_CHANGE", []shared.Change{statusChange}, allActors),
+					OldStatus: shared.LpaStatusStatutoryWaitingPeriod,
+					NewStatus: shared.LpaStatusRegistered,
+				},
+			}},
+		},
+		"other": {
+			update: shared.Update{
+				Type: "CORRECTION",
+				Diff: []shared.Change{{Key: "/donor/lastName"}, versionChange},
+			},
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			tc.update.Uid = "M-1"
+			tc.update.Id = "an-id"
+			tc.update.Applied = "2024-01-02T12:13:14.000000015Z"
+			tc.update.Author = "urn:opg:poas:sirius:users:1"
+
+			assert.Equal(t, tc.expected, NewChangeEvents(tc.update, lpa))
+		})
+	}
+}
@@ -3,13 +3,24 @@ package event
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"slices"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
 	"github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
 )
 
-const source = "opg.poas.lpastore"
+const (
+	source = "opg.poas.lpastore"
+
+	// putEventsLimit is the maximum number of entries EventBridge accepts in a
+	// single PutEvents request
+	putEventsLimit = 10
+)
 
 type EventBridgeClient interface {
 	PutEvents(ctx context.Context, params *eventbridge.PutEventsInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error)
@@ -27,7 +38,20 @@ func NewClient(cfg aws.Config, eventBusName string) *Client {
 	}
 }
 
-func (c *Client) SendLpaUpdated(ctx context.Context, event LpaUpdated, metric *Metric) error {
+// SendLpaUpdated puts an lpa-updated event, and a metric event for any metrics,
+// on the event bus in one request. The current trace context is included in the
+// event detail so that consumers can continue the trace. An error is returned
+// if either event could not be put.
+func (c *Client) SendLpaUpdated(ctx context.Context, event LpaUpdated, metrics []Metric) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "event.SendLpaUpdated", tracing.LpaUID(event.Uid), tracing.UpdateTypes(event.ChangeType))
+	defer tracing.EndSpan(span, &err)
+
+	traceContext := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, traceContext)
+	if len(traceContext) > 0 {
+		event.TraceContext = traceContext
+	}
+
 	v, err := json.Marshal(event)
 	if err != nil {
 		return err
@@ -40,12 +64,8 @@ func (c *Client) SendLpaUpdated(ctx context.Context, event LpaUpdated, metric *M
 		Detail:       aws.String(string(v)),
 	}}
 
-	if metric != nil {
-		metricData, err := json.Marshal(metrics{
-			Metrics: []metricWrapper{{
-				Metric: metric,
-			}},
-		})
+	if len(metrics) > 0 {
+		metricData, err := json.Marshal(metricsDetail(metrics))
 		if err != nil {
 			return err
 		}
@@ -58,9 +78,53 @@ func (c *Client) SendLpaUpdated(ctx context.Context, event LpaUpdated, metric *M
 		})
 	}
 
-	_, err = c.svc.PutEvents(ctx, &eventbridge.PutEventsInput{
+	output, err := c.svc.PutEvents(ctx, &eventbridge.PutEventsInput{
 		Entries: entries,
 	})
+	if err != nil {
+		return err
+	}
+
+	if output.FailedEntryCount > 0 {
+		return fmt.Errorf("failed to put %d events", output.FailedEntryCount)
+	}
+
+	return nil
+}
+
+// SendChangeEvents puts the change events on the event bus, in as few requests
+// as possible. An error is returned if any of the events could not be put.
+func (c *Client) SendChangeEvents(ctx context.Context, changeEvents []ChangeEvent) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "event.SendChangeEvents")
+	defer tracing.EndSpan(span, &err)
+
+	entries := make([]types.PutEventsRequestEntry, len(changeEvents))
+	for i, changeEvent := range changeEvents {
+		v, err := json.Marshal(changeEvent.Detail)
+		if err != nil {
+			return err
+		}
+
+		entries[i] = types.PutEventsRequestEntry{
+			EventBusName: aws.String(c.eventBusName),
+			Source:       aws.String(source),
+			DetailType:   aws.String(changeEvent.DetailType),
+			Detail:       aws.String(string(v)),
+		}
+	}
+
+	for chunk := range slices.Chunk(entries, putEventsLimit) {
+		output, err := c.svc.PutEvents(ctx, &eventbridge.PutEventsInput{
+			Entries: chunk,
+		})
+		if err != nil {
+			return err
+		}
+
+		if output.FailedEntryCount > 0 {
+			return fmt.Errorf("failed to put %d events", output.FailedEntryCount)
+		}
+	}
 
-	return err
+	return nil
 }
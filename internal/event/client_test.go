@@ -9,6 +9,12 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
 	"github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
 type ctxValueType string
@@ -17,6 +23,7 @@ const ctxValue ctxValueType = "for"
 
 var (
 	ctx          = context.WithValue(context.Background(), ctxValue, "testing")
+	spanCtx      = mock.MatchedBy(func(c context.Context) bool { return c.Value(ctxValue) == "testing" })
 	errExpected  = errors.New("err")
 	eventBusName = "an-event-bus-name"
 )
@@ -33,7 +40,7 @@ func TestClientSendLpaUpdated(t *testing.T) {
 
 	eventBridgeClient := newMockEventBridgeClient(t)
 	eventBridgeClient.EXPECT().
-		PutEvents(ctx, &eventbridge.PutEventsInput{
+		PutEvents(spanCtx, &eventbridge.PutEventsInput{
 			Entries: []types.PutEventsRequestEntry{{
 				EventBusName: aws.String(eventBusName),
 				Source:       aws.String(source),
@@ -49,12 +56,53 @@ func TestClientSendLpaUpdated(t *testing.T) {
 	assert.Equal(t, errExpected, err)
 }
 
-func TestClientSendLpaUpdatedWithMetric(t *testing.T) {
+func TestClientSendLpaUpdatedWhenEntriesFail(t *testing.T) {
+	eventBridgeClient := newMockEventBridgeClient(t)
+	eventBridgeClient.EXPECT().
+		PutEvents(spanCtx, mock.Anything).
+		Return(&eventbridge.PutEventsOutput{FailedEntryCount: 1}, nil)
+
+	client := &Client{svc: eventBridgeClient, eventBusName: eventBusName}
+
+	err := client.SendLpaUpdated(ctx, LpaUpdated{Uid: "M-1234-1234-1234"}, nil)
+	assert.EqualError(t, err, "failed to put 1 events")
+}
+
+func TestClientSendLpaUpdatedWithTraceContext(t *testing.T) {
+	otel.SetTracerProvider(sdktrace.NewTracerProvider())
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+	})
+
+	event := LpaUpdated{Uid: "M-1234-1234-1234", ChangeType: "CREATE"}
+
+	var traceparent string
+	eventBridgeClient := newMockEventBridgeClient(t)
+	eventBridgeClient.EXPECT().
+		PutEvents(spanCtx, mock.Anything).
+		RunAndReturn(func(ctx context.Context, input *eventbridge.PutEventsInput, _ ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error) {
+			spanContext := trace.SpanContextFromContext(ctx)
+			traceparent = "00-" + spanContext.TraceID().String() + "-" + spanContext.SpanID().String() + "-01"
+
+			assert.JSONEq(t, `{"uid":"M-1234-1234-1234","changeType":"CREATE","traceContext":{"traceparent":"`+traceparent+`"}}`, *input.Entries[0].Detail)
+			return &eventbridge.PutEventsOutput{}, nil
+		})
+
+	client := &Client{svc: eventBridgeClient, eventBusName: eventBusName}
+
+	err := client.SendLpaUpdated(ctx, event, nil)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, traceparent)
+}
+
+func TestClientSendLpaUpdatedWithMetrics(t *testing.T) {
 	event := LpaUpdated{Uid: "M-1234-1234-1234", ChangeType: "CREATE"}
 
 	eventBridgeClient := newMockEventBridgeClient(t)
 	eventBridgeClient.EXPECT().
-		PutEvents(ctx, &eventbridge.PutEventsInput{
+		PutEvents(spanCtx, &eventbridge.PutEventsInput{
 			Entries: []types.PutEventsRequestEntry{{
 				EventBusName: aws.String(eventBusName),
 				Source:       aws.String(source),
@@ -64,13 +112,80 @@ func TestClientSendLpaUpdatedWithMetric(t *testing.T) {
 				EventBusName: aws.String(eventBusName),
 				Source:       aws.String(source),
 				DetailType:   aws.String("metric"),
-				Detail:       aws.String(`{"metrics":[{"metric":{"Project":"X","Category":"Y","Subcategory":"","Environment":"","MeasureName":"","MeasureValue":"","MeasureValueType":"","Time":""}}]}`),
+				Detail:       aws.String(`{"metrics":[{"metric":{"Project":"X","Category":"Y","Subcategory":"","Environment":"","MeasureName":"","MeasureValue":"","MeasureValueType":"","Time":""}},{"metric":{"Project":"X","Category":"Z","Subcategory":"","Environment":"","MeasureName":"","MeasureValue":"","MeasureValueType":"","Time":""}}]}`),
 			}},
 		}).
 		Return(nil, errExpected)
 
 	client := &Client{svc: eventBridgeClient, eventBusName: eventBusName}
 
-	err := client.SendLpaUpdated(ctx, event, &Metric{Project: "X", Category: "Y"})
+	err := client.SendLpaUpdated(ctx, event, []Metric{{Project: "X", Category: "Y"}, {Project: "X", Category: "Z"}})
 	assert.Equal(t, errExpected, err)
 }
+
+func TestClientSendChangeEvents(t *testing.T) {
+	changeEvents := make([]ChangeEvent, 11)
+	for i := range changeEvents {
+		changeEvents[i] = ChangeEvent{
+			DetailType: DetailTypeLpaRegistered,
+			Detail:     LpaRegistered{LpaChange: LpaChange{Uid: "M-1234-1234-1234"}},
+		}
+	}
+
+	entry := types.PutEventsRequestEntry{
+		EventBusName: aws.String(eventBusName),
+		Source:       aws.String(source),
+		DetailType:   aws.String("lpa-registered"),
+		Detail:       aws.String(`{"uid":"M-1234-1234-1234","updateId":"","updateType":"","applied":"","author":"","changes":null,"actorUids":null}`),
+	}
+
+	eventBridgeClient := newMockEventBridgeClient(t)
+	eventBridgeClient.EXPECT().
+		PutEvents(spanCtx, mock.MatchedBy(func(input *eventbridge.PutEventsInput) bool {
+			return len(input.Entries) == 10
+		})).
+		RunAndReturn(func(_ context.Context, input *eventbridge.PutEventsInput, _ ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error) {
+			assert.Equal(t, entry, input.Entries[0])
+			return &eventbridge.PutEventsOutput{}, nil
+		}).
+		Once()
+	eventBridgeClient.EXPECT().
+		PutEvents(spanCtx, &eventbridge.PutEventsInput{Entries: []types.PutEventsRequestEntry{entry}}).
+		Return(&eventbridge.PutEventsOutput{}, nil).
+		Once()
+
+	client := &Client{svc: eventBridgeClient, eventBusName: eventBusName}
+
+	err := client.SendChangeEvents(ctx, changeEvents)
+	assert.Nil(t, err)
+}
+
+func TestClientSendChangeEventsWhenErrors(t *testing.T) {
+	changeEvents := []ChangeEvent{{DetailType: DetailTypeStatusChanged, Detail: StatusChanged{}}}
+
+	testcases := map[string]struct {
+		output *eventbridge.PutEventsOutput
+		err    error
+	}{
+		"client error": {
+			err: errExpected,
+		},
+		"failed entries": {
+			output: &eventbridge.PutEventsOutput{FailedEntryCount: 1},
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			eventBridgeClient := newMockEventBridgeClient(t)
+			eventBridgeClient.EXPECT().
+				PutEvents(spanCtx, mock.Anything).
+				Return(tc.output, tc.err)
+
+			client := &Client{svc: eventBridgeClient, eventBusName: eventBusName}
+
+			err := client.SendChangeEvents(ctx, changeEvents)
+			assert.Error(t, err)
+		})
+	}
+}
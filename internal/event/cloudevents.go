@@ -0,0 +1,137 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+const (
+	cloudEventsSpecVersion = "1.0"
+
+	// CloudEventsContentType is the media type of an event in the structured
+	// content mode
+	CloudEventsContentType = "application/cloudevents+json"
+)
+
+// CloudEvent is an event in the CloudEvents 1.0 structured JSON format. The
+// traceparent and tracestate attributes are from the distributed tracing
+// extension.
+type CloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	Id              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	Data            json.RawMessage `json:"data"`
+	TraceParent     string          `json:"traceparent,omitempty"`
+	TraceState      string          `json:"tracestate,omitempty"`
+}
+
+// A Transport delivers CloudEvents to where they are consumed.
+type Transport interface {
+	Send(ctx context.Context, events []CloudEvent) error
+}
+
+// CloudEventsPublisher sends the same events as Client, but wrapped in a
+// CloudEvents envelope and using any Transport.
+type CloudEventsPublisher struct {
+	transport Transport
+	newID     func() string
+	now       func() time.Time
+}
+
+func NewCloudEventsPublisher(transport Transport) *CloudEventsPublisher {
+	return &CloudEventsPublisher{
+		transport: transport,
+		newID:     uuid.NewString,
+		now:       time.Now,
+	}
+}
+
+// SendLpaUpdated sends an lpa-updated event, and a metric event for any
+// metrics. The update ID is used as the event ID when known, so that consumers
+// can ignore events that are sent more than once.
+func (p *CloudEventsPublisher) SendLpaUpdated(ctx context.Context, event LpaUpdated, metrics []Metric) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "event.SendLpaUpdated", tracing.LpaUID(event.Uid), tracing.UpdateTypes(event.ChangeType))
+	defer tracing.EndSpan(span, &err)
+
+	id := event.UpdateId
+	if id == "" {
+		id = p.newID()
+	}
+
+	lpaUpdated, err := p.cloudEvent(ctx, id, "lpa-updated", event.Uid, event)
+	if err != nil {
+		return err
+	}
+
+	cloudEvents := []CloudEvent{lpaUpdated}
+
+	if len(metrics) > 0 {
+		metricEvent, err := p.cloudEvent(ctx, p.newID(), "metric", "", metricsDetail(metrics))
+		if err != nil {
+			return err
+		}
+
+		cloudEvents = append(cloudEvents, metricEvent)
+	}
+
+	return p.transport.Send(ctx, cloudEvents)
+}
+
+// SendChangeEvents sends the change events. Their IDs are made from the update
+// ID and detail type, so that consumers can ignore events that are sent more
+// than once.
+func (p *CloudEventsPublisher) SendChangeEvents(ctx context.Context, changeEvents []ChangeEvent) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "event.SendChangeEvents")
+	defer tracing.EndSpan(span, &err)
+
+	cloudEvents := make([]CloudEvent, len(changeEvents))
+	for i, changeEvent := range changeEvents {
+		var uid, id string
+		if change, ok := changeEvent.Detail.(interface{ lpaChange() LpaChange }); ok {
+			uid = change.lpaChange().Uid
+			id = change.lpaChange().UpdateId + "/" + changeEvent.DetailType
+		} else {
+			id = p.newID()
+		}
+
+		cloudEvents[i], err = p.cloudEvent(ctx, id, changeEvent.DetailType, uid, changeEvent.Detail)
+		if err != nil {
+			return err
+		}
+	}
+
+	return p.transport.Send(ctx, cloudEvents)
+}
+
+func (p *CloudEventsPublisher) cloudEvent(ctx context.Context, id, eventType, subject string, data any) (CloudEvent, error) {
+	v, err := json.Marshal(data)
+	if err != nil {
+		return CloudEvent{}, err
+	}
+
+	traceContext := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, traceContext)
+
+	return CloudEvent{
+		SpecVersion:     cloudEventsSpecVersion,
+		Id:              id,
+		Source:          source,
+		Type:            source + "." + eventType,
+		Subject:         subject,
+		Time:            p.now().UTC(),
+		DataContentType: "application/json",
+		Data:            v,
+		TraceParent:     traceContext.Get("traceparent"),
+		TraceState:      traceContext.Get("tracestate"),
+	}, nil
+}
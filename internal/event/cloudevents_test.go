@@ -0,0 +1,163 @@
+package event
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+var testNow = time.Date(2024, time.January, 2, 12, 13, 14, 0, time.UTC)
+
+func newTestPublisher(transport Transport) *CloudEventsPublisher {
+	return &CloudEventsPublisher{
+		transport: transport,
+		newID:     func() string { return "a-new-id" },
+		now:       func() time.Time { return testNow },
+	}
+}
+
+func TestNewCloudEventsPublisher(t *testing.T) {
+	transport := &MemoryTransport{}
+	publisher := NewCloudEventsPublisher(transport)
+
+	assert.Equal(t, transport, publisher.transport)
+	assert.NotNil(t, publisher.newID)
+	assert.NotNil(t, publisher.now)
+}
+
+func TestCloudEventsPublisherSendLpaUpdated(t *testing.T) {
+	transport := &MemoryTransport{}
+	publisher := newTestPublisher(transport)
+
+	err := publisher.SendLpaUpdated(ctx, LpaUpdated{Uid: "M-1", ChangeType: "CREATE", UpdateId: "an-update-id"}, []Metric{{Project: "X"}})
+	assert.Nil(t, err)
+	assert.Equal(t, []CloudEvent{{
+		SpecVersion:     "1.0",
+		Id:              "an-update-id",
+		Source:          "opg.poas.lpastore",
+		Type:            "opg.poas.lpastore.lpa-updated",
+		Subject:         "M-1",
+		Time:            testNow,
+		DataContentType: "application/json",
+		Data:            json.RawMessage(`{"uid":"M-1","changeType":"CREATE","updateId":"an-update-id"}`),
+	}, {
+		SpecVersion:     "1.0",
+		Id:              "a-new-id",
+		Source:          "opg.poas.lpastore",
+		Type:            "opg.poas.lpastore.metric",
+		Time:            testNow,
+		DataContentType: "application/json",
+		Data:            json.RawMessage(`{"metrics":[{"metric":{"Project":"X","Category":"","Subcategory":"","Environment":"","MeasureName":"","MeasureValue":"","MeasureValueType":"","Time":""}}]}`),
+	}}, transport.Events())
+}
+
+func TestCloudEventsPublisherSendLpaUpdatedWithoutUpdateId(t *testing.T) {
+	transport := &MemoryTransport{}
+	publisher := newTestPublisher(transport)
+
+	err := publisher.SendLpaUpdated(ctx, LpaUpdated{Uid: "M-1", ChangeType: "CREATE"}, nil)
+	assert.Nil(t, err)
+
+	events := transport.Events()
+	assert.Len(t, events, 1)
+	assert.Equal(t, "a-new-id", events[0].Id)
+}
+
+func TestCloudEventsPublisherSendLpaUpdatedWithTraceContext(t *testing.T) {
+	otel.SetTracerProvider(sdktrace.NewTracerProvider())
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+	})
+
+	transport := newMockTransport(t)
+	transport.EXPECT().
+		Send(spanCtx, mock.MatchedBy(func(events []CloudEvent) bool {
+			return assert.Len(t, events, 1) &&
+				assert.Regexp(t, `^00-[0-9a-f]{32}-[0-9a-f]{16}-01$`, events[0].TraceParent)
+		})).
+		Return(nil)
+
+	tracedCtx, span := otel.Tracer("test").Start(ctx, "test")
+	defer span.End()
+
+	err := newTestPublisher(transport).SendLpaUpdated(tracedCtx, LpaUpdated{Uid: "M-1"}, nil)
+	assert.Nil(t, err)
+}
+
+func TestCloudEventsPublisherSendLpaUpdatedWhenTransportErrors(t *testing.T) {
+	transport := newMockTransport(t)
+	transport.EXPECT().
+		Send(spanCtx, mock.Anything).
+		Return(errExpected)
+
+	err := newTestPublisher(transport).SendLpaUpdated(ctx, LpaUpdated{Uid: "M-1"}, nil)
+	assert.Equal(t, errExpected, err)
+}
+
+func TestCloudEventsPublisherSendChangeEvents(t *testing.T) {
+	transport := &MemoryTransport{}
+	publisher := newTestPublisher(transport)
+
+	err := publisher.SendChangeEvents(ctx, []ChangeEvent{{
+		DetailType: DetailTypeStatusChanged,
+		Detail: StatusChanged{
+			LpaChange: LpaChange{Uid: "M-1", UpdateId: "an-update-id"},
+			NewStatus: shared.LpaStatusRegistered,
+		},
+	}})
+	assert.Nil(t, err)
+	assert.Equal(t, []CloudEvent{{
+		SpecVersion:     "1.0",
+		Id:              "an-update-id/status-changed",
+		Source:          "opg.poas.lpastore",
+		Type:            "opg.poas.lpastore.status-changed",
+		Subject:         "M-1",
+		Time:            testNow,
+		DataContentType: "application/json",
+		Data:            json.RawMessage(`{"uid":"M-1","updateId":"an-update-id","updateType":"","applied":"","author":"","changes":null,"actorUids":null,"oldStatus":"","newStatus":"registered"}`),
+	}}, transport.Events())
+}
+
+func TestCloudEventsPublisherSendChangeEventsWhenTransportErrors(t *testing.T) {
+	transport := newMockTransport(t)
+	transport.EXPECT().
+		Send(spanCtx, mock.Anything).
+		Return(errExpected)
+
+	err := newTestPublisher(transport).SendChangeEvents(ctx, []ChangeEvent{{DetailType: DetailTypeLpaRegistered, Detail: LpaRegistered{}}})
+	assert.Equal(t, errExpected, err)
+}
+
+func TestCloudEventMarshalJSON(t *testing.T) {
+	v, err := json.Marshal(CloudEvent{
+		SpecVersion:     "1.0",
+		Id:              "an-id",
+		Source:          "opg.poas.lpastore",
+		Type:            "opg.poas.lpastore.lpa-updated",
+		Subject:         "M-1",
+		Time:            testNow,
+		DataContentType: "application/json",
+		Data:            json.RawMessage(`{"uid":"M-1"}`),
+	})
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{
+		"specversion": "1.0",
+		"id": "an-id",
+		"source": "opg.poas.lpastore",
+		"type": "opg.poas.lpastore.lpa-updated",
+		"subject": "M-1",
+		"time": "2024-01-02T12:13:14Z",
+		"datacontenttype": "application/json",
+		"data": {"uid": "M-1"}
+	}`, string(v))
+}
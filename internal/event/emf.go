@@ -0,0 +1,118 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strconv"
+	"sync"
+)
+
+// emfMaxMetrics is the most metrics CloudWatch accepts in one embedded metric
+// format document
+const emfMaxMetrics = 100
+
+// EMFSink writes metrics to a log in the CloudWatch embedded metric format, so
+// that CloudWatch extracts them from the lambda's logs instead of them being
+// put on the event bus. The project is used as the namespace, and the
+// environment and subcategory as dimensions.
+type EMFSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewEMFSink(w io.Writer) *EMFSink {
+	return &EMFSink{w: w}
+}
+
+type emfMetadata struct {
+	Timestamp         int64          `json:"Timestamp"`
+	CloudWatchMetrics []emfDirective `json:"CloudWatchMetrics"`
+}
+
+type emfDirective struct {
+	Namespace  string      `json:"Namespace"`
+	Dimensions [][]string  `json:"Dimensions"`
+	Metrics    []emfMetric `json:"Metrics"`
+}
+
+type emfMetric struct {
+	Name string `json:"Name"`
+	Unit string `json:"Unit"`
+}
+
+// emfKey is what metrics must share to be written in the same document.
+type emfKey struct {
+	namespace   string
+	environment string
+	subcategory string
+	timestamp   int64
+}
+
+// SendMetrics writes a document for each run of metrics that share a namespace,
+// dimensions and time, with each on its own line.
+func (s *EMFSink) SendMetrics(_ context.Context, metrics []Metric) error {
+	var (
+		documents []map[string]any
+		document  map[string]any
+		directive *emfDirective
+		key       emfKey
+	)
+
+	for _, metric := range metrics {
+		metricKey := emfKey{
+			namespace:   metric.Project,
+			environment: metric.Environment,
+			subcategory: metric.Subcategory,
+			timestamp:   emfTimestamp(metric),
+		}
+
+		if document == nil || metricKey != key || document[metric.MeasureName] != nil || len(directive.Metrics) == emfMaxMetrics {
+			key = metricKey
+			metadata := &emfMetadata{
+				Timestamp: key.timestamp,
+				CloudWatchMetrics: []emfDirective{{
+					Namespace:  key.namespace,
+					Dimensions: [][]string{{"Environment", "Subcategory"}},
+				}},
+			}
+			directive = &metadata.CloudWatchMetrics[0]
+			document = map[string]any{
+				"_aws":        metadata,
+				"Environment": key.environment,
+				"Subcategory": key.subcategory,
+			}
+			documents = append(documents, document)
+		}
+
+		value, err := strconv.ParseFloat(metric.MeasureValue, 64)
+		if err != nil {
+			return err
+		}
+
+		unit, ok := measureUnits[metric.MeasureName]
+		if !ok {
+			unit = "Count"
+		}
+
+		directive.Metrics = append(directive.Metrics, emfMetric{Name: metric.MeasureName, Unit: unit})
+		document[metric.MeasureName] = value
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	enc := json.NewEncoder(s.w)
+	for _, document := range documents {
+		if err := enc.Encode(document); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func emfTimestamp(metric Metric) int64 {
+	ms, _ := strconv.ParseInt(metric.Time, 10, 64)
+	return ms
+}
@@ -0,0 +1,72 @@
+package event
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewEMFSink(t *testing.T) {
+	var buf bytes.Buffer
+
+	assert.Equal(t, &buf, NewEMFSink(&buf).w)
+}
+
+func TestEMFSinkSendMetrics(t *testing.T) {
+	now := time.Date(2024, time.January, 2, 12, 13, 14, 0, time.UTC)
+	metrics := NewMetrics("prod", now,
+		Count(SubcategoryRegistration, MeasureRegistered),
+		Measurement{Subcategory: SubcategoryRegistration, Name: MeasureTimeToRegister, Value: 86400},
+		Count(SubcategoryFunnelCompletionRate, "ONLINEATTORNEY"),
+		Count(SubcategoryFunnelCompletionRate, "ONLINEATTORNEY"),
+	)
+
+	var buf bytes.Buffer
+	err := NewEMFSink(&buf).SendMetrics(ctx, metrics)
+	assert.Nil(t, err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if assert.Len(t, lines, 3) {
+		assert.JSONEq(t, `{
+			"_aws": {
+				"Timestamp": 1704197594000,
+				"CloudWatchMetrics": [{
+					"Namespace": "MRLPA",
+					"Dimensions": [["Environment", "Subcategory"]],
+					"Metrics": [{"Name": "REGISTERED", "Unit": "Count"}, {"Name": "TIMETOREGISTER", "Unit": "Seconds"}]
+				}]
+			},
+			"Environment": "prod",
+			"Subcategory": "Registration",
+			"REGISTERED": 1,
+			"TIMETOREGISTER": 86400
+		}`, lines[0])
+
+		expectedFunnel := `{
+			"_aws": {
+				"Timestamp": 1704197594000,
+				"CloudWatchMetrics": [{
+					"Namespace": "MRLPA",
+					"Dimensions": [["Environment", "Subcategory"]],
+					"Metrics": [{"Name": "ONLINEATTORNEY", "Unit": "Count"}]
+				}]
+			},
+			"Environment": "prod",
+			"Subcategory": "FunnelCompletionRate",
+			"ONLINEATTORNEY": 1
+		}`
+		assert.JSONEq(t, expectedFunnel, lines[1])
+		assert.JSONEq(t, expectedFunnel, lines[2])
+	}
+}
+
+func TestEMFSinkSendMetricsWhenValueInvalid(t *testing.T) {
+	var buf bytes.Buffer
+	err := NewEMFSink(&buf).SendMetrics(ctx, []Metric{{MeasureName: "X", MeasureValue: "what"}})
+
+	assert.Error(t, err)
+	assert.Empty(t, buf.String())
+}
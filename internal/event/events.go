@@ -1,8 +1,32 @@
 package event
 
+import (
+	"encoding/json"
+
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+)
+
+// LpaUpdatedSchemaVersion is the version of the lpa-updated event schema
+// produced by NewLpaUpdated. Events without a schema version only have a uid
+// and changeType.
+const LpaUpdatedSchemaVersion = 2
+
 type LpaUpdated struct {
 	Uid        string `json:"uid"`
 	ChangeType string `json:"changeType"`
+
+	SchemaVersion int              `json:"schemaVersion,omitempty"`
+	UpdateId      string           `json:"updateId,omitempty"`
+	Author        shared.URN       `json:"author,omitempty"`
+	Applied       string           `json:"applied,omitempty"`
+	ChangedKeys   []string         `json:"changedKeys,omitempty"` // JSON pointers to the changed parts of the LPA
+	StatusBefore  shared.LpaStatus `json:"statusBefore,omitempty"`
+	StatusAfter   shared.LpaStatus `json:"statusAfter,omitempty"`
+	ActorUIDs     []string         `json:"actorUids,omitempty"` // actors whose details were changed, or all actors when the status changed
+
+	// TraceContext holds the W3C and X-Ray trace headers of the request that
+	// made the change, so consumers can join its trace.
+	TraceContext map[string]string `json:"traceContext,omitempty"`
 }
 
 type metrics struct {
@@ -23,3 +47,52 @@ type Metric struct {
 	MeasureValueType string
 	Time             string
 }
+
+// NewLpaUpdated creates an lpa-updated event for an update that has been
+// applied to lpa, which had statusBefore beforehand. A change to the empty key
+// replaces the whole LPA, as when it is created, so concerns every actor.
+func NewLpaUpdated(update shared.Update, statusBefore shared.LpaStatus, lpa shared.Lpa) LpaUpdated {
+	changes := update.Diff
+	if len(changes) == 0 {
+		changes = update.Changes
+	}
+
+	event := LpaUpdated{
+		Uid:           update.Uid,
+		ChangeType:    update.Type,
+		SchemaVersion: LpaUpdatedSchemaVersion,
+		UpdateId:      update.Id,
+		Author:        update.Author,
+		Applied:       update.Applied,
+		ChangedKeys:   make([]string, len(changes)),
+		StatusBefore:  statusBefore,
+		StatusAfter:   statusBefore,
+	}
+
+	allActors := false
+	var actorUIDs []string
+	for i, change := range changes {
+		event.ChangedKeys[i] = change.Key
+
+		switch change.Key {
+		case "":
+			event.StatusAfter = lpa.Status
+			allActors = true
+		case "/status":
+			_ = json.Unmarshal(change.New, &event.StatusAfter)
+			allActors = true
+		default:
+			if uid, ok := actorUID(lpa, change.Key); ok {
+				actorUIDs = append(actorUIDs, uid)
+			}
+		}
+	}
+
+	if allActors {
+		event.ActorUIDs = lpa.ActorUIDs()
+	} else {
+		event.ActorUIDs = uniqueUIDs(actorUIDs)
+	}
+
+	return event
+}
@@ -0,0 +1,114 @@
+package event
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewLpaUpdated(t *testing.T) {
+	lpa := shared.Lpa{
+		Uid:    "M-1",
+		Status: shared.LpaStatusInProgress,
+		LpaInit: shared.LpaInit{
+			Donor:               shared.Donor{Person: shared.Person{UID: "donor"}},
+			CertificateProvider: shared.CertificateProvider{Person: shared.Person{UID: "certificate-provider"}},
+			Attorneys: []shared.Attorney{
+				{Person: shared.Person{UID: "attorney-1"}},
+				{Person: shared.Person{UID: "attorney-2"}},
+			},
+			TrustCorporations: []shared.TrustCorporation{{UID: "trust-corporation"}},
+			PeopleToNotify:    []shared.PersonToNotify{{Person: shared.Person{UID: "person-to-notify"}}},
+		},
+	}
+	allActors := []string{"donor", "certificate-provider", "attorney-1", "attorney-2", "trust-corporation", "person-to-notify"}
+
+	testcases := map[string]struct {
+		update       shared.Update
+		statusBefore shared.LpaStatus
+		expected     LpaUpdated
+	}{
+		"create": {
+			update: shared.Update{
+				Type:    "CREATE",
+				Changes: []shared.Change{{Key: "", Old: json.RawMessage(`null`), New: json.RawMessage(`{}`)}},
+			},
+			expected: LpaUpdated{
+				ChangeType:  "CREATE",
+				ChangedKeys: []string{""},
+				StatusAfter: shared.LpaStatusInProgress,
+				ActorUIDs:   allActors,
+			},
+		},
+		"actors changed": {
+			update: shared.Update{
+				Type: "CORRECTION",
+				Diff: []shared.Change{
+					{Key: "/attorneys/1/firstNames"},
+					{Key: "/attorneys/1/lastName"},
+					{Key: "/donor/address/line1"},
+					{Key: "/trustCorporations/trust-corporation/name"},
+					{Key: "/peopleToNotify/0/lastName"},
+					{Key: "/version"},
+				},
+			},
+			statusBefore: shared.LpaStatusInProgress,
+			expected: LpaUpdated{
+				ChangeType:   "CORRECTION",
+				ChangedKeys:  []string{"/attorneys/1/firstNames", "/attorneys/1/lastName", "/donor/address/line1", "/trustCorporations/trust-corporation/name", "/peopleToNotify/0/lastName", "/version"},
+				StatusBefore: shared.LpaStatusInProgress,
+				StatusAfter:  shared.LpaStatusInProgress,
+				ActorUIDs:    []string{"attorney-2", "donor", "trust-corporation", "person-to-notify"},
+			},
+		},
+		"status changed": {
+			update: shared.Update{
+				Type: "OPG_STATUS_CHANGE",
+				Diff: []shared.Change{
+					{Key: "/status", Old: json.RawMessage(`"in-progress"`), New: json.RawMessage(`"cannot-register"`)},
+				},
+			},
+			statusBefore: shared.LpaStatusInProgress,
+			expected: LpaUpdated{
+				ChangeType:   "OPG_STATUS_CHANGE",
+				ChangedKeys:  []string{"/status"},
+				StatusBefore: shared.LpaStatusInProgress,
+				StatusAfter:  shared.LpaStatusCannotRegister,
+				ActorUIDs:    allActors,
+			},
+		},
+		"without diff": {
+			update: shared.Update{
+				Type:    "ATTORNEY_SIGN",
+				Changes: []shared.Change{{Key: "/attorneys/attorney-1/signedAt"}},
+			},
+			statusBefore: shared.LpaStatusInProgress,
+			expected: LpaUpdated{
+				ChangeType:   "ATTORNEY_SIGN",
+				ChangedKeys:  []string{"/attorneys/attorney-1/signedAt"},
+				StatusBefore: shared.LpaStatusInProgress,
+				StatusAfter:  shared.LpaStatusInProgress,
+				ActorUIDs:    []string{"attorney-1"},
+			},
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			tc.update.Id = "an-id"
+			tc.update.Uid = "M-1"
+			tc.update.Applied = "2024-01-02T12:13:14.000000015Z"
+			tc.update.Author = "urn:opg:poas:sirius:users:1"
+
+			tc.expected.Uid = "M-1"
+			tc.expected.SchemaVersion = LpaUpdatedSchemaVersion
+			tc.expected.UpdateId = "an-id"
+			tc.expected.Author = "urn:opg:poas:sirius:users:1"
+			tc.expected.Applied = "2024-01-02T12:13:14.000000015Z"
+
+			assert.Equal(t, tc.expected, NewLpaUpdated(tc.update, tc.statusBefore, lpa))
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package event
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+)
+
+const (
+	metricProject          = "MRLPA"
+	metricCategory         = "metric"
+	metricMeasureValueType = "BIGINT"
+)
+
+// Subcategories group the metrics that are reported together.
+const (
+	SubcategoryFunnelCompletionRate = "FunnelCompletionRate"
+	SubcategoryRegistration         = "Registration"
+	SubcategoryWithdrawal           = "Withdrawal"
+	SubcategoryOptOut               = "OptOut"
+	SubcategoryCorrection           = "Correction"
+)
+
+// Measure names, other than for FunnelCompletionRate which are the channel
+// followed by the party.
+const (
+	MeasureRegistered                 = "REGISTERED"
+	MeasureTimeToRegister             = "TIMETOREGISTER" // seconds from the donor signing to registration
+	MeasureWithdrawn                  = "WITHDRAWN"
+	MeasureCertificateProviderOptOut  = "CERTIFICATEPROVIDER"
+	MeasureAttorneyOptOut             = "ATTORNEY"
+	MeasureTrustCorporationOptOut     = "TRUSTCORPORATION"
+	MeasurePreRegistrationCorrection  = "PREREGISTRATION"
+	MeasurePostRegistrationCorrection = "POSTREGISTRATION"
+)
+
+// Parties whose completion of an LPA is counted by FunnelCompletion.
+const (
+	PartyDonor               = "DONOR"
+	PartyCertificateProvider = "CERTIFICATEPROVIDER"
+	PartyAttorney            = "ATTORNEY"
+	PartyTrustCorporation    = "TRUSTCORPORATION"
+)
+
+// measureUnits are the CloudWatch units of measures that are not counts.
+var measureUnits = map[string]string{
+	MeasureTimeToRegister: "Seconds",
+}
+
+// A Measurement is a value recorded by an update, without the details that are
+// the same for all metrics.
+type Measurement struct {
+	Subcategory string
+	Name        string
+	Value       int64
+}
+
+// MetricsEmitter is implemented by updates that emit metrics when applied. The
+// lpa is as it is after the update has been applied.
+type MetricsEmitter interface {
+	Measurements(lpa *shared.Lpa) []Measurement
+}
+
+// Count is a measurement of one occurrence of name.
+func Count(subcategory, name string) Measurement {
+	return Measurement{Subcategory: subcategory, Name: name, Value: 1}
+}
+
+// FunnelCompletion counts party completing their part of an LPA through
+// channel.
+func FunnelCompletion(party string, channel shared.Channel) Measurement {
+	if channel == shared.ChannelOnline {
+		return Count(SubcategoryFunnelCompletionRate, "ONLINE"+party)
+	}
+
+	return Count(SubcategoryFunnelCompletionRate, "PAPER"+party)
+}
+
+// NewMetrics creates the metrics to send for the measurements, as taken in
+// environment at now.
+func NewMetrics(environment string, now time.Time, measurements ...Measurement) []Metric {
+	if len(measurements) == 0 {
+		return nil
+	}
+
+	metrics := make([]Metric, len(measurements))
+	for i, measurement := range measurements {
+		metrics[i] = Metric{
+			Project:          metricProject,
+			Category:         metricCategory,
+			Subcategory:      measurement.Subcategory,
+			Environment:      environment,
+			MeasureName:      measurement.Name,
+			MeasureValue:     strconv.FormatInt(measurement.Value, 10),
+			MeasureValueType: metricMeasureValueType,
+			Time:             strconv.FormatInt(now.UnixMilli(), 10),
+		}
+	}
+
+	return metrics
+}
+
+// metricsDetail wraps metrics as expected in the detail of a metric event.
+func metricsDetail(ms []Metric) metrics {
+	detail := metrics{Metrics: make([]metricWrapper, len(ms))}
+	for i := range ms {
+		detail.Metrics[i] = metricWrapper{Metric: &ms[i]}
+	}
+
+	return detail
+}
@@ -0,0 +1,56 @@
+package event
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFunnelCompletion(t *testing.T) {
+	assert.Equal(t, Measurement{Subcategory: "FunnelCompletionRate", Name: "ONLINEDONOR", Value: 1}, FunnelCompletion(PartyDonor, shared.ChannelOnline))
+	assert.Equal(t, Measurement{Subcategory: "FunnelCompletionRate", Name: "PAPERATTORNEY", Value: 1}, FunnelCompletion(PartyAttorney, shared.ChannelPaper))
+}
+
+func TestNewMetrics(t *testing.T) {
+	now := time.Date(2024, time.January, 2, 12, 13, 14, 0, time.UTC)
+
+	metrics := NewMetrics("prod", now,
+		Count(SubcategoryRegistration, MeasureRegistered),
+		Measurement{Subcategory: SubcategoryRegistration, Name: MeasureTimeToRegister, Value: 86400})
+
+	assert.Equal(t, []Metric{{
+		Project:          "MRLPA",
+		Category:         "metric",
+		Subcategory:      "Registration",
+		Environment:      "prod",
+		MeasureName:      "REGISTERED",
+		MeasureValue:     "1",
+		MeasureValueType: "BIGINT",
+		Time:             "1704197594000",
+	}, {
+		Project:          "MRLPA",
+		Category:         "metric",
+		Subcategory:      "Registration",
+		Environment:      "prod",
+		MeasureName:      "TIMETOREGISTER",
+		MeasureValue:     "86400",
+		MeasureValueType: "BIGINT",
+		Time:             "1704197594000",
+	}}, metrics)
+}
+
+func TestNewMetricsWhenNone(t *testing.T) {
+	assert.Nil(t, NewMetrics("prod", time.Now()))
+}
+
+func TestMetricsDetail(t *testing.T) {
+	data, _ := json.Marshal(metricsDetail([]Metric{{Project: "X"}, {Project: "Y"}}))
+
+	assert.JSONEq(t, `{"metrics":[
+		{"metric":{"Project":"X","Category":"","Subcategory":"","Environment":"","MeasureName":"","MeasureValue":"","MeasureValueType":"","Time":""}},
+		{"metric":{"Project":"Y","Category":"","Subcategory":"","Environment":"","MeasureName":"","MeasureValue":"","MeasureValueType":"","Time":""}}
+	]}`, string(data))
+}
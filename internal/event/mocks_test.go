@@ -8,6 +8,8 @@ import (
 	"context"
 
 	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -124,3 +126,281 @@ func (_c *mockEventBridgeClient_PutEvents_Call) RunAndReturn(run func(ctx contex
 	_c.Call.Return(run)
 	return _c
 }
+
+// newMockTransport creates a new instance of mockTransport. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newMockTransport(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *mockTransport {
+	mock := &mockTransport{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// mockTransport is an autogenerated mock type for the Transport type
+type mockTransport struct {
+	mock.Mock
+}
+
+type mockTransport_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *mockTransport) EXPECT() *mockTransport_Expecter {
+	return &mockTransport_Expecter{mock: &_m.Mock}
+}
+
+// Send provides a mock function for the type mockTransport
+func (_mock *mockTransport) Send(ctx context.Context, events []CloudEvent) error {
+	ret := _mock.Called(ctx, events)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Send")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []CloudEvent) error); ok {
+		r0 = returnFunc(ctx, events)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// mockTransport_Send_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Send'
+type mockTransport_Send_Call struct {
+	*mock.Call
+}
+
+// Send is a helper method to define mock.On call
+//   - ctx context.Context
+//   - events []CloudEvent
+func (_e *mockTransport_Expecter) Send(ctx interface{}, events interface{}) *mockTransport_Send_Call {
+	return &mockTransport_Send_Call{Call: _e.mock.On("Send", ctx, events)}
+}
+
+func (_c *mockTransport_Send_Call) Run(run func(ctx context.Context, events []CloudEvent)) *mockTransport_Send_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 []CloudEvent
+		if args[1] != nil {
+			arg1 = args[1].([]CloudEvent)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *mockTransport_Send_Call) Return(err error) *mockTransport_Send_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *mockTransport_Send_Call) RunAndReturn(run func(ctx context.Context, events []CloudEvent) error) *mockTransport_Send_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// newMockMetricsEmitter creates a new instance of mockMetricsEmitter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newMockMetricsEmitter(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *mockMetricsEmitter {
+	mock := &mockMetricsEmitter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// mockMetricsEmitter is an autogenerated mock type for the MetricsEmitter type
+type mockMetricsEmitter struct {
+	mock.Mock
+}
+
+type mockMetricsEmitter_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *mockMetricsEmitter) EXPECT() *mockMetricsEmitter_Expecter {
+	return &mockMetricsEmitter_Expecter{mock: &_m.Mock}
+}
+
+// Measurements provides a mock function for the type mockMetricsEmitter
+func (_mock *mockMetricsEmitter) Measurements(lpa *shared.Lpa) []Measurement {
+	ret := _mock.Called(lpa)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Measurements")
+	}
+
+	var r0 []Measurement
+	if returnFunc, ok := ret.Get(0).(func(*shared.Lpa) []Measurement); ok {
+		r0 = returnFunc(lpa)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]Measurement)
+		}
+	}
+	return r0
+}
+
+// mockMetricsEmitter_Measurements_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Measurements'
+type mockMetricsEmitter_Measurements_Call struct {
+	*mock.Call
+}
+
+// Measurements is a helper method to define mock.On call
+//   - lpa *shared.Lpa
+func (_e *mockMetricsEmitter_Expecter) Measurements(lpa interface{}) *mockMetricsEmitter_Measurements_Call {
+	return &mockMetricsEmitter_Measurements_Call{Call: _e.mock.On("Measurements", lpa)}
+}
+
+func (_c *mockMetricsEmitter_Measurements_Call) Run(run func(lpa *shared.Lpa)) *mockMetricsEmitter_Measurements_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 *shared.Lpa
+		if args[0] != nil {
+			arg0 = args[0].(*shared.Lpa)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *mockMetricsEmitter_Measurements_Call) Return(measurements []Measurement) *mockMetricsEmitter_Measurements_Call {
+	_c.Call.Return(measurements)
+	return _c
+}
+
+func (_c *mockMetricsEmitter_Measurements_Call) RunAndReturn(run func(lpa *shared.Lpa) []Measurement) *mockMetricsEmitter_Measurements_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// newMockSQSClient creates a new instance of mockSQSClient. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newMockSQSClient(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *mockSQSClient {
+	mock := &mockSQSClient{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// mockSQSClient is an autogenerated mock type for the SQSClient type
+type mockSQSClient struct {
+	mock.Mock
+}
+
+type mockSQSClient_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *mockSQSClient) EXPECT() *mockSQSClient_Expecter {
+	return &mockSQSClient_Expecter{mock: &_m.Mock}
+}
+
+// SendMessageBatch provides a mock function for the type mockSQSClient
+func (_mock *mockSQSClient) SendMessageBatch(ctx context.Context, params *sqs.SendMessageBatchInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageBatchOutput, error) {
+	// func(*sqs.Options)
+	_va := make([]interface{}, len(optFns))
+	for _i := range optFns {
+		_va[_i] = optFns[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, params)
+	_ca = append(_ca, _va...)
+	ret := _mock.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SendMessageBatch")
+	}
+
+	var r0 *sqs.SendMessageBatchOutput
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *sqs.SendMessageBatchInput, ...func(*sqs.Options)) (*sqs.SendMessageBatchOutput, error)); ok {
+		return returnFunc(ctx, params, optFns...)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *sqs.SendMessageBatchInput, ...func(*sqs.Options)) *sqs.SendMessageBatchOutput); ok {
+		r0 = returnFunc(ctx, params, optFns...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*sqs.SendMessageBatchOutput)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, *sqs.SendMessageBatchInput, ...func(*sqs.Options)) error); ok {
+		r1 = returnFunc(ctx, params, optFns...)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockSQSClient_SendMessageBatch_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SendMessageBatch'
+type mockSQSClient_SendMessageBatch_Call struct {
+	*mock.Call
+}
+
+// SendMessageBatch is a helper method to define mock.On call
+//   - ctx context.Context
+//   - params *sqs.SendMessageBatchInput
+//   - optFns ...func(*sqs.Options)
+func (_e *mockSQSClient_Expecter) SendMessageBatch(ctx interface{}, params interface{}, optFns ...interface{}) *mockSQSClient_SendMessageBatch_Call {
+	return &mockSQSClient_SendMessageBatch_Call{Call: _e.mock.On("SendMessageBatch",
+		append([]interface{}{ctx, params}, optFns...)...)}
+}
+
+func (_c *mockSQSClient_SendMessageBatch_Call) Run(run func(ctx context.Context, params *sqs.SendMessageBatchInput, optFns ...func(*sqs.Options))) *mockSQSClient_SendMessageBatch_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 *sqs.SendMessageBatchInput
+		if args[1] != nil {
+			arg1 = args[1].(*sqs.SendMessageBatchInput)
+		}
+		var arg2 []func(*sqs.Options)
+		variadicArgs := make([]func(*sqs.Options), len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(func(*sqs.Options))
+			}
+		}
+		arg2 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2...,
+		)
+	})
+	return _c
+}
+
+func (_c *mockSQSClient_SendMessageBatch_Call) Return(sendMessageBatchOutput *sqs.SendMessageBatchOutput, err error) *mockSQSClient_SendMessageBatch_Call {
+	_c.Call.Return(sendMessageBatchOutput, err)
+	return _c
+}
+
+func (_c *mockSQSClient_SendMessageBatch_Call) RunAndReturn(run func(ctx context.Context, params *sqs.SendMessageBatchInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageBatchOutput, error)) *mockSQSClient_SendMessageBatch_Call {
+	_c.Call.Return(run)
+	return _c
+}
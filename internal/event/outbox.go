@@ -0,0 +1,56 @@
+package event
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// OutboxEntry is an lpa-updated event, and the metrics to send with it, that is
+// saved in the same transaction as the change it describes. The relay lambda
+// puts it on the event bus once the transaction has been committed, so the
+// event is sent at least once even if the event bus is unavailable.
+type OutboxEntry struct {
+	Id        string     `json:"id"`
+	Region    string     `json:"region"`
+	Event     LpaUpdated `json:"event"`
+	Metrics   []Metric   `json:"metrics,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	SentAt    *time.Time `json:"sentAt,omitempty"`
+	Metric    *Metric    `json:"metric,omitempty"` // set on entries saved before metrics were batched
+}
+
+// NewOutboxEntry creates an entry for the update with the given ID. The trace
+// context of ctx is kept with the event so that the relay can continue the
+// trace of the request that made the change.
+func NewOutboxEntry(ctx context.Context, id string, event LpaUpdated, metrics []Metric, now time.Time) OutboxEntry {
+	traceContext := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, traceContext)
+	if len(traceContext) > 0 {
+		event.TraceContext = traceContext
+	}
+
+	return OutboxEntry{
+		Id:        id,
+		Event:     event,
+		Metrics:   metrics,
+		CreatedAt: now,
+	}
+}
+
+// AllMetrics returns the metrics to send with the event, including any saved
+// by an earlier version.
+func (e OutboxEntry) AllMetrics() []Metric {
+	if e.Metric != nil {
+		return append([]Metric{*e.Metric}, e.Metrics...)
+	}
+
+	return e.Metrics
+}
+
+// Sent reports whether the entry has been put on the event bus.
+func (e OutboxEntry) Sent() bool {
+	return e.SentAt != nil
+}
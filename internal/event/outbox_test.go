@@ -0,0 +1,53 @@
+package event
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestNewOutboxEntry(t *testing.T) {
+	now := time.Now()
+	metrics := []Metric{{Project: "X"}}
+
+	entry := NewOutboxEntry(ctx, "an-id", LpaUpdated{Uid: "M-1234-1234-1234", ChangeType: "CREATE"}, metrics, now)
+	assert.Equal(t, OutboxEntry{
+		Id:        "an-id",
+		Event:     LpaUpdated{Uid: "M-1234-1234-1234", ChangeType: "CREATE"},
+		Metrics:   metrics,
+		CreatedAt: now,
+	}, entry)
+	assert.False(t, entry.Sent())
+	assert.Equal(t, metrics, entry.AllMetrics())
+}
+
+func TestOutboxEntryAllMetricsWhenSavedWithMetric(t *testing.T) {
+	entry := OutboxEntry{Metric: &Metric{Project: "X"}, Metrics: []Metric{{Project: "Y"}}}
+
+	assert.Equal(t, []Metric{{Project: "X"}, {Project: "Y"}}, entry.AllMetrics())
+}
+
+func TestNewOutboxEntryWithTraceContext(t *testing.T) {
+	otel.SetTracerProvider(sdktrace.NewTracerProvider())
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+	})
+
+	spanCtx, span := otel.Tracer("test").Start(ctx, "test")
+	defer span.End()
+
+	entry := NewOutboxEntry(spanCtx, "an-id", LpaUpdated{Uid: "M-1234-1234-1234"}, nil, time.Now())
+
+	spanContext := trace.SpanContextFromContext(spanCtx)
+	assert.Equal(t, map[string]string{
+		"traceparent": "00-" + spanContext.TraceID().String() + "-" + spanContext.SpanID().String() + "-01",
+	}, entry.Event.TraceContext)
+}
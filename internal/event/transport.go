@@ -0,0 +1,135 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	eventbridgetypes "github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// sendMessageBatchLimit is the maximum number of messages SQS accepts in a
+// single SendMessageBatch request
+const sendMessageBatchLimit = 10
+
+type SQSClient interface {
+	SendMessageBatch(ctx context.Context, params *sqs.SendMessageBatchInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageBatchOutput, error)
+}
+
+// NewTransport returns a transport that sends to the SQS queue if queueURL is
+// set, otherwise to the event bus.
+func NewTransport(cfg aws.Config, eventBusName, queueURL string) Transport {
+	if queueURL != "" {
+		return &SQSTransport{svc: sqs.NewFromConfig(cfg), queueURL: queueURL}
+	}
+
+	return &EventBridgeTransport{svc: eventbridge.NewFromConfig(cfg), eventBusName: eventBusName}
+}
+
+// EventBridgeTransport puts CloudEvents on an event bus, with the event type as
+// the detail type and the whole CloudEvent as the detail.
+type EventBridgeTransport struct {
+	svc          EventBridgeClient
+	eventBusName string
+}
+
+func (t *EventBridgeTransport) Send(ctx context.Context, events []CloudEvent) error {
+	entries := make([]eventbridgetypes.PutEventsRequestEntry, len(events))
+	for i, event := range events {
+		v, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+
+		entries[i] = eventbridgetypes.PutEventsRequestEntry{
+			EventBusName: aws.String(t.eventBusName),
+			Source:       aws.String(event.Source),
+			DetailType:   aws.String(event.Type),
+			Detail:       aws.String(string(v)),
+		}
+	}
+
+	for chunk := range slices.Chunk(entries, putEventsLimit) {
+		output, err := t.svc.PutEvents(ctx, &eventbridge.PutEventsInput{
+			Entries: chunk,
+		})
+		if err != nil {
+			return err
+		}
+
+		if output.FailedEntryCount > 0 {
+			return fmt.Errorf("failed to put %d events", output.FailedEntryCount)
+		}
+	}
+
+	return nil
+}
+
+// SQSTransport sends each CloudEvent as a message on an SQS queue.
+type SQSTransport struct {
+	svc      SQSClient
+	queueURL string
+}
+
+func (t *SQSTransport) Send(ctx context.Context, events []CloudEvent) error {
+	entries := make([]sqstypes.SendMessageBatchRequestEntry, len(events))
+	for i, event := range events {
+		v, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+
+		entries[i] = sqstypes.SendMessageBatchRequestEntry{
+			Id:          aws.String(strconv.Itoa(i)),
+			MessageBody: aws.String(string(v)),
+			MessageAttributes: map[string]sqstypes.MessageAttributeValue{
+				"content-type": {DataType: aws.String("String"), StringValue: aws.String(CloudEventsContentType)},
+			},
+		}
+	}
+
+	for chunk := range slices.Chunk(entries, sendMessageBatchLimit) {
+		output, err := t.svc.SendMessageBatch(ctx, &sqs.SendMessageBatchInput{
+			QueueUrl: aws.String(t.queueURL),
+			Entries:  chunk,
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(output.Failed) > 0 {
+			return fmt.Errorf("failed to send %d messages: %s", len(output.Failed), aws.ToString(output.Failed[0].Message))
+		}
+	}
+
+	return nil
+}
+
+// MemoryTransport keeps the CloudEvents it is sent, for use in tests.
+type MemoryTransport struct {
+	mu     sync.Mutex
+	events []CloudEvent
+}
+
+func (t *MemoryTransport) Send(_ context.Context, events []CloudEvent) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.events = append(t.events, events...)
+	return nil
+}
+
+// Events returns the CloudEvents sent so far.
+func (t *MemoryTransport) Events() []CloudEvent {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return slices.Clone(t.events)
+}
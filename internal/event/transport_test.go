@@ -0,0 +1,149 @@
+package event
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	eventbridgetypes "github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var testCloudEvent = CloudEvent{
+	SpecVersion:     "1.0",
+	Id:              "an-id",
+	Source:          "opg.poas.lpastore",
+	Type:            "opg.poas.lpastore.lpa-updated",
+	Subject:         "M-1",
+	Time:            testNow,
+	DataContentType: "application/json",
+	Data:            json.RawMessage(`{"uid":"M-1"}`),
+}
+
+const testCloudEventJSON = `{"specversion":"1.0","id":"an-id","source":"opg.poas.lpastore","type":"opg.poas.lpastore.lpa-updated","subject":"M-1","time":"2024-01-02T12:13:14Z","datacontenttype":"application/json","data":{"uid":"M-1"}}`
+
+func TestNewTransport(t *testing.T) {
+	eventBridgeTransport := NewTransport(aws.Config{}, eventBusName, "")
+	if assert.IsType(t, (*EventBridgeTransport)(nil), eventBridgeTransport) {
+		assert.IsType(t, (*eventbridge.Client)(nil), eventBridgeTransport.(*EventBridgeTransport).svc)
+		assert.Equal(t, eventBusName, eventBridgeTransport.(*EventBridgeTransport).eventBusName)
+	}
+
+	sqsTransport := NewTransport(aws.Config{}, eventBusName, "a-queue-url")
+	if assert.IsType(t, (*SQSTransport)(nil), sqsTransport) {
+		assert.IsType(t, (*sqs.Client)(nil), sqsTransport.(*SQSTransport).svc)
+		assert.Equal(t, "a-queue-url", sqsTransport.(*SQSTransport).queueURL)
+	}
+}
+
+func TestEventBridgeTransportSend(t *testing.T) {
+	entry := eventbridgetypes.PutEventsRequestEntry{
+		EventBusName: aws.String(eventBusName),
+		Source:       aws.String("opg.poas.lpastore"),
+		DetailType:   aws.String("opg.poas.lpastore.lpa-updated"),
+		Detail:       aws.String(testCloudEventJSON),
+	}
+
+	eventBridgeClient := newMockEventBridgeClient(t)
+	eventBridgeClient.EXPECT().
+		PutEvents(ctx, mock.MatchedBy(func(input *eventbridge.PutEventsInput) bool {
+			return len(input.Entries) == 10 && assert.Equal(t, entry, input.Entries[0])
+		})).
+		Return(&eventbridge.PutEventsOutput{}, nil).
+		Once()
+	eventBridgeClient.EXPECT().
+		PutEvents(ctx, &eventbridge.PutEventsInput{Entries: []eventbridgetypes.PutEventsRequestEntry{entry}}).
+		Return(&eventbridge.PutEventsOutput{}, nil).
+		Once()
+
+	transport := &EventBridgeTransport{svc: eventBridgeClient, eventBusName: eventBusName}
+
+	events := make([]CloudEvent, 11)
+	for i := range events {
+		events[i] = testCloudEvent
+	}
+
+	err := transport.Send(ctx, events)
+	assert.Nil(t, err)
+}
+
+func TestEventBridgeTransportSendWhenErrors(t *testing.T) {
+	testcases := map[string]struct {
+		output *eventbridge.PutEventsOutput
+		err    error
+	}{
+		"client error":   {err: errExpected},
+		"failed entries": {output: &eventbridge.PutEventsOutput{FailedEntryCount: 1}},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			eventBridgeClient := newMockEventBridgeClient(t)
+			eventBridgeClient.EXPECT().
+				PutEvents(ctx, mock.Anything).
+				Return(tc.output, tc.err)
+
+			transport := &EventBridgeTransport{svc: eventBridgeClient, eventBusName: eventBusName}
+
+			err := transport.Send(ctx, []CloudEvent{testCloudEvent})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSQSTransportSend(t *testing.T) {
+	sqsClient := newMockSQSClient(t)
+	sqsClient.EXPECT().
+		SendMessageBatch(ctx, &sqs.SendMessageBatchInput{
+			QueueUrl: aws.String("a-queue-url"),
+			Entries: []sqstypes.SendMessageBatchRequestEntry{{
+				Id:          aws.String("0"),
+				MessageBody: aws.String(testCloudEventJSON),
+				MessageAttributes: map[string]sqstypes.MessageAttributeValue{
+					"content-type": {DataType: aws.String("String"), StringValue: aws.String("application/cloudevents+json")},
+				},
+			}},
+		}).
+		Return(&sqs.SendMessageBatchOutput{}, nil)
+
+	transport := &SQSTransport{svc: sqsClient, queueURL: "a-queue-url"}
+
+	err := transport.Send(ctx, []CloudEvent{testCloudEvent})
+	assert.Nil(t, err)
+}
+
+func TestSQSTransportSendWhenErrors(t *testing.T) {
+	testcases := map[string]struct {
+		output *sqs.SendMessageBatchOutput
+		err    error
+	}{
+		"client error":    {err: errExpected},
+		"failed messages": {output: &sqs.SendMessageBatchOutput{Failed: []sqstypes.BatchResultErrorEntry{{Message: aws.String("oops")}}}},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			sqsClient := newMockSQSClient(t)
+			sqsClient.EXPECT().
+				SendMessageBatch(ctx, mock.Anything).
+				Return(tc.output, tc.err)
+
+			transport := &SQSTransport{svc: sqsClient, queueURL: "a-queue-url"}
+
+			err := transport.Send(ctx, []CloudEvent{testCloudEvent})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestMemoryTransport(t *testing.T) {
+	transport := &MemoryTransport{}
+
+	assert.Nil(t, transport.Send(ctx, []CloudEvent{testCloudEvent}))
+	assert.Nil(t, transport.Send(ctx, []CloudEvent{testCloudEvent}))
+	assert.Equal(t, []CloudEvent{testCloudEvent, testCloudEvent}, transport.Events())
+}
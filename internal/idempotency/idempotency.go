@@ -0,0 +1,118 @@
+// Package idempotency lets clients safely retry requests that change an LPA by
+// sending an Idempotency-Key header. The response to the first request with a
+// key is stored alongside the change it made, and returned again for any
+// retries until the key expires.
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+)
+
+const (
+	// Header is the request header clients set to make a request idempotent.
+	Header = "Idempotency-Key"
+
+	// ReplayedHeader is set on responses that were returned from a stored
+	// record rather than by processing the request again.
+	ReplayedHeader = "Idempotent-Replayed"
+
+	// DefaultTTL is how long keys are kept for when no other duration is
+	// configured.
+	DefaultTTL = 24 * time.Hour
+)
+
+// Record is the stored response to a request made with an Idempotency-Key.
+type Record struct {
+	Key         string            `dynamodbav:"key"`
+	RequestHash string            `dynamodbav:"requestHash"`
+	UpdateId    string            `dynamodbav:"updateId"`
+	StatusCode  int               `dynamodbav:"statusCode"`
+	Headers     map[string]string `dynamodbav:"headers,omitempty"`
+	Body        string            `dynamodbav:"body"`
+	CreatedAt   int64             `dynamodbav:"createdAt"`
+	ExpiresAt   int64             `dynamodbav:"expiresAt"`
+}
+
+// Key returns the key to store the response to req under, or "" if the request
+// does not have an Idempotency-Key header. Keys are scoped to the operation,
+// LPA and client so that clients cannot see each other's responses.
+func Key(req events.APIGatewayProxyRequest, operation, subject string) string {
+	values := shared.GetEventHeader(Header, req)
+	if len(values) == 0 || strings.TrimSpace(values[0]) == "" {
+		return ""
+	}
+
+	return fmt.Sprintf("%s#%s#%s#%s", operation, req.PathParameters["uid"], subject, strings.TrimSpace(values[0]))
+}
+
+// NewRecord creates a record of response being returned for req, to be kept
+// until ttl after now.
+func NewRecord(key string, req events.APIGatewayProxyRequest, updateId string, response events.APIGatewayProxyResponse, now time.Time, ttl time.Duration) Record {
+	return Record{
+		Key:         key,
+		RequestHash: hash(req.Body),
+		UpdateId:    updateId,
+		StatusCode:  response.StatusCode,
+		Headers:     response.Headers,
+		Body:        response.Body,
+		CreatedAt:   now.Unix(),
+		ExpiresAt:   now.Add(ttl).Unix(),
+	}
+}
+
+// Live reports whether the record exists and has not expired. DynamoDB can take
+// some time to remove expired items, so they must be ignored when read.
+func (r Record) Live(now time.Time) bool {
+	return r.Key != "" && r.ExpiresAt > now.Unix()
+}
+
+// Matches reports whether req has the same body as the request that the record
+// was created for.
+func (r Record) Matches(req events.APIGatewayProxyRequest) bool {
+	return r.RequestHash == hash(req.Body)
+}
+
+// Replay returns the stored response.
+func (r Record) Replay() events.APIGatewayProxyResponse {
+	headers := map[string]string{ReplayedHeader: "true"}
+	for k, v := range r.Headers {
+		headers[k] = v
+	}
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: r.StatusCode,
+		Headers:    headers,
+		Body:       r.Body,
+	}
+}
+
+// ParseTTL parses the configured duration to keep keys for, returning
+// DefaultTTL if it is not set or is not a positive duration.
+func ParseTTL(value string) (time.Duration, error) {
+	if value == "" {
+		return DefaultTTL, nil
+	}
+
+	ttl, err := time.ParseDuration(value)
+	if err != nil {
+		return DefaultTTL, err
+	}
+
+	if ttl <= 0 {
+		return DefaultTTL, fmt.Errorf("idempotency key TTL must be positive, got %s", value)
+	}
+
+	return ttl, nil
+}
+
+func hash(body string) string {
+	sum := sha256.Sum256([]byte(body))
+	return hex.EncodeToString(sum[:])
+}
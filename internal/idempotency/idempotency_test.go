@@ -0,0 +1,93 @@
+package idempotency
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+var testNow = time.Date(2024, time.January, 2, 12, 13, 14, 0, time.UTC)
+
+func TestKey(t *testing.T) {
+	req := events.APIGatewayProxyRequest{
+		PathParameters:    map[string]string{"uid": "M-1111-2222-3333"},
+		MultiValueHeaders: map[string][]string{"Idempotency-Key": {" abc "}},
+	}
+
+	assert.Equal(t, "update#M-1111-2222-3333#urn:client#abc", Key(req, "update", "urn:client"))
+}
+
+func TestKeyWhenLowercaseHeader(t *testing.T) {
+	req := events.APIGatewayProxyRequest{
+		PathParameters:    map[string]string{"uid": "M-1111-2222-3333"},
+		MultiValueHeaders: map[string][]string{"idempotency-key": {"abc"}},
+	}
+
+	assert.Equal(t, "create#M-1111-2222-3333#urn:client#abc", Key(req, "create", "urn:client"))
+}
+
+func TestKeyWhenMissing(t *testing.T) {
+	testcases := map[string]map[string][]string{
+		"no header": {},
+		"empty":     {"Idempotency-Key": {" "}},
+		"no values": {"Idempotency-Key": {}},
+	}
+
+	for name, headers := range testcases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, "", Key(events.APIGatewayProxyRequest{MultiValueHeaders: headers}, "update", "urn:client"))
+		})
+	}
+}
+
+func TestRecord(t *testing.T) {
+	req := events.APIGatewayProxyRequest{Body: `{"type":"CORRECTION"}`}
+	response := events.APIGatewayProxyResponse{
+		StatusCode: 201,
+		Headers:    map[string]string{"ETag": `"1"`},
+		Body:       `{"uid":"M-1111-2222-3333"}`,
+	}
+
+	record := NewRecord("a-key", req, "an-id", response, testNow, time.Hour)
+
+	assert.Equal(t, "a-key", record.Key)
+	assert.Equal(t, "an-id", record.UpdateId)
+	assert.Equal(t, testNow.Unix(), record.CreatedAt)
+	assert.Equal(t, testNow.Add(time.Hour).Unix(), record.ExpiresAt)
+
+	assert.True(t, record.Matches(req))
+	assert.False(t, record.Matches(events.APIGatewayProxyRequest{Body: `{"type":"DONOR_CONFIRM_IDENTITY"}`}))
+
+	assert.True(t, record.Live(testNow.Add(time.Hour-time.Second)))
+	assert.False(t, record.Live(testNow.Add(time.Hour)))
+	assert.False(t, Record{}.Live(testNow))
+
+	assert.Equal(t, events.APIGatewayProxyResponse{
+		StatusCode: 201,
+		Headers:    map[string]string{"ETag": `"1"`, "Idempotent-Replayed": "true"},
+		Body:       `{"uid":"M-1111-2222-3333"}`,
+	}, record.Replay())
+}
+
+func TestParseTTL(t *testing.T) {
+	testcases := map[string]struct {
+		value string
+		ttl   time.Duration
+		err   bool
+	}{
+		"unset":    {value: "", ttl: DefaultTTL},
+		"set":      {value: "90m", ttl: 90 * time.Minute},
+		"invalid":  {value: "a day", ttl: DefaultTTL, err: true},
+		"negative": {value: "-1h", ttl: DefaultTTL, err: true},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ttl, err := ParseTTL(tc.value)
+			assert.Equal(t, tc.ttl, ttl)
+			assert.Equal(t, tc.err, err != nil)
+		})
+	}
+}
@@ -0,0 +1,293 @@
+// Package jsondoc compares and modifies JSON documents using the JSON pointer
+// keys of [shared.Change].
+package jsondoc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+)
+
+var jsonNull = json.RawMessage("null")
+
+// Diff lists the changes that turn the document before into the document
+// after. Each change is for a single value that is not an object or array,
+// except when a property or array item has been added or removed, in which
+// case the whole value is given with null for the missing side.
+func Diff(before, after []byte) ([]shared.Change, error) {
+	var b, a any
+	if err := json.Unmarshal(before, &b); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(after, &a); err != nil {
+		return nil, err
+	}
+
+	var changes []shared.Change
+	if err := diff("", b, a, &changes); err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+func diff(key string, before, after any, changes *[]shared.Change) error {
+	switch b := before.(type) {
+	case map[string]any:
+		if a, ok := after.(map[string]any); ok {
+			names := make([]string, 0, len(b)+len(a))
+			for name := range b {
+				names = append(names, name)
+			}
+			for name := range a {
+				if _, ok := b[name]; !ok {
+					names = append(names, name)
+				}
+			}
+			sort.Strings(names)
+
+			for _, name := range names {
+				if err := diff(key+"/"+escape(name), b[name], a[name], changes); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+	case []any:
+		if a, ok := after.([]any); ok {
+			for i := 0; i < max(len(a), len(b)); i++ {
+				var bv, av any
+				if i < len(b) {
+					bv = b[i]
+				}
+				if i < len(a) {
+					av = a[i]
+				}
+
+				if err := diff(key+"/"+strconv.Itoa(i), bv, av, changes); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+
+	if reflect.DeepEqual(before, after) {
+		return nil
+	}
+
+	oldValue, err := json.Marshal(before)
+	if err != nil {
+		return err
+	}
+
+	newValue, err := json.Marshal(after)
+	if err != nil {
+		return err
+	}
+
+	*changes = append(*changes, shared.Change{Key: key, Old: oldValue, New: newValue})
+	return nil
+}
+
+// Revert undoes changes to the document, by setting the old value of each
+// change in reverse order. Setting null removes a property, or the last item
+// of an array.
+func Revert(doc []byte, changes []shared.Change) ([]byte, error) {
+	var v any
+	if err := json.Unmarshal(doc, &v); err != nil {
+		return nil, err
+	}
+
+	for i := len(changes) - 1; i >= 0; i-- {
+		var err error
+		if v, err = set(v, changes[i].Key, changes[i].Old); err != nil {
+			return nil, err
+		}
+	}
+
+	return json.Marshal(v)
+}
+
+// Apply makes changes to the document, by setting the new value of each change
+// in order. Setting null removes a property, or the last item of an array.
+func Apply(doc []byte, changes []shared.Change) ([]byte, error) {
+	var v any
+	if err := json.Unmarshal(doc, &v); err != nil {
+		return nil, err
+	}
+
+	for _, change := range changes {
+		var err error
+		if v, err = set(v, change.Key, change.New); err != nil {
+			return nil, err
+		}
+	}
+
+	return json.Marshal(v)
+}
+
+// Conflicts lists the keys of changes where the document no longer has the
+// new value, so that reverting the changes would lose a later change. A
+// missing value is treated as null.
+func Conflicts(doc []byte, changes []shared.Change) ([]string, error) {
+	var v any
+	if err := json.Unmarshal(doc, &v); err != nil {
+		return nil, err
+	}
+
+	var conflicts []string
+	for _, change := range changes {
+		var value any
+		if len(change.New) > 0 {
+			if err := json.Unmarshal(change.New, &value); err != nil {
+				return nil, fmt.Errorf("%s: %w", change.Key, err)
+			}
+		}
+
+		current, err := get(v, change.Key)
+		if err != nil {
+			return nil, err
+		}
+
+		if !reflect.DeepEqual(current, value) {
+			conflicts = append(conflicts, change.Key)
+		}
+	}
+
+	return conflicts, nil
+}
+
+func get(doc any, key string) (any, error) {
+	if key == "" {
+		return doc, nil
+	}
+	if !strings.HasPrefix(key, "/") {
+		return nil, fmt.Errorf("%s: key must start with /", key)
+	}
+
+	for _, part := range strings.Split(key[1:], "/") {
+		switch d := doc.(type) {
+		case map[string]any:
+			doc = d[unescape(part)]
+		case []any:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(d) {
+				return nil, nil
+			}
+			doc = d[i]
+		default:
+			return nil, nil
+		}
+	}
+
+	return doc, nil
+}
+
+func set(doc any, key string, raw json.RawMessage) (any, error) {
+	var value any
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	remove := len(raw) == 0 || bytes.Equal(raw, jsonNull)
+
+	if key == "" {
+		return value, nil
+	}
+	if !strings.HasPrefix(key, "/") {
+		return nil, fmt.Errorf("%s: key must start with /", key)
+	}
+
+	parts := strings.Split(key[1:], "/")
+	for i := range parts {
+		parts[i] = unescape(parts[i])
+	}
+
+	return setPath(doc, parts, value, remove, key)
+}
+
+func setPath(doc any, parts []string, value any, remove bool, key string) (any, error) {
+	part, rest := parts[0], parts[1:]
+
+	switch d := doc.(type) {
+	case nil:
+		if remove {
+			return nil, nil
+		}
+		if _, err := strconv.Atoi(part); err == nil {
+			return setPath([]any{}, parts, value, remove, key)
+		}
+		return setPath(map[string]any{}, parts, value, remove, key)
+
+	case map[string]any:
+		if len(rest) == 0 {
+			if remove {
+				delete(d, part)
+			} else {
+				d[part] = value
+			}
+			return d, nil
+		}
+
+		child, err := setPath(d[part], rest, value, remove, key)
+		if err != nil {
+			return nil, err
+		}
+		if child == nil {
+			delete(d, part)
+		} else {
+			d[part] = child
+		}
+		return d, nil
+
+	case []any:
+		i, err := strconv.Atoi(part)
+		if err != nil || i < 0 || i > len(d) {
+			return nil, fmt.Errorf("%s: index out of range", key)
+		}
+
+		if len(rest) == 0 {
+			switch {
+			case remove && i == len(d)-1:
+				return d[:i], nil
+			case remove && i == len(d):
+				return d, nil
+			case i == len(d):
+				return append(d, value), nil
+			default:
+				d[i] = value
+				return d, nil
+			}
+		}
+
+		if i == len(d) {
+			d = append(d, nil)
+		}
+
+		child, err := setPath(d[i], rest, value, remove, key)
+		if err != nil {
+			return nil, err
+		}
+		d[i] = child
+		return d, nil
+	}
+
+	return nil, fmt.Errorf("%s: cannot set a property of %T", key, doc)
+}
+
+func escape(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
+
+func unescape(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~1", "/"), "~0", "~")
+}
@@ -0,0 +1,112 @@
+package jsondoc
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	before := `{"status":"in-progress","donor":{"firstNames":"John","lastName":"Smith"},"attorneys":[{"uid":"a"}],"notes":[],"a/b":1}`
+	after := `{"status":"registered","donor":{"firstNames":"John","lastName":"Smyth","email":"j@example.com"},"attorneys":[{"uid":"a"},{"uid":"b"}],"notes":[],"registrationDate":"2024-01-02T00:00:00Z"}`
+
+	changes, err := Diff([]byte(before), []byte(after))
+	assert.Nil(t, err)
+	assert.Equal(t, []shared.Change{
+		{Key: "/a~1b", Old: json.RawMessage(`1`), New: json.RawMessage(`null`)},
+		{Key: "/attorneys/1", Old: json.RawMessage(`null`), New: json.RawMessage(`{"uid":"b"}`)},
+		{Key: "/donor/email", Old: json.RawMessage(`null`), New: json.RawMessage(`"j@example.com"`)},
+		{Key: "/donor/lastName", Old: json.RawMessage(`"Smith"`), New: json.RawMessage(`"Smyth"`)},
+		{Key: "/registrationDate", Old: json.RawMessage(`null`), New: json.RawMessage(`"2024-01-02T00:00:00Z"`)},
+		{Key: "/status", Old: json.RawMessage(`"in-progress"`), New: json.RawMessage(`"registered"`)},
+	}, changes)
+
+	reverted, err := Revert([]byte(after), changes)
+	assert.Nil(t, err)
+	assert.JSONEq(t, before, string(reverted))
+
+	applied, err := Apply([]byte(before), changes)
+	assert.Nil(t, err)
+	assert.JSONEq(t, after, string(applied))
+}
+
+func TestDiffWhenSame(t *testing.T) {
+	changes, err := Diff([]byte(`{"a":[1,{"b":2}]}`), []byte(`{"a":[1,{"b":2}]}`))
+	assert.Nil(t, err)
+	assert.Empty(t, changes)
+}
+
+func TestDiffWhenInvalid(t *testing.T) {
+	_, err := Diff([]byte(`{`), []byte(`{}`))
+	assert.Error(t, err)
+
+	_, err = Diff([]byte(`{}`), []byte(`{`))
+	assert.Error(t, err)
+}
+
+func TestRevertRoot(t *testing.T) {
+	reverted, err := Revert([]byte(`{"uid":"M-1111-1111-1111"}`), []shared.Change{
+		{Key: "", Old: json.RawMessage(`null`), New: json.RawMessage(`{"uid":"M-1111-1111-1111"}`)},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "null", string(reverted))
+}
+
+func TestRevertLeafChanges(t *testing.T) {
+	reverted, err := Revert([]byte(`{"certificateProvider":{"signedAt":"2024-01-02T00:00:00Z","email":"b@example.com"}}`), []shared.Change{
+		{Key: "/certificateProvider/signedAt", Old: json.RawMessage(`null`), New: json.RawMessage(`"2024-01-02T00:00:00Z"`)},
+		{Key: "/certificateProvider/email", Old: json.RawMessage(`"a@example.com"`), New: json.RawMessage(`"b@example.com"`)},
+	})
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"certificateProvider":{"email":"a@example.com"}}`, string(reverted))
+}
+
+func TestApplyCreatesParents(t *testing.T) {
+	applied, err := Apply([]byte(`{}`), []shared.Change{
+		{Key: "/donor/identityCheck/type", Old: json.RawMessage(`null`), New: json.RawMessage(`"one-login"`)},
+		{Key: "/notes/0", Old: json.RawMessage(`null`), New: json.RawMessage(`{"type":"a"}`)},
+	})
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"donor":{"identityCheck":{"type":"one-login"}},"notes":[{"type":"a"}]}`, string(applied))
+}
+
+func TestApplyWhenInvalid(t *testing.T) {
+	testcases := map[string]shared.Change{
+		"no leading slash":   {Key: "status", New: json.RawMessage(`"a"`)},
+		"index out of range": {Key: "/attorneys/5", New: json.RawMessage(`{}`)},
+		"not an index":       {Key: "/attorneys/x", New: json.RawMessage(`{}`)},
+		"not a container":    {Key: "/status/a", New: json.RawMessage(`"a"`)},
+		"invalid value":      {Key: "/status", New: json.RawMessage(`{`)},
+	}
+
+	for name, change := range testcases {
+		t.Run(name, func(t *testing.T) {
+			_, err := Apply([]byte(`{"status":"a","attorneys":[]}`), []shared.Change{change})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestConflicts(t *testing.T) {
+	doc := `{"status":"registered","donor":{"lastName":"Smyth"},"attorneys":[{"uid":"a"}]}`
+
+	conflicts, err := Conflicts([]byte(doc), []shared.Change{
+		{Key: "/status", Old: json.RawMessage(`"in-progress"`), New: json.RawMessage(`"registered"`)},
+		{Key: "/donor/lastName", Old: json.RawMessage(`"Smith"`), New: json.RawMessage(`"Smythe"`)},
+		{Key: "/donor/email", Old: json.RawMessage(`"a@example.com"`), New: json.RawMessage(`null`)},
+		{Key: "/attorneys/1", Old: json.RawMessage(`null`), New: json.RawMessage(`{"uid":"b"}`)},
+		{Key: "/attorneys/0/uid", Old: json.RawMessage(`"x"`), New: json.RawMessage(`"a"`)},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"/donor/lastName", "/attorneys/1"}, conflicts)
+}
+
+func TestConflictsWhenInvalid(t *testing.T) {
+	_, err := Conflicts([]byte(`{`), nil)
+	assert.Error(t, err)
+
+	_, err = Conflicts([]byte(`{}`), []shared.Change{{Key: "status", New: json.RawMessage(`1`)}})
+	assert.Error(t, err)
+}
@@ -14,6 +14,8 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/tracing"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
 )
 
 type awsS3Client interface {
@@ -43,7 +45,10 @@ func NewS3Client(awsConfig aws.Config, bucketName string) *S3Client {
 	}
 }
 
-func (c *S3Client) Put(ctx context.Context, objectKey string, obj any) error {
+func (c *S3Client) Put(ctx context.Context, objectKey string, obj any) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "objectstore.Put", semconv.AWSS3Bucket(c.bucketName), semconv.AWSS3Key(objectKey))
+	defer tracing.EndSpan(span, &err)
+
 	b, err := json.Marshal(obj)
 	if err != nil {
 		return err
@@ -59,7 +64,10 @@ func (c *S3Client) Put(ctx context.Context, objectKey string, obj any) error {
 	return err
 }
 
-func (c *S3Client) UploadFile(ctx context.Context, file shared.FileUpload, path string) (shared.File, error) {
+func (c *S3Client) UploadFile(ctx context.Context, file shared.FileUpload, path string) (_ shared.File, err error) {
+	ctx, span := tracing.StartSpan(ctx, "objectstore.UploadFile", semconv.AWSS3Bucket(c.bucketName), semconv.AWSS3Key(path))
+	defer tracing.EndSpan(span, &err)
+
 	imgData, err := base64.StdEncoding.DecodeString(file.Data)
 	if err != nil {
 		return shared.File{}, err
@@ -85,7 +93,10 @@ func (c *S3Client) UploadFile(ctx context.Context, file shared.FileUpload, path
 	}, nil
 }
 
-func (c *S3Client) PresignLpa(ctx context.Context, lpa shared.Lpa) (shared.Lpa, error) {
+func (c *S3Client) PresignLpa(ctx context.Context, lpa shared.Lpa) (_ shared.Lpa, err error) {
+	ctx, span := tracing.StartSpan(ctx, "objectstore.PresignLpa", semconv.AWSS3Bucket(c.bucketName), tracing.LpaUID(lpa.Uid))
+	defer tracing.EndSpan(span, &err)
+
 	if len(lpa.RestrictionsAndConditionsImages) > 0 {
 		for i, restrictionsImage := range lpa.RestrictionsAndConditionsImages {
 			req, err := c.presigner.PresignGetObject(ctx, &s3.GetObjectInput{
@@ -117,7 +128,10 @@ func (c *S3Client) PresignLpa(ctx context.Context, lpa shared.Lpa) (shared.Lpa,
 	return lpa, nil
 }
 
-func (c *S3Client) Get(ctx context.Context, objectKey string) (string, error) {
+func (c *S3Client) Get(ctx context.Context, objectKey string) (_ string, err error) {
+	ctx, span := tracing.StartSpan(ctx, "objectstore.Get", semconv.AWSS3Bucket(c.bucketName), semconv.AWSS3Key(objectKey))
+	defer tracing.EndSpan(span, &err)
+
 	result, err := c.awsClient.GetObject(ctx, &s3.GetObjectInput{
 		Bucket: aws.String(c.bucketName),
 		Key:    aws.String(objectKey),
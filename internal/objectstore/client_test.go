@@ -23,6 +23,7 @@ const ctxValue ctxValueType = "for"
 
 var (
 	ctx         = context.WithValue(context.Background(), ctxValue, "testing")
+	spanCtx     = mock.MatchedBy(func(c context.Context) bool { return c.Value(ctxValue) == "testing" })
 	errExpected = errors.New("expected")
 	bucketName  = "a-bucket"
 	objectKey   = "an-object-key"
@@ -31,7 +32,7 @@ var (
 func TestS3ClientPut(t *testing.T) {
 	awsS3Client := newMockAwsS3Client(t)
 	awsS3Client.EXPECT().
-		PutObject(ctx, &s3.PutObjectInput{
+		PutObject(spanCtx, &s3.PutObjectInput{
 			Bucket:               aws.String(bucketName),
 			Key:                  aws.String(objectKey),
 			Body:                 bytes.NewReader([]byte(`{"ID":1}`)),
@@ -56,7 +57,7 @@ func TestS3ClientUploadFile(t *testing.T) {
 
 	awsS3Client := newMockAwsS3Client(t)
 	awsS3Client.EXPECT().
-		PutObject(ctx, &s3.PutObjectInput{
+		PutObject(spanCtx, &s3.PutObjectInput{
 			Bucket:               aws.String(bucketName),
 			Key:                  aws.String("dir/myfile.txt"),
 			Body:                 bytes.NewReader([]byte("Contents of my file")),
@@ -110,14 +111,14 @@ func TestS3ClientUploadFileWhenS3Error(t *testing.T) {
 func TestS3ClientPresignLpa(t *testing.T) {
 	presigner := newMockPresignClient(t)
 	presigner.EXPECT().
-		PresignGetObject(ctx, &s3.GetObjectInput{
+		PresignGetObject(spanCtx, &s3.GetObjectInput{
 			Bucket: aws.String("bucket1"),
 			Key:    aws.String("x.jpg"),
 		}).
 		Return(&v4.PresignedHTTPRequest{URL: "aws/x.jpg?blah"}, nil).
 		Once()
 	presigner.EXPECT().
-		PresignGetObject(ctx, &s3.GetObjectInput{
+		PresignGetObject(spanCtx, &s3.GetObjectInput{
 			Bucket: aws.String("bucket1"),
 			Key:    aws.String("y.png"),
 		}).
@@ -166,7 +167,7 @@ func TestS3ClientPresignLpaWhenError(t *testing.T) {
 func TestS3ClientGetNoObjectFound(t *testing.T) {
 	awsS3Client := newMockAwsS3Client(t)
 	awsS3Client.EXPECT().
-		GetObject(ctx, &s3.GetObjectInput{
+		GetObject(spanCtx, &s3.GetObjectInput{
 			Bucket: aws.String("bucket1"),
 			Key:    aws.String("uid"),
 		}).
@@ -192,7 +193,7 @@ func TestS3ClientGetObjectFound(t *testing.T) {
 
 	awsS3Client := newMockAwsS3Client(t)
 	awsS3Client.EXPECT().
-		GetObject(ctx, &s3.GetObjectInput{
+		GetObject(spanCtx, &s3.GetObjectInput{
 			Bucket: aws.String("bucket1"),
 			Key:    aws.String("uid"),
 		}).
@@ -66,6 +66,10 @@ func (d Date) MarshalDynamoDBAttributeValue() (types.AttributeValue, error) {
 	return attributevalue.Marshal(string(bytes))
 }
 
+func (d Date) Time() time.Time {
+	return d.t
+}
+
 func (d Date) DateOnlyText() string {
 	if d.t.IsZero() {
 		return ""
@@ -0,0 +1,265 @@
+package shared
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"sync"
+	"time"
+)
+
+const (
+	// keyRefreshInterval is how long keys are used for before being fetched
+	// again, so that rotated keys are picked up without a cold start.
+	keyRefreshInterval = 5 * time.Minute
+
+	// minKeyRefreshInterval limits how often an unknown kid, or keys that could
+	// not be loaded, can cause the keys to be fetched again.
+	minKeyRefreshInterval = 30 * time.Second
+)
+
+var (
+	errInvalidIssuer = errors.New("invalid issuer")
+	errUnknownKid    = errors.New("unknown kid")
+)
+
+// KeySet is the keys trusted for each issuer, keyed by issuer. It is stored as
+// JSON in Secrets Manager.
+type KeySet map[string]IssuerKeys
+
+// IssuerKeys is the algorithm an issuer signs its tokens with, and the keys it
+// may sign them with. More than one key is listed while a key is rotated.
+type IssuerKeys struct {
+	Alg  string       `json:"alg"`
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// JSONWebKey is a key in the format of RFC 7517. RSA and EC keys are public
+// keys, oct keys are shared secrets and are only accepted for HS256.
+type JSONWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+	K   string `json:"k,omitempty"`
+}
+
+// missingIssuer reports whether any valid issuer has no keys in the set.
+func (s KeySet) missingIssuer() bool {
+	for _, iss := range validIssuers {
+		if _, ok := s[iss]; !ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+type issuerKeys struct {
+	alg  string
+	keys map[string]any
+}
+
+// parse checks that every key can be used with its issuer's algorithm, and
+// decodes them.
+func (s KeySet) parse() (map[string]issuerKeys, error) {
+	issuers := map[string]issuerKeys{}
+
+	for iss, set := range s {
+		keys := map[string]any{}
+
+		for _, jwk := range set.Keys {
+			if _, ok := keys[jwk.Kid]; ok {
+				return nil, fmt.Errorf("%s: duplicate kid %q", iss, jwk.Kid)
+			}
+
+			key, err := jwk.key(set.Alg)
+			if err != nil {
+				return nil, fmt.Errorf("%s: kid %q: %w", iss, jwk.Kid, err)
+			}
+
+			keys[jwk.Kid] = key
+		}
+
+		issuers[iss] = issuerKeys{alg: set.Alg, keys: keys}
+	}
+
+	return issuers, nil
+}
+
+func (k JSONWebKey) key(alg string) (any, error) {
+	switch alg {
+	case "RS256":
+		if k.Kty != "RSA" {
+			return nil, fmt.Errorf("RS256 requires an RSA key")
+		}
+
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("n: %w", err)
+		}
+
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("e: %w", err)
+		}
+
+		if !e.IsInt64() || e.Int64() < 2 || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("e: invalid exponent")
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "ES256":
+		if k.Kty != "EC" || k.Crv != "P-256" {
+			return nil, fmt.Errorf("ES256 requires an EC key on P-256")
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != 32 {
+			return nil, fmt.Errorf("x: invalid coordinate")
+		}
+
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil || len(y) != 32 {
+			return nil, fmt.Errorf("y: invalid coordinate")
+		}
+
+		if _, err := ecdh.P256().NewPublicKey(append(append([]byte{4}, x...), y...)); err != nil {
+			return nil, fmt.Errorf("point is not on P-256")
+		}
+
+		return &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}, nil
+
+	case "HS256":
+		if k.Kty != "oct" {
+			return nil, fmt.Errorf("HS256 requires an oct key")
+		}
+
+		secret, err := base64.RawURLEncoding.DecodeString(k.K)
+		if err != nil || len(secret) == 0 {
+			return nil, fmt.Errorf("k: invalid secret")
+		}
+
+		return secret, nil
+	}
+
+	return nil, fmt.Errorf("unsupported algorithm %q", alg)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, fmt.Errorf("empty value")
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
+
+// keyStore holds the keys used to verify tokens, fetching them again
+// periodically and when a token uses a kid that is not known.
+type keyStore struct {
+	fetch  func(context.Context) (KeySet, error)
+	logger logger
+	now    func() time.Time
+
+	mu      sync.Mutex
+	issuers map[string]issuerKeys
+	// refreshedAt is when the keys were last fetched, whether or not that
+	// succeeded
+	refreshedAt time.Time
+}
+
+func newKeyStore(fetch func(context.Context) (KeySet, error), logger logger) *keyStore {
+	return &keyStore{
+		fetch:  fetch,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// load fetches the keys, so they are available before the first token is
+// verified.
+func (s *keyStore) load(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.refresh(ctx)
+}
+
+// key returns the key to verify a token from the issuer, signed with alg using
+// the key identified by kid.
+func (s *keyStore) key(ctx context.Context, iss, alg, kid string) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// keys that could not be loaded are tried again, but no more often than an
+	// unknown kid would cause, so that an outage is not made worse
+	sinceRefresh := s.now().Sub(s.refreshedAt)
+	if sinceRefresh >= keyRefreshInterval || s.issuers == nil && sinceRefresh >= minKeyRefreshInterval {
+		s.refresh(ctx)
+	}
+
+	key, err := s.lookup(iss, alg, kid)
+	if errors.Is(err, errUnknownKid) && s.now().Sub(s.refreshedAt) >= minKeyRefreshInterval {
+		s.refresh(ctx)
+		key, err = s.lookup(iss, alg, kid)
+	}
+
+	return key, err
+}
+
+func (s *keyStore) lookup(iss, alg, kid string) (any, error) {
+	issuer, ok := s.issuers[iss]
+	if !ok {
+		return nil, errInvalidIssuer
+	}
+
+	if alg != issuer.alg {
+		return nil, fmt.Errorf("signing method %s is not permitted for %s", alg, iss)
+	}
+
+	key, ok := issuer.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w %q for %s", errUnknownKid, kid, iss)
+	}
+
+	return key, nil
+}
+
+// refresh fetches the keys, keeping the current keys if they cannot be
+// fetched so that verification continues with the last known keys.
+func (s *keyStore) refresh(ctx context.Context) {
+	s.refreshedAt = s.now()
+
+	set, err := s.fetch(ctx)
+	if err != nil {
+		s.logger.Error("Failed to fetch JWT keys", slog.Any("err", err))
+		return
+	}
+
+	issuers, err := set.parse()
+	if err != nil {
+		s.logger.Error("Failed to parse JWT keys", slog.Any("err", err))
+		return
+	}
+
+	s.issuers = issuers
+}
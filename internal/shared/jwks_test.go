@@ -0,0 +1,483 @@
+package shared
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var (
+	ctx           = context.WithValue(context.Background(), (*string)(nil), "testing")
+	expectedError = errors.New("err")
+
+	rsaKey, _ = rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _  = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+)
+
+func rsaJWK(kid string, key *rsa.PrivateKey) JSONWebKey {
+	return JSONWebKey{
+		Kid: kid,
+		Kty: "RSA",
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func ecJWK(kid string, key *ecdsa.PrivateKey) JSONWebKey {
+	return JSONWebKey{
+		Kid: kid,
+		Kty: "EC",
+		Crv: "P-256",
+		X:   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+		Y:   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+	}
+}
+
+func signToken(method jwt.SigningMethod, kid string, key any, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	tokenString, _ := token.SignedString(key)
+
+	return tokenString
+}
+
+func validClaims(iss string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"exp": time.Now().Add(time.Hour).Unix(),
+		"iat": time.Now().Add(-time.Minute).Unix(),
+		"iss": iss,
+		"sub": "urn:opg:poas:makeregister:users:e6707412-c9cd-4547-b428-7039a87e985e",
+	}
+}
+
+func staticKeys(set KeySet) *keyStore {
+	return newKeyStore(func(context.Context) (KeySet, error) { return set, nil }, nil)
+}
+
+func TestKeySetParse(t *testing.T) {
+	issuers, err := KeySet{
+		sirius: {Alg: "RS256", Keys: []JSONWebKey{rsaJWK("a", rsaKey)}},
+		mrlpa:  {Alg: "ES256", Keys: []JSONWebKey{ecJWK("b", ecKey)}},
+		use:    {Alg: "HS256", Keys: []JSONWebKey{{Kid: "c", Kty: "oct", K: "c2VjcmV0"}}},
+	}.parse()
+
+	assert.Nil(t, err)
+	assert.Equal(t, &rsaKey.PublicKey, issuers[sirius].keys["a"])
+	assert.Equal(t, ecKey.PublicKey.X, issuers[mrlpa].keys["b"].(*ecdsa.PublicKey).X)
+	assert.Equal(t, []byte("secret"), issuers[use].keys["c"])
+}
+
+func TestKeySetParseWhenInvalid(t *testing.T) {
+	testcases := map[string]struct {
+		set   KeySet
+		error string
+	}{
+		"unsupported algorithm": {
+			set:   KeySet{sirius: {Alg: "none", Keys: []JSONWebKey{{Kid: "a"}}}},
+			error: `opg.poas.sirius: kid "a": unsupported algorithm "none"`,
+		},
+		"RSA key for ES256": {
+			set:   KeySet{sirius: {Alg: "ES256", Keys: []JSONWebKey{rsaJWK("a", rsaKey)}}},
+			error: `opg.poas.sirius: kid "a": ES256 requires an EC key on P-256`,
+		},
+		"EC key for RS256": {
+			set:   KeySet{sirius: {Alg: "RS256", Keys: []JSONWebKey{ecJWK("a", ecKey)}}},
+			error: `opg.poas.sirius: kid "a": RS256 requires an RSA key`,
+		},
+		"oct key for RS256": {
+			set:   KeySet{sirius: {Alg: "RS256", Keys: []JSONWebKey{{Kid: "a", Kty: "oct", K: "c2VjcmV0"}}}},
+			error: `opg.poas.sirius: kid "a": RS256 requires an RSA key`,
+		},
+		"EC point not on curve": {
+			set: KeySet{sirius: {Alg: "ES256", Keys: []JSONWebKey{{
+				Kid: "a",
+				Kty: "EC",
+				Crv: "P-256",
+				X:   base64.RawURLEncoding.EncodeToString(make([]byte, 32)),
+				Y:   base64.RawURLEncoding.EncodeToString(make([]byte, 32)),
+			}}}},
+			error: `opg.poas.sirius: kid "a": point is not on P-256`,
+		},
+		"duplicate kid": {
+			set:   KeySet{sirius: {Alg: "RS256", Keys: []JSONWebKey{rsaJWK("a", rsaKey), rsaJWK("a", rsaKey)}}},
+			error: `opg.poas.sirius: duplicate kid "a"`,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			_, err := tc.set.parse()
+			assert.EqualError(t, err, tc.error)
+		})
+	}
+}
+
+func TestVerifyTokenWithAsymmetricKeys(t *testing.T) {
+	v := JWTVerifier{keys: staticKeys(KeySet{
+		sirius: {Alg: "RS256", Keys: []JSONWebKey{rsaJWK("rsa-1", rsaKey)}},
+		mrlpa:  {Alg: "ES256", Keys: []JSONWebKey{ecJWK("ec-1", ecKey)}},
+	})}
+
+	claims, err := v.verifyToken(ctx, signToken(jwt.SigningMethodRS256, "rsa-1", rsaKey, validClaims(sirius)))
+	assert.Nil(t, err)
+	assert.Equal(t, sirius, claims.Issuer)
+
+	claims, err = v.verifyToken(ctx, signToken(jwt.SigningMethodES256, "ec-1", ecKey, validClaims(mrlpa)))
+	assert.Nil(t, err)
+	assert.Equal(t, mrlpa, claims.Issuer)
+}
+
+func TestVerifyTokenWhenAlgorithmNotPinnedForIssuer(t *testing.T) {
+	v := JWTVerifier{keys: staticKeys(KeySet{
+		sirius: {Alg: "RS256", Keys: []JSONWebKey{rsaJWK("rsa-1", rsaKey)}},
+		mrlpa:  {Alg: "ES256", Keys: []JSONWebKey{ecJWK("ec-1", ecKey)}},
+	})}
+
+	testcases := map[string]string{
+		"ES256 for RS256 issuer": signToken(jwt.SigningMethodES256, "rsa-1", ecKey, validClaims(sirius)),
+		"RS256 for ES256 issuer": signToken(jwt.SigningMethodRS256, "ec-1", rsaKey, validClaims(mrlpa)),
+		"HS256 with public key":  signToken(jwt.SigningMethodHS256, "rsa-1", rsaKey.N.Bytes(), validClaims(sirius)),
+		"none":                   signToken(jwt.SigningMethodNone, "rsa-1", jwt.UnsafeAllowNoneSignatureType, validClaims(sirius)),
+	}
+
+	for name, token := range testcases {
+		t.Run(name, func(t *testing.T) {
+			_, err := v.verifyToken(ctx, token)
+			assert.NotNil(t, err)
+		})
+	}
+}
+
+func TestVerifyTokenWhenSignedByAnotherIssuersKey(t *testing.T) {
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	v := JWTVerifier{keys: staticKeys(KeySet{
+		sirius: {Alg: "RS256", Keys: []JSONWebKey{rsaJWK("sirius-1", rsaKey)}},
+		mrlpa:  {Alg: "RS256", Keys: []JSONWebKey{rsaJWK("mrlpa-1", otherKey)}},
+	})}
+
+	_, err := v.verifyToken(ctx, signToken(jwt.SigningMethodRS256, "sirius-1", rsaKey, validClaims(mrlpa)))
+	assert.ErrorIs(t, err, errUnknownKid)
+}
+
+func TestVerifyTokenDuringRotation(t *testing.T) {
+	newKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	v := JWTVerifier{keys: staticKeys(KeySet{
+		sirius: {Alg: "RS256", Keys: []JSONWebKey{rsaJWK("old", rsaKey), rsaJWK("new", newKey)}},
+	})}
+
+	_, err := v.verifyToken(ctx, signToken(jwt.SigningMethodRS256, "old", rsaKey, validClaims(sirius)))
+	assert.Nil(t, err)
+
+	_, err = v.verifyToken(ctx, signToken(jwt.SigningMethodRS256, "new", newKey, validClaims(sirius)))
+	assert.Nil(t, err)
+
+	_, err = v.verifyToken(ctx, signToken(jwt.SigningMethodRS256, "old", newKey, validClaims(sirius)))
+	assert.NotNil(t, err)
+}
+
+func TestKeyStoreRefreshesWhenStale(t *testing.T) {
+	now := time.Now()
+	sets := []KeySet{
+		{sirius: {Alg: "RS256", Keys: []JSONWebKey{rsaJWK("a", rsaKey)}}},
+		{sirius: {Alg: "ES256", Keys: []JSONWebKey{ecJWK("b", ecKey)}}},
+	}
+
+	fetches := 0
+	store := newKeyStore(func(context.Context) (KeySet, error) {
+		fetches++
+		return sets[fetches-1], nil
+	}, nil)
+	store.now = func() time.Time { return now }
+
+	_, err := store.key(ctx, sirius, "RS256", "a")
+	assert.Nil(t, err)
+
+	now = now.Add(keyRefreshInterval - time.Second)
+	_, err = store.key(ctx, sirius, "RS256", "a")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, fetches)
+
+	now = now.Add(time.Second)
+	key, err := store.key(ctx, sirius, "ES256", "b")
+	assert.Nil(t, err)
+	assert.Equal(t, ecKey.PublicKey.X, key.(*ecdsa.PublicKey).X)
+	assert.Equal(t, 2, fetches)
+}
+
+func TestKeyStoreRefreshesOnUnknownKid(t *testing.T) {
+	now := time.Now()
+	sets := []KeySet{
+		{sirius: {Alg: "RS256", Keys: []JSONWebKey{rsaJWK("a", rsaKey)}}},
+		{sirius: {Alg: "RS256", Keys: []JSONWebKey{rsaJWK("a", rsaKey), rsaJWK("b", rsaKey)}}},
+	}
+
+	fetches := 0
+	store := newKeyStore(func(context.Context) (KeySet, error) {
+		fetches++
+		return sets[min(fetches, len(sets))-1], nil
+	}, nil)
+	store.now = func() time.Time { return now }
+
+	store.load(ctx)
+
+	_, err := store.key(ctx, sirius, "RS256", "b")
+	assert.ErrorIs(t, err, errUnknownKid)
+	assert.Equal(t, 1, fetches)
+
+	now = now.Add(minKeyRefreshInterval)
+	_, err = store.key(ctx, sirius, "RS256", "b")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, fetches)
+}
+
+func TestKeyStoreKeepsKeysWhenRefreshFails(t *testing.T) {
+	now := time.Now()
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		Error("Failed to fetch JWT keys", slog.Any("err", expectedError))
+
+	fetches := 0
+	store := newKeyStore(func(context.Context) (KeySet, error) {
+		fetches++
+		if fetches > 1 {
+			return nil, expectedError
+		}
+		return KeySet{sirius: {Alg: "RS256", Keys: []JSONWebKey{rsaJWK("a", rsaKey)}}}, nil
+	}, logger)
+	store.now = func() time.Time { return now }
+
+	store.load(ctx)
+
+	now = now.Add(keyRefreshInterval)
+	key, err := store.key(ctx, sirius, "RS256", "a")
+	assert.Nil(t, err)
+	assert.Equal(t, &rsaKey.PublicKey, key)
+}
+
+func TestKeyStoreLimitsRetriesWhenLoadFails(t *testing.T) {
+	now := time.Now()
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		Error("Failed to fetch JWT keys", slog.Any("err", expectedError)).
+		Twice()
+
+	fetches := 0
+	store := newKeyStore(func(context.Context) (KeySet, error) {
+		fetches++
+		if fetches <= 2 {
+			return nil, expectedError
+		}
+		return KeySet{sirius: {Alg: "RS256", Keys: []JSONWebKey{rsaJWK("a", rsaKey)}}}, nil
+	}, logger)
+	store.now = func() time.Time { return now }
+
+	store.load(ctx)
+
+	_, err := store.key(ctx, sirius, "RS256", "a")
+	assert.ErrorIs(t, err, errInvalidIssuer)
+	assert.Equal(t, 1, fetches)
+
+	now = now.Add(minKeyRefreshInterval - time.Second)
+	_, err = store.key(ctx, sirius, "RS256", "a")
+	assert.ErrorIs(t, err, errInvalidIssuer)
+	assert.Equal(t, 1, fetches)
+
+	now = now.Add(time.Second)
+	_, err = store.key(ctx, sirius, "RS256", "a")
+	assert.ErrorIs(t, err, errInvalidIssuer)
+	assert.Equal(t, 2, fetches)
+
+	now = now.Add(minKeyRefreshInterval)
+	key, err := store.key(ctx, sirius, "RS256", "a")
+	assert.Nil(t, err)
+	assert.Equal(t, &rsaKey.PublicKey, key)
+	assert.Equal(t, 3, fetches)
+}
+
+func TestKeyStoreKeepsKeysWhenRefreshInvalid(t *testing.T) {
+	now := time.Now()
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		Error("Failed to parse JWT keys", mock.Anything)
+
+	fetches := 0
+	store := newKeyStore(func(context.Context) (KeySet, error) {
+		fetches++
+		if fetches > 1 {
+			return KeySet{sirius: {Alg: "RS256", Keys: []JSONWebKey{ecJWK("a", ecKey)}}}, nil
+		}
+		return KeySet{sirius: {Alg: "RS256", Keys: []JSONWebKey{rsaJWK("a", rsaKey)}}}, nil
+	}, logger)
+	store.now = func() time.Time { return now }
+
+	store.load(ctx)
+
+	now = now.Add(keyRefreshInterval)
+	key, err := store.key(ctx, sirius, "RS256", "a")
+	assert.Nil(t, err)
+	assert.Equal(t, &rsaKey.PublicKey, key)
+}
+
+func TestFetchKeySet(t *testing.T) {
+	client := newMockSecretsClient(t)
+	client.EXPECT().
+		GetSecretValue(ctx, mock.MatchedBy(func(input *secretsmanager.GetSecretValueInput) bool {
+			return *input.SecretId == "keys-arn"
+		})).
+		Return(&secretsmanager.GetSecretValueOutput{
+			SecretString: aws.String(`{"opg.poas.sirius":{"alg":"RS256","keys":[{"kid":"a","kty":"RSA","n":"AQAB","e":"AQAB"}]}}`),
+		}, nil)
+
+	set, err := fetchKeySet(ctx, client, "keys-arn")
+	assert.Nil(t, err)
+	assert.Equal(t, KeySet{sirius: {Alg: "RS256", Keys: []JSONWebKey{{Kid: "a", Kty: "RSA", N: "AQAB", E: "AQAB"}}}}, set)
+}
+
+func TestFetchKeySetWhenError(t *testing.T) {
+	client := newMockSecretsClient(t)
+	client.EXPECT().
+		GetSecretValue(ctx, mock.Anything).
+		Return(nil, expectedError)
+
+	_, err := fetchKeySet(ctx, client, "keys-arn")
+	assert.Equal(t, expectedError, err)
+}
+
+func TestFetchSecretKeySet(t *testing.T) {
+	client := newMockSecretsClient(t)
+	client.EXPECT().
+		GetSecretValue(ctx, mock.Anything).
+		Return(&secretsmanager.GetSecretValueOutput{SecretString: aws.String("secret")}, nil)
+
+	set, err := fetchSecretKeySet(ctx, client, "secret-arn")
+	assert.Nil(t, err)
+	assert.Len(t, set, len(validIssuers))
+
+	for _, iss := range validIssuers {
+		assert.Equal(t, IssuerKeys{Alg: "HS256", Keys: []JSONWebKey{{Kty: "oct", K: "c2VjcmV0"}}}, set[iss])
+	}
+}
+
+func TestFetchKeySetWhenEmpty(t *testing.T) {
+	client := newMockSecretsClient(t)
+	client.EXPECT().
+		GetSecretValue(ctx, mock.Anything).
+		Return(&secretsmanager.GetSecretValueOutput{SecretString: aws.String("")}, nil)
+
+	set, err := fetchKeySet(ctx, client, "keys-arn")
+	assert.Nil(t, err)
+	assert.Equal(t, KeySet{}, set)
+}
+
+func TestFetchKeySetWithSecret(t *testing.T) {
+	client := newMockSecretsClient(t)
+	client.EXPECT().
+		GetSecretValue(ctx, mock.MatchedBy(func(input *secretsmanager.GetSecretValueInput) bool {
+			return *input.SecretId == "keys-arn"
+		})).
+		Return(&secretsmanager.GetSecretValueOutput{
+			SecretString: aws.String(`{"opg.poas.sirius":{"alg":"RS256","keys":[{"kid":"a","kty":"RSA","n":"AQAB","e":"AQAB"}]}}`),
+		}, nil)
+	client.EXPECT().
+		GetSecretValue(ctx, mock.MatchedBy(func(input *secretsmanager.GetSecretValueInput) bool {
+			return *input.SecretId == "secret-arn"
+		})).
+		Return(&secretsmanager.GetSecretValueOutput{SecretString: aws.String("secret")}, nil)
+
+	set, err := fetchKeySetWithSecret(ctx, client, "keys-arn", "secret-arn")
+	assert.Nil(t, err)
+	assert.Equal(t, KeySet{
+		sirius: {Alg: "RS256", Keys: []JSONWebKey{{Kid: "a", Kty: "RSA", N: "AQAB", E: "AQAB"}}},
+		mrlpa:  {Alg: "HS256", Keys: []JSONWebKey{{Kty: "oct", K: "c2VjcmV0"}}},
+		use:    {Alg: "HS256", Keys: []JSONWebKey{{Kty: "oct", K: "c2VjcmV0"}}},
+	}, set)
+}
+
+func TestFetchKeySetWithSecretWhenEveryIssuerHasKeys(t *testing.T) {
+	client := newMockSecretsClient(t)
+	client.EXPECT().
+		GetSecretValue(ctx, mock.Anything).
+		Return(&secretsmanager.GetSecretValueOutput{
+			SecretString: aws.String(`{"opg.poas.sirius":{"alg":"HS256","keys":[]},"opg.poas.makeregister":{"alg":"HS256","keys":[]},"opg.poas.use":{"alg":"HS256","keys":[]}}`),
+		}, nil).
+		Once()
+
+	set, err := fetchKeySetWithSecret(ctx, client, "keys-arn", "secret-arn")
+	assert.Nil(t, err)
+	assert.Len(t, set, 3)
+}
+
+func TestFetchKeySetWithSecretWhenSecretErrors(t *testing.T) {
+	client := newMockSecretsClient(t)
+	client.EXPECT().
+		GetSecretValue(ctx, mock.MatchedBy(func(input *secretsmanager.GetSecretValueInput) bool {
+			return *input.SecretId == "keys-arn"
+		})).
+		Return(&secretsmanager.GetSecretValueOutput{SecretString: aws.String(`{}`)}, nil)
+	client.EXPECT().
+		GetSecretValue(ctx, mock.Anything).
+		Return(nil, expectedError)
+
+	_, err := fetchKeySetWithSecret(ctx, client, "keys-arn", "secret-arn")
+	assert.Equal(t, expectedError, err)
+}
+
+func TestVerifyTokenWithKeysAndSharedSecret(t *testing.T) {
+	client := newMockSecretsClient(t)
+	client.EXPECT().
+		GetSecretValue(ctx, mock.MatchedBy(func(input *secretsmanager.GetSecretValueInput) bool {
+			return *input.SecretId == "keys-arn"
+		})).
+		Return(&secretsmanager.GetSecretValueOutput{
+			SecretString: aws.String(`{"opg.poas.sirius":{"alg":"RS256","keys":[` + jwkJSON(rsaJWK("rsa-1", rsaKey)) + `]}}`),
+		}, nil)
+	client.EXPECT().
+		GetSecretValue(ctx, mock.MatchedBy(func(input *secretsmanager.GetSecretValueInput) bool {
+			return *input.SecretId == "secret-arn"
+		})).
+		Return(&secretsmanager.GetSecretValueOutput{SecretString: aws.String(string(secretKey))}, nil)
+
+	keys := newKeyStore(func(ctx context.Context) (KeySet, error) {
+		return fetchKeySetWithSecret(ctx, client, "keys-arn", "secret-arn")
+	}, nil)
+	keys.load(ctx)
+
+	v := JWTVerifier{keys: keys}
+
+	_, err := v.verifyToken(ctx, signToken(jwt.SigningMethodRS256, "rsa-1", rsaKey, validClaims(sirius)))
+	assert.Nil(t, err, "issuer with keys")
+
+	_, err = v.verifyToken(ctx, signToken(jwt.SigningMethodHS256, "", secretKey, validClaims(mrlpa)))
+	assert.Nil(t, err, "issuer without keys")
+
+	_, err = v.verifyToken(ctx, signToken(jwt.SigningMethodHS256, "", secretKey, validClaims(sirius)))
+	assert.NotNil(t, err, "shared secret for issuer with keys")
+}
+
+func jwkJSON(jwk JSONWebKey) string {
+	data, _ := json.Marshal(jwk)
+	return string(data)
+}
@@ -2,6 +2,8 @@ package shared
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -32,18 +34,9 @@ type LpaStoreClaims struct {
 	jwt.RegisteredClaims
 }
 
-// note that default validation for RegisteredClaims checks exp is in the future
+// note that default validation for RegisteredClaims checks exp is in the
+// future and nbf is in the past, and JWTVerifier checks iat
 func (l LpaStoreClaims) Validate() error {
-	// validate issued at (iat)
-	iat, err := l.GetIssuedAt()
-	if err != nil {
-		return err
-	}
-
-	if iat.After(time.Now()) {
-		return errors.New("IssuedAt must not be in the future")
-	}
-
 	// validate issuer (iss)
 	iss, err := l.GetIssuer()
 	if err != nil {
@@ -77,38 +70,170 @@ func (l LpaStoreClaims) Validate() error {
 	return nil
 }
 
+const (
+	// defaultClockSkew is how far the clocks of issuers may differ from ours
+	// when checking exp, nbf and iat, unless JWT_CLOCK_SKEW is set.
+	defaultClockSkew = 30 * time.Second
+
+	// defaultMaxLifetime is the longest a token can be valid for, unless
+	// JWT_MAX_LIFETIME is set.
+	defaultMaxLifetime = time.Hour
+)
+
+// TokenStore records the IDs of tokens that have been used.
+type TokenStore interface {
+	PutTokenId(ctx context.Context, id string, expiresAt time.Time) (bool, error)
+}
+
 type JWTVerifier struct {
-	secretKey []byte
-	Logger    logger
+	keys *keyStore
+
+	// audience must be in the aud of each token, when it is set.
+	audience string
+
+	// maxLifetime is the longest a token can be valid for, from iat to exp.
+	maxLifetime time.Duration
+
+	clockSkew time.Duration
+
+	// Tokens, when set, is used to reject a token with a jti that has been
+	// seen before. Tokens must then have a jti.
+	Tokens TokenStore
+
+	Logger logger
 }
 
 type logger interface {
 	Error(string, ...any)
-	Info(string, ...any)
+	InfoContext(context.Context, string, ...any)
 }
 
+// NewJWTVerifier creates a verifier using the key set stored in the secret
+// JWT_KEYS_ARN. While issuers move to asymmetric keys, the shared secret
+// JWT_SECRET_KEY_ARN is used with HS256 for every issuer that is not in the key
+// set, or for every issuer if JWT_KEYS_ARN is not set.
+//
+// Tokens are checked against JWT_AUDIENCE when it is set, and against
+// JWT_MAX_LIFETIME and JWT_CLOCK_SKEW or their defaults.
 func NewJWTVerifier(cfg aws.Config, logger logger) JWTVerifier {
 	client := secretsmanager.NewFromConfig(cfg)
 
-	secretKey, err := client.GetSecretValue(context.Background(), &secretsmanager.GetSecretValueInput{
-		SecretId: aws.String(os.Getenv("JWT_SECRET_KEY_ARN")),
+	fetch := func(ctx context.Context) (KeySet, error) {
+		return fetchKeySetWithSecret(ctx, client, os.Getenv("JWT_KEYS_ARN"), os.Getenv("JWT_SECRET_KEY_ARN"))
+	}
+	if os.Getenv("JWT_KEYS_ARN") == "" {
+		fetch = func(ctx context.Context) (KeySet, error) {
+			return fetchSecretKeySet(ctx, client, os.Getenv("JWT_SECRET_KEY_ARN"))
+		}
+	}
+
+	keys := newKeyStore(fetch, logger)
+	keys.load(context.Background())
+
+	return JWTVerifier{
+		keys:        keys,
+		audience:    os.Getenv("JWT_AUDIENCE"),
+		maxLifetime: durationFromEnv("JWT_MAX_LIFETIME", defaultMaxLifetime, logger),
+		clockSkew:   durationFromEnv("JWT_CLOCK_SKEW", defaultClockSkew, logger),
+		Logger:      logger,
+	}
+}
+
+func durationFromEnv(name string, fallback time.Duration, logger logger) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		logger.Error(fmt.Sprintf("invalid %s, using default", name), slog.String("value", value))
+		return fallback
+	}
+
+	return d
+}
+
+type secretsClient interface {
+	GetSecretValue(context.Context, *secretsmanager.GetSecretValueInput, ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)
+}
+
+func fetchKeySet(ctx context.Context, client secretsClient, secretId string) (KeySet, error) {
+	secret, err := client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(secretId),
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the secret is created empty, before any issuer has keys
+	set := KeySet{}
+	if value := aws.ToString(secret.SecretString); value != "" {
+		if err := json.Unmarshal([]byte(value), &set); err != nil {
+			return nil, err
+		}
+	}
+
+	return set, nil
+}
 
+// fetchKeySetWithSecret fetches the key set, and trusts the shared secret with
+// HS256 for any issuer that is not in it. The secret is only fetched when an
+// issuer is missing.
+func fetchKeySetWithSecret(ctx context.Context, client secretsClient, keysId, secretId string) (KeySet, error) {
+	set, err := fetchKeySet(ctx, client, keysId)
 	if err != nil {
-		logger.Error("Failed to fetch JWT signing secret", slog.Any("err", err))
+		return nil, err
 	}
 
-	return JWTVerifier{
-		secretKey: []byte(*secretKey.SecretString),
-		Logger:    logger,
+	if secretId == "" || !set.missingIssuer() {
+		return set, nil
+	}
+
+	secretSet, err := fetchSecretKeySet(ctx, client, secretId)
+	if err != nil {
+		return nil, err
+	}
+
+	for iss, keys := range secretSet {
+		if _, ok := set[iss]; !ok {
+			set[iss] = keys
+		}
 	}
+
+	return set, nil
+}
+
+func fetchSecretKeySet(ctx context.Context, client secretsClient, secretId string) (KeySet, error) {
+	secret, err := client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(secretId),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return secretKeySet([]byte(aws.ToString(secret.SecretString))), nil
+}
+
+// secretKeySet trusts tokens signed with HS256 using the secret, without a
+// kid, for every valid issuer.
+func secretKeySet(secret []byte) KeySet {
+	set := KeySet{}
+	for _, iss := range validIssuers {
+		set[iss] = IssuerKeys{
+			Alg:  jwt.SigningMethodHS256.Alg(),
+			Keys: []JSONWebKey{{Kty: "oct", K: base64.RawURLEncoding.EncodeToString(secret)}},
+		}
+	}
+
+	return set
 }
 
 var bearerRegexp = regexp.MustCompile("^Bearer[ ]+")
 
 // verify JWT from event header
 // returns true if verified, false otherwise
-func (v JWTVerifier) VerifyHeader(event events.APIGatewayProxyRequest) (*LpaStoreClaims, error) {
+func (v JWTVerifier) VerifyHeader(ctx context.Context, event events.APIGatewayProxyRequest) (*LpaStoreClaims, error) {
 	jwtHeaders := GetEventHeader("X-Jwt-Authorization", event)
 
 	if len(jwtHeaders) < 1 {
@@ -116,23 +241,30 @@ func (v JWTVerifier) VerifyHeader(event events.APIGatewayProxyRequest) (*LpaStor
 	}
 
 	tokenStr := bearerRegexp.ReplaceAllString(jwtHeaders[0], "")
-	claims, err := v.verifyToken(tokenStr)
+	claims, err := v.verifyToken(ctx, tokenStr)
 	if err != nil {
 		return nil, err
 	}
 
-	v.Logger.Info(fmt.Sprintf("JWT valid for %s", claims.Subject), slog.Any("subject", claims.Subject))
+	v.Logger.InfoContext(ctx, fmt.Sprintf("JWT valid for %s", claims.Subject), slog.Any("subject", claims.Subject))
 
 	return claims, nil
 }
 
 // tokenStr is the JWT token, minus any "Bearer: " prefix
-func (v JWTVerifier) verifyToken(tokenStr string) (*LpaStoreClaims, error) {
+func (v JWTVerifier) verifyToken(ctx context.Context, tokenStr string) (*LpaStoreClaims, error) {
 	lsc := LpaStoreClaims{}
 
 	parsedToken, err := jwt.ParseWithClaims(tokenStr, &lsc, func(token *jwt.Token) (interface{}, error) {
-		return v.secretKey, nil
-	})
+		iss, err := token.Claims.GetIssuer()
+		if err != nil {
+			return nil, err
+		}
+
+		kid, _ := token.Header["kid"].(string)
+
+		return v.keys.key(ctx, iss, token.Method.Alg(), kid)
+	}, v.parserOptions()...)
 
 	if err != nil {
 		return nil, err
@@ -142,5 +274,66 @@ func (v JWTVerifier) verifyToken(tokenStr string) (*LpaStoreClaims, error) {
 		return nil, fmt.Errorf("invalid JWT")
 	}
 
+	if err := v.validateLifetime(lsc); err != nil {
+		return nil, err
+	}
+
+	if err := v.validateNotReplayed(ctx, lsc); err != nil {
+		return nil, err
+	}
+
 	return &lsc, nil
 }
+
+func (v JWTVerifier) parserOptions() []jwt.ParserOption {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256", "ES256", "HS256"}),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(v.clockSkew),
+	}
+
+	if v.audience != "" {
+		options = append(options, jwt.WithAudience(v.audience))
+	}
+
+	return options
+}
+
+func (v JWTVerifier) validateLifetime(claims LpaStoreClaims) error {
+	if claims.IssuedAt == nil {
+		return errors.New("IssuedAt is required")
+	}
+
+	if claims.IssuedAt.After(time.Now().Add(v.clockSkew)) {
+		return errors.New("IssuedAt must not be in the future")
+	}
+
+	if v.maxLifetime > 0 && claims.ExpiresAt.Sub(claims.IssuedAt.Time) > v.maxLifetime {
+		return fmt.Errorf("token must not be valid for longer than %s", v.maxLifetime)
+	}
+
+	return nil
+}
+
+// validateNotReplayed records the jti, so that the token is rejected if it is
+// used again before it expires.
+func (v JWTVerifier) validateNotReplayed(ctx context.Context, claims LpaStoreClaims) error {
+	if v.Tokens == nil {
+		return nil
+	}
+
+	if claims.ID == "" {
+		return errors.New("jti is required")
+	}
+
+	unused, err := v.Tokens.PutTokenId(ctx, claims.Issuer+"#"+claims.ID, claims.ExpiresAt.Add(v.clockSkew))
+	if err != nil {
+		return fmt.Errorf("could not check jti: %w", err)
+	}
+
+	if !unused {
+		return errors.New("token has already been used")
+	}
+
+	return nil
+}
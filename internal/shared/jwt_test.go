@@ -1,6 +1,7 @@
 package shared
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"testing"
@@ -9,13 +10,16 @@ import (
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 var secretKey = []byte("mysupersecrettestkeythatis128bits")
 
 var verifier = JWTVerifier{
-	secretKey: secretKey,
-	Logger:    nil,
+	keys: newKeyStore(func(context.Context) (KeySet, error) {
+		return secretKeySet(secretKey), nil
+	}, nil),
+	Logger: nil,
 }
 
 func createToken(claims jwt.MapClaims) string {
@@ -27,7 +31,7 @@ func createToken(claims jwt.MapClaims) string {
 }
 
 func TestVerifyEmptyJwt(t *testing.T) {
-	_, err := verifier.verifyToken("")
+	_, err := verifier.verifyToken(ctx, "")
 	assert.NotNil(t, err)
 }
 
@@ -39,7 +43,7 @@ func TestVerifyExpInPast(t *testing.T) {
 		"sub": "urn:opg:poas:makeregister:users:e6707412-c9cd-4547-b428-7039a87e985e",
 	})
 
-	_, err := verifier.verifyToken(token)
+	_, err := verifier.verifyToken(ctx, token)
 
 	assert.NotNil(t, err)
 	if err != nil {
@@ -55,7 +59,7 @@ func TestVerifyIatInFuture(t *testing.T) {
 		"sub": "urn:opg:sirius:users:34",
 	})
 
-	_, err := verifier.verifyToken(token)
+	_, err := verifier.verifyToken(ctx, token)
 
 	assert.NotNil(t, err)
 	if err != nil {
@@ -71,7 +75,7 @@ func TestVerifyIssuer(t *testing.T) {
 		"sub": "urn:opg:poas:makeregister:users:e6707412-c9cd-4547-b428-7039a87e985e",
 	})
 
-	_, err := verifier.verifyToken(token)
+	_, err := verifier.verifyToken(ctx, token)
 
 	assert.NotNil(t, err)
 	if err != nil {
@@ -110,7 +114,7 @@ func TestVerifySub(t *testing.T) {
 				"sub": tc.sub,
 			})
 
-			_, err := verifier.verifyToken(token)
+			_, err := verifier.verifyToken(ctx, token)
 
 			if tc.shouldFail {
 				assert.NotNil(t, err)
@@ -129,7 +133,7 @@ func TestVerifyHeaderNoJWTHeader(t *testing.T) {
 		MultiValueHeaders: map[string][]string{},
 	}
 
-	_, err := verifier.VerifyHeader(event)
+	_, err := verifier.VerifyHeader(ctx, event)
 	assert.NotNil(t, err)
 }
 
@@ -151,10 +155,204 @@ func TestVerifyHeader(t *testing.T) {
 
 	logger := newMockLogger(t)
 	logger.EXPECT().
-		Info("JWT valid for urn:opg:sirius:users:34", slog.Any("subject", "urn:opg:sirius:users:34"))
+		InfoContext(ctx, "JWT valid for urn:opg:sirius:users:34", slog.Any("subject", "urn:opg:sirius:users:34"))
 
 	verifier.Logger = logger
 
-	_, err := verifier.VerifyHeader(event)
+	_, err := verifier.VerifyHeader(ctx, event)
 	assert.Nil(t, err)
 }
+
+func TestVerifyAudience(t *testing.T) {
+	v := verifier
+	v.audience = "opg.poas.lpastore"
+
+	testcases := map[string]struct {
+		aud   any
+		error string
+	}{
+		"matching":       {aud: "opg.poas.lpastore"},
+		"matching array": {aud: []string{"opg.poas.other", "opg.poas.lpastore"}},
+		"other service":  {aud: "opg.poas.other", error: "token has invalid audience"},
+		"missing":        {error: "aud claim is required"},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			claims := jwt.MapClaims{
+				"exp": time.Now().Add(time.Hour).Unix(),
+				"iat": time.Now().Add(-time.Minute).Unix(),
+				"iss": "opg.poas.sirius",
+				"sub": "urn:opg:sirius:users:34",
+			}
+			if tc.aud != nil {
+				claims["aud"] = tc.aud
+			}
+
+			_, err := v.verifyToken(ctx, createToken(claims))
+			if tc.error == "" {
+				assert.Nil(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.error)
+			}
+		})
+	}
+}
+
+func TestVerifyTimesWithClockSkew(t *testing.T) {
+	v := verifier
+	v.clockSkew = time.Minute
+
+	testcases := map[string]struct {
+		claims jwt.MapClaims
+		error  string
+	}{
+		"iat within skew": {
+			claims: jwt.MapClaims{"iat": time.Now().Add(30 * time.Second).Unix(), "exp": time.Now().Add(time.Hour).Unix()},
+		},
+		"iat beyond skew": {
+			claims: jwt.MapClaims{"iat": time.Now().Add(2 * time.Minute).Unix(), "exp": time.Now().Add(time.Hour).Unix()},
+			error:  "IssuedAt must not be in the future",
+		},
+		"exp within skew": {
+			claims: jwt.MapClaims{"iat": time.Now().Add(-time.Hour).Unix(), "exp": time.Now().Add(-30 * time.Second).Unix()},
+		},
+		"exp beyond skew": {
+			claims: jwt.MapClaims{"iat": time.Now().Add(-time.Hour).Unix(), "exp": time.Now().Add(-2 * time.Minute).Unix()},
+			error:  "token is expired",
+		},
+		"nbf within skew": {
+			claims: jwt.MapClaims{"iat": time.Now().Unix(), "nbf": time.Now().Add(30 * time.Second).Unix(), "exp": time.Now().Add(time.Hour).Unix()},
+		},
+		"nbf beyond skew": {
+			claims: jwt.MapClaims{"iat": time.Now().Unix(), "nbf": time.Now().Add(2 * time.Minute).Unix(), "exp": time.Now().Add(time.Hour).Unix()},
+			error:  "token is not valid yet",
+		},
+		"missing exp": {
+			claims: jwt.MapClaims{"iat": time.Now().Unix()},
+			error:  "token is missing required claim: exp claim is required",
+		},
+		"missing iat": {
+			claims: jwt.MapClaims{"exp": time.Now().Add(time.Hour).Unix()},
+			error:  "IssuedAt is required",
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			tc.claims["iss"] = "opg.poas.sirius"
+			tc.claims["sub"] = "urn:opg:sirius:users:34"
+
+			_, err := v.verifyToken(ctx, createToken(tc.claims))
+			if tc.error == "" {
+				assert.Nil(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.error)
+			}
+		})
+	}
+}
+
+func TestVerifyMaxLifetime(t *testing.T) {
+	v := verifier
+	v.maxLifetime = time.Hour
+
+	iat := time.Now().Add(-time.Minute)
+
+	_, err := v.verifyToken(ctx, createToken(jwt.MapClaims{
+		"exp": iat.Add(time.Hour).Unix(),
+		"iat": iat.Unix(),
+		"iss": "opg.poas.sirius",
+		"sub": "urn:opg:sirius:users:34",
+	}))
+	assert.Nil(t, err)
+
+	_, err = v.verifyToken(ctx, createToken(jwt.MapClaims{
+		"exp": iat.Add(time.Hour + time.Second).Unix(),
+		"iat": iat.Unix(),
+		"iss": "opg.poas.sirius",
+		"sub": "urn:opg:sirius:users:34",
+	}))
+	assert.EqualError(t, err, "token must not be valid for longer than 1h0m0s")
+}
+
+func TestVerifyJti(t *testing.T) {
+	exp := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	tokens := newMockTokenStore(t)
+	tokens.EXPECT().
+		PutTokenId(ctx, "opg.poas.sirius#a-jti", exp.Add(time.Minute)).
+		Return(true, nil).
+		Once()
+	tokens.EXPECT().
+		PutTokenId(ctx, "opg.poas.sirius#a-jti", exp.Add(time.Minute)).
+		Return(false, nil).
+		Once()
+
+	v := verifier
+	v.clockSkew = time.Minute
+	v.Tokens = tokens
+
+	token := createToken(jwt.MapClaims{
+		"exp": exp.Unix(),
+		"iat": time.Now().Add(-time.Minute).Unix(),
+		"iss": "opg.poas.sirius",
+		"sub": "urn:opg:sirius:users:34",
+		"jti": "a-jti",
+	})
+
+	_, err := v.verifyToken(ctx, token)
+	assert.Nil(t, err)
+
+	_, err = v.verifyToken(ctx, token)
+	assert.EqualError(t, err, "token has already been used")
+}
+
+func TestVerifyJtiWhenMissing(t *testing.T) {
+	v := verifier
+	v.Tokens = newMockTokenStore(t)
+
+	_, err := v.verifyToken(ctx, createToken(jwt.MapClaims{
+		"exp": time.Now().Add(time.Hour).Unix(),
+		"iat": time.Now().Add(-time.Minute).Unix(),
+		"iss": "opg.poas.sirius",
+		"sub": "urn:opg:sirius:users:34",
+	}))
+	assert.EqualError(t, err, "jti is required")
+}
+
+func TestVerifyJtiWhenTokenStoreErrors(t *testing.T) {
+	tokens := newMockTokenStore(t)
+	tokens.EXPECT().
+		PutTokenId(mock.Anything, mock.Anything, mock.Anything).
+		Return(false, expectedError)
+
+	v := verifier
+	v.Tokens = tokens
+
+	_, err := v.verifyToken(ctx, createToken(jwt.MapClaims{
+		"exp": time.Now().Add(time.Hour).Unix(),
+		"iat": time.Now().Add(-time.Minute).Unix(),
+		"iss": "opg.poas.sirius",
+		"sub": "urn:opg:sirius:users:34",
+		"jti": "a-jti",
+	}))
+	assert.ErrorIs(t, err, expectedError)
+}
+
+func TestDurationFromEnv(t *testing.T) {
+	t.Setenv("A_DURATION", "5m")
+	assert.Equal(t, 5*time.Minute, durationFromEnv("A_DURATION", time.Second, nil))
+
+	t.Setenv("A_DURATION", "")
+	assert.Equal(t, time.Second, durationFromEnv("A_DURATION", time.Second, nil))
+}
+
+func TestDurationFromEnvWhenInvalid(t *testing.T) {
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		Error("invalid A_DURATION, using default", slog.String("value", "soon"))
+
+	t.Setenv("A_DURATION", "soon")
+	assert.Equal(t, time.Second, durationFromEnv("A_DURATION", time.Second, logger))
+}
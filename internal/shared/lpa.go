@@ -2,6 +2,7 @@ package shared
 
 import (
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -43,6 +44,7 @@ type Lpa struct {
 	Status                                 LpaStatus  `json:"status"`
 	RegistrationDate                       *time.Time `json:"registrationDate,omitempty"`
 	UpdatedAt                              time.Time  `json:"updatedAt"`
+	Version                                int        `json:"version"`
 	RestrictionsAndConditionsImages        []File     `json:"restrictionsAndConditionsImages,omitempty"`
 	HowAttorneysMakeDecisionsDetailsImages []File     `json:"howAttorneysMakeDecisionsDetailsImages,omitempty"`
 	Notes                                  []Note     `json:"notes,omitempty"`
@@ -76,10 +78,11 @@ const (
 	LpaStatusCancelled              = LpaStatus("cancelled")
 	LpaStatusDoNotRegister          = LpaStatus("do-not-register")
 	LpaStatusExpired                = LpaStatus("expired")
+	LpaStatusDonorDeceased          = LpaStatus("donor-deceased")
 )
 
 func (l LpaStatus) IsValid() bool {
-	return l == LpaStatusInProgress || l == LpaStatusStatutoryWaitingPeriod || l == LpaStatusRegistered || l == LpaStatusCannotRegister || l == LpaStatusWithdrawn || l == LpaStatusCancelled || l == LpaStatusDoNotRegister || l == LpaStatusExpired
+	return l == LpaStatusInProgress || l == LpaStatusStatutoryWaitingPeriod || l == LpaStatusRegistered || l == LpaStatusCannotRegister || l == LpaStatusWithdrawn || l == LpaStatusCancelled || l == LpaStatusDoNotRegister || l == LpaStatusExpired || l == LpaStatusDonorDeceased
 }
 
 func (lpa *Lpa) FindAttorneyIndex(changeKey string) (int, bool) {
@@ -113,3 +116,53 @@ func (lpa *Lpa) FindTrustCorporationIndex(changeKey string) (int, bool) {
 func (lpa *Lpa) AddNote(note Note) {
 	lpa.Notes = append(lpa.Notes, note)
 }
+
+// ETag identifies the current version of the LPA, for use in ETag and
+// If-Match headers.
+func (lpa *Lpa) ETag() string {
+	return `"` + strconv.Itoa(lpa.Version) + `"`
+}
+
+// MatchesETag reports whether the values of an If-Match header match the
+// current version of the LPA. Each value may be a comma separated list of
+// ETags, or "*" to match any version.
+func (lpa *Lpa) MatchesETag(ifMatch []string) bool {
+	etag := lpa.ETag()
+
+	for _, value := range ifMatch {
+		for _, v := range strings.Split(value, ",") {
+			v = strings.TrimSpace(v)
+			if v == "*" || v == etag {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// ActorUIDs lists the UIDs of the donor, certificate provider, attorneys,
+// trust corporations and people to notify on the LPA, without duplicates.
+func (lpa *Lpa) ActorUIDs() []string {
+	uids := []string{lpa.Donor.UID, lpa.CertificateProvider.UID}
+	for _, attorney := range lpa.Attorneys {
+		uids = append(uids, attorney.UID)
+	}
+	for _, trustCorporation := range lpa.TrustCorporations {
+		uids = append(uids, trustCorporation.UID)
+	}
+	for _, personToNotify := range lpa.PeopleToNotify {
+		uids = append(uids, personToNotify.UID)
+	}
+
+	seen := map[string]bool{"": true}
+	result := make([]string, 0, len(uids))
+	for _, uid := range uids {
+		if !seen[uid] {
+			seen[uid] = true
+			result = append(result, uid)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,73 @@
+package shared
+
+import "slices"
+
+// lpaStatusTransitions lists the statuses an LPA can move to from each status.
+// A status without an entry allows no further status changes.
+var lpaStatusTransitions = map[LpaStatus][]LpaStatus{
+	LpaStatusInProgress: {
+		LpaStatusStatutoryWaitingPeriod,
+		LpaStatusCannotRegister,
+		LpaStatusWithdrawn,
+		LpaStatusExpired,
+		LpaStatusDonorDeceased,
+	},
+	LpaStatusStatutoryWaitingPeriod: {
+		LpaStatusRegistered,
+		LpaStatusCannotRegister,
+		LpaStatusWithdrawn,
+		LpaStatusDoNotRegister,
+		LpaStatusExpired,
+		LpaStatusDonorDeceased,
+	},
+	LpaStatusDoNotRegister: {
+		LpaStatusCannotRegister,
+		LpaStatusWithdrawn,
+		LpaStatusExpired,
+		LpaStatusDonorDeceased,
+	},
+	LpaStatusRegistered: {
+		LpaStatusCancelled,
+		LpaStatusDonorDeceased,
+	},
+	// an LPA that has ended can still be recorded as unable to be registered,
+	// or as withdrawn by the donor, to correct why it ended
+	LpaStatusWithdrawn: {
+		LpaStatusCannotRegister,
+	},
+	LpaStatusExpired: {
+		LpaStatusCannotRegister,
+		LpaStatusWithdrawn,
+	},
+	LpaStatusCancelled: {
+		LpaStatusWithdrawn,
+	},
+}
+
+// finalLpaStatuses are the statuses of LPAs that have ended, so cannot be
+// registered or used.
+var finalLpaStatuses = []LpaStatus{
+	LpaStatusCannotRegister,
+	LpaStatusWithdrawn,
+	LpaStatusCancelled,
+	LpaStatusExpired,
+	LpaStatusDonorDeceased,
+}
+
+// CanTransitionTo reports whether an LPA with this status can move to next.
+func (l LpaStatus) CanTransitionTo(next LpaStatus) bool {
+	return slices.Contains(lpaStatusTransitions[l], next)
+}
+
+// NextStatuses lists the statuses an LPA with this status can move to. It
+// does not consider anything else about the LPA, so an update causing the
+// change may still be rejected.
+func (l LpaStatus) NextStatuses() []LpaStatus {
+	return slices.Clone(lpaStatusTransitions[l])
+}
+
+// IsFinal reports whether an LPA with this status has ended. Its status may
+// still be changed to another final status.
+func (l LpaStatus) IsFinal() bool {
+	return slices.Contains(finalLpaStatuses, l)
+}
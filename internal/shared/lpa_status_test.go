@@ -0,0 +1,55 @@
+package shared
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLpaStatusCanTransitionTo(t *testing.T) {
+	testcases := map[string]struct {
+		from, to LpaStatus
+		allowed  bool
+	}{
+		"in-progress to statutory-waiting-period": {from: LpaStatusInProgress, to: LpaStatusStatutoryWaitingPeriod, allowed: true},
+		"statutory-waiting-period to registered":  {from: LpaStatusStatutoryWaitingPeriod, to: LpaStatusRegistered, allowed: true},
+		"registered to cancelled":                 {from: LpaStatusRegistered, to: LpaStatusCancelled, allowed: true},
+		"registered to donor-deceased":            {from: LpaStatusRegistered, to: LpaStatusDonorDeceased, allowed: true},
+		"in-progress to registered":               {from: LpaStatusInProgress, to: LpaStatusRegistered},
+		"registered to withdrawn":                 {from: LpaStatusRegistered, to: LpaStatusWithdrawn},
+		"withdrawn to withdrawn":                  {from: LpaStatusWithdrawn, to: LpaStatusWithdrawn},
+		"withdrawn to cannot-register":            {from: LpaStatusWithdrawn, to: LpaStatusCannotRegister, allowed: true},
+		"expired to cannot-register":              {from: LpaStatusExpired, to: LpaStatusCannotRegister, allowed: true},
+		"expired to withdrawn":                    {from: LpaStatusExpired, to: LpaStatusWithdrawn, allowed: true},
+		"cancelled to withdrawn":                  {from: LpaStatusCancelled, to: LpaStatusWithdrawn, allowed: true},
+		"cancelled to cannot-register":            {from: LpaStatusCancelled, to: LpaStatusCannotRegister},
+		"cannot-register to in-progress":          {from: LpaStatusCannotRegister, to: LpaStatusInProgress},
+		"unset to in-progress":                    {from: LpaStatus(""), to: LpaStatusInProgress},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.allowed, tc.from.CanTransitionTo(tc.to))
+		})
+	}
+}
+
+func TestLpaStatusNextStatuses(t *testing.T) {
+	assert.Equal(t, []LpaStatus{LpaStatusCancelled, LpaStatusDonorDeceased}, LpaStatusRegistered.NextStatuses())
+	assert.Equal(t, []LpaStatus{LpaStatusCannotRegister}, LpaStatusWithdrawn.NextStatuses())
+	assert.Empty(t, LpaStatusDonorDeceased.NextStatuses())
+
+	next := LpaStatusRegistered.NextStatuses()
+	next[0] = LpaStatusWithdrawn
+	assert.Equal(t, LpaStatusCancelled, LpaStatusRegistered.NextStatuses()[0])
+}
+
+func TestLpaStatusIsFinal(t *testing.T) {
+	for _, status := range []LpaStatus{LpaStatusInProgress, LpaStatusStatutoryWaitingPeriod, LpaStatusDoNotRegister, LpaStatusRegistered} {
+		assert.False(t, status.IsFinal(), status)
+	}
+
+	for _, status := range []LpaStatus{LpaStatusCannotRegister, LpaStatusWithdrawn, LpaStatusCancelled, LpaStatusExpired, LpaStatusDonorDeceased} {
+		assert.True(t, status.IsFinal(), status)
+	}
+}
@@ -77,3 +77,48 @@ func TestLpaFindTrustCorporationIndex(t *testing.T) {
 		})
 	}
 }
+
+func TestLpaETag(t *testing.T) {
+	lpa := Lpa{Version: 4}
+	assert.Equal(t, `"4"`, lpa.ETag())
+}
+
+func TestLpaMatchesETag(t *testing.T) {
+	lpa := Lpa{Version: 4}
+
+	testcases := map[string]struct {
+		ifMatch []string
+		matches bool
+	}{
+		"match":           {ifMatch: []string{`"4"`}, matches: true},
+		"any":             {ifMatch: []string{"*"}, matches: true},
+		"in list":         {ifMatch: []string{`"3", "4"`}, matches: true},
+		"in later header": {ifMatch: []string{`"3"`, `"4"`}, matches: true},
+		"old version":     {ifMatch: []string{`"3"`}, matches: false},
+		"weak":            {ifMatch: []string{`W/"4"`}, matches: false},
+		"unquoted":        {ifMatch: []string{"4"}, matches: false},
+		"empty":           {ifMatch: []string{}, matches: false},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.matches, lpa.MatchesETag(tc.ifMatch))
+		})
+	}
+}
+
+func TestLpaActorUIDs(t *testing.T) {
+	lpa := &Lpa{LpaInit: LpaInit{
+		Donor:               Donor{Person: Person{UID: "donor"}},
+		CertificateProvider: CertificateProvider{Person: Person{UID: "certificate-provider"}},
+		Attorneys: []Attorney{
+			{Person: Person{UID: "attorney-1"}},
+			{Person: Person{UID: ""}},
+			{Person: Person{UID: "attorney-2"}},
+		},
+		TrustCorporations: []TrustCorporation{{UID: "trust-corporation"}},
+		PeopleToNotify:    []PersonToNotify{{Person: Person{UID: "person-to-notify"}}, {Person: Person{UID: "attorney-1"}}},
+	}}
+
+	assert.Equal(t, []string{"donor", "certificate-provider", "attorney-1", "attorney-2", "trust-corporation", "person-to-notify"}, lpa.ActorUIDs())
+}
@@ -5,9 +5,112 @@
 package shared
 
 import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
 	mock "github.com/stretchr/testify/mock"
 )
 
+// newMockTokenStore creates a new instance of mockTokenStore. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newMockTokenStore(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *mockTokenStore {
+	mock := &mockTokenStore{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// mockTokenStore is an autogenerated mock type for the TokenStore type
+type mockTokenStore struct {
+	mock.Mock
+}
+
+type mockTokenStore_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *mockTokenStore) EXPECT() *mockTokenStore_Expecter {
+	return &mockTokenStore_Expecter{mock: &_m.Mock}
+}
+
+// PutTokenId provides a mock function for the type mockTokenStore
+func (_mock *mockTokenStore) PutTokenId(ctx context.Context, id string, expiresAt time.Time) (bool, error) {
+	ret := _mock.Called(ctx, id, expiresAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PutTokenId")
+	}
+
+	var r0 bool
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, time.Time) (bool, error)); ok {
+		return returnFunc(ctx, id, expiresAt)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, time.Time) bool); ok {
+		r0 = returnFunc(ctx, id, expiresAt)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, time.Time) error); ok {
+		r1 = returnFunc(ctx, id, expiresAt)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockTokenStore_PutTokenId_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PutTokenId'
+type mockTokenStore_PutTokenId_Call struct {
+	*mock.Call
+}
+
+// PutTokenId is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id string
+//   - expiresAt time.Time
+func (_e *mockTokenStore_Expecter) PutTokenId(ctx interface{}, id interface{}, expiresAt interface{}) *mockTokenStore_PutTokenId_Call {
+	return &mockTokenStore_PutTokenId_Call{Call: _e.mock.On("PutTokenId", ctx, id, expiresAt)}
+}
+
+func (_c *mockTokenStore_PutTokenId_Call) Run(run func(ctx context.Context, id string, expiresAt time.Time)) *mockTokenStore_PutTokenId_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 time.Time
+		if args[2] != nil {
+			arg2 = args[2].(time.Time)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *mockTokenStore_PutTokenId_Call) Return(b bool, err error) *mockTokenStore_PutTokenId_Call {
+	_c.Call.Return(b, err)
+	return _c
+}
+
+func (_c *mockTokenStore_PutTokenId_Call) RunAndReturn(run func(ctx context.Context, id string, expiresAt time.Time) (bool, error)) *mockTokenStore_PutTokenId_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // newMockLogger creates a new instance of mockLogger. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func newMockLogger(t interface {
@@ -89,56 +192,176 @@ func (_c *mockLogger_Error_Call) RunAndReturn(run func(s string, vs ...any)) *mo
 	return _c
 }
 
-// Info provides a mock function for the type mockLogger
-func (_mock *mockLogger) Info(s string, vs ...any) {
+// InfoContext provides a mock function for the type mockLogger
+func (_mock *mockLogger) InfoContext(context1 context.Context, s string, vs ...any) {
 	var _ca []interface{}
-	_ca = append(_ca, s)
+	_ca = append(_ca, context1, s)
 	_ca = append(_ca, vs...)
 	_mock.Called(_ca...)
 	return
 }
 
-// mockLogger_Info_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Info'
-type mockLogger_Info_Call struct {
+// mockLogger_InfoContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'InfoContext'
+type mockLogger_InfoContext_Call struct {
 	*mock.Call
 }
 
-// Info is a helper method to define mock.On call
+// InfoContext is a helper method to define mock.On call
+//   - context1 context.Context
 //   - s string
 //   - vs ...any
-func (_e *mockLogger_Expecter) Info(s interface{}, vs ...interface{}) *mockLogger_Info_Call {
-	return &mockLogger_Info_Call{Call: _e.mock.On("Info",
-		append([]interface{}{s}, vs...)...)}
+func (_e *mockLogger_Expecter) InfoContext(context1 interface{}, s interface{}, vs ...interface{}) *mockLogger_InfoContext_Call {
+	return &mockLogger_InfoContext_Call{Call: _e.mock.On("InfoContext",
+		append([]interface{}{context1, s}, vs...)...)}
 }
 
-func (_c *mockLogger_Info_Call) Run(run func(s string, vs ...any)) *mockLogger_Info_Call {
+func (_c *mockLogger_InfoContext_Call) Run(run func(context1 context.Context, s string, vs ...any)) *mockLogger_InfoContext_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		var arg0 string
+		var arg0 context.Context
 		if args[0] != nil {
-			arg0 = args[0].(string)
+			arg0 = args[0].(context.Context)
 		}
-		var arg1 []any
-		variadicArgs := make([]any, len(args)-1)
-		for i, a := range args[1:] {
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 []any
+		variadicArgs := make([]any, len(args)-2)
+		for i, a := range args[2:] {
 			if a != nil {
 				variadicArgs[i] = a.(any)
 			}
 		}
-		arg1 = variadicArgs
+		arg2 = variadicArgs
 		run(
 			arg0,
-			arg1...,
+			arg1,
+			arg2...,
 		)
 	})
 	return _c
 }
 
-func (_c *mockLogger_Info_Call) Return() *mockLogger_Info_Call {
+func (_c *mockLogger_InfoContext_Call) Return() *mockLogger_InfoContext_Call {
 	_c.Call.Return()
 	return _c
 }
 
-func (_c *mockLogger_Info_Call) RunAndReturn(run func(s string, vs ...any)) *mockLogger_Info_Call {
+func (_c *mockLogger_InfoContext_Call) RunAndReturn(run func(context1 context.Context, s string, vs ...any)) *mockLogger_InfoContext_Call {
 	_c.Run(run)
 	return _c
 }
+
+// newMockSecretsClient creates a new instance of mockSecretsClient. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newMockSecretsClient(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *mockSecretsClient {
+	mock := &mockSecretsClient{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// mockSecretsClient is an autogenerated mock type for the secretsClient type
+type mockSecretsClient struct {
+	mock.Mock
+}
+
+type mockSecretsClient_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *mockSecretsClient) EXPECT() *mockSecretsClient_Expecter {
+	return &mockSecretsClient_Expecter{mock: &_m.Mock}
+}
+
+// GetSecretValue provides a mock function for the type mockSecretsClient
+func (_mock *mockSecretsClient) GetSecretValue(context1 context.Context, getSecretValueInput *secretsmanager.GetSecretValueInput, fns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+	// func(*secretsmanager.Options)
+	_va := make([]interface{}, len(fns))
+	for _i := range fns {
+		_va[_i] = fns[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, context1, getSecretValueInput)
+	_ca = append(_ca, _va...)
+	ret := _mock.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSecretValue")
+	}
+
+	var r0 *secretsmanager.GetSecretValueOutput
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *secretsmanager.GetSecretValueInput, ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)); ok {
+		return returnFunc(context1, getSecretValueInput, fns...)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *secretsmanager.GetSecretValueInput, ...func(*secretsmanager.Options)) *secretsmanager.GetSecretValueOutput); ok {
+		r0 = returnFunc(context1, getSecretValueInput, fns...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*secretsmanager.GetSecretValueOutput)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, *secretsmanager.GetSecretValueInput, ...func(*secretsmanager.Options)) error); ok {
+		r1 = returnFunc(context1, getSecretValueInput, fns...)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockSecretsClient_GetSecretValue_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetSecretValue'
+type mockSecretsClient_GetSecretValue_Call struct {
+	*mock.Call
+}
+
+// GetSecretValue is a helper method to define mock.On call
+//   - context1 context.Context
+//   - getSecretValueInput *secretsmanager.GetSecretValueInput
+//   - fns ...func(*secretsmanager.Options)
+func (_e *mockSecretsClient_Expecter) GetSecretValue(context1 interface{}, getSecretValueInput interface{}, fns ...interface{}) *mockSecretsClient_GetSecretValue_Call {
+	return &mockSecretsClient_GetSecretValue_Call{Call: _e.mock.On("GetSecretValue",
+		append([]interface{}{context1, getSecretValueInput}, fns...)...)}
+}
+
+func (_c *mockSecretsClient_GetSecretValue_Call) Run(run func(context1 context.Context, getSecretValueInput *secretsmanager.GetSecretValueInput, fns ...func(*secretsmanager.Options))) *mockSecretsClient_GetSecretValue_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 *secretsmanager.GetSecretValueInput
+		if args[1] != nil {
+			arg1 = args[1].(*secretsmanager.GetSecretValueInput)
+		}
+		var arg2 []func(*secretsmanager.Options)
+		variadicArgs := make([]func(*secretsmanager.Options), len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(func(*secretsmanager.Options))
+			}
+		}
+		arg2 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2...,
+		)
+	})
+	return _c
+}
+
+func (_c *mockSecretsClient_GetSecretValue_Call) Return(getSecretValueOutput *secretsmanager.GetSecretValueOutput, err error) *mockSecretsClient_GetSecretValue_Call {
+	_c.Call.Return(getSecretValueOutput, err)
+	return _c
+}
+
+func (_c *mockSecretsClient_GetSecretValue_Call) RunAndReturn(run func(context1 context.Context, getSecretValueInput *secretsmanager.GetSecretValueInput, fns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)) *mockSecretsClient_GetSecretValue_Call {
+	_c.Call.Return(run)
+	return _c
+}
@@ -29,6 +29,7 @@ type Donor struct {
 	OtherNamesKnownBy         string         `json:"otherNamesKnownBy,omitempty"`
 	ContactLanguagePreference Lang           `json:"contactLanguagePreference"`
 	IdentityCheck             *IdentityCheck `json:"identityCheck,omitempty"`
+	DateOfDeath               *Date          `json:"dateOfDeath,omitempty"`
 }
 
 type CertificateProvider struct {
@@ -88,6 +89,7 @@ type Attorney struct {
 	ContactLanguagePreference Lang            `json:"contactLanguagePreference,omitempty"`
 	Channel                   Channel         `json:"channel"`
 	CannotMakeJointDecisions  bool            `json:"cannotMakeJointDecisions,omitempty"`
+	DateOfDeath               *Date           `json:"dateOfDeath,omitempty"`
 }
 
 type TrustCorporation struct {
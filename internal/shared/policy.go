@@ -0,0 +1,137 @@
+package shared
+
+import (
+	"slices"
+	"strings"
+)
+
+// Operation is an endpoint that a request can be authorised for.
+type Operation string
+
+const (
+	OperationCreate      = Operation("create")
+	OperationGet         = Operation("get")
+	OperationGetByActor  = Operation("getbyactor")
+	OperationGetList     = Operation("getlist")
+	OperationGetStatic   = Operation("getstatic")
+	OperationGetStatuses = Operation("getstatuses")
+	OperationGetUpdates  = Operation("getupdates")
+	OperationSearch      = Operation("search")
+	OperationUpdate      = Operation("update")
+)
+
+// AnyUpdateType can be given in Permissions.UpdateTypes to allow every type of
+// update.
+const AnyUpdateType = "*"
+
+// Principal identifies who a request is made by, from the issuer of its JWT
+// and the type of subject the JWT was issued for.
+type Principal struct {
+	Issuer      string
+	SubjectType string
+}
+
+// Permissions lists what a principal is allowed to do. UpdateTypes only
+// applies to OperationUpdate.
+type Permissions struct {
+	Operations  []Operation
+	UpdateTypes []string
+}
+
+// AuthorisationPolicy maps each principal to its permissions. Any principal
+// or operation not listed is forbidden.
+type AuthorisationPolicy map[Principal]Permissions
+
+// Policy is the authorisation policy enforced by every lambda.
+var Policy = AuthorisationPolicy{
+	{Issuer: sirius, SubjectType: "users"}: {
+		Operations: []Operation{
+			OperationCreate,
+			OperationGet,
+			OperationGetByActor,
+			OperationGetList,
+			OperationGetStatic,
+			OperationGetStatuses,
+			OperationGetUpdates,
+			OperationSearch,
+			OperationUpdate,
+		},
+		UpdateTypes: []string{AnyUpdateType},
+	},
+	{Issuer: mrlpa, SubjectType: "users"}: {
+		Operations: []Operation{
+			OperationCreate,
+			OperationGet,
+			OperationGetByActor,
+			OperationGetList,
+			OperationGetStatic,
+			OperationGetStatuses,
+			OperationGetUpdates,
+			OperationUpdate,
+		},
+		UpdateTypes: []string{
+			"ATTORNEY_OPT_OUT",
+			"ATTORNEY_SIGN",
+			"CERTIFICATE_PROVIDER_CONFIRM_IDENTITY",
+			"CERTIFICATE_PROVIDER_OPT_OUT",
+			"CERTIFICATE_PROVIDER_SIGN",
+			"DONOR_CONFIRM_IDENTITY",
+			"DONOR_WITHDRAW_LPA",
+			"PAPER_ATTORNEY_ACCESS_ONLINE",
+			"PAPER_CERTIFICATE_PROVIDER_ACCESS_ONLINE",
+			"PERFECT",
+			"STATUTORY_WAITING_PERIOD",
+			"TRUST_CORPORATION_OPT_OUT",
+			"TRUST_CORPORATION_SIGN",
+		},
+	},
+	{Issuer: use, SubjectType: "users"}: {
+		Operations: []Operation{
+			OperationGet,
+			OperationGetByActor,
+			OperationGetList,
+			OperationGetStatic,
+		},
+	},
+}
+
+// Allows reports whether the policy permits the request the claims were
+// given for to perform the operation, with each of the update types.
+func (p AuthorisationPolicy) Allows(claims *LpaStoreClaims, operation Operation, updateTypes ...string) bool {
+	if claims == nil {
+		return false
+	}
+
+	permissions, ok := p[Principal{Issuer: claims.Issuer, SubjectType: subjectType(claims.Subject)}]
+	if !ok || !slices.Contains(permissions.Operations, operation) {
+		return false
+	}
+
+	if slices.Contains(permissions.UpdateTypes, AnyUpdateType) {
+		return true
+	}
+
+	for _, updateType := range updateTypes {
+		if !slices.Contains(permissions.UpdateTypes, updateType) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Authorise reports whether Policy permits the operation for the claims.
+func Authorise(claims *LpaStoreClaims, operation Operation, updateTypes ...string) bool {
+	return Policy.Allows(claims, operation, updateTypes...)
+}
+
+// subjectType returns the type of subject from a URN like
+// urn:opg:poas:sirius:users:123, which is the part before the identifier.
+func subjectType(sub string) string {
+	parts := strings.Split(sub, ":")
+	if len(parts) < 2 {
+		return ""
+	}
+
+	return parts[len(parts)-2]
+}
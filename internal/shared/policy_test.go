@@ -0,0 +1,60 @@
+package shared
+
+import (
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func claimsFor(iss, sub string) *LpaStoreClaims {
+	return &LpaStoreClaims{RegisteredClaims: jwt.RegisteredClaims{Issuer: iss, Subject: sub}}
+}
+
+func TestAuthorise(t *testing.T) {
+	siriusUser := claimsFor("opg.poas.sirius", "urn:opg:poas:sirius:users:34")
+	makeRegisterUser := claimsFor("opg.poas.makeregister", "urn:opg:poas:makeregister:users:e6707412-c9cd-4547-b428-7039a87e985e")
+	useUser := claimsFor("opg.poas.use", "urn:opg:poas:use:users:ccba2c6c-33c6-497c-8248-25241ebf7edd")
+
+	testcases := map[string]struct {
+		claims      *LpaStoreClaims
+		operation   Operation
+		updateTypes []string
+		allowed     bool
+	}{
+		"sirius search":                      {claims: siriusUser, operation: OperationSearch, allowed: true},
+		"sirius register":                    {claims: siriusUser, operation: OperationUpdate, updateTypes: []string{"REGISTER"}, allowed: true},
+		"sirius short subject":               {claims: claimsFor("opg.poas.sirius", "urn:opg:sirius:users:34"), operation: OperationGet, allowed: true},
+		"makeregister create":                {claims: makeRegisterUser, operation: OperationCreate, allowed: true},
+		"makeregister sign":                  {claims: makeRegisterUser, operation: OperationUpdate, updateTypes: []string{"CERTIFICATE_PROVIDER_SIGN", "ATTORNEY_SIGN"}, allowed: true},
+		"makeregister register":              {claims: makeRegisterUser, operation: OperationUpdate, updateTypes: []string{"REGISTER"}},
+		"makeregister some types not":        {claims: makeRegisterUser, operation: OperationUpdate, updateTypes: []string{"ATTORNEY_SIGN", "OPG_STATUS_CHANGE"}},
+		"makeregister search":                {claims: makeRegisterUser, operation: OperationSearch},
+		"use get":                            {claims: useUser, operation: OperationGet, allowed: true},
+		"use getbyactor":                     {claims: useUser, operation: OperationGetByActor, allowed: true},
+		"use update":                         {claims: useUser, operation: OperationUpdate, updateTypes: []string{"OPG_STATUS_CHANGE"}},
+		"use create":                         {claims: useUser, operation: OperationCreate},
+		"unknown issuer":                     {claims: claimsFor("opg.poas.other", "urn:opg:poas:other:users:1"), operation: OperationGet},
+		"unknown subject type":               {claims: claimsFor("opg.poas.sirius", "urn:opg:poas:sirius:systems:1"), operation: OperationGet},
+		"subject not a urn":                  {claims: claimsFor("opg.poas.sirius", "34"), operation: OperationGet},
+		"no claims":                          {operation: OperationGet},
+		"makeregister update without a type": {claims: makeRegisterUser, operation: OperationUpdate, allowed: true},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.allowed, Authorise(tc.claims, tc.operation, tc.updateTypes...))
+		})
+	}
+}
+
+func TestAuthorisationPolicyAllows(t *testing.T) {
+	policy := AuthorisationPolicy{
+		{Issuer: "a", SubjectType: "users"}: {Operations: []Operation{OperationUpdate}, UpdateTypes: []string{"X"}},
+	}
+
+	assert.True(t, policy.Allows(claimsFor("a", "urn:a:users:1"), OperationUpdate, "X"))
+	assert.False(t, policy.Allows(claimsFor("a", "urn:a:users:1"), OperationUpdate, "Y"))
+	assert.False(t, policy.Allows(claimsFor("a", "urn:a:users:1"), OperationGet))
+	assert.False(t, policy.Allows(claimsFor("b", "urn:b:users:1"), OperationUpdate, "X"))
+}
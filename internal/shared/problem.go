@@ -1,9 +1,11 @@
 package shared
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
 	"strings"
 	"time"
@@ -27,21 +29,59 @@ var (
 		Code:       "UNAUTHORISED",
 		Detail:     "Invalid JWT",
 	}
+	ProblemForbidden = Problem{
+		StatusCode: 403,
+		Code:       "FORBIDDEN",
+		Detail:     "Not permitted to make this request",
+	}
 	ProblemNotFoundRequest = Problem{
 		StatusCode: 404,
 		Code:       "NOT_FOUND",
 		Detail:     "Record not found",
 	}
+	ProblemConflict = Problem{
+		StatusCode: 409,
+		Code:       "CONFLICT",
+		Detail:     "Record has been changed by another request",
+	}
+	ProblemPreconditionFailed = Problem{
+		StatusCode: 412,
+		Code:       "PRECONDITION_FAILED",
+		Detail:     "Record has been changed since it was retrieved",
+	}
+	ProblemIdempotencyKeyReused = Problem{
+		StatusCode: 422,
+		Code:       "IDEMPOTENCY_KEY_REUSED",
+		Detail:     "Idempotency-Key has already been used for a different request",
+	}
 )
 
+// problemTypePrefix is prefixed to the kebab-cased Code to give the type of a
+// problem.
+const problemTypePrefix = "urn:opg:poas:lpa-store:problem:"
+
+// Problem is an RFC 7807 problem details response, with Code and Errors as
+// extension members. Type, Title, Instance and RequestID are set by Respond.
 type Problem struct {
-	StatusCode int          `json:"-"`
-	Code       string       `json:"code"`
+	Type       string       `json:"type"`
+	Title      string       `json:"title"`
+	StatusCode int          `json:"status"`
 	Detail     string       `json:"detail"`
+	Instance   string       `json:"instance,omitempty"`
+	Code       string       `json:"code"`
+	RequestID  string       `json:"requestId,omitempty"`
 	Errors     []FieldError `json:"errors,omitempty"`
 }
 
-func (problem Problem) Respond() (events.APIGatewayProxyResponse, error) {
+func (problem Problem) Respond(ctx context.Context) (events.APIGatewayProxyResponse, error) {
+	problem.Type = problemTypePrefix + strings.ToLower(strings.ReplaceAll(problem.Code, "_", "-"))
+	problem.Title = http.StatusText(problem.StatusCode)
+
+	if req, ok := requestFromContext(ctx); ok {
+		problem.Instance = req.path
+		problem.RequestID = req.id
+	}
+
 	var errorString = ""
 	for _, ve := range problem.Errors {
 		errorString += fmt.Sprintf("%s %s, ", ve.Source, ve.Detail)
@@ -55,6 +95,7 @@ func (problem Problem) Respond() (events.APIGatewayProxyResponse, error) {
 		Status:      problem.StatusCode,
 		Problem:     problem,
 		ErrorString: strings.TrimRight(errorString, ", "),
+		RequestID:   problem.RequestID,
 	})
 
 	code := problem.StatusCode
@@ -62,11 +103,12 @@ func (problem Problem) Respond() (events.APIGatewayProxyResponse, error) {
 
 	if err != nil {
 		code = 500
-		body = []byte("{\"code\":\"INTERNAL_SERVER_ERROR\",\"detail\":\"Internal server error\"}")
+		body = []byte("{\"type\":\"" + problemTypePrefix + "internal-server-error\",\"title\":\"Internal Server Error\",\"status\":500,\"detail\":\"Internal server error\",\"code\":\"INTERNAL_SERVER_ERROR\"}")
 	}
 
 	return events.APIGatewayProxyResponse{
 		StatusCode: code,
+		Headers:    map[string]string{"Content-Type": "application/problem+json"},
 		Body:       string(body),
 	}, nil
 }
@@ -84,4 +126,5 @@ type LogEvent struct {
 	Status      int       `json:"status"`
 	Problem     Problem   `json:"problem"`
 	ErrorString string    `json:"error_string,omitempty"`
+	RequestID   string    `json:"request_id,omitempty"`
 }
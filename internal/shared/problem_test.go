@@ -0,0 +1,44 @@
+package shared
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProblemRespond(t *testing.T) {
+	problem := ProblemInvalidRequest
+	problem.Errors = []FieldError{{Source: "/uid", Detail: "invalid uid format"}}
+
+	resp, err := problem.Respond(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+	assert.Equal(t, map[string]string{"Content-Type": "application/problem+json"}, resp.Headers)
+	assert.JSONEq(t, `{
+		"type":"urn:opg:poas:lpa-store:problem:invalid-request",
+		"title":"Bad Request",
+		"status":400,
+		"detail":"Invalid request",
+		"code":"INVALID_REQUEST",
+		"errors":[{"source":"/uid","detail":"invalid uid format"}]
+	}`, resp.Body)
+}
+
+func TestProblemRespondTypes(t *testing.T) {
+	testcases := map[string]Problem{
+		"urn:opg:poas:lpa-store:problem:internal-server-error":  ProblemInternalServerError,
+		"urn:opg:poas:lpa-store:problem:unauthorised":           ProblemUnauthorisedRequest,
+		"urn:opg:poas:lpa-store:problem:forbidden":              ProblemForbidden,
+		"urn:opg:poas:lpa-store:problem:not-found":              ProblemNotFoundRequest,
+		"urn:opg:poas:lpa-store:problem:conflict":               ProblemConflict,
+		"urn:opg:poas:lpa-store:problem:precondition-failed":    ProblemPreconditionFailed,
+		"urn:opg:poas:lpa-store:problem:idempotency-key-reused": ProblemIdempotencyKeyReused,
+	}
+
+	for expected, problem := range testcases {
+		t.Run(problem.Code, func(t *testing.T) {
+			resp, _ := problem.Respond(ctx)
+			assert.Contains(t, resp.Body, `"type":"`+expected+`"`)
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package shared
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/google/uuid"
+)
+
+const RequestIDHeader = "X-Request-Id"
+
+var requestIDRegexp = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// Handler is the signature of each lambda's HandleEvent.
+type Handler func(context.Context, events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error)
+
+type requestInfoKey struct{}
+
+type requestInfo struct {
+	id   string
+	path string
+}
+
+type correlationIDKey struct{}
+
+// requestFromContext returns the request added to ctx by WithRequestID, if
+// there is one.
+func requestFromContext(ctx context.Context) (requestInfo, bool) {
+	req, ok := ctx.Value(requestInfoKey{}).(requestInfo)
+	return req, ok
+}
+
+// ContextWithCorrelationID returns a copy of ctx with an ID that is added to
+// log lines from loggers created with NewRequestLogger. It is used by lambdas
+// that are not handling a request, to tie together the log lines about the
+// same record.
+func ContextWithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, id)
+}
+
+// RequestID returns the X-Request-Id given with the request, or a new ID if
+// there is not a valid one.
+func RequestID(req events.APIGatewayProxyRequest) string {
+	if values := GetEventHeader(RequestIDHeader, req); len(values) > 0 && requestIDRegexp.MatchString(values[0]) {
+		return values[0]
+	}
+
+	return uuid.NewString()
+}
+
+// WithRequestID wraps handler so that each request has an ID, which is added
+// to log lines from loggers created with NewRequestLogger, to problems, and to
+// the X-Request-Id header of the response.
+func WithRequestID(handler Handler) Handler {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		id := RequestID(req)
+
+		ctx = context.WithValue(ctx, requestInfoKey{}, requestInfo{id: id, path: req.Path})
+
+		resp, err := handler(ctx, req)
+
+		if resp.Headers == nil {
+			resp.Headers = map[string]string{}
+		}
+		resp.Headers[RequestIDHeader] = id
+
+		return resp, err
+	}
+}
+
+// NewRequestLogger returns a logger that adds the ID of the request being
+// handled, or the correlation ID, to each line logged with its context.
+func NewRequestLogger(logger *slog.Logger) *slog.Logger {
+	return slog.New(requestIDHandler{handler: logger.Handler()})
+}
+
+type requestIDHandler struct {
+	handler slog.Handler
+}
+
+func (h requestIDHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h requestIDHandler) Handle(ctx context.Context, record slog.Record) error {
+	if req, ok := requestFromContext(ctx); ok {
+		record = record.Clone()
+		record.AddAttrs(slog.String("request_id", req.id))
+	}
+
+	if id, ok := ctx.Value(correlationIDKey{}).(string); ok {
+		record = record.Clone()
+		record.AddAttrs(slog.String("correlation_id", id))
+	}
+
+	return h.handler.Handle(ctx, record)
+}
+
+func (h requestIDHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return requestIDHandler{handler: h.handler.WithAttrs(attrs)}
+}
+
+func (h requestIDHandler) WithGroup(name string) slog.Handler {
+	return requestIDHandler{handler: h.handler.WithGroup(name)}
+}
@@ -0,0 +1,121 @@
+package shared
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	assert.Equal(t, "abc-123", RequestID(events.APIGatewayProxyRequest{
+		MultiValueHeaders: map[string][]string{"X-Request-Id": {"abc-123"}},
+	}))
+
+	assert.Equal(t, "abc-123", RequestID(events.APIGatewayProxyRequest{
+		MultiValueHeaders: map[string][]string{"x-request-id": {"abc-123"}},
+	}))
+}
+
+func TestRequestIDWhenGenerated(t *testing.T) {
+	testcases := map[string]events.APIGatewayProxyRequest{
+		"missing":   {},
+		"empty":     {MultiValueHeaders: map[string][]string{"X-Request-Id": {""}}},
+		"invalid":   {MultiValueHeaders: map[string][]string{"X-Request-Id": {"abc\n123"}}},
+		"too long":  {MultiValueHeaders: map[string][]string{"X-Request-Id": {string(bytes.Repeat([]byte("a"), 129))}}},
+		"no values": {MultiValueHeaders: map[string][]string{"X-Request-Id": {}}},
+	}
+
+	for name, req := range testcases {
+		t.Run(name, func(t *testing.T) {
+			_, err := uuid.Parse(RequestID(req))
+			assert.Nil(t, err)
+		})
+	}
+}
+
+func TestWithRequestID(t *testing.T) {
+	req := events.APIGatewayProxyRequest{
+		Path:              "/lpas/M-1234",
+		MultiValueHeaders: map[string][]string{"X-Request-Id": {"abc-123"}},
+	}
+
+	handler := WithRequestID(func(ctx context.Context, r events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		assert.Equal(t, req, r)
+		return ProblemNotFoundRequest.Respond(ctx)
+	})
+
+	resp, err := handler(context.Background(), req)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		"Content-Type": "application/problem+json",
+		"X-Request-Id": "abc-123",
+	}, resp.Headers)
+	assert.JSONEq(t, `{
+		"type":"urn:opg:poas:lpa-store:problem:not-found",
+		"title":"Not Found",
+		"status":404,
+		"detail":"Record not found",
+		"instance":"/lpas/M-1234",
+		"code":"NOT_FOUND",
+		"requestId":"abc-123"
+	}`, resp.Body)
+}
+
+func TestWithRequestIDWhenNoHeaders(t *testing.T) {
+	handler := WithRequestID(func(ctx context.Context, r events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{StatusCode: 200}, nil
+	})
+
+	resp, err := handler(context.Background(), events.APIGatewayProxyRequest{})
+	assert.Nil(t, err)
+	assert.Len(t, resp.Headers, 1)
+	assert.NotEmpty(t, resp.Headers[RequestIDHeader])
+}
+
+func TestNewRequestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewRequestLogger(slog.New(slog.NewJSONHandler(&buf, nil)).With("service_name", "test"))
+
+	logger.Info("outside request")
+
+	handler := WithRequestID(func(ctx context.Context, r events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		logger.InfoContext(ctx, "inside request", slog.String("a", "b"))
+		return events.APIGatewayProxyResponse{}, nil
+	})
+
+	_, _ = handler(context.Background(), events.APIGatewayProxyRequest{
+		MultiValueHeaders: map[string][]string{"X-Request-Id": {"abc-123"}},
+	})
+
+	decoder := json.NewDecoder(&buf)
+
+	var outside, inside map[string]any
+	assert.Nil(t, decoder.Decode(&outside))
+	assert.Nil(t, decoder.Decode(&inside))
+
+	assert.Equal(t, "outside request", outside["msg"])
+	assert.NotContains(t, outside, "request_id")
+
+	assert.Equal(t, "inside request", inside["msg"])
+	assert.Equal(t, "test", inside["service_name"])
+	assert.Equal(t, "b", inside["a"])
+	assert.Equal(t, "abc-123", inside["request_id"])
+}
+
+func TestNewRequestLoggerWithCorrelationID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewRequestLogger(slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	logger.InfoContext(ContextWithCorrelationID(context.Background(), "an-id"), "a message")
+
+	var line map[string]any
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, "an-id", line["correlation_id"])
+	assert.NotContains(t, line, "request_id")
+}
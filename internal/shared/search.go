@@ -0,0 +1,32 @@
+package shared
+
+import (
+	"strings"
+	"unicode"
+)
+
+// DonorSearchKey normalises a donor's last name, date of birth and postcode
+// into the key that LPAs are indexed by for searching. Names are case-folded
+// with whitespace collapsed, and postcodes are upper-cased with whitespace
+// removed. If any part is missing the key is empty, as the LPA cannot be
+// found by searching.
+func DonorSearchKey(lastName string, dateOfBirth Date, postcode string) string {
+	lastName = strings.ToLower(strings.Join(strings.Fields(lastName), " "))
+	postcode = strings.ToUpper(strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, postcode))
+
+	if lastName == "" || dateOfBirth.IsZero() || postcode == "" {
+		return ""
+	}
+
+	return lastName + "#" + dateOfBirth.DateOnlyText() + "#" + postcode
+}
+
+// SearchKey is the DonorSearchKey for the LPA's donor.
+func (lpa *Lpa) SearchKey() string {
+	return DonorSearchKey(lpa.Donor.LastName, lpa.Donor.DateOfBirth, lpa.Donor.Address.Postcode)
+}
@@ -0,0 +1,55 @@
+package shared
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDonorSearchKey(t *testing.T) {
+	dob := Date{t: time.Date(1980, time.April, 5, 0, 0, 0, 0, time.UTC)}
+
+	testcases := map[string]struct {
+		lastName    string
+		dateOfBirth Date
+		postcode    string
+		expected    string
+	}{
+		"normalised": {
+			lastName:    "  Van  Der BERG ",
+			dateOfBirth: dob,
+			postcode:    "sw1a  1aa",
+			expected:    "van der berg#1980-04-05#SW1A1AA",
+		},
+		"missing last name": {
+			dateOfBirth: dob,
+			postcode:    "SW1A 1AA",
+		},
+		"missing date of birth": {
+			lastName: "Smith",
+			postcode: "SW1A 1AA",
+		},
+		"missing postcode": {
+			lastName:    "Smith",
+			dateOfBirth: dob,
+			postcode:    " ",
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, DonorSearchKey(tc.lastName, tc.dateOfBirth, tc.postcode))
+		})
+	}
+}
+
+func TestLpaSearchKey(t *testing.T) {
+	lpa := &Lpa{LpaInit: LpaInit{Donor: Donor{
+		Person:      Person{LastName: "Smith"},
+		DateOfBirth: Date{t: time.Date(1980, time.April, 5, 0, 0, 0, 0, time.UTC)},
+		Address:     Address{Postcode: "B14 7ED"},
+	}}}
+
+	assert.Equal(t, "smith#1980-04-05#B147ED", lpa.SearchKey())
+}
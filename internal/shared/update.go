@@ -26,13 +26,19 @@ func (u URN) Details() AuthorDetails {
 	}
 }
 
+// AppliedFormat is the format of Update.Applied. It is RFC3339 with a fixed
+// number of fractional digits, so that updates sort in the order they were
+// applied even when several are made in the same second.
+const AppliedFormat = "2006-01-02T15:04:05.000000000Z07:00"
+
 type Update struct {
 	Id      string   `json:"id"`      // UUID for the update
 	Uid     string   `json:"uid"`     // UID of the changed LPA
-	Applied string   `json:"applied"` // RFC3339 datetime
+	Applied string   `json:"applied"` // datetime in AppliedFormat
 	Author  URN      `json:"author"`
 	Type    string   `json:"type"`
 	Changes []Change `json:"changes"`
+	Diff    []Change `json:"diff,omitempty"` // all changes made to the LPA, including those implied by the type
 }
 
 type AuthorDetails struct {
@@ -0,0 +1,136 @@
+// Package tracing sets up OpenTelemetry for the lambdas and provides helpers
+// for creating spans around calls to other services.
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"os"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+	"go.opentelemetry.io/contrib/propagators/aws/xray"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/ministryofjustice/opg-data-lpa-store"
+
+// Start sets the global tracer provider and propagator. Spans are only
+// exported when OTEL_EXPORTER_OTLP_ENDPOINT is set, the exporter is otherwise
+// configured with the standard OTEL_EXPORTER_OTLP_* variables. If the exporter
+// cannot be created the error is returned with a provider that does not export.
+func Start(ctx context.Context, serviceName string) (*sdktrace.TracerProvider, error) {
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(serviceName))),
+		sdktrace.WithIDGenerator(xray.NewIDGenerator()),
+	}
+
+	var err error
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" {
+		var exporter *otlptrace.Exporter
+		exporter, err = otlptracegrpc.New(ctx)
+		if err == nil {
+			opts = append(opts, sdktrace.WithBatcher(exporter))
+		}
+	}
+
+	tp := sdktrace.NewTracerProvider(opts...)
+
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, xray.Propagator{}))
+
+	return tp, err
+}
+
+// WithTracing wraps handler so that each request is handled in a server span,
+// continuing any trace given in the request headers. Spans are flushed before
+// returning as the execution environment may be frozen between requests.
+func WithTracing(tp *sdktrace.TracerProvider, handler shared.Handler) shared.Handler {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		ctx = otel.GetTextMapPropagator().Extract(ctx, headerCarrier(req))
+
+		route := req.Resource
+		if route == "" {
+			route = req.Path
+		}
+
+		ctx, span := tp.Tracer(tracerName).Start(ctx, req.HTTPMethod+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(req.HTTPMethod),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(req.Path),
+			))
+
+		resp, err := handler(ctx, req)
+
+		span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+		if id := resp.Headers[shared.RequestIDHeader]; id != "" {
+			span.SetAttributes(attribute.String("request.id", id))
+		}
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		} else if resp.StatusCode >= 500 {
+			span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+		}
+
+		span.End()
+		_ = tp.ForceFlush(ctx)
+
+		return resp, err
+	}
+}
+
+// StartSpan starts a client span for a call to another service. The span
+// should be ended with EndSpan.
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...))
+}
+
+// EndSpan ends span, recording the error that err points to if there is one.
+// It is intended to be deferred with a named error result.
+func EndSpan(span trace.Span, err *error) {
+	if err != nil && *err != nil {
+		span.RecordError(*err)
+		span.SetStatus(codes.Error, (*err).Error())
+	}
+
+	span.End()
+}
+
+// LpaUID is the attribute for the UID of the LPA a span is for.
+func LpaUID(uid string) attribute.KeyValue {
+	return attribute.String("lpa.uid", uid)
+}
+
+// UpdateTypes is the attribute for the types of the updates a span is for.
+func UpdateTypes(updateTypes ...string) attribute.KeyValue {
+	return attribute.StringSlice("lpa.update_types", updateTypes)
+}
+
+func headerCarrier(req events.APIGatewayProxyRequest) propagation.HeaderCarrier {
+	header := http.Header{}
+	for k, v := range req.Headers {
+		header.Set(k, v)
+	}
+	for k, values := range req.MultiValueHeaders {
+		header.Del(k)
+		for _, v := range values {
+			header.Add(k, v)
+		}
+	}
+
+	return propagation.HeaderCarrier(header)
+}
@@ -0,0 +1,164 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+var errExpected = errors.New("err")
+
+func setupTracing(t *testing.T) (*sdktrace.TracerProvider, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+	})
+
+	return tp, recorder
+}
+
+func TestWithTracing(t *testing.T) {
+	tp, recorder := setupTracing(t)
+
+	req := events.APIGatewayProxyRequest{
+		HTTPMethod: "GET",
+		Resource:   "/lpas/{uid}",
+		Path:       "/lpas/M-1234",
+		MultiValueHeaders: map[string][]string{
+			"traceparent": {"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"},
+		},
+	}
+
+	var handlerSpan trace.SpanContext
+	handler := WithTracing(tp, func(ctx context.Context, r events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		handlerSpan = trace.SpanContextFromContext(ctx)
+		return events.APIGatewayProxyResponse{StatusCode: 200, Headers: map[string]string{"X-Request-Id": "abc-123"}}, nil
+	})
+
+	resp, err := handler(context.Background(), req)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	spans := recorder.Ended()
+	if assert.Len(t, spans, 1) {
+		span := spans[0]
+		assert.Equal(t, "GET /lpas/{uid}", span.Name())
+		assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+		assert.Equal(t, "0af7651916cd43dd8448eb211c80319c", span.SpanContext().TraceID().String())
+		assert.Equal(t, "b7ad6b7169203331", span.Parent().SpanID().String())
+		assert.Equal(t, span.SpanContext(), handlerSpan)
+		assert.Equal(t, codes.Unset, span.Status().Code)
+		assert.Subset(t, span.Attributes(), []attribute.KeyValue{
+			attribute.String("http.request.method", "GET"),
+			attribute.String("http.route", "/lpas/{uid}"),
+			attribute.String("url.path", "/lpas/M-1234"),
+			attribute.Int("http.response.status_code", 200),
+			attribute.String("request.id", "abc-123"),
+		})
+	}
+}
+
+func TestWithTracingWhenServerError(t *testing.T) {
+	tp, recorder := setupTracing(t)
+
+	handler := WithTracing(tp, func(ctx context.Context, r events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{StatusCode: 500}, nil
+	})
+
+	_, _ = handler(context.Background(), events.APIGatewayProxyRequest{})
+
+	spans := recorder.Ended()
+	if assert.Len(t, spans, 1) {
+		assert.False(t, spans[0].Parent().IsValid())
+		assert.Equal(t, codes.Error, spans[0].Status().Code)
+	}
+}
+
+func TestWithTracingWhenError(t *testing.T) {
+	tp, recorder := setupTracing(t)
+
+	handler := WithTracing(tp, func(ctx context.Context, r events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{}, errExpected
+	})
+
+	_, err := handler(context.Background(), events.APIGatewayProxyRequest{})
+	assert.Equal(t, errExpected, err)
+
+	spans := recorder.Ended()
+	if assert.Len(t, spans, 1) {
+		assert.Equal(t, codes.Error, spans[0].Status().Code)
+		assert.Len(t, spans[0].Events(), 1)
+	}
+}
+
+func TestStartSpan(t *testing.T) {
+	_, recorder := setupTracing(t)
+
+	parentCtx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+
+	func() (err error) {
+		_, span := StartSpan(parentCtx, "ddb.Get", LpaUID("M-1234"), UpdateTypes("CREATE"))
+		defer EndSpan(span, &err)
+
+		return nil
+	}()
+
+	parent.End()
+
+	spans := recorder.Ended()
+	if assert.Len(t, spans, 2) {
+		span := spans[0]
+		assert.Equal(t, "ddb.Get", span.Name())
+		assert.Equal(t, trace.SpanKindClient, span.SpanKind())
+		assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID())
+		assert.Equal(t, codes.Unset, span.Status().Code)
+		assert.Equal(t, []attribute.KeyValue{
+			attribute.String("lpa.uid", "M-1234"),
+			attribute.StringSlice("lpa.update_types", []string{"CREATE"}),
+		}, span.Attributes())
+	}
+}
+
+func TestEndSpanWhenError(t *testing.T) {
+	_, recorder := setupTracing(t)
+
+	err := func() (err error) {
+		_, span := StartSpan(context.Background(), "ddb.Get")
+		defer EndSpan(span, &err)
+
+		return errExpected
+	}()
+	assert.Equal(t, errExpected, err)
+
+	spans := recorder.Ended()
+	if assert.Len(t, spans, 1) {
+		assert.Equal(t, codes.Error, spans[0].Status().Code)
+		assert.Equal(t, "err", spans[0].Status().Description)
+	}
+}
+
+func TestStart(t *testing.T) {
+	setupTracing(t)
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+
+	tp, err := Start(context.Background(), "test")
+	assert.Nil(t, err)
+	assert.Equal(t, tp, otel.GetTracerProvider())
+	assert.ElementsMatch(t, []string{"traceparent", "tracestate", "X-Amzn-Trace-Id"}, otel.GetTextMapPropagator().Fields())
+}
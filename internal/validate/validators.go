@@ -87,6 +87,12 @@ func (v EmptyValidator) Valid(val any) string {
 		} else {
 			return msgNotProvided
 		}
+	case *shared.Date:
+		if v == nil || v.IsZero() {
+			return ""
+		} else {
+			return msgNotProvided
+		}
 	}
 
 	return msgType
@@ -20,6 +20,9 @@ func TestEmpty(t *testing.T) {
 	assert.Equal(t, "field must not be provided", Empty().Valid("a"))
 	assert.Equal(t, "", Empty().Valid(time.Time{}))
 	assert.Equal(t, "field must not be provided", Empty().Valid(time.Now()))
+	assert.Equal(t, "", Empty().Valid((*shared.Date)(nil)))
+	date := newDate("2020-01-02")
+	assert.Equal(t, "field must not be provided", Empty().Valid(&date))
 }
 
 func TestUUID(t *testing.T) {
@@ -0,0 +1,206 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+)
+
+const (
+	defaultMaxAttempts = 4
+	defaultBackoff     = 500 * time.Millisecond
+	defaultTimeout     = 10 * time.Second
+
+	// secretTTL is how long a subscription's secret is used before it is
+	// fetched again, so that rotated secrets are picked up
+	secretTTL = 5 * time.Minute
+)
+
+type Doer interface {
+	Do(*http.Request) (*http.Response, error)
+}
+
+type SecretsClient interface {
+	GetSecretValue(context.Context, *secretsmanager.GetSecretValueInput, ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)
+}
+
+type cachedSecret struct {
+	value     string
+	fetchedAt time.Time
+}
+
+// Dispatcher POSTs payloads to subscriptions' callback URLs, retrying with
+// exponential backoff when the receiver is unavailable.
+type Dispatcher struct {
+	client      Doer
+	secrets     SecretsClient
+	maxAttempts int
+	backoff     time.Duration
+	sleep       func(context.Context, time.Duration) error
+	now         func() time.Time
+
+	mu           sync.Mutex
+	secretsByARN map[string]cachedSecret
+}
+
+// NewDispatcher creates a Dispatcher. When client is nil deliveries are only
+// made to public addresses, checked as each connection is made.
+func NewDispatcher(client Doer, secrets SecretsClient) *Dispatcher {
+	if client == nil {
+		client = publicClient()
+	}
+
+	return &Dispatcher{
+		client:       client,
+		secrets:      secrets,
+		maxAttempts:  defaultMaxAttempts,
+		backoff:      defaultBackoff,
+		sleep:        sleep,
+		now:          time.Now,
+		secretsByARN: map[string]cachedSecret{},
+	}
+}
+
+// Deliver sends the payload to the subscription, returning the number of
+// attempts made. Network errors, timeouts, 429 and 5xx responses are retried;
+// other responses that are not 2xx, and addresses that are not public, are not. Retrying stops early when the
+// backoff would take it past the deadline of ctx. No attempt is made if the
+// subscription's secret cannot be fetched.
+func (d *Dispatcher) Deliver(ctx context.Context, subscription Subscription, payload Payload) (int, error) {
+	secret, err := d.secret(ctx, subscription.SecretARN)
+	if err != nil {
+		return 0, err
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return 0, err
+	}
+
+	var attempt int
+	for attempt = 1; ; attempt++ {
+		retry, err := d.post(ctx, subscription.CallbackURL, secret, payload.DeliveryId, body)
+		if err == nil {
+			return attempt, nil
+		}
+
+		if !retry || attempt >= d.maxAttempts {
+			return attempt, err
+		}
+
+		wait := d.backoff << (attempt - 1)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= wait {
+			return attempt, err
+		}
+
+		if err := d.sleep(ctx, wait); err != nil {
+			return attempt, err
+		}
+	}
+}
+
+// secret returns the value of the secret with the given ARN, fetching it from
+// Secrets Manager when it has not been fetched within secretTTL.
+func (d *Dispatcher) secret(ctx context.Context, secretARN string) (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if cached, ok := d.secretsByARN[secretARN]; ok && d.now().Sub(cached.fetchedAt) < secretTTL {
+		return cached.value, nil
+	}
+
+	output, err := d.secrets.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(secretARN),
+	})
+	if err != nil {
+		return "", fmt.Errorf("fetching secret: %w", err)
+	}
+
+	value := aws.ToString(output.SecretString)
+	if len(value) < minSecretLength {
+		return "", fmt.Errorf("secret must be at least %d characters", minSecretLength)
+	}
+
+	d.secretsByARN[secretARN] = cachedSecret{value: value, fetchedAt: d.now()}
+
+	return value, nil
+}
+
+func (d *Dispatcher) post(ctx context.Context, callbackURL, secret, deliveryId string, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, callbackURL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	timestamp := d.now().Unix()
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(DeliveryHeader, deliveryId)
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil && !errors.Is(err, errNotPublic), err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+
+	retry := resp.StatusCode == http.StatusRequestTimeout ||
+		resp.StatusCode == http.StatusTooManyRequests ||
+		resp.StatusCode >= 500
+
+	return retry, fmt.Errorf("callback responded %d", resp.StatusCode)
+}
+
+// publicClient returns a client that refuses to connect to addresses that are
+// not public, so that a callback host resolving to one, or redirecting to one,
+// cannot be used to reach services inside the VPC.
+func publicClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: defaultTimeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil || !isPublic(addrPort.Addr()) {
+				return errNotPublic
+			}
+
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{Timeout: defaultTimeout, Transport: transport}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
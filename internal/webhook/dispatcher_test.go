@@ -0,0 +1,257 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var testNow = time.Date(2024, time.January, 2, 12, 13, 14, 0, time.UTC)
+
+type receiver struct {
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, body)
+
+	status := http.StatusNoContent
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func testDispatcher(t *testing.T, r *receiver) (*Dispatcher, Subscription, *[]time.Duration) {
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+
+	secrets := newMockSecretsClient(t)
+	secrets.EXPECT().
+		GetSecretValue(mock.Anything, &secretsmanager.GetSecretValueInput{SecretId: aws.String(testSecretARN)}).
+		Return(&secretsmanager.GetSecretValueOutput{SecretString: aws.String(testSecret)}, nil).
+		Maybe()
+
+	var sleeps []time.Duration
+
+	return &Dispatcher{
+			client:      server.Client(),
+			secrets:     secrets,
+			maxAttempts: 3,
+			backoff:     time.Second,
+			sleep: func(_ context.Context, d time.Duration) error {
+				sleeps = append(sleeps, d)
+				return nil
+			},
+			now:          func() time.Time { return testNow },
+			secretsByARN: map[string]cachedSecret{},
+		},
+		Subscription{Id: "a-subscription", CallbackURL: server.URL + "/hook", SecretARN: testSecretARN},
+		&sleeps
+}
+
+func TestNewDispatcher(t *testing.T) {
+	secrets := newMockSecretsClient(t)
+	dispatcher := NewDispatcher(nil, secrets)
+
+	assert.IsType(t, (*http.Client)(nil), dispatcher.client)
+	assert.Equal(t, secrets, dispatcher.secrets)
+	assert.Equal(t, defaultMaxAttempts, dispatcher.maxAttempts)
+	assert.Equal(t, defaultBackoff, dispatcher.backoff)
+}
+
+func TestDispatcherDeliver(t *testing.T) {
+	r := &receiver{}
+	dispatcher, subscription, sleeps := testDispatcher(t, r)
+	payload := NewPayload(subscription, shared.Update{Id: "an-update", Uid: "M-1111-2222-3333", Type: "REGISTER"}, shared.LpaStatusRegistered)
+
+	attempts, err := dispatcher.Deliver(context.Background(), subscription, payload)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, attempts)
+	assert.Empty(t, *sleeps)
+
+	if assert.Len(t, r.requests, 1) {
+		req := r.requests[0]
+		assert.Equal(t, http.MethodPost, req.Method)
+		assert.Equal(t, "/hook", req.URL.Path)
+		assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+		assert.Equal(t, "an-update/a-subscription", req.Header.Get(DeliveryHeader))
+		assert.Equal(t, strconv.FormatInt(testNow.Unix(), 10), req.Header.Get(TimestampHeader))
+		assert.True(t, Verify(testSecret, testNow.Unix(), r.bodies[0], req.Header.Get(SignatureHeader)))
+
+		var received Payload
+		assert.Nil(t, json.Unmarshal(r.bodies[0], &received))
+		assert.Equal(t, payload, received)
+	}
+}
+
+func TestDispatcherDeliverRetries(t *testing.T) {
+	r := &receiver{statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}}
+	dispatcher, subscription, sleeps := testDispatcher(t, r)
+
+	attempts, err := dispatcher.Deliver(context.Background(), subscription, Payload{DeliveryId: "a-delivery"})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, *sleeps)
+	assert.Len(t, r.requests, 3)
+}
+
+func TestDispatcherDeliverWhenRetriesExhausted(t *testing.T) {
+	r := &receiver{statuses: []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusInternalServerError}}
+	dispatcher, subscription, _ := testDispatcher(t, r)
+
+	attempts, err := dispatcher.Deliver(context.Background(), subscription, Payload{DeliveryId: "a-delivery"})
+	assert.EqualError(t, err, "callback responded 500")
+	assert.Equal(t, 3, attempts)
+}
+
+func TestDispatcherDeliverWhenRejected(t *testing.T) {
+	r := &receiver{statuses: []int{http.StatusBadRequest}}
+	dispatcher, subscription, sleeps := testDispatcher(t, r)
+
+	attempts, err := dispatcher.Deliver(context.Background(), subscription, Payload{DeliveryId: "a-delivery"})
+	assert.EqualError(t, err, "callback responded 400")
+	assert.Equal(t, 1, attempts)
+	assert.Empty(t, *sleeps)
+}
+
+func TestDispatcherDeliverWhenUnreachable(t *testing.T) {
+	dispatcher, subscription, _ := testDispatcher(t, &receiver{})
+	subscription.CallbackURL = "http://127.0.0.1:1/hook"
+
+	attempts, err := dispatcher.Deliver(context.Background(), subscription, Payload{DeliveryId: "a-delivery"})
+	assert.Error(t, err)
+	assert.Equal(t, 3, attempts)
+}
+
+func TestDispatcherDeliverWhenNotPublic(t *testing.T) {
+	r := &receiver{}
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+
+	secrets := newMockSecretsClient(t)
+	secrets.EXPECT().
+		GetSecretValue(mock.Anything, mock.Anything).
+		Return(&secretsmanager.GetSecretValueOutput{SecretString: aws.String(testSecret)}, nil)
+
+	dispatcher := NewDispatcher(nil, secrets)
+	subscription := Subscription{Id: "a-subscription", CallbackURL: server.URL + "/hook", SecretARN: testSecretARN}
+
+	attempts, err := dispatcher.Deliver(context.Background(), subscription, Payload{DeliveryId: "a-delivery"})
+	assert.ErrorIs(t, err, errNotPublic)
+	assert.Equal(t, 1, attempts)
+	assert.Empty(t, r.requests)
+}
+
+func TestDispatcherDeliverWhenContextCancelledWhileWaiting(t *testing.T) {
+	r := &receiver{statuses: []int{http.StatusServiceUnavailable}}
+	dispatcher, subscription, _ := testDispatcher(t, r)
+	dispatcher.sleep = sleep
+	dispatcher.backoff = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	attempts, err := dispatcher.Deliver(ctx, subscription, Payload{DeliveryId: "a-delivery"})
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Equal(t, 1, attempts)
+}
+
+func TestDispatcherDeliverWhenBackoffPassesDeadline(t *testing.T) {
+	r := &receiver{statuses: []int{http.StatusServiceUnavailable}}
+	dispatcher, subscription, sleeps := testDispatcher(t, r)
+	dispatcher.backoff = time.Hour
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	attempts, err := dispatcher.Deliver(ctx, subscription, Payload{DeliveryId: "a-delivery"})
+	assert.EqualError(t, err, "callback responded 503")
+	assert.Equal(t, 1, attempts)
+	assert.Empty(t, *sleeps)
+}
+
+func TestDispatcherDeliverFetchesSecretAfterTTL(t *testing.T) {
+	r := &receiver{}
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+
+	secrets := newMockSecretsClient(t)
+	secrets.EXPECT().
+		GetSecretValue(mock.Anything, &secretsmanager.GetSecretValueInput{SecretId: aws.String(testSecretARN)}).
+		Return(&secretsmanager.GetSecretValueOutput{SecretString: aws.String(testSecret)}, nil).
+		Once()
+	secrets.EXPECT().
+		GetSecretValue(mock.Anything, &secretsmanager.GetSecretValueInput{SecretId: aws.String(testSecretARN)}).
+		Return(&secretsmanager.GetSecretValueOutput{SecretString: aws.String("a-rotated-secret-that-is-long-enough")}, nil).
+		Once()
+
+	now := testNow
+	dispatcher := NewDispatcher(server.Client(), secrets)
+	dispatcher.now = func() time.Time { return now }
+	subscription := Subscription{Id: "a-subscription", CallbackURL: server.URL + "/hook", SecretARN: testSecretARN}
+
+	for _, after := range []time.Duration{0, secretTTL - time.Second, secretTTL} {
+		now = testNow.Add(after)
+
+		_, err := dispatcher.Deliver(context.Background(), subscription, Payload{DeliveryId: "a-delivery"})
+		assert.Nil(t, err)
+	}
+
+	if assert.Len(t, r.requests, 3) {
+		assert.True(t, Verify(testSecret, testNow.Unix(), r.bodies[0], r.requests[0].Header.Get(SignatureHeader)))
+		assert.True(t, Verify(testSecret, now.Add(-time.Second).Unix(), r.bodies[1], r.requests[1].Header.Get(SignatureHeader)))
+		assert.True(t, Verify("a-rotated-secret-that-is-long-enough", now.Unix(), r.bodies[2], r.requests[2].Header.Get(SignatureHeader)))
+	}
+}
+
+func TestDispatcherDeliverWhenSecretErrors(t *testing.T) {
+	testcases := map[string]struct {
+		output *secretsmanager.GetSecretValueOutput
+		err    error
+		errMsg string
+	}{
+		"fetch fails": {
+			err:    errors.New("expect"),
+			errMsg: "fetching secret: expect",
+		},
+		"too short": {
+			output: &secretsmanager.GetSecretValueOutput{SecretString: aws.String("short")},
+			errMsg: "secret must be at least 32 characters",
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			r := &receiver{}
+			dispatcher, subscription, _ := testDispatcher(t, r)
+
+			secrets := newMockSecretsClient(t)
+			secrets.EXPECT().
+				GetSecretValue(mock.Anything, mock.Anything).
+				Return(tc.output, tc.err)
+			dispatcher.secrets = secrets
+
+			attempts, err := dispatcher.Deliver(context.Background(), subscription, Payload{DeliveryId: "a-delivery"})
+			assert.EqualError(t, err, tc.errMsg)
+			assert.Equal(t, 0, attempts)
+			assert.Empty(t, r.requests)
+		})
+	}
+}
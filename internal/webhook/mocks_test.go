@@ -0,0 +1,318 @@
+// Code generated by mockery; DO NOT EDIT.
+// github.com/vektra/mockery
+// template: testify
+
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"net/netip"
+
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// newMockDoer creates a new instance of mockDoer. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newMockDoer(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *mockDoer {
+	mock := &mockDoer{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// mockDoer is an autogenerated mock type for the Doer type
+type mockDoer struct {
+	mock.Mock
+}
+
+type mockDoer_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *mockDoer) EXPECT() *mockDoer_Expecter {
+	return &mockDoer_Expecter{mock: &_m.Mock}
+}
+
+// Do provides a mock function for the type mockDoer
+func (_mock *mockDoer) Do(request *http.Request) (*http.Response, error) {
+	ret := _mock.Called(request)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Do")
+	}
+
+	var r0 *http.Response
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(*http.Request) (*http.Response, error)); ok {
+		return returnFunc(request)
+	}
+	if returnFunc, ok := ret.Get(0).(func(*http.Request) *http.Response); ok {
+		r0 = returnFunc(request)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*http.Response)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(*http.Request) error); ok {
+		r1 = returnFunc(request)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockDoer_Do_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Do'
+type mockDoer_Do_Call struct {
+	*mock.Call
+}
+
+// Do is a helper method to define mock.On call
+//   - request *http.Request
+func (_e *mockDoer_Expecter) Do(request interface{}) *mockDoer_Do_Call {
+	return &mockDoer_Do_Call{Call: _e.mock.On("Do", request)}
+}
+
+func (_c *mockDoer_Do_Call) Run(run func(request *http.Request)) *mockDoer_Do_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 *http.Request
+		if args[0] != nil {
+			arg0 = args[0].(*http.Request)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *mockDoer_Do_Call) Return(response *http.Response, err error) *mockDoer_Do_Call {
+	_c.Call.Return(response, err)
+	return _c
+}
+
+func (_c *mockDoer_Do_Call) RunAndReturn(run func(request *http.Request) (*http.Response, error)) *mockDoer_Do_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// newMockSecretsClient creates a new instance of mockSecretsClient. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newMockSecretsClient(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *mockSecretsClient {
+	mock := &mockSecretsClient{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// mockSecretsClient is an autogenerated mock type for the SecretsClient type
+type mockSecretsClient struct {
+	mock.Mock
+}
+
+type mockSecretsClient_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *mockSecretsClient) EXPECT() *mockSecretsClient_Expecter {
+	return &mockSecretsClient_Expecter{mock: &_m.Mock}
+}
+
+// GetSecretValue provides a mock function for the type mockSecretsClient
+func (_mock *mockSecretsClient) GetSecretValue(context1 context.Context, getSecretValueInput *secretsmanager.GetSecretValueInput, fns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+	// func(*secretsmanager.Options)
+	_va := make([]interface{}, len(fns))
+	for _i := range fns {
+		_va[_i] = fns[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, context1, getSecretValueInput)
+	_ca = append(_ca, _va...)
+	ret := _mock.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSecretValue")
+	}
+
+	var r0 *secretsmanager.GetSecretValueOutput
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *secretsmanager.GetSecretValueInput, ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)); ok {
+		return returnFunc(context1, getSecretValueInput, fns...)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *secretsmanager.GetSecretValueInput, ...func(*secretsmanager.Options)) *secretsmanager.GetSecretValueOutput); ok {
+		r0 = returnFunc(context1, getSecretValueInput, fns...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*secretsmanager.GetSecretValueOutput)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, *secretsmanager.GetSecretValueInput, ...func(*secretsmanager.Options)) error); ok {
+		r1 = returnFunc(context1, getSecretValueInput, fns...)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockSecretsClient_GetSecretValue_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetSecretValue'
+type mockSecretsClient_GetSecretValue_Call struct {
+	*mock.Call
+}
+
+// GetSecretValue is a helper method to define mock.On call
+//   - context1 context.Context
+//   - getSecretValueInput *secretsmanager.GetSecretValueInput
+//   - fns ...func(*secretsmanager.Options)
+func (_e *mockSecretsClient_Expecter) GetSecretValue(context1 interface{}, getSecretValueInput interface{}, fns ...interface{}) *mockSecretsClient_GetSecretValue_Call {
+	return &mockSecretsClient_GetSecretValue_Call{Call: _e.mock.On("GetSecretValue",
+		append([]interface{}{context1, getSecretValueInput}, fns...)...)}
+}
+
+func (_c *mockSecretsClient_GetSecretValue_Call) Run(run func(context1 context.Context, getSecretValueInput *secretsmanager.GetSecretValueInput, fns ...func(*secretsmanager.Options))) *mockSecretsClient_GetSecretValue_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 *secretsmanager.GetSecretValueInput
+		if args[1] != nil {
+			arg1 = args[1].(*secretsmanager.GetSecretValueInput)
+		}
+		var arg2 []func(*secretsmanager.Options)
+		variadicArgs := make([]func(*secretsmanager.Options), len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(func(*secretsmanager.Options))
+			}
+		}
+		arg2 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2...,
+		)
+	})
+	return _c
+}
+
+func (_c *mockSecretsClient_GetSecretValue_Call) Return(getSecretValueOutput *secretsmanager.GetSecretValueOutput, err error) *mockSecretsClient_GetSecretValue_Call {
+	_c.Call.Return(getSecretValueOutput, err)
+	return _c
+}
+
+func (_c *mockSecretsClient_GetSecretValue_Call) RunAndReturn(run func(context1 context.Context, getSecretValueInput *secretsmanager.GetSecretValueInput, fns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)) *mockSecretsClient_GetSecretValue_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// newMockResolver creates a new instance of mockResolver. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newMockResolver(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *mockResolver {
+	mock := &mockResolver{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// mockResolver is an autogenerated mock type for the Resolver type
+type mockResolver struct {
+	mock.Mock
+}
+
+type mockResolver_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *mockResolver) EXPECT() *mockResolver_Expecter {
+	return &mockResolver_Expecter{mock: &_m.Mock}
+}
+
+// LookupNetIP provides a mock function for the type mockResolver
+func (_mock *mockResolver) LookupNetIP(ctx context.Context, network string, host string) ([]netip.Addr, error) {
+	ret := _mock.Called(ctx, network, host)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LookupNetIP")
+	}
+
+	var r0 []netip.Addr
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) ([]netip.Addr, error)); ok {
+		return returnFunc(ctx, network, host)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) []netip.Addr); ok {
+		r0 = returnFunc(ctx, network, host)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]netip.Addr)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = returnFunc(ctx, network, host)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockResolver_LookupNetIP_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'LookupNetIP'
+type mockResolver_LookupNetIP_Call struct {
+	*mock.Call
+}
+
+// LookupNetIP is a helper method to define mock.On call
+//   - ctx context.Context
+//   - network string
+//   - host string
+func (_e *mockResolver_Expecter) LookupNetIP(ctx interface{}, network interface{}, host interface{}) *mockResolver_LookupNetIP_Call {
+	return &mockResolver_LookupNetIP_Call{Call: _e.mock.On("LookupNetIP", ctx, network, host)}
+}
+
+func (_c *mockResolver_LookupNetIP_Call) Run(run func(ctx context.Context, network string, host string)) *mockResolver_LookupNetIP_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *mockResolver_LookupNetIP_Call) Return(addrs []netip.Addr, err error) *mockResolver_LookupNetIP_Call {
+	_c.Call.Return(addrs, err)
+	return _c
+}
+
+func (_c *mockResolver_LookupNetIP_Call) RunAndReturn(run func(ctx context.Context, network string, host string) ([]netip.Addr, error)) *mockResolver_LookupNetIP_Call {
+	_c.Call.Return(run)
+	return _c
+}
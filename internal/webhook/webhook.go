@@ -0,0 +1,195 @@
+// Package webhook delivers changes to LPAs to services that have subscribed
+// with a callback URL, for those that cannot consume events from EventBridge.
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+)
+
+// Headers sent with each delivery. The signature is of the timestamp and body,
+// so that a receiver can reject old deliveries being replayed.
+const (
+	SignatureHeader = "X-Lpa-Store-Signature"
+	TimestampHeader = "X-Lpa-Store-Timestamp"
+	DeliveryHeader  = "X-Lpa-Store-Delivery"
+)
+
+// minSecretLength is the fewest bytes a subscription's secret can have
+const minSecretLength = 32
+
+// errNotPublic is returned when a callback host is, or resolves to, an address
+// that is not on the public internet, so that subscriptions cannot be used to
+// reach services inside the VPC.
+var errNotPublic = errors.New("callback host must be public")
+
+// nonPublicPrefixes are the special-purpose ranges that netip does not
+// already classify as private, loopback, link-local or multicast.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// Subscription is a request to be sent the updates made to LPAs. Empty
+// UpdateTypes or Statuses match any; Statuses are matched against the status
+// of the LPA after the update. The secret deliveries are signed with is kept
+// in Secrets Manager, under SecretARN.
+type Subscription struct {
+	Id          string             `json:"id"`
+	CallbackURL string             `json:"callbackUrl"`
+	UpdateTypes []string           `json:"updateTypes,omitempty"`
+	Statuses    []shared.LpaStatus `json:"statuses,omitempty"`
+	SecretARN   string             `json:"secretArn"`
+	CreatedAt   time.Time          `json:"createdAt"`
+}
+
+// Validate checks that the subscription can be delivered to. Callback URLs
+// must use https, and must not be to localhost or an address that is not
+// public. Hostnames are checked with ValidateCallbackHost.
+func (s Subscription) Validate() error {
+	if s.Id == "" {
+		return errors.New("subscription id is required")
+	}
+
+	u, err := url.Parse(s.CallbackURL)
+	if err != nil || !u.IsAbs() || u.Host == "" {
+		return errors.New("callback url must be an absolute url")
+	}
+
+	if u.Scheme != "https" {
+		return errors.New("callback url must use https")
+	}
+
+	if host := u.Hostname(); host == "localhost" {
+		return errNotPublic
+	} else if addr, err := netip.ParseAddr(host); err == nil && !isPublic(addr) {
+		return errNotPublic
+	}
+
+	if a, err := arn.Parse(s.SecretARN); err != nil || a.Service != "secretsmanager" {
+		return errors.New("secret arn must be the arn of a secrets manager secret")
+	}
+
+	for _, status := range s.Statuses {
+		if !status.IsValid() {
+			return errors.New("invalid status " + string(status))
+		}
+	}
+
+	return nil
+}
+
+// Resolver looks up the addresses of a host, as net.Resolver does.
+type Resolver interface {
+	LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error)
+}
+
+// ValidateCallbackHost checks that every address the callback URL's host
+// resolves to is public. The addresses are checked again when delivering, as
+// they may change.
+func (s Subscription) ValidateCallbackHost(ctx context.Context, resolver Resolver) error {
+	u, err := url.Parse(s.CallbackURL)
+	if err != nil {
+		return err
+	}
+
+	addrs, err := resolver.LookupNetIP(ctx, "ip", u.Hostname())
+	if err != nil {
+		return fmt.Errorf("resolving callback host: %w", err)
+	}
+
+	for _, addr := range addrs {
+		if !isPublic(addr) {
+			return errNotPublic
+		}
+	}
+
+	return nil
+}
+
+// isPublic reports whether addr is on the public internet.
+func isPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Matches reports whether an update of updateType, leaving the LPA with
+// status, should be delivered to the subscription.
+func (s Subscription) Matches(updateType string, status shared.LpaStatus) bool {
+	return (len(s.UpdateTypes) == 0 || slices.Contains(s.UpdateTypes, updateType)) &&
+		(len(s.Statuses) == 0 || slices.Contains(s.Statuses, status))
+}
+
+// Payload is the body of a delivery. DeliveryId is the same each time an
+// update is delivered to a subscription, so receivers can ignore repeats.
+type Payload struct {
+	DeliveryId     string           `json:"deliveryId"`
+	SubscriptionId string           `json:"subscriptionId"`
+	Status         shared.LpaStatus `json:"status"`
+	Update         shared.Update    `json:"update"`
+}
+
+// NewPayload creates the payload delivering update to the subscription.
+func NewPayload(subscription Subscription, update shared.Update, status shared.LpaStatus) Payload {
+	return Payload{
+		DeliveryId:     update.Id + "/" + subscription.Id,
+		SubscriptionId: subscription.Id,
+		Status:         status,
+		Update:         update,
+	}
+}
+
+// DeadLetter records a delivery that could not be made.
+type DeadLetter struct {
+	DeliveryId     string          `json:"deliveryId"`
+	SubscriptionId string          `json:"subscriptionId"`
+	CallbackURL    string          `json:"callbackUrl"`
+	Payload        json.RawMessage `json:"payload"`
+	Attempts       int             `json:"attempts"`
+	LastError      string          `json:"lastError"`
+	FailedAt       time.Time       `json:"failedAt"`
+}
+
+// Sign returns the signature of a delivery, as sent in the SignatureHeader.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is valid for the delivery, for use by
+// receivers.
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
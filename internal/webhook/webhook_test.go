@@ -0,0 +1,224 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/netip"
+	"testing"
+
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testSecret    = "a-secret-that-is-long-enough-to-use"
+	testSecretARN = "arn:aws:secretsmanager:eu-west-1:123456789012:secret:webhooks/a-subscription-AbCdEf"
+)
+
+func TestSubscriptionValidate(t *testing.T) {
+	testcases := map[string]string{
+		"https":     "https://example.com/hook",
+		"public ip": "https://93.184.216.34/hook",
+	}
+
+	for name, callbackURL := range testcases {
+		t.Run(name, func(t *testing.T) {
+			err := Subscription{
+				Id:          "a-subscription",
+				CallbackURL: callbackURL,
+				Statuses:    []shared.LpaStatus{shared.LpaStatusRegistered},
+				SecretARN:   testSecretARN,
+			}.Validate()
+
+			assert.Nil(t, err)
+		})
+	}
+}
+
+func TestSubscriptionValidateWhenInvalid(t *testing.T) {
+	valid := Subscription{Id: "a-subscription", CallbackURL: "https://example.com/hook", SecretARN: testSecretARN}
+
+	testcases := map[string]struct {
+		subscription Subscription
+		err          string
+	}{
+		"missing id": {
+			subscription: Subscription{CallbackURL: valid.CallbackURL, SecretARN: valid.SecretARN},
+			err:          "subscription id is required",
+		},
+		"relative url": {
+			subscription: Subscription{Id: valid.Id, CallbackURL: "/hook", SecretARN: valid.SecretARN},
+			err:          "callback url must be an absolute url",
+		},
+		"http": {
+			subscription: Subscription{Id: valid.Id, CallbackURL: "http://example.com/hook", SecretARN: valid.SecretARN},
+			err:          "callback url must use https",
+		},
+		"localhost": {
+			subscription: Subscription{Id: valid.Id, CallbackURL: "https://localhost:8080/hook", SecretARN: valid.SecretARN},
+			err:          "callback host must be public",
+		},
+		"loopback": {
+			subscription: Subscription{Id: valid.Id, CallbackURL: "https://127.0.0.1:8080/hook", SecretARN: valid.SecretARN},
+			err:          "callback host must be public",
+		},
+		"private ip": {
+			subscription: Subscription{Id: valid.Id, CallbackURL: "https://10.0.1.2/hook", SecretARN: valid.SecretARN},
+			err:          "callback host must be public",
+		},
+		"metadata ip": {
+			subscription: Subscription{Id: valid.Id, CallbackURL: "https://169.254.169.254/hook", SecretARN: valid.SecretARN},
+			err:          "callback host must be public",
+		},
+		"missing secret arn": {
+			subscription: Subscription{Id: valid.Id, CallbackURL: valid.CallbackURL},
+			err:          "secret arn must be the arn of a secrets manager secret",
+		},
+		"not a secret arn": {
+			subscription: Subscription{Id: valid.Id, CallbackURL: valid.CallbackURL, SecretARN: "arn:aws:s3:::a-bucket"},
+			err:          "secret arn must be the arn of a secrets manager secret",
+		},
+		"invalid status": {
+			subscription: Subscription{Id: valid.Id, CallbackURL: valid.CallbackURL, SecretARN: valid.SecretARN, Statuses: []shared.LpaStatus{"what"}},
+			err:          "invalid status what",
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			assert.EqualError(t, tc.subscription.Validate(), tc.err)
+		})
+	}
+}
+
+func TestSubscriptionValidateCallbackHost(t *testing.T) {
+	testcases := map[string]struct {
+		addrs []netip.Addr
+		err   error
+	}{
+		"public": {
+			addrs: []netip.Addr{netip.MustParseAddr("93.184.216.34"), netip.MustParseAddr("2606:2800:220:1::1")},
+		},
+		"one private": {
+			addrs: []netip.Addr{netip.MustParseAddr("93.184.216.34"), netip.MustParseAddr("10.0.1.2")},
+			err:   errNotPublic,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			resolver := newMockResolver(t)
+			resolver.EXPECT().
+				LookupNetIP(context.Background(), "ip", "example.com").
+				Return(tc.addrs, nil)
+
+			err := Subscription{CallbackURL: "https://example.com/hook"}.ValidateCallbackHost(context.Background(), resolver)
+			assert.Equal(t, tc.err, err)
+		})
+	}
+}
+
+func TestSubscriptionValidateCallbackHostWhenResolverErrors(t *testing.T) {
+	expectedError := errors.New("hmm")
+
+	resolver := newMockResolver(t)
+	resolver.EXPECT().
+		LookupNetIP(context.Background(), "ip", "example.com").
+		Return(nil, expectedError)
+
+	err := Subscription{CallbackURL: "https://example.com/hook"}.ValidateCallbackHost(context.Background(), resolver)
+	assert.ErrorIs(t, err, expectedError)
+}
+
+func TestIsPublic(t *testing.T) {
+	testcases := map[string]bool{
+		"93.184.216.34":        true,
+		"2606:2800:220:1::1":   true,
+		"::ffff:93.184.216.34": true,
+		"127.0.0.1":            false,
+		"10.0.1.2":             false,
+		"172.16.0.1":           false,
+		"192.168.1.1":          false,
+		"169.254.169.254":      false,
+		"100.64.0.1":           false,
+		"0.0.0.0":              false,
+		"255.255.255.255":      false,
+		"224.0.0.1":            false,
+		"::1":                  false,
+		"fd00:ec2::254":        false,
+		"fe80::1":              false,
+		"::ffff:10.0.1.2":      false,
+		"64:ff9b::a00:102":     false,
+	}
+
+	for addr, public := range testcases {
+		t.Run(addr, func(t *testing.T) {
+			assert.Equal(t, public, isPublic(netip.MustParseAddr(addr)))
+		})
+	}
+}
+
+func TestSubscriptionMatches(t *testing.T) {
+	testcases := map[string]struct {
+		subscription Subscription
+		matches      bool
+	}{
+		"any": {
+			subscription: Subscription{},
+			matches:      true,
+		},
+		"update type": {
+			subscription: Subscription{UpdateTypes: []string{"CORRECTION", "REGISTER"}},
+			matches:      true,
+		},
+		"status": {
+			subscription: Subscription{Statuses: []shared.LpaStatus{shared.LpaStatusRegistered}},
+			matches:      true,
+		},
+		"both": {
+			subscription: Subscription{UpdateTypes: []string{"REGISTER"}, Statuses: []shared.LpaStatus{shared.LpaStatusRegistered}},
+			matches:      true,
+		},
+		"other update type": {
+			subscription: Subscription{UpdateTypes: []string{"CORRECTION"}},
+		},
+		"other status": {
+			subscription: Subscription{UpdateTypes: []string{"REGISTER"}, Statuses: []shared.LpaStatus{shared.LpaStatusWithdrawn}},
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.matches, tc.subscription.Matches("REGISTER", shared.LpaStatusRegistered))
+		})
+	}
+}
+
+func TestNewPayload(t *testing.T) {
+	update := shared.Update{Id: "an-update", Uid: "M-1111-2222-3333", Type: "REGISTER"}
+
+	payload := NewPayload(Subscription{Id: "a-subscription"}, update, shared.LpaStatusRegistered)
+
+	assert.Equal(t, Payload{
+		DeliveryId:     "an-update/a-subscription",
+		SubscriptionId: "a-subscription",
+		Status:         shared.LpaStatusRegistered,
+		Update:         update,
+	}, payload)
+
+	_, err := json.Marshal(payload)
+	assert.Nil(t, err)
+}
+
+func TestSignAndVerify(t *testing.T) {
+	body := []byte(`{"deliveryId":"a"}`)
+
+	signature := Sign(testSecret, 1700000000, body)
+
+	assert.Regexp(t, "^sha256=[0-9a-f]{64}$", signature)
+	assert.True(t, Verify(testSecret, 1700000000, body, signature))
+	assert.False(t, Verify(testSecret, 1700000001, body, signature))
+	assert.False(t, Verify(testSecret, 1700000000, []byte(`{"deliveryId":"b"}`), signature))
+	assert.False(t, Verify("another-secret-that-is-long-enough", 1700000000, body, signature))
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/ddb"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/event"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/tracing"
+	"github.com/ministryofjustice/opg-go-common/telemetry"
+)
+
+type EventClient interface {
+	SendChangeEvents(ctx context.Context, changeEvents []event.ChangeEvent) error
+}
+
+type Logger interface {
+	ErrorContext(context.Context, string, ...any)
+	WarnContext(context.Context, string, ...any)
+	InfoContext(context.Context, string, ...any)
+	DebugContext(context.Context, string, ...any)
+}
+
+type Store interface {
+	Get(ctx context.Context, uid string) (shared.Lpa, error)
+}
+
+type Lambda struct {
+	eventClient EventClient
+	store       Store
+	logger      Logger
+}
+
+// HandleEvent sends change events for the updates inserted in the stream
+// records. If the events for an update cannot be sent then the records from it
+// onwards are reported as failed, so that they are retried in order.
+func (l *Lambda) HandleEvent(ctx context.Context, e events.DynamoDBEvent) (events.DynamoDBEventResponse, error) {
+	for _, record := range e.Records {
+		if record.EventName != string(events.DynamoDBOperationTypeInsert) {
+			continue
+		}
+
+		ctx := shared.ContextWithCorrelationID(ctx, record.Change.SequenceNumber)
+
+		if err := l.sendChangeEvents(ctx, record.Change.NewImage); err != nil {
+			l.logger.ErrorContext(ctx, "error sending change events", slog.String("sequenceNumber", record.Change.SequenceNumber), slog.Any("err", err))
+
+			return events.DynamoDBEventResponse{
+				BatchItemFailures: []events.DynamoDBBatchItemFailure{{ItemIdentifier: record.Change.SequenceNumber}},
+			}, nil
+		}
+	}
+
+	return events.DynamoDBEventResponse{}, nil
+}
+
+func (l *Lambda) sendChangeEvents(ctx context.Context, image map[string]events.DynamoDBAttributeValue) error {
+	update, err := ddb.UnmarshalUpdate(image)
+	if err != nil {
+		return err
+	}
+
+	// the LPA is only needed to find actor UIDs, which do not change, so it
+	// does not matter if it has been updated again since
+	lpa, err := l.store.Get(ctx, update.Uid)
+	if err != nil {
+		return err
+	}
+
+	changeEvents := event.NewChangeEvents(update, lpa)
+	if len(changeEvents) == 0 {
+		return nil
+	}
+
+	l.logger.DebugContext(ctx, "sending change events", slog.String("uid", update.Uid), slog.String("updateId", update.Id), slog.Int("count", len(changeEvents)))
+
+	return l.eventClient.SendChangeEvents(ctx, changeEvents)
+}
+
+func main() {
+	ctx := context.Background()
+	logger := shared.NewRequestLogger(telemetry.NewLogger("opg-data-lpa-store/changeevents"))
+
+	// set endpoint to "" outside dev to use default AWS resolver
+	endpointURL := os.Getenv("AWS_BASE_URL")
+
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		logger.Error("failed to load aws config", slog.Any("err", err))
+	}
+
+	if endpointURL != "" {
+		cfg.BaseEndpoint = aws.String(endpointURL)
+	}
+
+	tp, err := tracing.Start(ctx, "opg-data-lpa-store/changeevents")
+	if err != nil {
+		logger.Error("failed to start tracing", slog.Any("err", err))
+	}
+
+	var eventClient EventClient = event.NewClient(cfg, os.Getenv("EVENT_BUS_NAME"))
+	if os.Getenv("EVENT_FORMAT") == "cloudevents" {
+		// EVENT_QUEUE_URL sends to an SQS queue instead of the event bus
+		eventClient = event.NewCloudEventsPublisher(event.NewTransport(cfg, os.Getenv("EVENT_BUS_NAME"), os.Getenv("EVENT_QUEUE_URL")))
+	}
+
+	l := &Lambda{
+		eventClient: eventClient,
+		store: ddb.New(
+			cfg,
+			os.Getenv("DDB_TABLE_NAME_DEEDS"),
+			os.Getenv("DDB_TABLE_NAME_CHANGES"),
+			os.Getenv("DDB_TABLE_NAME_ACTORS"),
+			os.Getenv("DDB_TABLE_NAME_IDEMPOTENCY"),
+			os.Getenv("DDB_TABLE_NAME_OUTBOX"),
+		),
+		logger: logger,
+	}
+
+	lambda.Start(func(ctx context.Context, e events.DynamoDBEvent) (events.DynamoDBEventResponse, error) {
+		defer func() { _ = tp.ForceFlush(ctx) }()
+
+		return l.HandleEvent(ctx, e)
+	})
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/event"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var (
+	ctx         = context.WithValue(context.Background(), (*string)(nil), "testing")
+	recordCtx   = mock.MatchedBy(func(c context.Context) bool { return c.Value((*string)(nil)) == "testing" })
+	errExpected = errors.New("expect")
+)
+
+func insertRecord(uid, updateType, sequenceNumber string) events.DynamoDBEventRecord {
+	return events.DynamoDBEventRecord{
+		EventName: string(events.DynamoDBOperationTypeInsert),
+		Change: events.DynamoDBStreamRecord{
+			NewImage: map[string]events.DynamoDBAttributeValue{
+				"id":      events.NewStringAttribute("an-id"),
+				"uid":     events.NewStringAttribute(uid),
+				"applied": events.NewStringAttribute("2024-01-02T12:13:14.000000015Z"),
+				"author":  events.NewStringAttribute("urn:opg:poas:sirius:users:1"),
+				"type":    events.NewStringAttribute(updateType),
+				"changes": events.NewListAttribute(nil),
+			},
+			SequenceNumber: sequenceNumber,
+		},
+	}
+}
+
+func TestLambdaHandleEvent(t *testing.T) {
+	lpa := shared.Lpa{Uid: "M-1", LpaInit: shared.LpaInit{Donor: shared.Donor{Person: shared.Person{UID: "donor"}}}}
+
+	store := newMockStore(t)
+	store.EXPECT().
+		Get(recordCtx, "M-1").
+		Return(lpa, nil)
+	store.EXPECT().
+		Get(recordCtx, "M-2").
+		Return(shared.Lpa{Uid: "M-2"}, nil)
+
+	eventClient := newMockEventClient(t)
+	eventClient.EXPECT().
+		SendChangeEvents(recordCtx, []event.ChangeEvent{{
+			DetailType: event.DetailTypeLpaRegistered,
+			Detail: event.LpaRegistered{LpaChange: event.LpaChange{
+				Uid:        "M-1",
+				UpdateId:   "an-id",
+				UpdateType: "REGISTER",
+				Applied:    "2024-01-02T12:13:14.000000015Z",
+				Author:     "urn:opg:poas:sirius:users:1",
+				Changes:    []shared.Change{},
+				ActorUIDs:  []string{"donor"},
+			}},
+		}}).
+		Return(nil)
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "sending change events", slog.String("uid", "M-1"), slog.String("updateId", "an-id"), slog.Int("count", 1))
+
+	l := &Lambda{
+		eventClient: eventClient,
+		store:       store,
+		logger:      logger,
+	}
+
+	resp, err := l.HandleEvent(ctx, events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{
+		insertRecord("M-1", "REGISTER", "1"),
+		{EventName: string(events.DynamoDBOperationTypeRemove)},
+		insertRecord("M-2", "CORRECTION", "3"),
+	}})
+	assert.Nil(t, err)
+	assert.Equal(t, events.DynamoDBEventResponse{}, resp)
+}
+
+func TestLambdaHandleEventWhenInvalidImage(t *testing.T) {
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		ErrorContext(mock.Anything, "error sending change events", slog.String("sequenceNumber", "1"), mock.Anything)
+
+	l := &Lambda{logger: logger}
+
+	resp, err := l.HandleEvent(ctx, events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{{
+		EventName: string(events.DynamoDBOperationTypeInsert),
+		Change: events.DynamoDBStreamRecord{
+			NewImage:       map[string]events.DynamoDBAttributeValue{"changes": events.NewBooleanAttribute(true)},
+			SequenceNumber: "1",
+		},
+	}}})
+	assert.Nil(t, err)
+	assert.Equal(t, events.DynamoDBEventResponse{
+		BatchItemFailures: []events.DynamoDBBatchItemFailure{{ItemIdentifier: "1"}},
+	}, resp)
+}
+
+func TestLambdaHandleEventWhenStoreErrors(t *testing.T) {
+	store := newMockStore(t)
+	store.EXPECT().
+		Get(recordCtx, "M-1").
+		Return(shared.Lpa{}, errExpected)
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		ErrorContext(mock.Anything, "error sending change events", slog.String("sequenceNumber", "1"), slog.Any("err", errExpected))
+
+	l := &Lambda{store: store, logger: logger}
+
+	resp, err := l.HandleEvent(ctx, events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{
+		insertRecord("M-1", "REGISTER", "1"),
+		insertRecord("M-2", "REGISTER", "2"),
+	}})
+	assert.Nil(t, err)
+	assert.Equal(t, events.DynamoDBEventResponse{
+		BatchItemFailures: []events.DynamoDBBatchItemFailure{{ItemIdentifier: "1"}},
+	}, resp)
+}
+
+func TestLambdaHandleEventWhenSendChangeEventsErrors(t *testing.T) {
+	store := newMockStore(t)
+	store.EXPECT().
+		Get(recordCtx, mock.Anything).
+		Return(shared.Lpa{}, nil)
+
+	eventClient := newMockEventClient(t)
+	eventClient.EXPECT().
+		SendChangeEvents(recordCtx, mock.Anything).
+		Return(nil).
+		Once()
+	eventClient.EXPECT().
+		SendChangeEvents(recordCtx, mock.Anything).
+		Return(errExpected).
+		Once()
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "sending change events", mock.Anything, mock.Anything, mock.Anything)
+	logger.EXPECT().
+		ErrorContext(mock.Anything, "error sending change events", slog.String("sequenceNumber", "2"), slog.Any("err", errExpected))
+
+	l := &Lambda{
+		eventClient: eventClient,
+		store:       store,
+		logger:      logger,
+	}
+
+	resp, err := l.HandleEvent(ctx, events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{
+		insertRecord("M-1", "REGISTER", "1"),
+		insertRecord("M-2", "REGISTER", "2"),
+		insertRecord("M-3", "REGISTER", "3"),
+	}})
+	assert.Nil(t, err)
+	assert.Equal(t, events.DynamoDBEventResponse{
+		BatchItemFailures: []events.DynamoDBBatchItemFailure{{ItemIdentifier: "2"}},
+	}, resp)
+}
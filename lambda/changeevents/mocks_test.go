@@ -0,0 +1,457 @@
+// Code generated by mockery; DO NOT EDIT.
+// github.com/vektra/mockery
+// template: testify
+
+package main
+
+import (
+	"context"
+
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/event"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// newMockEventClient creates a new instance of mockEventClient. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newMockEventClient(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *mockEventClient {
+	mock := &mockEventClient{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// mockEventClient is an autogenerated mock type for the EventClient type
+type mockEventClient struct {
+	mock.Mock
+}
+
+type mockEventClient_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *mockEventClient) EXPECT() *mockEventClient_Expecter {
+	return &mockEventClient_Expecter{mock: &_m.Mock}
+}
+
+// SendChangeEvents provides a mock function for the type mockEventClient
+func (_mock *mockEventClient) SendChangeEvents(ctx context.Context, changeEvents []event.ChangeEvent) error {
+	ret := _mock.Called(ctx, changeEvents)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SendChangeEvents")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []event.ChangeEvent) error); ok {
+		r0 = returnFunc(ctx, changeEvents)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// mockEventClient_SendChangeEvents_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SendChangeEvents'
+type mockEventClient_SendChangeEvents_Call struct {
+	*mock.Call
+}
+
+// SendChangeEvents is a helper method to define mock.On call
+//   - ctx context.Context
+//   - changeEvents []event.ChangeEvent
+func (_e *mockEventClient_Expecter) SendChangeEvents(ctx interface{}, changeEvents interface{}) *mockEventClient_SendChangeEvents_Call {
+	return &mockEventClient_SendChangeEvents_Call{Call: _e.mock.On("SendChangeEvents", ctx, changeEvents)}
+}
+
+func (_c *mockEventClient_SendChangeEvents_Call) Run(run func(ctx context.Context, changeEvents []event.ChangeEvent)) *mockEventClient_SendChangeEvents_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 []event.ChangeEvent
+		if args[1] != nil {
+			arg1 = args[1].([]event.ChangeEvent)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *mockEventClient_SendChangeEvents_Call) Return(err error) *mockEventClient_SendChangeEvents_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *mockEventClient_SendChangeEvents_Call) RunAndReturn(run func(ctx context.Context, changeEvents []event.ChangeEvent) error) *mockEventClient_SendChangeEvents_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// newMockLogger creates a new instance of mockLogger. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newMockLogger(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *mockLogger {
+	mock := &mockLogger{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// mockLogger is an autogenerated mock type for the Logger type
+type mockLogger struct {
+	mock.Mock
+}
+
+type mockLogger_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *mockLogger) EXPECT() *mockLogger_Expecter {
+	return &mockLogger_Expecter{mock: &_m.Mock}
+}
+
+// DebugContext provides a mock function for the type mockLogger
+func (_mock *mockLogger) DebugContext(context1 context.Context, s string, vs ...any) {
+	var _ca []interface{}
+	_ca = append(_ca, context1, s)
+	_ca = append(_ca, vs...)
+	_mock.Called(_ca...)
+	return
+}
+
+// mockLogger_DebugContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DebugContext'
+type mockLogger_DebugContext_Call struct {
+	*mock.Call
+}
+
+// DebugContext is a helper method to define mock.On call
+//   - context1 context.Context
+//   - s string
+//   - vs ...any
+func (_e *mockLogger_Expecter) DebugContext(context1 interface{}, s interface{}, vs ...interface{}) *mockLogger_DebugContext_Call {
+	return &mockLogger_DebugContext_Call{Call: _e.mock.On("DebugContext",
+		append([]interface{}{context1, s}, vs...)...)}
+}
+
+func (_c *mockLogger_DebugContext_Call) Run(run func(context1 context.Context, s string, vs ...any)) *mockLogger_DebugContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 []any
+		variadicArgs := make([]any, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(any)
+			}
+		}
+		arg2 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2...,
+		)
+	})
+	return _c
+}
+
+func (_c *mockLogger_DebugContext_Call) Return() *mockLogger_DebugContext_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *mockLogger_DebugContext_Call) RunAndReturn(run func(context1 context.Context, s string, vs ...any)) *mockLogger_DebugContext_Call {
+	_c.Run(run)
+	return _c
+}
+
+// ErrorContext provides a mock function for the type mockLogger
+func (_mock *mockLogger) ErrorContext(context1 context.Context, s string, vs ...any) {
+	var _ca []interface{}
+	_ca = append(_ca, context1, s)
+	_ca = append(_ca, vs...)
+	_mock.Called(_ca...)
+	return
+}
+
+// mockLogger_ErrorContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ErrorContext'
+type mockLogger_ErrorContext_Call struct {
+	*mock.Call
+}
+
+// ErrorContext is a helper method to define mock.On call
+//   - context1 context.Context
+//   - s string
+//   - vs ...any
+func (_e *mockLogger_Expecter) ErrorContext(context1 interface{}, s interface{}, vs ...interface{}) *mockLogger_ErrorContext_Call {
+	return &mockLogger_ErrorContext_Call{Call: _e.mock.On("ErrorContext",
+		append([]interface{}{context1, s}, vs...)...)}
+}
+
+func (_c *mockLogger_ErrorContext_Call) Run(run func(context1 context.Context, s string, vs ...any)) *mockLogger_ErrorContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 []any
+		variadicArgs := make([]any, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(any)
+			}
+		}
+		arg2 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2...,
+		)
+	})
+	return _c
+}
+
+func (_c *mockLogger_ErrorContext_Call) Return() *mockLogger_ErrorContext_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *mockLogger_ErrorContext_Call) RunAndReturn(run func(context1 context.Context, s string, vs ...any)) *mockLogger_ErrorContext_Call {
+	_c.Run(run)
+	return _c
+}
+
+// InfoContext provides a mock function for the type mockLogger
+func (_mock *mockLogger) InfoContext(context1 context.Context, s string, vs ...any) {
+	var _ca []interface{}
+	_ca = append(_ca, context1, s)
+	_ca = append(_ca, vs...)
+	_mock.Called(_ca...)
+	return
+}
+
+// mockLogger_InfoContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'InfoContext'
+type mockLogger_InfoContext_Call struct {
+	*mock.Call
+}
+
+// InfoContext is a helper method to define mock.On call
+//   - context1 context.Context
+//   - s string
+//   - vs ...any
+func (_e *mockLogger_Expecter) InfoContext(context1 interface{}, s interface{}, vs ...interface{}) *mockLogger_InfoContext_Call {
+	return &mockLogger_InfoContext_Call{Call: _e.mock.On("InfoContext",
+		append([]interface{}{context1, s}, vs...)...)}
+}
+
+func (_c *mockLogger_InfoContext_Call) Run(run func(context1 context.Context, s string, vs ...any)) *mockLogger_InfoContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 []any
+		variadicArgs := make([]any, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(any)
+			}
+		}
+		arg2 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2...,
+		)
+	})
+	return _c
+}
+
+func (_c *mockLogger_InfoContext_Call) Return() *mockLogger_InfoContext_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *mockLogger_InfoContext_Call) RunAndReturn(run func(context1 context.Context, s string, vs ...any)) *mockLogger_InfoContext_Call {
+	_c.Run(run)
+	return _c
+}
+
+// WarnContext provides a mock function for the type mockLogger
+func (_mock *mockLogger) WarnContext(context1 context.Context, s string, vs ...any) {
+	var _ca []interface{}
+	_ca = append(_ca, context1, s)
+	_ca = append(_ca, vs...)
+	_mock.Called(_ca...)
+	return
+}
+
+// mockLogger_WarnContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'WarnContext'
+type mockLogger_WarnContext_Call struct {
+	*mock.Call
+}
+
+// WarnContext is a helper method to define mock.On call
+//   - context1 context.Context
+//   - s string
+//   - vs ...any
+func (_e *mockLogger_Expecter) WarnContext(context1 interface{}, s interface{}, vs ...interface{}) *mockLogger_WarnContext_Call {
+	return &mockLogger_WarnContext_Call{Call: _e.mock.On("WarnContext",
+		append([]interface{}{context1, s}, vs...)...)}
+}
+
+func (_c *mockLogger_WarnContext_Call) Run(run func(context1 context.Context, s string, vs ...any)) *mockLogger_WarnContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 []any
+		variadicArgs := make([]any, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(any)
+			}
+		}
+		arg2 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2...,
+		)
+	})
+	return _c
+}
+
+func (_c *mockLogger_WarnContext_Call) Return() *mockLogger_WarnContext_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *mockLogger_WarnContext_Call) RunAndReturn(run func(context1 context.Context, s string, vs ...any)) *mockLogger_WarnContext_Call {
+	_c.Run(run)
+	return _c
+}
+
+// newMockStore creates a new instance of mockStore. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newMockStore(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *mockStore {
+	mock := &mockStore{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// mockStore is an autogenerated mock type for the Store type
+type mockStore struct {
+	mock.Mock
+}
+
+type mockStore_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *mockStore) EXPECT() *mockStore_Expecter {
+	return &mockStore_Expecter{mock: &_m.Mock}
+}
+
+// Get provides a mock function for the type mockStore
+func (_mock *mockStore) Get(ctx context.Context, uid string) (shared.Lpa, error) {
+	ret := _mock.Called(ctx, uid)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Get")
+	}
+
+	var r0 shared.Lpa
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (shared.Lpa, error)); ok {
+		return returnFunc(ctx, uid)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) shared.Lpa); ok {
+		r0 = returnFunc(ctx, uid)
+	} else {
+		r0 = ret.Get(0).(shared.Lpa)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, uid)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockStore_Get_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Get'
+type mockStore_Get_Call struct {
+	*mock.Call
+}
+
+// Get is a helper method to define mock.On call
+//   - ctx context.Context
+//   - uid string
+func (_e *mockStore_Expecter) Get(ctx interface{}, uid interface{}) *mockStore_Get_Call {
+	return &mockStore_Get_Call{Call: _e.mock.On("Get", ctx, uid)}
+}
+
+func (_c *mockStore_Get_Call) Run(run func(ctx context.Context, uid string)) *mockStore_Get_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *mockStore_Get_Call) Return(lpa shared.Lpa, err error) *mockStore_Get_Call {
+	_c.Call.Return(lpa, err)
+	return _c
+}
+
+func (_c *mockStore_Get_Call) RunAndReturn(run func(ctx context.Context, uid string) (shared.Lpa, error)) *mockStore_Get_Call {
+	_c.Call.Return(run)
+	return _c
+}
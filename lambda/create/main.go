@@ -3,10 +3,10 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
-	"strconv"
 	"time"
 
 	"github.com/aws/aws-lambda-go/events"
@@ -16,25 +16,24 @@ import (
 	"github.com/google/uuid"
 	"github.com/ministryofjustice/opg-data-lpa-store/internal/ddb"
 	"github.com/ministryofjustice/opg-data-lpa-store/internal/event"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/idempotency"
 	"github.com/ministryofjustice/opg-data-lpa-store/internal/objectstore"
 	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/tracing"
 	"github.com/ministryofjustice/opg-go-common/telemetry"
 )
 
-type EventClient interface {
-	SendLpaUpdated(ctx context.Context, event event.LpaUpdated, metric *event.Metric) error
-}
-
 type Logger interface {
-	Error(string, ...any)
-	Warn(string, ...any)
-	Info(string, ...any)
-	Debug(string, ...any)
+	ErrorContext(context.Context, string, ...any)
+	WarnContext(context.Context, string, ...any)
+	InfoContext(context.Context, string, ...any)
+	DebugContext(context.Context, string, ...any)
 }
 
 type Store interface {
-	Put(ctx context.Context, data any) error
+	Create(ctx context.Context, lpa shared.Lpa, update shared.Update, record *idempotency.Record, outbox event.OutboxEntry) error
 	Get(ctx context.Context, uid string) (shared.Lpa, error)
+	GetIdempotencyRecord(ctx context.Context, key string) (idempotency.Record, error)
 }
 
 type S3Client interface {
@@ -43,53 +42,70 @@ type S3Client interface {
 }
 
 type Verifier interface {
-	VerifyHeader(events.APIGatewayProxyRequest) (*shared.LpaStoreClaims, error)
+	VerifyHeader(context.Context, events.APIGatewayProxyRequest) (*shared.LpaStoreClaims, error)
 }
 
 type Lambda struct {
-	eventClient      EventClient
 	staticLpaStorage S3Client
 	store            Store
 	verifier         Verifier
 	logger           Logger
 	environment      string
 	now              func() time.Time
+	idempotencyTTL   time.Duration
 }
 
 func (l *Lambda) HandleEvent(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
 	uid := req.PathParameters["uid"]
 
-	_, err := l.verifier.VerifyHeader(req)
+	claims, err := l.verifier.VerifyHeader(ctx, req)
 	if err != nil {
-		l.logger.Info("Unable to verify JWT from header")
-		return shared.ProblemUnauthorisedRequest.Respond()
+		l.logger.InfoContext(ctx, "Unable to verify JWT from header")
+		return shared.ProblemUnauthorisedRequest.Respond(ctx)
 	}
 
-	l.logger.Debug("Successfully parsed JWT from event header")
+	l.logger.DebugContext(ctx, "Successfully parsed JWT from event header")
+
+	if !shared.Authorise(claims, shared.OperationCreate) {
+		l.logger.InfoContext(ctx, "JWT not permitted to make request")
+		return shared.ProblemForbidden.Respond(ctx)
+	}
 
 	response := events.APIGatewayProxyResponse{
 		StatusCode: 500,
 		Body:       "{\"code\":\"INTERNAL_SERVER_ERROR\",\"detail\":\"Internal server error\"}",
 	}
 
+	subject, _ := claims.GetSubject()
+	idempotencyKey := idempotency.Key(req, "create", subject)
+
+	record, problem := l.getIdempotencyRecord(ctx, idempotencyKey, req)
+	if problem != nil {
+		return problem.Respond(ctx)
+	}
+	if record != nil {
+		l.logger.InfoContext(ctx, "replaying response for Idempotency-Key", slog.String("uid", uid), slog.String("updateId", record.UpdateId))
+		return record.Replay(), nil
+	}
+
 	// check for existing Lpa
 	var existingLpa shared.Lpa
 	existingLpa, err = l.store.Get(ctx, uid)
 	if err != nil {
-		l.logger.Error("error fetching LPA", slog.Any("err", err))
-		return shared.ProblemInternalServerError.Respond()
+		l.logger.ErrorContext(ctx, "error fetching LPA", slog.Any("err", err))
+		return shared.ProblemInternalServerError.Respond(ctx)
 	}
 
 	if existingLpa.Uid == uid {
-		problem := shared.ProblemInvalidRequest
+		problem := shared.ProblemConflict
 		problem.Detail = "LPA with UID already exists"
-		return problem.Respond()
+		return problem.Respond(ctx)
 	}
 
 	var input shared.LpaInit
 	if err := json.Unmarshal([]byte(req.Body), &input); err != nil {
-		l.logger.Error("error unmarshalling request", slog.Any("err", err))
-		return shared.ProblemInternalServerError.Respond()
+		l.logger.ErrorContext(ctx, "error unmarshalling request", slog.Any("err", err))
+		return shared.ProblemInternalServerError.Respond(ctx)
 	}
 
 	input = SetDefaults(input)
@@ -97,12 +113,12 @@ func (l *Lambda) HandleEvent(ctx context.Context, req events.APIGatewayProxyRequ
 	// validation
 	if errs := Validate(input); len(errs) > 0 {
 		if input.Channel == shared.ChannelPaper {
-			l.logger.Info("encountered validation errors in lpa", slog.String("uid", uid))
+			l.logger.InfoContext(ctx, "encountered validation errors in lpa", slog.String("uid", uid))
 		} else {
 			problem := shared.ProblemInvalidRequest
 			problem.Errors = errs
 
-			return problem.Respond()
+			return problem.Respond(ctx)
 		}
 	}
 
@@ -111,17 +127,22 @@ func (l *Lambda) HandleEvent(ctx context.Context, req events.APIGatewayProxyRequ
 		Uid:       uid,
 		Status:    shared.LpaStatusInProgress,
 		UpdatedAt: l.now(),
+		Version:   1,
 	}
 
+	// scans are saved under the ID of this request's update, so that a request
+	// that loses a race to create the LPA cannot overwrite the winner's
+	updateId := uuid.NewString()
+
 	if data.Channel == shared.ChannelPaper && len(input.RestrictionsAndConditionsImages) > 0 {
 		data.RestrictionsAndConditionsImages = make([]shared.File, len(input.RestrictionsAndConditionsImages))
 		for i, image := range input.RestrictionsAndConditionsImages {
-			path := fmt.Sprintf("%s/scans/rc_%d_%s", data.Uid, i, image.Filename)
+			path := fmt.Sprintf("%s/scans/%s/rc_%d_%s", data.Uid, updateId, i, image.Filename)
 
 			data.RestrictionsAndConditionsImages[i], err = l.staticLpaStorage.UploadFile(ctx, image, path)
 			if err != nil {
-				l.logger.Error("error saving restrictions and conditions image", slog.Any("err", err))
-				return shared.ProblemInternalServerError.Respond()
+				l.logger.ErrorContext(ctx, "error saving restrictions and conditions image", slog.Any("err", err))
+				return shared.ProblemInternalServerError.Respond(ctx)
 			}
 		}
 	}
@@ -129,12 +150,12 @@ func (l *Lambda) HandleEvent(ctx context.Context, req events.APIGatewayProxyRequ
 	if data.Channel == shared.ChannelPaper && len(input.HowAttorneysMakeDecisionsDetailsImages) > 0 {
 		data.HowAttorneysMakeDecisionsDetailsImages = make([]shared.File, len(input.HowAttorneysMakeDecisionsDetailsImages))
 		for i, image := range input.HowAttorneysMakeDecisionsDetailsImages {
-			path := fmt.Sprintf("%s/scans/amd_%d_%s", data.Uid, i, image.Filename)
+			path := fmt.Sprintf("%s/scans/%s/amd_%d_%s", data.Uid, updateId, i, image.Filename)
 
 			data.HowAttorneysMakeDecisionsDetailsImages[i], err = l.staticLpaStorage.UploadFile(ctx, image, path)
 			if err != nil {
-				l.logger.Error("error saving how attorneys make decisions image", slog.Any("err", err))
-				return shared.ProblemInternalServerError.Respond()
+				l.logger.ErrorContext(ctx, "error saving how attorneys make decisions image", slog.Any("err", err))
+				return shared.ProblemInternalServerError.Respond(ctx)
 			}
 		}
 	}
@@ -174,52 +195,102 @@ func (l *Lambda) HandleEvent(ctx context.Context, req events.APIGatewayProxyRequ
 		data.AuthorisedSignatory.UID = uuid.NewString()
 	}
 
+	// record the LPA as created in full, so the changes have a starting point
+	snapshot, err := json.Marshal(data)
+	if err != nil {
+		l.logger.ErrorContext(ctx, "error marshalling LPA", slog.Any("err", err))
+		return shared.ProblemInternalServerError.Respond(ctx)
+	}
+
+	update := shared.Update{
+		Id:      updateId,
+		Uid:     uid,
+		Applied: l.now().UTC().Format(shared.AppliedFormat),
+		Author:  shared.URN(subject),
+		Type:    "CREATE",
+		Changes: []shared.Change{{Key: "", Old: json.RawMessage("null"), New: snapshot}},
+	}
+
+	response.StatusCode = 201
+	response.Body = `{}`
+
+	if idempotencyKey != "" {
+		newRecord := idempotency.NewRecord(idempotencyKey, req, update.Id, response, l.now(), l.idempotencyTTL)
+		record = &newRecord
+	}
+
+	// the lpa-updated event is sent by the outbox relay once saved
+	metrics := event.NewMetrics(l.environment, l.now(), event.FunnelCompletion(event.PartyDonor, data.Channel))
+	outbox := event.NewOutboxEntry(ctx, update.Id, event.NewLpaUpdated(update, "", data), metrics, l.now())
+
 	// save
-	if err := l.store.Put(ctx, data); err != nil {
-		l.logger.Error("error saving LPA", slog.Any("err", err))
-		return shared.ProblemInternalServerError.Respond()
+	if err := l.store.Create(ctx, data, update, record, outbox); err != nil {
+		if errors.Is(err, ddb.ErrConditionFailed) {
+			// a retry with the same Idempotency-Key may have been saved first
+			if existing, problem := l.getIdempotencyRecord(ctx, idempotencyKey, req); problem != nil {
+				return problem.Respond(ctx)
+			} else if existing != nil {
+				l.logger.InfoContext(ctx, "replaying response for Idempotency-Key", slog.String("uid", uid), slog.String("updateId", existing.UpdateId))
+				return existing.Replay(), nil
+			}
+
+			l.logger.InfoContext(ctx, "LPA with UID was created by another request", slog.String("uid", uid))
+			problem := shared.ProblemConflict
+			problem.Detail = "LPA with UID already exists"
+			return problem.Respond(ctx)
+		}
+
+		if errors.Is(err, ddb.ErrTooManyItems) {
+			l.logger.InfoContext(ctx, "too many items to save at once", slog.String("uid", uid))
+			problem := shared.ProblemInvalidRequest
+			problem.Detail = "LPA has too many actors to save"
+			return problem.Respond(ctx)
+		}
+
+		l.logger.ErrorContext(ctx, "error saving LPA", slog.Any("err", err))
+		return shared.ProblemInternalServerError.Respond(ctx)
 	}
 
 	// save to static storage as JSON
 	objectKey := fmt.Sprintf("%s/donor-executed-lpa.json", data.Uid)
 
 	if err := l.staticLpaStorage.Put(ctx, objectKey, data); err != nil {
-		l.logger.Error("error saving static record", slog.Any("err", err))
-		return shared.ProblemInternalServerError.Respond()
+		l.logger.ErrorContext(ctx, "error saving static record", slog.Any("err", err))
+		return shared.ProblemInternalServerError.Respond(ctx)
 	}
 
-	measureName := "ONLINEDONOR"
-	if data.Channel == shared.ChannelPaper {
-		measureName = "PAPERDONOR"
+	return response, nil
+}
+
+// getIdempotencyRecord returns the record of the response already given for
+// the Idempotency-Key, if there is one. A problem is returned if the key was
+// used for a different request.
+func (l *Lambda) getIdempotencyRecord(ctx context.Context, key string, req events.APIGatewayProxyRequest) (*idempotency.Record, *shared.Problem) {
+	if key == "" {
+		return nil, nil
 	}
 
-	// send lpa-updated event
-	if err := l.eventClient.SendLpaUpdated(ctx, event.LpaUpdated{
-		Uid:        uid,
-		ChangeType: "CREATE",
-	}, &event.Metric{
-		Project:          "MRLPA",
-		Category:         "metric",
-		Subcategory:      "FunnelCompletionRate",
-		Environment:      l.environment,
-		MeasureName:      measureName,
-		MeasureValue:     "1",
-		MeasureValueType: "BIGINT",
-		Time:             strconv.FormatInt(l.now().UnixMilli(), 10),
-	}); err != nil {
-		l.logger.Error("unexpected error occurred", slog.Any("err", err))
+	record, err := l.store.GetIdempotencyRecord(ctx, key)
+	if err != nil {
+		l.logger.ErrorContext(ctx, "error fetching idempotency record", slog.Any("err", err))
+		return nil, &shared.ProblemInternalServerError
 	}
 
-	// respond
-	response.StatusCode = 201
-	response.Body = `{}`
+	if !record.Live(l.now()) {
+		return nil, nil
+	}
 
-	return response, nil
+	if !record.Matches(req) {
+		l.logger.InfoContext(ctx, "Idempotency-Key reused for a different request", slog.String("uid", req.PathParameters["uid"]))
+		return nil, &shared.ProblemIdempotencyKeyReused
+	}
+
+	return &record, nil
 }
 
 func main() {
 	ctx := context.Background()
-	logger := telemetry.NewLogger("opg-data-lpa-store/create")
+	logger := shared.NewRequestLogger(telemetry.NewLogger("opg-data-lpa-store/create"))
 
 	// set endpoint to "" outside dev to use default AWS resolver
 	endpointURL := os.Getenv("AWS_BASE_URL")
@@ -233,22 +304,40 @@ func main() {
 		cfg.BaseEndpoint = aws.String(endpointURL)
 	}
 
+	idempotencyTTL, err := idempotency.ParseTTL(os.Getenv("IDEMPOTENCY_KEY_TTL"))
+	if err != nil {
+		logger.Error("invalid idempotency key TTL, using default", slog.Any("err", err))
+	}
+
+	tp, err := tracing.Start(ctx, "opg-data-lpa-store/create")
+	if err != nil {
+		logger.Error("failed to start tracing", slog.Any("err", err))
+	}
+
+	verifier := shared.NewJWTVerifier(cfg, logger)
+	if tableName := os.Getenv("DDB_TABLE_NAME_TOKENS"); tableName != "" {
+		verifier.Tokens = ddb.NewTokenClient(cfg, tableName)
+	}
+
 	l := &Lambda{
-		eventClient: event.NewClient(cfg, os.Getenv("EVENT_BUS_NAME")),
 		store: ddb.New(
 			cfg,
 			os.Getenv("DDB_TABLE_NAME_DEEDS"),
 			os.Getenv("DDB_TABLE_NAME_CHANGES"),
+			os.Getenv("DDB_TABLE_NAME_ACTORS"),
+			os.Getenv("DDB_TABLE_NAME_IDEMPOTENCY"),
+			os.Getenv("DDB_TABLE_NAME_OUTBOX"),
 		),
 		staticLpaStorage: objectstore.NewS3Client(
 			cfg,
 			os.Getenv("S3_BUCKET_NAME_ORIGINAL"),
 		),
-		verifier:    shared.NewJWTVerifier(cfg, logger),
-		logger:      logger,
-		environment: os.Getenv("ENVIRONMENT"),
-		now:         time.Now,
+		verifier:       verifier,
+		logger:         logger,
+		environment:    os.Getenv("ENVIRONMENT"),
+		now:            time.Now,
+		idempotencyTTL: idempotencyTTL,
 	}
 
-	lambda.Start(l.HandleEvent)
+	lambda.Start(tracing.WithTracing(tp, shared.WithRequestID(l.HandleEvent)))
 }
@@ -5,18 +5,28 @@ import (
 	"encoding/json"
 	"errors"
 	"log/slog"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"testing"
 	"time"
 
 	"github.com/aws/aws-lambda-go/events"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/ddb"
 	"github.com/ministryofjustice/opg-data-lpa-store/internal/event"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/idempotency"
 	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
+var siriusClaims = &shared.LpaStoreClaims{RegisteredClaims: jwt.RegisteredClaims{
+	Issuer:  "opg.poas.sirius",
+	Subject: "urn:opg:poas:sirius:users:1",
+}}
+
 var (
 	ctx          = context.WithValue(context.Background(), (*string)(nil), "testing")
 	errExample   = errors.New("err")
@@ -83,6 +93,66 @@ func makeDate(s string) shared.Date {
 	return *d
 }
 
+func createUpdate(t *testing.T, lpa shared.Lpa) any {
+	snapshot, _ := json.Marshal(lpa)
+
+	return mock.MatchedBy(func(update shared.Update) bool {
+		id := update.Id
+		update.Id = ""
+
+		return assert.NoError(t, uuid.Validate(id)) &&
+			assert.Equal(t, shared.Update{
+				Uid:     "my-uid",
+				Applied: "2024-01-02T12:13:14.000000015Z",
+				Author:  "urn:opg:poas:sirius:users:an-author",
+				Type:    "CREATE",
+				Changes: []shared.Change{{Key: "", Old: json.RawMessage("null"), New: snapshot}},
+			}, update)
+	})
+}
+
+// scanPath matches the path of a scan saved under the ID of the request's
+// update.
+func scanPath(t *testing.T, filename string) any {
+	return mock.MatchedBy(func(path string) bool {
+		dir, name := filepath.Split(path)
+		id := filepath.Base(dir)
+
+		return assert.Equal(t, "my-uid/scans/"+id+"/", dir) &&
+			assert.NoError(t, uuid.Validate(id)) &&
+			assert.Equal(t, filename, name)
+	})
+}
+
+func createOutbox(t *testing.T, environment, measureName string) any {
+	return mock.MatchedBy(func(entry event.OutboxEntry) bool {
+		return assert.NoError(t, uuid.Validate(entry.Id)) &&
+			assert.Equal(t, event.LpaUpdated{
+				Uid:           "my-uid",
+				ChangeType:    "CREATE",
+				SchemaVersion: event.LpaUpdatedSchemaVersion,
+				UpdateId:      entry.Id,
+				Author:        "urn:opg:poas:sirius:users:an-author",
+				Applied:       "2024-01-02T12:13:14.000000015Z",
+				ChangedKeys:   []string{""},
+				StatusAfter:   shared.LpaStatusInProgress,
+				ActorUIDs:     entry.Event.ActorUIDs,
+			}, entry.Event) &&
+			assert.NotEmpty(t, entry.Event.ActorUIDs) &&
+			assert.Equal(t, []event.Metric{{
+				Project:          "MRLPA",
+				Category:         "metric",
+				Subcategory:      "FunnelCompletionRate",
+				Environment:      environment,
+				MeasureName:      measureName,
+				MeasureValue:     "1",
+				MeasureValueType: "BIGINT",
+				Time:             strconv.FormatInt(testNow.UnixMilli(), 10),
+			}}, entry.Metrics) &&
+			assert.Equal(t, testNow, entry.CreatedAt)
+	})
+}
+
 func TestLambdaHandleEvent(t *testing.T) {
 	onlineWithDefault := validLpaInit
 	onlineWithDefault.WhenTheLpaCanBeUsed = shared.CanUseUnset
@@ -113,6 +183,7 @@ func TestLambdaHandleEvent(t *testing.T) {
 				Uid:       "my-uid",
 				Status:    shared.LpaStatusInProgress,
 				UpdatedAt: testNow,
+				Version:   1,
 				LpaInit:   validLpaInit,
 			},
 		},
@@ -123,6 +194,7 @@ func TestLambdaHandleEvent(t *testing.T) {
 				Uid:       "my-uid",
 				Status:    shared.LpaStatusInProgress,
 				UpdatedAt: testNow,
+				Version:   1,
 				LpaInit:   onlineWithDefaultLpa,
 			},
 		},
@@ -133,6 +205,7 @@ func TestLambdaHandleEvent(t *testing.T) {
 				Uid:       "my-uid",
 				Status:    shared.LpaStatusInProgress,
 				UpdatedAt: testNow,
+				Version:   1,
 				LpaInit:   paperLpaInit,
 			},
 		},
@@ -143,6 +216,7 @@ func TestLambdaHandleEvent(t *testing.T) {
 				Uid:       "my-uid",
 				Status:    shared.LpaStatusInProgress,
 				UpdatedAt: testNow,
+				Version:   1,
 				LpaInit:   paperWithDefaultLpa,
 			},
 		},
@@ -159,19 +233,19 @@ func TestLambdaHandleEvent(t *testing.T) {
 
 			verifier := newMockVerifier(t)
 			verifier.EXPECT().
-				VerifyHeader(req).
-				Return(nil, nil)
+				VerifyHeader(mock.Anything, req).
+				Return(&shared.LpaStoreClaims{RegisteredClaims: jwt.RegisteredClaims{Issuer: "opg.poas.sirius", Subject: "urn:opg:poas:sirius:users:an-author"}}, nil)
 
 			logger := newMockLogger(t)
 			logger.EXPECT().
-				Debug("Successfully parsed JWT from event header")
+				DebugContext(mock.Anything, "Successfully parsed JWT from event header")
 
 			store := newMockStore(t)
 			store.EXPECT().
 				Get(ctx, "my-uid").
 				Return(shared.Lpa{}, nil)
 			store.EXPECT().
-				Put(ctx, tc.lpa).
+				Create(ctx, tc.lpa, createUpdate(t, tc.lpa), (*idempotency.Record)(nil), createOutbox(t, "E", tc.measureName)).
 				Return(nil)
 
 			staticLpaStorage := newMockS3Client(t)
@@ -179,29 +253,11 @@ func TestLambdaHandleEvent(t *testing.T) {
 				Put(ctx, "my-uid/donor-executed-lpa.json", tc.lpa).
 				Return(nil)
 
-			eventClient := newMockEventClient(t)
-			eventClient.EXPECT().
-				SendLpaUpdated(ctx, event.LpaUpdated{
-					Uid:        "my-uid",
-					ChangeType: "CREATE",
-				}, &event.Metric{
-					Project:          "MRLPA",
-					Category:         "metric",
-					Subcategory:      "FunnelCompletionRate",
-					Environment:      "E",
-					MeasureName:      tc.measureName,
-					MeasureValue:     "1",
-					MeasureValueType: "BIGINT",
-					Time:             strconv.FormatInt(testNow.UnixMilli(), 10),
-				}).
-				Return(nil)
-
 			lambda := &Lambda{
 				verifier:         verifier,
 				logger:           logger,
 				store:            store,
 				staticLpaStorage: staticLpaStorage,
-				eventClient:      eventClient,
 				now:              testNowFn,
 				environment:      "E",
 			}
@@ -229,6 +285,7 @@ func TestLambdaHandleEventWhenPaperSubmissionContainsImages(t *testing.T) {
 		Uid:       "my-uid",
 		Status:    shared.LpaStatusInProgress,
 		UpdatedAt: testNow,
+		Version:   1,
 		LpaInit:   lpaInit,
 	}
 	lpa.RestrictionsAndConditionsImages = []shared.File{{Path: "a", Hash: "b"}}
@@ -240,19 +297,19 @@ func TestLambdaHandleEventWhenPaperSubmissionContainsImages(t *testing.T) {
 
 	verifier := newMockVerifier(t)
 	verifier.EXPECT().
-		VerifyHeader(req).
-		Return(nil, nil)
+		VerifyHeader(mock.Anything, req).
+		Return(&shared.LpaStoreClaims{RegisteredClaims: jwt.RegisteredClaims{Issuer: "opg.poas.sirius", Subject: "urn:opg:poas:sirius:users:an-author"}}, nil)
 
 	logger := newMockLogger(t)
 	logger.EXPECT().
-		Debug("Successfully parsed JWT from event header")
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header")
 
 	store := newMockStore(t)
 	store.EXPECT().
 		Get(ctx, "my-uid").
 		Return(shared.Lpa{}, nil)
 	store.EXPECT().
-		Put(ctx, lpa).
+		Create(ctx, lpa, createUpdate(t, lpa), (*idempotency.Record)(nil), createOutbox(t, "", "PAPERDONOR")).
 		Return(nil)
 
 	staticLpaStorage := newMockS3Client(t)
@@ -260,23 +317,14 @@ func TestLambdaHandleEventWhenPaperSubmissionContainsImages(t *testing.T) {
 		Put(ctx, "my-uid/donor-executed-lpa.json", lpa).
 		Return(nil)
 	staticLpaStorage.EXPECT().
-		UploadFile(ctx, shared.FileUpload{Filename: "restriction.jpg", Data: "some-base64"}, "my-uid/scans/rc_0_restriction.jpg").
+		UploadFile(ctx, shared.FileUpload{Filename: "restriction.jpg", Data: "some-base64"}, scanPath(t, "rc_0_restriction.jpg")).
 		Return(shared.File{Path: "a", Hash: "b"}, nil)
 
-	eventClient := newMockEventClient(t)
-	eventClient.EXPECT().
-		SendLpaUpdated(ctx, event.LpaUpdated{
-			Uid:        "my-uid",
-			ChangeType: "CREATE",
-		}, mock.Anything).
-		Return(nil)
-
 	lambda := &Lambda{
 		verifier:         verifier,
 		logger:           logger,
 		store:            store,
 		staticLpaStorage: staticLpaStorage,
-		eventClient:      eventClient,
 		now:              testNowFn,
 	}
 
@@ -298,6 +346,7 @@ func TestLambdaHandleEventWhenPaperSubmissionHasValidationErrors(t *testing.T) {
 		Uid:       "my-uid",
 		Status:    shared.LpaStatusInProgress,
 		UpdatedAt: testNow,
+		Version:   1,
 		LpaInit:   lpaInit,
 	}
 
@@ -308,21 +357,21 @@ func TestLambdaHandleEventWhenPaperSubmissionHasValidationErrors(t *testing.T) {
 
 	verifier := newMockVerifier(t)
 	verifier.EXPECT().
-		VerifyHeader(req).
-		Return(nil, nil)
+		VerifyHeader(mock.Anything, req).
+		Return(&shared.LpaStoreClaims{RegisteredClaims: jwt.RegisteredClaims{Issuer: "opg.poas.sirius", Subject: "urn:opg:poas:sirius:users:an-author"}}, nil)
 
 	logger := newMockLogger(t)
 	logger.EXPECT().
-		Debug("Successfully parsed JWT from event header")
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header")
 	logger.EXPECT().
-		Info("encountered validation errors in lpa", slog.String("uid", "my-uid"))
+		InfoContext(mock.Anything, "encountered validation errors in lpa", slog.String("uid", "my-uid"))
 
 	store := newMockStore(t)
 	store.EXPECT().
 		Get(ctx, "my-uid").
 		Return(shared.Lpa{}, nil)
 	store.EXPECT().
-		Put(ctx, lpa).
+		Create(ctx, lpa, createUpdate(t, lpa), (*idempotency.Record)(nil), createOutbox(t, "", "PAPERDONOR")).
 		Return(nil)
 
 	staticLpaStorage := newMockS3Client(t)
@@ -330,20 +379,11 @@ func TestLambdaHandleEventWhenPaperSubmissionHasValidationErrors(t *testing.T) {
 		Put(ctx, "my-uid/donor-executed-lpa.json", lpa).
 		Return(nil)
 
-	eventClient := newMockEventClient(t)
-	eventClient.EXPECT().
-		SendLpaUpdated(ctx, event.LpaUpdated{
-			Uid:        "my-uid",
-			ChangeType: "CREATE",
-		}, mock.Anything).
-		Return(nil)
-
 	lambda := &Lambda{
 		verifier:         verifier,
 		logger:           logger,
 		store:            store,
 		staticLpaStorage: staticLpaStorage,
-		eventClient:      eventClient,
 		now:              testNowFn,
 	}
 
@@ -363,12 +403,12 @@ func TestLambdaHandleEventWhenUnauthorised(t *testing.T) {
 
 	verifier := newMockVerifier(t)
 	verifier.EXPECT().
-		VerifyHeader(req).
+		VerifyHeader(mock.Anything, req).
 		Return(nil, errExample)
 
 	logger := newMockLogger(t)
 	logger.EXPECT().
-		Info("Unable to verify JWT from header")
+		InfoContext(mock.Anything, "Unable to verify JWT from header")
 
 	lambda := &Lambda{
 		verifier: verifier,
@@ -380,7 +420,8 @@ func TestLambdaHandleEventWhenUnauthorised(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, events.APIGatewayProxyResponse{
 		StatusCode: 401,
-		Body:       `{"code":"UNAUTHORISED","detail":"Invalid JWT"}`,
+		Headers:    map[string]string{"Content-Type": "application/problem+json"},
+		Body:       `{"type":"urn:opg:poas:lpa-store:problem:unauthorised","title":"Unauthorized","status":401,"detail":"Invalid JWT","code":"UNAUTHORISED"}`,
 	}, resp)
 }
 
@@ -392,12 +433,12 @@ func TestLambdaHandleEventWhenLpaAlreadyExists(t *testing.T) {
 
 	verifier := newMockVerifier(t)
 	verifier.EXPECT().
-		VerifyHeader(req).
-		Return(nil, nil)
+		VerifyHeader(mock.Anything, req).
+		Return(&shared.LpaStoreClaims{RegisteredClaims: jwt.RegisteredClaims{Issuer: "opg.poas.sirius", Subject: "urn:opg:poas:sirius:users:an-author"}}, nil)
 
 	logger := newMockLogger(t)
 	logger.EXPECT().
-		Debug("Successfully parsed JWT from event header")
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header")
 
 	store := newMockStore(t)
 	store.EXPECT().
@@ -414,19 +455,14 @@ func TestLambdaHandleEventWhenLpaAlreadyExists(t *testing.T) {
 	resp, err := lambda.HandleEvent(ctx, req)
 	assert.Nil(t, err)
 	assert.Equal(t, events.APIGatewayProxyResponse{
-		StatusCode: 400,
-		Body:       `{"code":"INVALID_REQUEST","detail":"LPA with UID already exists"}`,
+		StatusCode: 409,
+		Headers:    map[string]string{"Content-Type": "application/problem+json"},
+		Body:       `{"type":"urn:opg:poas:lpa-store:problem:conflict","title":"Conflict","status":409,"detail":"LPA with UID already exists","code":"CONFLICT"}`,
 	}, resp)
 }
 
-func TestLambdaHandleEventWhenUploadFileErrors(t *testing.T) {
-	lpaInit := validLpaInit
-	lpaInit.Channel = shared.ChannelPaper
-	lpaInit.RestrictionsAndConditionsImages = []shared.FileUpload{{
-		Filename: "restriction.jpg",
-		Data:     "some-base64",
-	}}
-	body, _ := json.Marshal(lpaInit)
+func TestLambdaHandleEventWhenLpaCreatedByAnotherRequest(t *testing.T) {
+	body, _ := json.Marshal(validLpaInit)
 
 	req := events.APIGatewayProxyRequest{
 		PathParameters: map[string]string{"uid": "my-uid"},
@@ -435,43 +471,132 @@ func TestLambdaHandleEventWhenUploadFileErrors(t *testing.T) {
 
 	verifier := newMockVerifier(t)
 	verifier.EXPECT().
-		VerifyHeader(req).
-		Return(nil, nil)
+		VerifyHeader(mock.Anything, req).
+		Return(siriusClaims, nil)
 
 	logger := newMockLogger(t)
 	logger.EXPECT().
-		Debug("Successfully parsed JWT from event header")
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header")
 	logger.EXPECT().
-		Error("error saving restrictions and conditions image", slog.Any("err", errExample))
+		InfoContext(mock.Anything, "LPA with UID was created by another request", slog.String("uid", "my-uid"))
 
 	store := newMockStore(t)
 	store.EXPECT().
 		Get(ctx, "my-uid").
 		Return(shared.Lpa{}, nil)
+	store.EXPECT().
+		Create(ctx, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(ddb.ErrConditionFailed)
 
-	staticLpaStorage := newMockS3Client(t)
-	staticLpaStorage.EXPECT().
-		UploadFile(ctx, shared.FileUpload{Filename: "restriction.jpg", Data: "some-base64"}, "my-uid/scans/rc_0_restriction.jpg").
-		Return(shared.File{}, errExample)
+	lambda := &Lambda{
+		verifier: verifier,
+		logger:   logger,
+		store:    store,
+		now:      testNowFn,
+	}
+
+	resp, err := lambda.HandleEvent(ctx, req)
+	assert.Nil(t, err)
+	assert.Equal(t, events.APIGatewayProxyResponse{
+		StatusCode: 409,
+		Headers:    map[string]string{"Content-Type": "application/problem+json"},
+		Body:       `{"type":"urn:opg:poas:lpa-store:problem:conflict","title":"Conflict","status":409,"detail":"LPA with UID already exists","code":"CONFLICT"}`,
+	}, resp)
+}
+
+func TestLambdaHandleEventWhenCreateHasTooManyItems(t *testing.T) {
+	body, _ := json.Marshal(validLpaInit)
+
+	req := events.APIGatewayProxyRequest{
+		PathParameters: map[string]string{"uid": "my-uid"},
+		Body:           string(body),
+	}
+
+	verifier := newMockVerifier(t)
+	verifier.EXPECT().
+		VerifyHeader(mock.Anything, req).
+		Return(siriusClaims, nil)
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header")
+	logger.EXPECT().
+		InfoContext(mock.Anything, "too many items to save at once", slog.String("uid", "my-uid"))
+
+	store := newMockStore(t)
+	store.EXPECT().
+		Get(ctx, "my-uid").
+		Return(shared.Lpa{}, nil)
+	store.EXPECT().
+		Create(ctx, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(ddb.ErrTooManyItems)
 
 	lambda := &Lambda{
-		verifier:         verifier,
-		logger:           logger,
-		store:            store,
-		staticLpaStorage: staticLpaStorage,
-		now:              testNowFn,
+		verifier: verifier,
+		logger:   logger,
+		store:    store,
+		now:      testNowFn,
+	}
+
+	resp, err := lambda.HandleEvent(ctx, req)
+	assert.Nil(t, err)
+	assert.Equal(t, events.APIGatewayProxyResponse{
+		StatusCode: 400,
+		Headers:    map[string]string{"Content-Type": "application/problem+json"},
+		Body:       `{"type":"urn:opg:poas:lpa-store:problem:invalid-request","title":"Bad Request","status":400,"detail":"LPA has too many actors to save","code":"INVALID_REQUEST"}`,
+	}, resp)
+}
+
+func TestLambdaHandleEventWhenCreateErrors(t *testing.T) {
+	body, _ := json.Marshal(validLpaInit)
+
+	req := events.APIGatewayProxyRequest{
+		PathParameters: map[string]string{"uid": "my-uid"},
+		Body:           string(body),
+	}
+
+	verifier := newMockVerifier(t)
+	verifier.EXPECT().
+		VerifyHeader(mock.Anything, req).
+		Return(siriusClaims, nil)
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header")
+	logger.EXPECT().
+		ErrorContext(mock.Anything, "error saving LPA", slog.Any("err", errExample))
+
+	store := newMockStore(t)
+	store.EXPECT().
+		Get(ctx, "my-uid").
+		Return(shared.Lpa{}, nil)
+	store.EXPECT().
+		Create(ctx, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(errExample)
+
+	lambda := &Lambda{
+		verifier: verifier,
+		logger:   logger,
+		store:    store,
+		now:      testNowFn,
 	}
 
 	resp, err := lambda.HandleEvent(ctx, req)
 	assert.Nil(t, err)
 	assert.Equal(t, events.APIGatewayProxyResponse{
 		StatusCode: 500,
-		Body:       `{"code":"INTERNAL_SERVER_ERROR","detail":"Internal server error"}`,
+		Headers:    map[string]string{"Content-Type": "application/problem+json"},
+		Body:       `{"type":"urn:opg:poas:lpa-store:problem:internal-server-error","title":"Internal Server Error","status":500,"detail":"Internal server error","code":"INTERNAL_SERVER_ERROR"}`,
 	}, resp)
 }
 
-func TestLambdaHandleEventWhenSendLpaUpdatedErrors(t *testing.T) {
+func TestLambdaHandleEventWhenUploadFileErrors(t *testing.T) {
 	lpaInit := validLpaInit
+	lpaInit.Channel = shared.ChannelPaper
+	lpaInit.RestrictionsAndConditionsImages = []shared.FileUpload{{
+		Filename: "restriction.jpg",
+		Data:     "some-base64",
+	}}
 	body, _ := json.Marshal(lpaInit)
 
 	req := events.APIGatewayProxyRequest{
@@ -481,50 +606,39 @@ func TestLambdaHandleEventWhenSendLpaUpdatedErrors(t *testing.T) {
 
 	verifier := newMockVerifier(t)
 	verifier.EXPECT().
-		VerifyHeader(req).
-		Return(nil, nil)
+		VerifyHeader(mock.Anything, req).
+		Return(&shared.LpaStoreClaims{RegisteredClaims: jwt.RegisteredClaims{Issuer: "opg.poas.sirius", Subject: "urn:opg:poas:sirius:users:an-author"}}, nil)
 
 	logger := newMockLogger(t)
 	logger.EXPECT().
-		Debug("Successfully parsed JWT from event header")
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header")
 	logger.EXPECT().
-		Error("unexpected error occurred", slog.Any("err", errExample))
+		ErrorContext(mock.Anything, "error saving restrictions and conditions image", slog.Any("err", errExample))
 
 	store := newMockStore(t)
 	store.EXPECT().
 		Get(ctx, "my-uid").
 		Return(shared.Lpa{}, nil)
-	store.EXPECT().
-		Put(ctx, mock.Anything).
-		Return(nil)
 
 	staticLpaStorage := newMockS3Client(t)
 	staticLpaStorage.EXPECT().
-		Put(ctx, "my-uid/donor-executed-lpa.json", mock.Anything).
-		Return(nil)
-
-	eventClient := newMockEventClient(t)
-	eventClient.EXPECT().
-		SendLpaUpdated(ctx, event.LpaUpdated{
-			Uid:        "my-uid",
-			ChangeType: "CREATE",
-		}, mock.Anything).
-		Return(errExample)
+		UploadFile(ctx, shared.FileUpload{Filename: "restriction.jpg", Data: "some-base64"}, scanPath(t, "rc_0_restriction.jpg")).
+		Return(shared.File{}, errExample)
 
 	lambda := &Lambda{
 		verifier:         verifier,
 		logger:           logger,
 		store:            store,
 		staticLpaStorage: staticLpaStorage,
-		eventClient:      eventClient,
 		now:              testNowFn,
 	}
 
 	resp, err := lambda.HandleEvent(ctx, req)
 	assert.Nil(t, err)
 	assert.Equal(t, events.APIGatewayProxyResponse{
-		StatusCode: 201,
-		Body:       "{}",
+		StatusCode: 500,
+		Headers:    map[string]string{"Content-Type": "application/problem+json"},
+		Body:       `{"type":"urn:opg:poas:lpa-store:problem:internal-server-error","title":"Internal Server Error","status":500,"detail":"Internal server error","code":"INTERNAL_SERVER_ERROR"}`,
 	}, resp)
 }
 
@@ -580,21 +694,21 @@ func TestLambdaHandleEventAddsActorUids(t *testing.T) {
 
 	verifier := newMockVerifier(t)
 	verifier.EXPECT().
-		VerifyHeader(req).
-		Return(nil, nil)
+		VerifyHeader(mock.Anything, req).
+		Return(&shared.LpaStoreClaims{RegisteredClaims: jwt.RegisteredClaims{Issuer: "opg.poas.sirius", Subject: "urn:opg:poas:sirius:users:an-author"}}, nil)
 
 	logger := newMockLogger(t)
 	logger.EXPECT().
-		Debug("Successfully parsed JWT from event header")
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header")
 	logger.EXPECT().
-		Info("encountered validation errors in lpa", mock.Anything)
+		InfoContext(mock.Anything, "encountered validation errors in lpa", mock.Anything)
 
 	store := newMockStore(t)
 	store.EXPECT().
 		Get(ctx, "my-uid").
 		Return(shared.Lpa{}, nil)
 	store.EXPECT().
-		Put(ctx, mock.MatchedBy(func(lpa shared.Lpa) bool {
+		Create(ctx, mock.MatchedBy(func(lpa shared.Lpa) bool {
 			return uuidRegex.MatchString(lpa.Donor.UID) &&
 				uuidRegex.MatchString(lpa.CertificateProvider.UID) &&
 				uuidRegex.MatchString(lpa.Attorneys[0].UID) &&
@@ -605,7 +719,7 @@ func TestLambdaHandleEventAddsActorUids(t *testing.T) {
 				uuidRegex.MatchString(lpa.PeopleToNotify[1].UID) &&
 				uuidRegex.MatchString(lpa.IndependentWitness.UID) &&
 				uuidRegex.MatchString(lpa.AuthorisedSignatory.UID)
-		})).
+		}), mock.Anything, mock.Anything, createOutbox(t, "E", "PAPERDONOR")).
 		Return(nil)
 
 	staticLpaStorage := newMockS3Client(t)
@@ -613,29 +727,11 @@ func TestLambdaHandleEventAddsActorUids(t *testing.T) {
 		Put(ctx, "my-uid/donor-executed-lpa.json", mock.Anything).
 		Return(nil)
 
-	eventClient := newMockEventClient(t)
-	eventClient.EXPECT().
-		SendLpaUpdated(ctx, event.LpaUpdated{
-			Uid:        "my-uid",
-			ChangeType: "CREATE",
-		}, &event.Metric{
-			Project:          "MRLPA",
-			Category:         "metric",
-			Subcategory:      "FunnelCompletionRate",
-			Environment:      "E",
-			MeasureName:      "PAPERDONOR",
-			MeasureValue:     "1",
-			MeasureValueType: "BIGINT",
-			Time:             strconv.FormatInt(testNow.UnixMilli(), 10),
-		}).
-		Return(nil)
-
 	lambda := &Lambda{
 		verifier:         verifier,
 		logger:           logger,
 		store:            store,
 		staticLpaStorage: staticLpaStorage,
-		eventClient:      eventClient,
 		environment:      "E",
 		now:              testNowFn,
 	}
@@ -647,3 +743,181 @@ func TestLambdaHandleEventAddsActorUids(t *testing.T) {
 		Body:       "{}",
 	}, resp)
 }
+
+func TestLambdaHandleEventWithIdempotencyKey(t *testing.T) {
+	body, _ := json.Marshal(validLpaInit)
+
+	req := events.APIGatewayProxyRequest{
+		PathParameters:    map[string]string{"uid": "my-uid"},
+		MultiValueHeaders: map[string][]string{"Idempotency-Key": {"a-key"}},
+		Body:              string(body),
+	}
+
+	verifier := newMockVerifier(t)
+	verifier.EXPECT().
+		VerifyHeader(mock.Anything, req).
+		Return(&shared.LpaStoreClaims{RegisteredClaims: jwt.RegisteredClaims{Issuer: "opg.poas.sirius", Subject: "urn:opg:poas:sirius:users:an-author"}}, nil)
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header")
+
+	store := newMockStore(t)
+	store.EXPECT().
+		GetIdempotencyRecord(ctx, "create#my-uid#urn:opg:poas:sirius:users:an-author#a-key").
+		Return(idempotency.Record{}, nil)
+	store.EXPECT().
+		Get(ctx, "my-uid").
+		Return(shared.Lpa{}, nil)
+	store.EXPECT().
+		Create(ctx, mock.Anything, mock.Anything, mock.MatchedBy(func(record *idempotency.Record) bool {
+			return assert.Equal(t, "create#my-uid#urn:opg:poas:sirius:users:an-author#a-key", record.Key) &&
+				assert.True(t, record.Matches(req)) &&
+				assert.Equal(t, 201, record.StatusCode) &&
+				assert.Equal(t, `{}`, record.Body) &&
+				assert.Equal(t, testNow.Add(time.Hour).Unix(), record.ExpiresAt)
+		}), mock.Anything).
+		Return(nil)
+
+	staticLpaStorage := newMockS3Client(t)
+	staticLpaStorage.EXPECT().
+		Put(ctx, "my-uid/donor-executed-lpa.json", mock.Anything).
+		Return(nil)
+
+	lambda := &Lambda{
+		staticLpaStorage: staticLpaStorage,
+		verifier:         verifier,
+		logger:           logger,
+		store:            store,
+		now:              testNowFn,
+		idempotencyTTL:   time.Hour,
+	}
+
+	resp, err := lambda.HandleEvent(ctx, req)
+	assert.Nil(t, err)
+	assert.Equal(t, 201, resp.StatusCode)
+}
+
+func TestLambdaHandleEventWhenIdempotencyKeyReplayed(t *testing.T) {
+	req := events.APIGatewayProxyRequest{
+		PathParameters:    map[string]string{"uid": "my-uid"},
+		MultiValueHeaders: map[string][]string{"Idempotency-Key": {"a-key"}},
+		Body:              "{}",
+	}
+
+	verifier := newMockVerifier(t)
+	verifier.EXPECT().
+		VerifyHeader(mock.Anything, req).
+		Return(siriusClaims, nil)
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header")
+	logger.EXPECT().
+		InfoContext(mock.Anything, "replaying response for Idempotency-Key", slog.String("uid", "my-uid"), slog.String("updateId", "an-id"))
+
+	store := newMockStore(t)
+	store.EXPECT().
+		GetIdempotencyRecord(ctx, "create#my-uid#urn:opg:poas:sirius:users:1#a-key").
+		Return(idempotency.NewRecord("create#my-uid#urn:opg:poas:sirius:users:1#a-key", req, "an-id", events.APIGatewayProxyResponse{StatusCode: 201, Body: `{}`}, testNow, time.Hour), nil)
+
+	lambda := &Lambda{
+		verifier: verifier,
+		logger:   logger,
+		store:    store,
+		now:      testNowFn,
+	}
+
+	resp, err := lambda.HandleEvent(ctx, req)
+	assert.Nil(t, err)
+	assert.Equal(t, events.APIGatewayProxyResponse{
+		StatusCode: 201,
+		Headers:    map[string]string{"Idempotent-Replayed": "true"},
+		Body:       `{}`,
+	}, resp)
+}
+
+func TestLambdaHandleEventWhenIdempotencyKeyReused(t *testing.T) {
+	req := events.APIGatewayProxyRequest{
+		PathParameters:    map[string]string{"uid": "my-uid"},
+		MultiValueHeaders: map[string][]string{"Idempotency-Key": {"a-key"}},
+		Body:              "{}",
+	}
+
+	verifier := newMockVerifier(t)
+	verifier.EXPECT().
+		VerifyHeader(mock.Anything, req).
+		Return(siriusClaims, nil)
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header")
+	logger.EXPECT().
+		InfoContext(mock.Anything, "Idempotency-Key reused for a different request", slog.String("uid", "my-uid"))
+
+	store := newMockStore(t)
+	store.EXPECT().
+		GetIdempotencyRecord(ctx, mock.Anything).
+		Return(idempotency.Record{Key: "create#my-uid#urn:opg:poas:sirius:users:1#a-key", RequestHash: "other", ExpiresAt: testNow.Add(time.Minute).Unix()}, nil)
+
+	lambda := &Lambda{
+		verifier: verifier,
+		logger:   logger,
+		store:    store,
+		now:      testNowFn,
+	}
+
+	resp, err := lambda.HandleEvent(ctx, req)
+	assert.Nil(t, err)
+	assert.Equal(t, 422, resp.StatusCode)
+}
+
+func TestLambdaHandleEventWhenIdempotencyKeySavedByAnotherRequest(t *testing.T) {
+	body, _ := json.Marshal(validLpaInit)
+
+	req := events.APIGatewayProxyRequest{
+		PathParameters:    map[string]string{"uid": "my-uid"},
+		MultiValueHeaders: map[string][]string{"Idempotency-Key": {"a-key"}},
+		Body:              string(body),
+	}
+
+	verifier := newMockVerifier(t)
+	verifier.EXPECT().
+		VerifyHeader(mock.Anything, req).
+		Return(siriusClaims, nil)
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header")
+	logger.EXPECT().
+		InfoContext(mock.Anything, "replaying response for Idempotency-Key", slog.String("uid", "my-uid"), slog.String("updateId", "an-id"))
+
+	store := newMockStore(t)
+	store.EXPECT().
+		GetIdempotencyRecord(ctx, "create#my-uid#urn:opg:poas:sirius:users:1#a-key").
+		Return(idempotency.Record{}, nil).
+		Once()
+	store.EXPECT().
+		Get(ctx, "my-uid").
+		Return(shared.Lpa{}, nil)
+	store.EXPECT().
+		Create(ctx, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(ddb.ErrConditionFailed)
+	store.EXPECT().
+		GetIdempotencyRecord(ctx, "create#my-uid#urn:opg:poas:sirius:users:1#a-key").
+		Return(idempotency.NewRecord("create#my-uid#urn:opg:poas:sirius:users:1#a-key", req, "an-id", events.APIGatewayProxyResponse{StatusCode: 201, Body: `{}`}, testNow, time.Hour), nil).
+		Once()
+
+	lambda := &Lambda{
+		verifier:       verifier,
+		logger:         logger,
+		store:          store,
+		now:            testNowFn,
+		idempotencyTTL: time.Hour,
+	}
+
+	resp, err := lambda.HandleEvent(ctx, req)
+	assert.Nil(t, err)
+	assert.Equal(t, 201, resp.StatusCode)
+	assert.Equal(t, "true", resp.Headers["Idempotent-Replayed"])
+}
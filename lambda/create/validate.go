@@ -25,6 +25,7 @@ func Validate(lpa shared.LpaInit) []shared.FieldError {
 		validate.WithSource("/donor/firstNames", lpa.Donor.FirstNames, validate.NotEmpty()),
 		validate.WithSource("/donor/lastName", lpa.Donor.LastName, validate.NotEmpty()),
 		validate.WithSource("/donor/dateOfBirth", lpa.Donor.DateOfBirth, validate.Date()),
+		validate.WithSource("/donor/dateOfDeath", lpa.Donor.DateOfDeath, validate.Empty()),
 		validateAddress("/donor/address", lpa.Donor.Address),
 		validate.WithSource("/donor/contactLanguagePreference", lpa.Donor.ContactLanguagePreference, validate.Valid()),
 		validate.IfFunc(lpa.Donor.IdentityCheck != nil, func() []shared.FieldError {
@@ -113,6 +114,7 @@ func validateAttorney(prefix string, attorney shared.Attorney) []shared.FieldErr
 		validate.WithSource(fmt.Sprintf("%s/firstNames", prefix), attorney.FirstNames, validate.NotEmpty()),
 		validate.WithSource(fmt.Sprintf("%s/lastName", prefix), attorney.LastName, validate.NotEmpty()),
 		validate.WithSource(fmt.Sprintf("%s/dateOfBirth", prefix), attorney.DateOfBirth, validate.Date()),
+		validate.WithSource(fmt.Sprintf("%s/dateOfDeath", prefix), attorney.DateOfDeath, validate.Empty()),
 		validateAddress(fmt.Sprintf("%s/address", prefix), attorney.Address),
 		validate.WithSource(fmt.Sprintf("%s/status", prefix), attorney.Status, validate.Valid()),
 		validate.WithSource(fmt.Sprintf("%s/channel", prefix), attorney.Channel, validate.Valid()),
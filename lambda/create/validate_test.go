@@ -132,6 +132,16 @@ func TestValidateAttorneyMalformedDateOfBirth(t *testing.T) {
 	assert.Equal(t, errors, []shared.FieldError{{Source: "/test/dateOfBirth", Detail: "invalid format"}})
 }
 
+func TestValidateAttorneyWithDateOfDeath(t *testing.T) {
+	attorney := makeAttorney()
+	dateOfDeath := newDate("2020-01-02")
+	attorney.DateOfDeath = &dateOfDeath
+
+	errors := validateAttorney("/test", attorney)
+
+	assert.Equal(t, errors, []shared.FieldError{{Source: "/test/dateOfDeath", Detail: "field must not be provided"}})
+}
+
 func TestValidateAttorneyInvalidStatus(t *testing.T) {
 	attorney := makeAttorney()
 	attorney.Status = "bad status"
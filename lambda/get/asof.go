@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/jsondoc"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+)
+
+// asOf rebuilds the LPA as it was at the given time, by reverting the updates
+// applied after it, newest first as returned by GetChanges. It returns false if
+// the LPA had not been created by then.
+//
+// LPAs created before the creation was recorded as a change have no CREATE
+// update to revert, so they are treated as created at their earliest update, or
+// when last updated if there are none.
+//
+// Updates recorded before the diff was stored only have the changes that were
+// requested, so changes implied by their type, like a status, are not reverted.
+func asOf(lpa shared.Lpa, updates []shared.Update, at time.Time) (shared.Lpa, bool, error) {
+	createdAt, err := legacyCreatedAt(lpa, updates)
+	if err != nil {
+		return lpa, false, err
+	}
+
+	if at.Before(createdAt) {
+		return shared.Lpa{}, false, nil
+	}
+
+	doc, err := json.Marshal(lpa)
+	if err != nil {
+		return lpa, false, err
+	}
+
+	for _, update := range updates {
+		applied, err := time.Parse(time.RFC3339, update.Applied)
+		if err != nil {
+			return lpa, false, fmt.Errorf("update %s: %w", update.Id, err)
+		}
+
+		if !applied.After(at) {
+			break
+		}
+
+		changes := update.Diff
+		if len(changes) == 0 {
+			changes = update.Changes
+		}
+
+		if doc, err = jsondoc.Revert(doc, changes); err != nil {
+			return lpa, false, fmt.Errorf("update %s: %w", update.Id, err)
+		}
+	}
+
+	if string(doc) == "null" {
+		return shared.Lpa{}, false, nil
+	}
+
+	var result shared.Lpa
+	if err := json.Unmarshal(doc, &result); err != nil {
+		return lpa, false, err
+	}
+
+	return result, true, nil
+}
+
+// legacyCreatedAt returns the earliest time an LPA without a CREATE update is
+// known to have existed, or the zero time if it has a CREATE update.
+func legacyCreatedAt(lpa shared.Lpa, updates []shared.Update) (time.Time, error) {
+	if len(updates) == 0 {
+		return lpa.UpdatedAt, nil
+	}
+
+	oldest := updates[len(updates)-1]
+	if oldest.Type == "CREATE" {
+		return time.Time{}, nil
+	}
+
+	applied, err := time.Parse(time.RFC3339, oldest.Applied)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("update %s: %w", oldest.Id, err)
+	}
+
+	return applied, nil
+}
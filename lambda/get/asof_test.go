@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+	"github.com/stretchr/testify/assert"
+)
+
+var testUpdates = []shared.Update{
+	{
+		Id:      "3",
+		Applied: "2024-03-01T00:00:00Z",
+		Type:    "REGISTER",
+		Diff: []shared.Change{
+			{Key: "/status", Old: json.RawMessage(`"statutory-waiting-period"`), New: json.RawMessage(`"registered"`)},
+			{Key: "/version", Old: json.RawMessage(`2`), New: json.RawMessage(`3`)},
+		},
+	},
+	{
+		Id:      "2",
+		Applied: "2024-02-01T00:00:00Z",
+		Type:    "CORRECTION",
+		Changes: []shared.Change{
+			{Key: "/donor/lastName", Old: json.RawMessage(`"Smith"`), New: json.RawMessage(`"Smyth"`)},
+		},
+	},
+	{
+		Id:      "1",
+		Applied: "2024-01-01T00:00:00Z",
+		Type:    "CREATE",
+		Changes: []shared.Change{
+			{Key: "", Old: json.RawMessage(`null`), New: json.RawMessage(`{}`)},
+		},
+	},
+}
+
+func TestAsOf(t *testing.T) {
+	lpa := shared.Lpa{
+		Uid:     "M-1111-1111-1111",
+		Status:  shared.LpaStatusRegistered,
+		Version: 3,
+		LpaInit: shared.LpaInit{Donor: shared.Donor{Person: shared.Person{LastName: "Smyth"}}},
+	}
+
+	testcases := map[string]struct {
+		at       time.Time
+		expected shared.Lpa
+	}{
+		"now": {
+			at:       time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC),
+			expected: lpa,
+		},
+		"at last update": {
+			at:       time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+			expected: lpa,
+		},
+		"before diff": {
+			at: time.Date(2024, time.February, 15, 0, 0, 0, 0, time.UTC),
+			expected: shared.Lpa{
+				Uid:     "M-1111-1111-1111",
+				Status:  shared.LpaStatusStatutoryWaitingPeriod,
+				Version: 2,
+				LpaInit: shared.LpaInit{Donor: shared.Donor{Person: shared.Person{LastName: "Smyth"}}},
+			},
+		},
+		"before changes": {
+			at: time.Date(2024, time.January, 15, 0, 0, 0, 0, time.UTC),
+			expected: shared.Lpa{
+				Uid:     "M-1111-1111-1111",
+				Status:  shared.LpaStatusStatutoryWaitingPeriod,
+				Version: 2,
+				LpaInit: shared.LpaInit{Donor: shared.Donor{Person: shared.Person{LastName: "Smith"}}},
+			},
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			result, found, err := asOf(lpa, testUpdates, tc.at)
+			assert.Nil(t, err)
+			assert.True(t, found)
+			assert.Equal(t, tc.expected, result)
+		})
+	}
+}
+
+func TestAsOfWhenNotCreated(t *testing.T) {
+	_, found, err := asOf(shared.Lpa{Uid: "M-1111-1111-1111"}, testUpdates, time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC))
+	assert.Nil(t, err)
+	assert.False(t, found)
+}
+
+func TestAsOfWhenLegacy(t *testing.T) {
+	lpa := shared.Lpa{
+		Uid:       "M-1111-1111-1111",
+		Status:    shared.LpaStatusRegistered,
+		UpdatedAt: time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+	}
+	updates := testUpdates[:2]
+
+	testcases := map[string]struct {
+		lpa     shared.Lpa
+		updates []shared.Update
+		at      time.Time
+		found   bool
+	}{
+		"after earliest update": {
+			lpa:     lpa,
+			updates: updates,
+			at:      time.Date(2024, time.February, 15, 0, 0, 0, 0, time.UTC),
+			found:   true,
+		},
+		"before earliest update": {
+			lpa:     lpa,
+			updates: updates,
+			at:      time.Date(2024, time.January, 15, 0, 0, 0, 0, time.UTC),
+		},
+		"after last updated without updates": {
+			lpa:   lpa,
+			at:    time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+			found: true,
+		},
+		"before last updated without updates": {
+			lpa: lpa,
+			at:  time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			_, found, err := asOf(tc.lpa, tc.updates, tc.at)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.found, found)
+		})
+	}
+}
+
+func TestAsOfWhenInvalidApplied(t *testing.T) {
+	_, _, err := asOf(shared.Lpa{}, []shared.Update{{Applied: "yesterday"}}, time.Now())
+	assert.Error(t, err)
+}
+
+func TestAsOfWhenChangeCannotBeReverted(t *testing.T) {
+	_, _, err := asOf(shared.Lpa{}, []shared.Update{{
+		Applied: "2024-01-01T00:00:00Z",
+		Changes: []shared.Change{{Key: "/attorneys/5", Old: json.RawMessage(`{}`)}},
+	}, testUpdates[2]}, time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC))
+	assert.Error(t, err)
+}
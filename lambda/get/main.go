@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"log/slog"
 	"os"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
@@ -13,17 +14,19 @@ import (
 	"github.com/ministryofjustice/opg-data-lpa-store/internal/ddb"
 	"github.com/ministryofjustice/opg-data-lpa-store/internal/objectstore"
 	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/tracing"
 	"github.com/ministryofjustice/opg-go-common/telemetry"
 )
 
 type Logger interface {
-	Error(string, ...any)
-	Info(string, ...any)
-	Debug(string, ...any)
+	ErrorContext(context.Context, string, ...any)
+	InfoContext(context.Context, string, ...any)
+	DebugContext(context.Context, string, ...any)
 }
 
 type Store interface {
 	Get(ctx context.Context, uid string) (shared.Lpa, error)
+	GetChanges(ctx context.Context, uid string) ([]shared.Update, error)
 }
 
 type PresignClient interface {
@@ -31,7 +34,7 @@ type PresignClient interface {
 }
 
 type Verifier interface {
-	VerifyHeader(events.APIGatewayProxyRequest) (*shared.LpaStoreClaims, error)
+	VerifyHeader(context.Context, events.APIGatewayProxyRequest) (*shared.LpaStoreClaims, error)
 }
 
 type Lambda struct {
@@ -42,48 +45,85 @@ type Lambda struct {
 }
 
 func (l *Lambda) HandleEvent(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	_, err := l.verifier.VerifyHeader(event)
+	claims, err := l.verifier.VerifyHeader(ctx, event)
 	if err != nil {
-		l.logger.Info("Unable to verify JWT from header")
-		return shared.ProblemUnauthorisedRequest.Respond()
+		l.logger.InfoContext(ctx, "Unable to verify JWT from header")
+		return shared.ProblemUnauthorisedRequest.Respond(ctx)
 	}
 
-	l.logger.Debug("Successfully parsed JWT from event header")
+	l.logger.DebugContext(ctx, "Successfully parsed JWT from event header")
+
+	if !shared.Authorise(claims, shared.OperationGet) {
+		l.logger.InfoContext(ctx, "JWT not permitted to make request")
+		return shared.ProblemForbidden.Respond(ctx)
+	}
 
 	response := events.APIGatewayProxyResponse{
 		StatusCode: 500,
 		Body:       "{\"code\":\"INTERNAL_SERVER_ERROR\",\"detail\":\"Internal server error\"}",
 	}
 
+	var at time.Time
+	asOfParam, hasAsOf := event.QueryStringParameters["asOf"]
+	if hasAsOf {
+		if at, err = time.Parse(time.RFC3339, asOfParam); err != nil {
+			problem := shared.ProblemInvalidRequest
+			problem.Errors = []shared.FieldError{{Source: "/asOf", Detail: "invalid format"}}
+			return problem.Respond(ctx)
+		}
+	}
+
 	lpa, err := l.store.Get(ctx, event.PathParameters["uid"])
 	if err != nil {
-		l.logger.Error("error fetching LPA", slog.Any("err", err))
-		return shared.ProblemInternalServerError.Respond()
+		l.logger.ErrorContext(ctx, "error fetching LPA", slog.Any("err", err))
+		return shared.ProblemInternalServerError.Respond(ctx)
 	}
 
 	// If item can't be found in DynamoDB then it returns empty object hence 404 error returned if
 	// empty object returned
 	if lpa.Uid == "" {
-		l.logger.Debug("Uid not found")
-		return shared.ProblemNotFoundRequest.Respond()
+		l.logger.DebugContext(ctx, "Uid not found")
+		return shared.ProblemNotFoundRequest.Respond(ctx)
+	}
+
+	if hasAsOf {
+		updates, err := l.store.GetChanges(ctx, lpa.Uid)
+		if err != nil {
+			l.logger.ErrorContext(ctx, "error fetching updates", slog.Any("err", err))
+			return shared.ProblemInternalServerError.Respond(ctx)
+		}
+
+		var found bool
+		if lpa, found, err = asOf(lpa, updates, at); err != nil {
+			l.logger.ErrorContext(ctx, "error reverting updates", slog.Any("err", err))
+			return shared.ProblemInternalServerError.Respond(ctx)
+		}
+
+		if !found {
+			l.logger.DebugContext(ctx, "LPA not created at time")
+			return shared.ProblemNotFoundRequest.Respond(ctx)
+		}
 	}
 
 	_, presignImages := event.QueryStringParameters["presign-images"]
 	if presignImages {
 		lpa, err = l.presignClient.PresignLpa(ctx, lpa)
 		if err != nil {
-			l.logger.Error("error signing URL", slog.Any("err", err))
-			return shared.ProblemInternalServerError.Respond()
+			l.logger.ErrorContext(ctx, "error signing URL", slog.Any("err", err))
+			return shared.ProblemInternalServerError.Respond(ctx)
 		}
 	}
 
 	body, err := json.Marshal(lpa)
 	if err != nil {
-		l.logger.Error("error marshalling LPA", slog.Any("err", err))
-		return shared.ProblemInternalServerError.Respond()
+		l.logger.ErrorContext(ctx, "error marshalling LPA", slog.Any("err", err))
+		return shared.ProblemInternalServerError.Respond(ctx)
 	}
 
 	response.StatusCode = 200
+	if !hasAsOf {
+		response.Headers = map[string]string{"ETag": lpa.ETag()}
+	}
 	response.Body = string(body)
 
 	return response, nil
@@ -91,7 +131,7 @@ func (l *Lambda) HandleEvent(ctx context.Context, event events.APIGatewayProxyRe
 
 func main() {
 	ctx := context.Background()
-	logger := telemetry.NewLogger("opg-data-lpa-store/get")
+	logger := shared.NewRequestLogger(telemetry.NewLogger("opg-data-lpa-store/get"))
 
 	// set endpoint to "" outside dev to use default AWS resolver
 	endpointURL := os.Getenv("AWS_BASE_URL")
@@ -105,19 +145,32 @@ func main() {
 		cfg.BaseEndpoint = aws.String(endpointURL)
 	}
 
+	tp, err := tracing.Start(ctx, "opg-data-lpa-store/get")
+	if err != nil {
+		logger.Error("failed to start tracing", slog.Any("err", err))
+	}
+
+	verifier := shared.NewJWTVerifier(cfg, logger)
+	if tableName := os.Getenv("DDB_TABLE_NAME_TOKENS"); tableName != "" {
+		verifier.Tokens = ddb.NewTokenClient(cfg, tableName)
+	}
+
 	l := &Lambda{
 		store: ddb.New(
 			cfg,
 			os.Getenv("DDB_TABLE_NAME_DEEDS"),
 			os.Getenv("DDB_TABLE_NAME_CHANGES"),
+			os.Getenv("DDB_TABLE_NAME_ACTORS"),
+			os.Getenv("DDB_TABLE_NAME_IDEMPOTENCY"),
+			os.Getenv("DDB_TABLE_NAME_OUTBOX"),
 		),
 		presignClient: objectstore.NewS3Client(
 			cfg,
 			os.Getenv("S3_BUCKET_NAME_ORIGINAL"),
 		),
-		verifier: shared.NewJWTVerifier(cfg, logger),
+		verifier: verifier,
 		logger:   logger,
 	}
 
-	lambda.Start(l.HandleEvent)
+	lambda.Start(tracing.WithTracing(tp, shared.WithRequestID(l.HandleEvent)))
 }
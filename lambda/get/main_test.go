@@ -8,11 +8,17 @@ import (
 	"testing"
 
 	"github.com/aws/aws-lambda-go/events"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
 	"github.com/stretchr/testify/assert"
 	mock "github.com/stretchr/testify/mock"
 )
 
+var siriusClaims = &shared.LpaStoreClaims{RegisteredClaims: jwt.RegisteredClaims{
+	Issuer:  "opg.poas.sirius",
+	Subject: "urn:opg:poas:sirius:users:1",
+}}
+
 var (
 	ctx        = context.WithValue(context.Background(), (*string)(nil), "testing")
 	errExample = errors.New("err")
@@ -23,17 +29,17 @@ func TestLambdaHandleEvent(t *testing.T) {
 		PathParameters: map[string]string{"uid": "my-uid"},
 	}
 
-	lpa := shared.Lpa{Uid: "my-uid"}
+	lpa := shared.Lpa{Uid: "my-uid", Version: 3}
 	body, _ := json.Marshal(lpa)
 
 	verifier := newMockVerifier(t)
 	verifier.EXPECT().
-		VerifyHeader(req).
-		Return(nil, nil)
+		VerifyHeader(mock.Anything, req).
+		Return(siriusClaims, nil)
 
 	logger := newMockLogger(t)
 	logger.EXPECT().
-		Debug("Successfully parsed JWT from event header")
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header")
 
 	store := newMockStore(t)
 	store.EXPECT().
@@ -50,6 +56,7 @@ func TestLambdaHandleEvent(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, events.APIGatewayProxyResponse{
 		StatusCode: 200,
+		Headers:    map[string]string{"ETag": `"3"`},
 		Body:       string(body),
 	}, resp)
 }
@@ -66,12 +73,12 @@ func TestLambdaHandleEventWhenPresignImages(t *testing.T) {
 
 	verifier := newMockVerifier(t)
 	verifier.EXPECT().
-		VerifyHeader(req).
-		Return(nil, nil)
+		VerifyHeader(mock.Anything, req).
+		Return(siriusClaims, nil)
 
 	logger := newMockLogger(t)
 	logger.EXPECT().
-		Debug("Successfully parsed JWT from event header")
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header")
 
 	store := newMockStore(t)
 	store.EXPECT().
@@ -94,6 +101,7 @@ func TestLambdaHandleEventWhenPresignImages(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, events.APIGatewayProxyResponse{
 		StatusCode: 200,
+		Headers:    map[string]string{"ETag": `"0"`},
 		Body:       string(body),
 	}, resp)
 }
@@ -108,14 +116,14 @@ func TestLambdaHandleEventWhenPresignImagesErrors(t *testing.T) {
 
 	verifier := newMockVerifier(t)
 	verifier.EXPECT().
-		VerifyHeader(req).
-		Return(nil, nil)
+		VerifyHeader(mock.Anything, req).
+		Return(siriusClaims, nil)
 
 	logger := newMockLogger(t)
 	logger.EXPECT().
-		Debug("Successfully parsed JWT from event header")
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header")
 	logger.EXPECT().
-		Error("error signing URL", mock.Anything)
+		ErrorContext(mock.Anything, "error signing URL", mock.Anything)
 
 	store := newMockStore(t)
 	store.EXPECT().
@@ -138,7 +146,8 @@ func TestLambdaHandleEventWhenPresignImagesErrors(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, events.APIGatewayProxyResponse{
 		StatusCode: 500,
-		Body:       `{"code":"INTERNAL_SERVER_ERROR","detail":"Internal server error"}`,
+		Headers:    map[string]string{"Content-Type": "application/problem+json"},
+		Body:       `{"type":"urn:opg:poas:lpa-store:problem:internal-server-error","title":"Internal Server Error","status":500,"detail":"Internal server error","code":"INTERNAL_SERVER_ERROR"}`,
 	}, resp)
 }
 
@@ -149,12 +158,12 @@ func TestLambdaHandleEventWhenUnauthorised(t *testing.T) {
 
 	verifier := newMockVerifier(t)
 	verifier.EXPECT().
-		VerifyHeader(req).
+		VerifyHeader(mock.Anything, req).
 		Return(nil, errExample)
 
 	logger := newMockLogger(t)
 	logger.EXPECT().
-		Info("Unable to verify JWT from header")
+		InfoContext(mock.Anything, "Unable to verify JWT from header")
 
 	lambda := &Lambda{
 		verifier: verifier,
@@ -165,7 +174,41 @@ func TestLambdaHandleEventWhenUnauthorised(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, events.APIGatewayProxyResponse{
 		StatusCode: 401,
-		Body:       `{"code":"UNAUTHORISED","detail":"Invalid JWT"}`,
+		Headers:    map[string]string{"Content-Type": "application/problem+json"},
+		Body:       `{"type":"urn:opg:poas:lpa-store:problem:unauthorised","title":"Unauthorized","status":401,"detail":"Invalid JWT","code":"UNAUTHORISED"}`,
+	}, resp)
+}
+
+func TestLambdaHandleEventWhenForbidden(t *testing.T) {
+	req := events.APIGatewayProxyRequest{
+		PathParameters: map[string]string{"uid": "my-uid"},
+	}
+
+	verifier := newMockVerifier(t)
+	verifier.EXPECT().
+		VerifyHeader(mock.Anything, req).
+		Return(&shared.LpaStoreClaims{RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:  "opg.poas.sirius",
+			Subject: "urn:opg:poas:sirius:systems:1",
+		}}, nil)
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header")
+	logger.EXPECT().
+		InfoContext(mock.Anything, "JWT not permitted to make request")
+
+	lambda := &Lambda{
+		verifier: verifier,
+		logger:   logger,
+	}
+
+	resp, err := lambda.HandleEvent(ctx, req)
+	assert.Nil(t, err)
+	assert.Equal(t, events.APIGatewayProxyResponse{
+		StatusCode: 403,
+		Headers:    map[string]string{"Content-Type": "application/problem+json"},
+		Body:       `{"type":"urn:opg:poas:lpa-store:problem:forbidden","title":"Forbidden","status":403,"detail":"Not permitted to make this request","code":"FORBIDDEN"}`,
 	}, resp)
 }
 
@@ -176,14 +219,14 @@ func TestLambdaHandleEventWhenNotFound(t *testing.T) {
 
 	verifier := newMockVerifier(t)
 	verifier.EXPECT().
-		VerifyHeader(req).
-		Return(nil, nil)
+		VerifyHeader(mock.Anything, req).
+		Return(siriusClaims, nil)
 
 	logger := newMockLogger(t)
 	logger.EXPECT().
-		Debug("Successfully parsed JWT from event header")
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header")
 	logger.EXPECT().
-		Debug("Uid not found")
+		DebugContext(mock.Anything, "Uid not found")
 
 	store := newMockStore(t)
 	store.EXPECT().
@@ -200,7 +243,8 @@ func TestLambdaHandleEventWhenNotFound(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, events.APIGatewayProxyResponse{
 		StatusCode: 404,
-		Body:       `{"code":"NOT_FOUND","detail":"Record not found"}`,
+		Headers:    map[string]string{"Content-Type": "application/problem+json"},
+		Body:       `{"type":"urn:opg:poas:lpa-store:problem:not-found","title":"Not Found","status":404,"detail":"Record not found","code":"NOT_FOUND"}`,
 	}, resp)
 }
 
@@ -211,14 +255,14 @@ func TestLambdaHandleEventWhenStoreErrors(t *testing.T) {
 
 	verifier := newMockVerifier(t)
 	verifier.EXPECT().
-		VerifyHeader(req).
-		Return(nil, nil)
+		VerifyHeader(mock.Anything, req).
+		Return(siriusClaims, nil)
 
 	logger := newMockLogger(t)
 	logger.EXPECT().
-		Debug("Successfully parsed JWT from event header")
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header")
 	logger.EXPECT().
-		Error("error fetching LPA", slog.Any("err", errExample))
+		ErrorContext(mock.Anything, "error fetching LPA", slog.Any("err", errExample))
 
 	store := newMockStore(t)
 	store.EXPECT().
@@ -235,6 +279,167 @@ func TestLambdaHandleEventWhenStoreErrors(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, events.APIGatewayProxyResponse{
 		StatusCode: 500,
-		Body:       `{"code":"INTERNAL_SERVER_ERROR","detail":"Internal server error"}`,
+		Headers:    map[string]string{"Content-Type": "application/problem+json"},
+		Body:       `{"type":"urn:opg:poas:lpa-store:problem:internal-server-error","title":"Internal Server Error","status":500,"detail":"Internal server error","code":"INTERNAL_SERVER_ERROR"}`,
+	}, resp)
+}
+
+func TestLambdaHandleEventWhenAsOf(t *testing.T) {
+	req := events.APIGatewayProxyRequest{
+		PathParameters:        map[string]string{"uid": "my-uid"},
+		QueryStringParameters: map[string]string{"asOf": "2024-01-15T00:00:00Z"},
+	}
+
+	body, _ := json.Marshal(shared.Lpa{Uid: "my-uid", Status: shared.LpaStatusInProgress, Version: 1})
+
+	verifier := newMockVerifier(t)
+	verifier.EXPECT().
+		VerifyHeader(mock.Anything, req).
+		Return(siriusClaims, nil)
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header")
+
+	store := newMockStore(t)
+	store.EXPECT().
+		Get(ctx, "my-uid").
+		Return(shared.Lpa{Uid: "my-uid", Status: shared.LpaStatusStatutoryWaitingPeriod, Version: 2}, nil)
+	store.EXPECT().
+		GetChanges(ctx, "my-uid").
+		Return([]shared.Update{{
+			Applied: "2024-02-01T00:00:00Z",
+			Diff: []shared.Change{
+				{Key: "/status", Old: json.RawMessage(`"in-progress"`), New: json.RawMessage(`"statutory-waiting-period"`)},
+				{Key: "/version", Old: json.RawMessage(`1`), New: json.RawMessage(`2`)},
+			},
+		}, {
+			Applied: "2024-01-01T00:00:00Z",
+			Changes: []shared.Change{{Key: "", Old: json.RawMessage(`null`), New: json.RawMessage(`{}`)}},
+		}}, nil)
+
+	lambda := &Lambda{
+		verifier: verifier,
+		logger:   logger,
+		store:    store,
+	}
+
+	resp, err := lambda.HandleEvent(ctx, req)
+	assert.Nil(t, err)
+	assert.Equal(t, events.APIGatewayProxyResponse{
+		StatusCode: 200,
+		Body:       string(body),
+	}, resp)
+}
+
+func TestLambdaHandleEventWhenAsOfBeforeCreated(t *testing.T) {
+	req := events.APIGatewayProxyRequest{
+		PathParameters:        map[string]string{"uid": "my-uid"},
+		QueryStringParameters: map[string]string{"asOf": "2023-01-01T00:00:00Z"},
+	}
+
+	verifier := newMockVerifier(t)
+	verifier.EXPECT().
+		VerifyHeader(mock.Anything, req).
+		Return(siriusClaims, nil)
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header")
+	logger.EXPECT().
+		DebugContext(mock.Anything, "LPA not created at time")
+
+	store := newMockStore(t)
+	store.EXPECT().
+		Get(ctx, "my-uid").
+		Return(shared.Lpa{Uid: "my-uid"}, nil)
+	store.EXPECT().
+		GetChanges(ctx, "my-uid").
+		Return([]shared.Update{{
+			Applied: "2024-01-01T00:00:00Z",
+			Changes: []shared.Change{{Key: "", Old: json.RawMessage(`null`), New: json.RawMessage(`{}`)}},
+		}}, nil)
+
+	lambda := &Lambda{
+		verifier: verifier,
+		logger:   logger,
+		store:    store,
+	}
+
+	resp, err := lambda.HandleEvent(ctx, req)
+	assert.Nil(t, err)
+	assert.Equal(t, events.APIGatewayProxyResponse{
+		StatusCode: 404,
+		Headers:    map[string]string{"Content-Type": "application/problem+json"},
+		Body:       `{"type":"urn:opg:poas:lpa-store:problem:not-found","title":"Not Found","status":404,"detail":"Record not found","code":"NOT_FOUND"}`,
+	}, resp)
+}
+
+func TestLambdaHandleEventWhenAsOfInvalid(t *testing.T) {
+	req := events.APIGatewayProxyRequest{
+		PathParameters:        map[string]string{"uid": "my-uid"},
+		QueryStringParameters: map[string]string{"asOf": "yesterday"},
+	}
+
+	verifier := newMockVerifier(t)
+	verifier.EXPECT().
+		VerifyHeader(mock.Anything, req).
+		Return(siriusClaims, nil)
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header")
+
+	lambda := &Lambda{
+		verifier: verifier,
+		logger:   logger,
+	}
+
+	resp, err := lambda.HandleEvent(ctx, req)
+	assert.Nil(t, err)
+	assert.Equal(t, events.APIGatewayProxyResponse{
+		StatusCode: 400,
+		Headers:    map[string]string{"Content-Type": "application/problem+json"},
+		Body:       `{"type":"urn:opg:poas:lpa-store:problem:invalid-request","title":"Bad Request","status":400,"detail":"Invalid request","code":"INVALID_REQUEST","errors":[{"source":"/asOf","detail":"invalid format"}]}`,
+	}, resp)
+}
+
+func TestLambdaHandleEventWhenAsOfGetChangesErrors(t *testing.T) {
+	req := events.APIGatewayProxyRequest{
+		PathParameters:        map[string]string{"uid": "my-uid"},
+		QueryStringParameters: map[string]string{"asOf": "2024-01-15T00:00:00Z"},
+	}
+
+	verifier := newMockVerifier(t)
+	verifier.EXPECT().
+		VerifyHeader(mock.Anything, req).
+		Return(siriusClaims, nil)
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header")
+	logger.EXPECT().
+		ErrorContext(mock.Anything, "error fetching updates", slog.Any("err", errExample))
+
+	store := newMockStore(t)
+	store.EXPECT().
+		Get(ctx, "my-uid").
+		Return(shared.Lpa{Uid: "my-uid"}, nil)
+	store.EXPECT().
+		GetChanges(ctx, "my-uid").
+		Return(nil, errExample)
+
+	lambda := &Lambda{
+		verifier: verifier,
+		logger:   logger,
+		store:    store,
+	}
+
+	resp, err := lambda.HandleEvent(ctx, req)
+	assert.Nil(t, err)
+	assert.Equal(t, events.APIGatewayProxyResponse{
+		StatusCode: 500,
+		Headers:    map[string]string{"Content-Type": "application/problem+json"},
+		Body:       `{"type":"urn:opg:poas:lpa-store:problem:internal-server-error","title":"Internal Server Error","status":500,"detail":"Internal server error","code":"INTERNAL_SERVER_ERROR"}`,
 	}, resp)
 }
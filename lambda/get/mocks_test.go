@@ -39,164 +39,182 @@ func (_m *mockLogger) EXPECT() *mockLogger_Expecter {
 	return &mockLogger_Expecter{mock: &_m.Mock}
 }
 
-// Debug provides a mock function for the type mockLogger
-func (_mock *mockLogger) Debug(s string, vs ...any) {
+// DebugContext provides a mock function for the type mockLogger
+func (_mock *mockLogger) DebugContext(context1 context.Context, s string, vs ...any) {
 	var _ca []interface{}
-	_ca = append(_ca, s)
+	_ca = append(_ca, context1, s)
 	_ca = append(_ca, vs...)
 	_mock.Called(_ca...)
 	return
 }
 
-// mockLogger_Debug_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Debug'
-type mockLogger_Debug_Call struct {
+// mockLogger_DebugContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DebugContext'
+type mockLogger_DebugContext_Call struct {
 	*mock.Call
 }
 
-// Debug is a helper method to define mock.On call
+// DebugContext is a helper method to define mock.On call
+//   - context1 context.Context
 //   - s string
 //   - vs ...any
-func (_e *mockLogger_Expecter) Debug(s interface{}, vs ...interface{}) *mockLogger_Debug_Call {
-	return &mockLogger_Debug_Call{Call: _e.mock.On("Debug",
-		append([]interface{}{s}, vs...)...)}
+func (_e *mockLogger_Expecter) DebugContext(context1 interface{}, s interface{}, vs ...interface{}) *mockLogger_DebugContext_Call {
+	return &mockLogger_DebugContext_Call{Call: _e.mock.On("DebugContext",
+		append([]interface{}{context1, s}, vs...)...)}
 }
 
-func (_c *mockLogger_Debug_Call) Run(run func(s string, vs ...any)) *mockLogger_Debug_Call {
+func (_c *mockLogger_DebugContext_Call) Run(run func(context1 context.Context, s string, vs ...any)) *mockLogger_DebugContext_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		var arg0 string
+		var arg0 context.Context
 		if args[0] != nil {
-			arg0 = args[0].(string)
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
 		}
-		var arg1 []any
-		variadicArgs := make([]any, len(args)-1)
-		for i, a := range args[1:] {
+		var arg2 []any
+		variadicArgs := make([]any, len(args)-2)
+		for i, a := range args[2:] {
 			if a != nil {
 				variadicArgs[i] = a.(any)
 			}
 		}
-		arg1 = variadicArgs
+		arg2 = variadicArgs
 		run(
 			arg0,
-			arg1...,
+			arg1,
+			arg2...,
 		)
 	})
 	return _c
 }
 
-func (_c *mockLogger_Debug_Call) Return() *mockLogger_Debug_Call {
+func (_c *mockLogger_DebugContext_Call) Return() *mockLogger_DebugContext_Call {
 	_c.Call.Return()
 	return _c
 }
 
-func (_c *mockLogger_Debug_Call) RunAndReturn(run func(s string, vs ...any)) *mockLogger_Debug_Call {
+func (_c *mockLogger_DebugContext_Call) RunAndReturn(run func(context1 context.Context, s string, vs ...any)) *mockLogger_DebugContext_Call {
 	_c.Run(run)
 	return _c
 }
 
-// Error provides a mock function for the type mockLogger
-func (_mock *mockLogger) Error(s string, vs ...any) {
+// ErrorContext provides a mock function for the type mockLogger
+func (_mock *mockLogger) ErrorContext(context1 context.Context, s string, vs ...any) {
 	var _ca []interface{}
-	_ca = append(_ca, s)
+	_ca = append(_ca, context1, s)
 	_ca = append(_ca, vs...)
 	_mock.Called(_ca...)
 	return
 }
 
-// mockLogger_Error_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Error'
-type mockLogger_Error_Call struct {
+// mockLogger_ErrorContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ErrorContext'
+type mockLogger_ErrorContext_Call struct {
 	*mock.Call
 }
 
-// Error is a helper method to define mock.On call
+// ErrorContext is a helper method to define mock.On call
+//   - context1 context.Context
 //   - s string
 //   - vs ...any
-func (_e *mockLogger_Expecter) Error(s interface{}, vs ...interface{}) *mockLogger_Error_Call {
-	return &mockLogger_Error_Call{Call: _e.mock.On("Error",
-		append([]interface{}{s}, vs...)...)}
+func (_e *mockLogger_Expecter) ErrorContext(context1 interface{}, s interface{}, vs ...interface{}) *mockLogger_ErrorContext_Call {
+	return &mockLogger_ErrorContext_Call{Call: _e.mock.On("ErrorContext",
+		append([]interface{}{context1, s}, vs...)...)}
 }
 
-func (_c *mockLogger_Error_Call) Run(run func(s string, vs ...any)) *mockLogger_Error_Call {
+func (_c *mockLogger_ErrorContext_Call) Run(run func(context1 context.Context, s string, vs ...any)) *mockLogger_ErrorContext_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		var arg0 string
+		var arg0 context.Context
 		if args[0] != nil {
-			arg0 = args[0].(string)
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
 		}
-		var arg1 []any
-		variadicArgs := make([]any, len(args)-1)
-		for i, a := range args[1:] {
+		var arg2 []any
+		variadicArgs := make([]any, len(args)-2)
+		for i, a := range args[2:] {
 			if a != nil {
 				variadicArgs[i] = a.(any)
 			}
 		}
-		arg1 = variadicArgs
+		arg2 = variadicArgs
 		run(
 			arg0,
-			arg1...,
+			arg1,
+			arg2...,
 		)
 	})
 	return _c
 }
 
-func (_c *mockLogger_Error_Call) Return() *mockLogger_Error_Call {
+func (_c *mockLogger_ErrorContext_Call) Return() *mockLogger_ErrorContext_Call {
 	_c.Call.Return()
 	return _c
 }
 
-func (_c *mockLogger_Error_Call) RunAndReturn(run func(s string, vs ...any)) *mockLogger_Error_Call {
+func (_c *mockLogger_ErrorContext_Call) RunAndReturn(run func(context1 context.Context, s string, vs ...any)) *mockLogger_ErrorContext_Call {
 	_c.Run(run)
 	return _c
 }
 
-// Info provides a mock function for the type mockLogger
-func (_mock *mockLogger) Info(s string, vs ...any) {
+// InfoContext provides a mock function for the type mockLogger
+func (_mock *mockLogger) InfoContext(context1 context.Context, s string, vs ...any) {
 	var _ca []interface{}
-	_ca = append(_ca, s)
+	_ca = append(_ca, context1, s)
 	_ca = append(_ca, vs...)
 	_mock.Called(_ca...)
 	return
 }
 
-// mockLogger_Info_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Info'
-type mockLogger_Info_Call struct {
+// mockLogger_InfoContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'InfoContext'
+type mockLogger_InfoContext_Call struct {
 	*mock.Call
 }
 
-// Info is a helper method to define mock.On call
+// InfoContext is a helper method to define mock.On call
+//   - context1 context.Context
 //   - s string
 //   - vs ...any
-func (_e *mockLogger_Expecter) Info(s interface{}, vs ...interface{}) *mockLogger_Info_Call {
-	return &mockLogger_Info_Call{Call: _e.mock.On("Info",
-		append([]interface{}{s}, vs...)...)}
+func (_e *mockLogger_Expecter) InfoContext(context1 interface{}, s interface{}, vs ...interface{}) *mockLogger_InfoContext_Call {
+	return &mockLogger_InfoContext_Call{Call: _e.mock.On("InfoContext",
+		append([]interface{}{context1, s}, vs...)...)}
 }
 
-func (_c *mockLogger_Info_Call) Run(run func(s string, vs ...any)) *mockLogger_Info_Call {
+func (_c *mockLogger_InfoContext_Call) Run(run func(context1 context.Context, s string, vs ...any)) *mockLogger_InfoContext_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		var arg0 string
+		var arg0 context.Context
 		if args[0] != nil {
-			arg0 = args[0].(string)
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
 		}
-		var arg1 []any
-		variadicArgs := make([]any, len(args)-1)
-		for i, a := range args[1:] {
+		var arg2 []any
+		variadicArgs := make([]any, len(args)-2)
+		for i, a := range args[2:] {
 			if a != nil {
 				variadicArgs[i] = a.(any)
 			}
 		}
-		arg1 = variadicArgs
+		arg2 = variadicArgs
 		run(
 			arg0,
-			arg1...,
+			arg1,
+			arg2...,
 		)
 	})
 	return _c
 }
 
-func (_c *mockLogger_Info_Call) Return() *mockLogger_Info_Call {
+func (_c *mockLogger_InfoContext_Call) Return() *mockLogger_InfoContext_Call {
 	_c.Call.Return()
 	return _c
 }
 
-func (_c *mockLogger_Info_Call) RunAndReturn(run func(s string, vs ...any)) *mockLogger_Info_Call {
+func (_c *mockLogger_InfoContext_Call) RunAndReturn(run func(context1 context.Context, s string, vs ...any)) *mockLogger_InfoContext_Call {
 	_c.Run(run)
 	return _c
 }
@@ -294,6 +312,74 @@ func (_c *mockStore_Get_Call) RunAndReturn(run func(ctx context.Context, uid str
 	return _c
 }
 
+// GetChanges provides a mock function for the type mockStore
+func (_mock *mockStore) GetChanges(ctx context.Context, uid string) ([]shared.Update, error) {
+	ret := _mock.Called(ctx, uid)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetChanges")
+	}
+
+	var r0 []shared.Update
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) ([]shared.Update, error)); ok {
+		return returnFunc(ctx, uid)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) []shared.Update); ok {
+		r0 = returnFunc(ctx, uid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]shared.Update)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, uid)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockStore_GetChanges_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetChanges'
+type mockStore_GetChanges_Call struct {
+	*mock.Call
+}
+
+// GetChanges is a helper method to define mock.On call
+//   - ctx context.Context
+//   - uid string
+func (_e *mockStore_Expecter) GetChanges(ctx interface{}, uid interface{}) *mockStore_GetChanges_Call {
+	return &mockStore_GetChanges_Call{Call: _e.mock.On("GetChanges", ctx, uid)}
+}
+
+func (_c *mockStore_GetChanges_Call) Run(run func(ctx context.Context, uid string)) *mockStore_GetChanges_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *mockStore_GetChanges_Call) Return(updates []shared.Update, err error) *mockStore_GetChanges_Call {
+	_c.Call.Return(updates, err)
+	return _c
+}
+
+func (_c *mockStore_GetChanges_Call) RunAndReturn(run func(ctx context.Context, uid string) ([]shared.Update, error)) *mockStore_GetChanges_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // newMockPresignClient creates a new instance of mockPresignClient. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func newMockPresignClient(t interface {
@@ -415,8 +501,8 @@ func (_m *mockVerifier) EXPECT() *mockVerifier_Expecter {
 }
 
 // VerifyHeader provides a mock function for the type mockVerifier
-func (_mock *mockVerifier) VerifyHeader(aPIGatewayProxyRequest events.APIGatewayProxyRequest) (*shared.LpaStoreClaims, error) {
-	ret := _mock.Called(aPIGatewayProxyRequest)
+func (_mock *mockVerifier) VerifyHeader(context1 context.Context, aPIGatewayProxyRequest events.APIGatewayProxyRequest) (*shared.LpaStoreClaims, error) {
+	ret := _mock.Called(context1, aPIGatewayProxyRequest)
 
 	if len(ret) == 0 {
 		panic("no return value specified for VerifyHeader")
@@ -424,18 +510,18 @@ func (_mock *mockVerifier) VerifyHeader(aPIGatewayProxyRequest events.APIGateway
 
 	var r0 *shared.LpaStoreClaims
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(events.APIGatewayProxyRequest) (*shared.LpaStoreClaims, error)); ok {
-		return returnFunc(aPIGatewayProxyRequest)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, events.APIGatewayProxyRequest) (*shared.LpaStoreClaims, error)); ok {
+		return returnFunc(context1, aPIGatewayProxyRequest)
 	}
-	if returnFunc, ok := ret.Get(0).(func(events.APIGatewayProxyRequest) *shared.LpaStoreClaims); ok {
-		r0 = returnFunc(aPIGatewayProxyRequest)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, events.APIGatewayProxyRequest) *shared.LpaStoreClaims); ok {
+		r0 = returnFunc(context1, aPIGatewayProxyRequest)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*shared.LpaStoreClaims)
 		}
 	}
-	if returnFunc, ok := ret.Get(1).(func(events.APIGatewayProxyRequest) error); ok {
-		r1 = returnFunc(aPIGatewayProxyRequest)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, events.APIGatewayProxyRequest) error); ok {
+		r1 = returnFunc(context1, aPIGatewayProxyRequest)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -448,19 +534,25 @@ type mockVerifier_VerifyHeader_Call struct {
 }
 
 // VerifyHeader is a helper method to define mock.On call
+//   - context1 context.Context
 //   - aPIGatewayProxyRequest events.APIGatewayProxyRequest
-func (_e *mockVerifier_Expecter) VerifyHeader(aPIGatewayProxyRequest interface{}) *mockVerifier_VerifyHeader_Call {
-	return &mockVerifier_VerifyHeader_Call{Call: _e.mock.On("VerifyHeader", aPIGatewayProxyRequest)}
+func (_e *mockVerifier_Expecter) VerifyHeader(context1 interface{}, aPIGatewayProxyRequest interface{}) *mockVerifier_VerifyHeader_Call {
+	return &mockVerifier_VerifyHeader_Call{Call: _e.mock.On("VerifyHeader", context1, aPIGatewayProxyRequest)}
 }
 
-func (_c *mockVerifier_VerifyHeader_Call) Run(run func(aPIGatewayProxyRequest events.APIGatewayProxyRequest)) *mockVerifier_VerifyHeader_Call {
+func (_c *mockVerifier_VerifyHeader_Call) Run(run func(context1 context.Context, aPIGatewayProxyRequest events.APIGatewayProxyRequest)) *mockVerifier_VerifyHeader_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		var arg0 events.APIGatewayProxyRequest
+		var arg0 context.Context
 		if args[0] != nil {
-			arg0 = args[0].(events.APIGatewayProxyRequest)
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 events.APIGatewayProxyRequest
+		if args[1] != nil {
+			arg1 = args[1].(events.APIGatewayProxyRequest)
 		}
 		run(
 			arg0,
+			arg1,
 		)
 	})
 	return _c
@@ -471,7 +563,7 @@ func (_c *mockVerifier_VerifyHeader_Call) Return(lpaStoreClaims *shared.LpaStore
 	return _c
 }
 
-func (_c *mockVerifier_VerifyHeader_Call) RunAndReturn(run func(aPIGatewayProxyRequest events.APIGatewayProxyRequest) (*shared.LpaStoreClaims, error)) *mockVerifier_VerifyHeader_Call {
+func (_c *mockVerifier_VerifyHeader_Call) RunAndReturn(run func(context1 context.Context, aPIGatewayProxyRequest events.APIGatewayProxyRequest) (*shared.LpaStoreClaims, error)) *mockVerifier_VerifyHeader_Call {
 	_c.Call.Return(run)
 	return _c
 }
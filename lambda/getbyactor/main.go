@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"slices"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/ddb"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/tracing"
+	"github.com/ministryofjustice/opg-go-common/telemetry"
+)
+
+type Logger interface {
+	ErrorContext(context.Context, string, ...any)
+	InfoContext(context.Context, string, ...any)
+	DebugContext(context.Context, string, ...any)
+}
+
+type Store interface {
+	GetUidsByActor(ctx context.Context, actorUid string) ([]string, error)
+	GetList(ctx context.Context, uids []string) ([]shared.Lpa, error)
+}
+
+type Verifier interface {
+	VerifyHeader(context.Context, events.APIGatewayProxyRequest) (*shared.LpaStoreClaims, error)
+}
+
+type Lambda struct {
+	store    Store
+	verifier Verifier
+	logger   Logger
+}
+
+type lpasResponse struct {
+	Lpas []shared.Lpa `json:"lpas"`
+}
+
+func (l *Lambda) HandleEvent(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	claims, err := l.verifier.VerifyHeader(ctx, event)
+	if err != nil {
+		l.logger.InfoContext(ctx, "Unable to verify JWT from header")
+		return shared.ProblemUnauthorisedRequest.Respond(ctx)
+	}
+
+	l.logger.DebugContext(ctx, "Successfully parsed JWT from event header")
+
+	if !shared.Authorise(claims, shared.OperationGetByActor) {
+		l.logger.InfoContext(ctx, "JWT not permitted to make request")
+		return shared.ProblemForbidden.Respond(ctx)
+	}
+
+	response := events.APIGatewayProxyResponse{
+		StatusCode: 500,
+		Body:       "{\"code\":\"INTERNAL_SERVER_ERROR\",\"detail\":\"Internal server error\"}",
+	}
+
+	actorUid := event.PathParameters["actorUid"]
+
+	uids, err := l.store.GetUidsByActor(ctx, actorUid)
+	if err != nil {
+		l.logger.ErrorContext(ctx, "error fetching LPA UIDs for actor", slog.Any("err", err))
+		return shared.ProblemInternalServerError.Respond(ctx)
+	}
+
+	lpas := []shared.Lpa{}
+	if len(uids) > 0 {
+		found, err := l.store.GetList(ctx, uids)
+		if err != nil {
+			l.logger.ErrorContext(ctx, "error fetching LPAs", slog.Any("err", err))
+			return shared.ProblemInternalServerError.Respond(ctx)
+		}
+
+		// the actors table is written in the same transaction as the LPA, but
+		// check anyway so that a stale mapping can never expose an LPA
+		for _, lpa := range found {
+			if slices.Contains(lpa.ActorUIDs(), actorUid) {
+				lpas = append(lpas, lpa)
+			}
+		}
+	}
+
+	body, err := json.Marshal(lpasResponse{Lpas: lpas})
+	if err != nil {
+		l.logger.ErrorContext(ctx, "error marshalling LPAs", slog.Any("err", err))
+		return shared.ProblemInternalServerError.Respond(ctx)
+	}
+
+	response.StatusCode = 200
+	response.Body = string(body)
+
+	return response, nil
+}
+
+func main() {
+	ctx := context.Background()
+	logger := shared.NewRequestLogger(telemetry.NewLogger("opg-data-lpa-store/getbyactor"))
+
+	// set endpoint to "" outside dev to use default AWS resolver
+	endpointURL := os.Getenv("AWS_BASE_URL")
+
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		logger.Error("failed to load aws config", slog.Any("err", err))
+	}
+
+	if endpointURL != "" {
+		cfg.BaseEndpoint = aws.String(endpointURL)
+	}
+
+	tp, err := tracing.Start(ctx, "opg-data-lpa-store/getbyactor")
+	if err != nil {
+		logger.Error("failed to start tracing", slog.Any("err", err))
+	}
+
+	verifier := shared.NewJWTVerifier(cfg, logger)
+	if tableName := os.Getenv("DDB_TABLE_NAME_TOKENS"); tableName != "" {
+		verifier.Tokens = ddb.NewTokenClient(cfg, tableName)
+	}
+
+	l := &Lambda{
+		store: ddb.New(
+			cfg,
+			os.Getenv("DDB_TABLE_NAME_DEEDS"),
+			os.Getenv("DDB_TABLE_NAME_CHANGES"),
+			os.Getenv("DDB_TABLE_NAME_ACTORS"),
+			os.Getenv("DDB_TABLE_NAME_IDEMPOTENCY"),
+			os.Getenv("DDB_TABLE_NAME_OUTBOX"),
+		),
+		verifier: verifier,
+		logger:   logger,
+	}
+
+	lambda.Start(tracing.WithTracing(tp, shared.WithRequestID(l.HandleEvent)))
+}
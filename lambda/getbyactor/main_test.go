@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var siriusClaims = &shared.LpaStoreClaims{RegisteredClaims: jwt.RegisteredClaims{
+	Issuer:  "opg.poas.sirius",
+	Subject: "urn:opg:poas:sirius:users:1",
+}}
+
+var (
+	ctx        = context.WithValue(context.Background(), (*string)(nil), "testing")
+	errExample = errors.New("err")
+)
+
+func TestLambdaHandleEvent(t *testing.T) {
+	req := events.APIGatewayProxyRequest{
+		PathParameters: map[string]string{"actorUid": "an-actor"},
+	}
+
+	verifier := newMockVerifier(t)
+	verifier.EXPECT().
+		VerifyHeader(mock.Anything, req).
+		Return(siriusClaims, nil)
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header")
+
+	lpa := shared.Lpa{Uid: "M-1111-1111-1111", LpaInit: shared.LpaInit{Donor: shared.Donor{Person: shared.Person{UID: "an-actor"}}}}
+	staleLpa := shared.Lpa{Uid: "M-2222-2222-2222", LpaInit: shared.LpaInit{Donor: shared.Donor{Person: shared.Person{UID: "another-actor"}}}}
+	body, _ := json.Marshal(lpasResponse{Lpas: []shared.Lpa{lpa}})
+
+	store := newMockStore(t)
+	store.EXPECT().
+		GetUidsByActor(ctx, "an-actor").
+		Return([]string{"M-1111-1111-1111", "M-2222-2222-2222"}, nil)
+	store.EXPECT().
+		GetList(ctx, []string{"M-1111-1111-1111", "M-2222-2222-2222"}).
+		Return([]shared.Lpa{lpa, staleLpa}, nil)
+
+	lambda := &Lambda{
+		verifier: verifier,
+		logger:   logger,
+		store:    store,
+	}
+
+	resp, err := lambda.HandleEvent(ctx, req)
+	assert.Nil(t, err)
+	assert.Equal(t, events.APIGatewayProxyResponse{
+		StatusCode: 200,
+		Body:       string(body),
+	}, resp)
+}
+
+func TestLambdaHandleEventWhenNoLpas(t *testing.T) {
+	req := events.APIGatewayProxyRequest{
+		PathParameters: map[string]string{"actorUid": "an-actor"},
+	}
+
+	verifier := newMockVerifier(t)
+	verifier.EXPECT().
+		VerifyHeader(mock.Anything, req).
+		Return(siriusClaims, nil)
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header")
+
+	store := newMockStore(t)
+	store.EXPECT().
+		GetUidsByActor(ctx, "an-actor").
+		Return(nil, nil)
+
+	lambda := &Lambda{
+		verifier: verifier,
+		logger:   logger,
+		store:    store,
+	}
+
+	resp, err := lambda.HandleEvent(ctx, req)
+	assert.Nil(t, err)
+	assert.Equal(t, events.APIGatewayProxyResponse{
+		StatusCode: 200,
+		Body:       `{"lpas":[]}`,
+	}, resp)
+}
+
+func TestLambdaHandleEventWhenUnauthorised(t *testing.T) {
+	req := events.APIGatewayProxyRequest{
+		PathParameters: map[string]string{"actorUid": "an-actor"},
+	}
+
+	verifier := newMockVerifier(t)
+	verifier.EXPECT().
+		VerifyHeader(mock.Anything, req).
+		Return(nil, errExample)
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		InfoContext(mock.Anything, "Unable to verify JWT from header")
+
+	lambda := &Lambda{
+		verifier: verifier,
+		logger:   logger,
+	}
+
+	resp, err := lambda.HandleEvent(ctx, req)
+	assert.Nil(t, err)
+	assert.Equal(t, events.APIGatewayProxyResponse{
+		StatusCode: 401,
+		Headers:    map[string]string{"Content-Type": "application/problem+json"},
+		Body:       `{"type":"urn:opg:poas:lpa-store:problem:unauthorised","title":"Unauthorized","status":401,"detail":"Invalid JWT","code":"UNAUTHORISED"}`,
+	}, resp)
+}
+
+func TestLambdaHandleEventWhenGetUidsByActorErrors(t *testing.T) {
+	req := events.APIGatewayProxyRequest{
+		PathParameters: map[string]string{"actorUid": "an-actor"},
+	}
+
+	verifier := newMockVerifier(t)
+	verifier.EXPECT().
+		VerifyHeader(mock.Anything, req).
+		Return(siriusClaims, nil)
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header")
+	logger.EXPECT().
+		ErrorContext(mock.Anything, "error fetching LPA UIDs for actor", slog.Any("err", errExample))
+
+	store := newMockStore(t)
+	store.EXPECT().
+		GetUidsByActor(ctx, "an-actor").
+		Return(nil, errExample)
+
+	lambda := &Lambda{
+		verifier: verifier,
+		logger:   logger,
+		store:    store,
+	}
+
+	resp, err := lambda.HandleEvent(ctx, req)
+	assert.Nil(t, err)
+	assert.Equal(t, events.APIGatewayProxyResponse{
+		StatusCode: 500,
+		Headers:    map[string]string{"Content-Type": "application/problem+json"},
+		Body:       `{"type":"urn:opg:poas:lpa-store:problem:internal-server-error","title":"Internal Server Error","status":500,"detail":"Internal server error","code":"INTERNAL_SERVER_ERROR"}`,
+	}, resp)
+}
+
+func TestLambdaHandleEventWhenGetListErrors(t *testing.T) {
+	req := events.APIGatewayProxyRequest{
+		PathParameters: map[string]string{"actorUid": "an-actor"},
+	}
+
+	verifier := newMockVerifier(t)
+	verifier.EXPECT().
+		VerifyHeader(mock.Anything, req).
+		Return(siriusClaims, nil)
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header")
+	logger.EXPECT().
+		ErrorContext(mock.Anything, "error fetching LPAs", slog.Any("err", errExample))
+
+	store := newMockStore(t)
+	store.EXPECT().
+		GetUidsByActor(ctx, "an-actor").
+		Return([]string{"M-1111-1111-1111"}, nil)
+	store.EXPECT().
+		GetList(ctx, []string{"M-1111-1111-1111"}).
+		Return(nil, errExample)
+
+	lambda := &Lambda{
+		verifier: verifier,
+		logger:   logger,
+		store:    store,
+	}
+
+	resp, err := lambda.HandleEvent(ctx, req)
+	assert.Nil(t, err)
+	assert.Equal(t, events.APIGatewayProxyResponse{
+		StatusCode: 500,
+		Headers:    map[string]string{"Content-Type": "application/problem+json"},
+		Body:       `{"type":"urn:opg:poas:lpa-store:problem:internal-server-error","title":"Internal Server Error","status":500,"detail":"Internal server error","code":"INTERNAL_SERVER_ERROR"}`,
+	}, resp)
+}
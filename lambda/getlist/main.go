@@ -13,13 +13,14 @@ import (
 	"github.com/ministryofjustice/opg-data-lpa-store/internal/ddb"
 	"github.com/ministryofjustice/opg-data-lpa-store/internal/objectstore"
 	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/tracing"
 	"github.com/ministryofjustice/opg-go-common/telemetry"
 )
 
 type Logger interface {
-	Error(string, ...any)
-	Info(string, ...any)
-	Debug(string, ...any)
+	ErrorContext(context.Context, string, ...any)
+	InfoContext(context.Context, string, ...any)
+	DebugContext(context.Context, string, ...any)
 }
 
 type Store interface {
@@ -31,7 +32,7 @@ type PresignClient interface {
 }
 
 type Verifier interface {
-	VerifyHeader(events.APIGatewayProxyRequest) (*shared.LpaStoreClaims, error)
+	VerifyHeader(context.Context, events.APIGatewayProxyRequest) (*shared.LpaStoreClaims, error)
 }
 
 type Lambda struct {
@@ -41,22 +42,25 @@ type Lambda struct {
 	logger        Logger
 }
 
-type lpasRequest struct {
-	UIDs []string `json:"uids"`
-}
-
 type lpasResponse struct {
-	Lpas []shared.Lpa `json:"lpas"`
+	Lpas      []any    `json:"lpas"`
+	NotFound  []string `json:"notFound,omitempty"`
+	NextToken string   `json:"nextToken,omitempty"`
 }
 
 func (l *Lambda) HandleEvent(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	_, err := l.verifier.VerifyHeader(event)
+	claims, err := l.verifier.VerifyHeader(ctx, event)
 	if err != nil {
-		l.logger.Info("Unable to verify JWT from header")
-		return shared.ProblemUnauthorisedRequest.Respond()
+		l.logger.InfoContext(ctx, "Unable to verify JWT from header")
+		return shared.ProblemUnauthorisedRequest.Respond(ctx)
 	}
 
-	l.logger.Debug("Successfully parsed JWT from event header")
+	l.logger.DebugContext(ctx, "Successfully parsed JWT from event header")
+
+	if !shared.Authorise(claims, shared.OperationGetList) {
+		l.logger.InfoContext(ctx, "JWT not permitted to make request")
+		return shared.ProblemForbidden.Respond(ctx)
+	}
 
 	response := events.APIGatewayProxyResponse{
 		StatusCode: 500,
@@ -65,14 +69,21 @@ func (l *Lambda) HandleEvent(ctx context.Context, event events.APIGatewayProxyRe
 
 	var req lpasRequest
 	if err := json.Unmarshal([]byte(event.Body), &req); err != nil {
-		l.logger.Error("error unmarshalling request", slog.Any("err", err))
-		return shared.ProblemInternalServerError.Respond()
+		l.logger.ErrorContext(ctx, "error unmarshalling request", slog.Any("err", err))
+		return shared.ProblemInternalServerError.Respond(ctx)
 	}
 
-	lpas, err := l.store.GetList(ctx, req.UIDs)
+	offset, errs := req.validate()
+	if len(errs) > 0 {
+		problem := shared.ProblemInvalidRequest
+		problem.Errors = errs
+		return problem.Respond(ctx)
+	}
+
+	lpas, missing, next, err := l.page(ctx, req, offset)
 	if err != nil {
-		l.logger.Error("error fetching LPAs", slog.Any("err", err))
-		return shared.ProblemInternalServerError.Respond()
+		l.logger.ErrorContext(ctx, "error fetching LPAs", slog.Any("err", err))
+		return shared.ProblemInternalServerError.Respond(ctx)
 	}
 
 	_, presignImages := event.QueryStringParameters["presign-images"]
@@ -80,16 +91,28 @@ func (l *Lambda) HandleEvent(ctx context.Context, event events.APIGatewayProxyRe
 		for i, lpa := range lpas {
 			lpas[i], err = l.presignClient.PresignLpa(ctx, lpa)
 			if err != nil {
-				l.logger.Error("error signing URL", slog.Any("err", err))
-				return shared.ProblemInternalServerError.Respond()
+				l.logger.ErrorContext(ctx, "error signing URL", slog.Any("err", err))
+				return shared.ProblemInternalServerError.Respond(ctx)
 			}
 		}
 	}
 
-	body, err := json.Marshal(lpasResponse{Lpas: lpas})
+	result := lpasResponse{Lpas: make([]any, len(lpas)), NotFound: missing}
+	if next < len(req.UIDs) {
+		result.NextToken = encodeToken(next)
+	}
+
+	for i, lpa := range lpas {
+		if result.Lpas[i], err = req.project(lpa); err != nil {
+			l.logger.ErrorContext(ctx, "error projecting LPA", slog.Any("err", err))
+			return shared.ProblemInternalServerError.Respond(ctx)
+		}
+	}
+
+	body, err := json.Marshal(result)
 	if err != nil {
-		l.logger.Error("error marshalling LPA", slog.Any("err", err))
-		return shared.ProblemInternalServerError.Respond()
+		l.logger.ErrorContext(ctx, "error marshalling LPA", slog.Any("err", err))
+		return shared.ProblemInternalServerError.Respond(ctx)
 	}
 
 	response.StatusCode = 200
@@ -98,9 +121,62 @@ func (l *Lambda) HandleEvent(ctx context.Context, event events.APIGatewayProxyRe
 	return response, nil
 }
 
+// page fetches the LPAs matching the request's filters, starting at offset in
+// the requested UIDs, until limit LPAs are found. It returns the UIDs without
+// an LPA that were passed over, and the offset that the next page starts from.
+func (l *Lambda) page(ctx context.Context, req lpasRequest, offset int) ([]shared.Lpa, []string, int, error) {
+	limit := req.limit()
+
+	var (
+		lpas    []shared.Lpa
+		missing []string
+		seen    = map[string]bool{}
+	)
+
+	for offset < len(req.UIDs) {
+		chunk := req.UIDs[offset:min(offset+limit, len(req.UIDs))]
+
+		found, err := l.store.GetList(ctx, chunk)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+
+		byUid := make(map[string]shared.Lpa, len(found))
+		for _, lpa := range found {
+			byUid[lpa.Uid] = lpa
+		}
+
+		for i, uid := range chunk {
+			if seen[uid] {
+				continue
+			}
+			seen[uid] = true
+
+			lpa, ok := byUid[uid]
+			if !ok {
+				missing = append(missing, uid)
+				continue
+			}
+
+			if !req.matches(lpa) {
+				continue
+			}
+
+			lpas = append(lpas, lpa)
+			if len(lpas) == limit {
+				return lpas, missing, offset + i + 1, nil
+			}
+		}
+
+		offset += len(chunk)
+	}
+
+	return lpas, missing, offset, nil
+}
+
 func main() {
 	ctx := context.Background()
-	logger := telemetry.NewLogger("opg-data-lpa-store/getlist")
+	logger := shared.NewRequestLogger(telemetry.NewLogger("opg-data-lpa-store/getlist"))
 
 	// set endpoint to "" outside dev to use default AWS resolver
 	endpointURL := os.Getenv("AWS_BASE_URL")
@@ -114,19 +190,32 @@ func main() {
 		cfg.BaseEndpoint = aws.String(endpointURL)
 	}
 
+	tp, err := tracing.Start(ctx, "opg-data-lpa-store/getlist")
+	if err != nil {
+		logger.Error("failed to start tracing", slog.Any("err", err))
+	}
+
+	verifier := shared.NewJWTVerifier(cfg, logger)
+	if tableName := os.Getenv("DDB_TABLE_NAME_TOKENS"); tableName != "" {
+		verifier.Tokens = ddb.NewTokenClient(cfg, tableName)
+	}
+
 	l := &Lambda{
 		store: ddb.New(
 			cfg,
 			os.Getenv("DDB_TABLE_NAME_DEEDS"),
 			os.Getenv("DDB_TABLE_NAME_CHANGES"),
+			os.Getenv("DDB_TABLE_NAME_ACTORS"),
+			os.Getenv("DDB_TABLE_NAME_IDEMPOTENCY"),
+			os.Getenv("DDB_TABLE_NAME_OUTBOX"),
 		),
 		presignClient: objectstore.NewS3Client(
 			cfg,
 			os.Getenv("S3_BUCKET_NAME_ORIGINAL"),
 		),
-		verifier: shared.NewJWTVerifier(cfg, logger),
+		verifier: verifier,
 		logger:   logger,
 	}
 
-	lambda.Start(l.HandleEvent)
+	lambda.Start(tracing.WithTracing(tp, shared.WithRequestID(l.HandleEvent)))
 }
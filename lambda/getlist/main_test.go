@@ -5,14 +5,21 @@ import (
 	"encoding/json"
 	"errors"
 	"log/slog"
+	"strings"
 	"testing"
 
 	"github.com/aws/aws-lambda-go/events"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
+var siriusClaims = &shared.LpaStoreClaims{RegisteredClaims: jwt.RegisteredClaims{
+	Issuer:  "opg.poas.sirius",
+	Subject: "urn:opg:poas:sirius:users:1",
+}}
+
 type ctxValueType string
 
 const ctxValue ctxValueType = "for"
@@ -28,16 +35,16 @@ func TestLambdaHandleEvent(t *testing.T) {
 	}
 
 	lpas := []shared.Lpa{{Uid: "my-uid"}, {Uid: "another-uid"}}
-	body, _ := json.Marshal(lpasResponse{Lpas: lpas})
+	body, _ := json.Marshal(lpasResponse{Lpas: []any{lpas[0], lpas[1]}})
 
 	verifier := newMockVerifier(t)
 	verifier.EXPECT().
-		VerifyHeader(req).
-		Return(nil, nil)
+		VerifyHeader(mock.Anything, req).
+		Return(siriusClaims, nil)
 
 	logger := newMockLogger(t)
 	logger.EXPECT().
-		Debug("Successfully parsed JWT from event header")
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header")
 
 	store := newMockStore(t)
 	store.EXPECT().
@@ -58,6 +65,208 @@ func TestLambdaHandleEvent(t *testing.T) {
 	}, resp)
 }
 
+func TestLambdaHandleEventWhenNotFound(t *testing.T) {
+	req := events.APIGatewayProxyRequest{
+		Body: `{"uids":["my-uid","missing-uid","another-uid","missing-uid"]}`,
+	}
+
+	verifier := newMockVerifier(t)
+	verifier.EXPECT().
+		VerifyHeader(mock.Anything, req).
+		Return(siriusClaims, nil)
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header")
+
+	store := newMockStore(t)
+	store.EXPECT().
+		GetList(ctx, []string{"my-uid", "missing-uid", "another-uid", "missing-uid"}).
+		Return([]shared.Lpa{{Uid: "my-uid"}, {Uid: "another-uid"}}, nil)
+
+	lambda := &Lambda{
+		verifier: verifier,
+		logger:   logger,
+		store:    store,
+	}
+
+	resp, err := lambda.HandleEvent(ctx, req)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var body struct {
+		Lpas     []shared.Lpa
+		NotFound []string
+	}
+	_ = json.Unmarshal([]byte(resp.Body), &body)
+	assert.Equal(t, []shared.Lpa{{Uid: "my-uid"}, {Uid: "another-uid"}}, body.Lpas)
+	assert.Equal(t, []string{"missing-uid"}, body.NotFound)
+}
+
+func TestLambdaHandleEventWhenPaged(t *testing.T) {
+	testcases := map[string]struct {
+		body      string
+		gets      map[string][]shared.Lpa
+		lpas      []string
+		notFound  []string
+		nextToken string
+	}{
+		"first page": {
+			body: `{"uids":["a","b","c","d","e"],"limit":2}`,
+			gets: map[string][]shared.Lpa{
+				"a,b": {{Uid: "a"}, {Uid: "b"}},
+			},
+			lpas:      []string{"a", "b"},
+			nextToken: encodeToken(2),
+		},
+		"last page": {
+			body: `{"uids":["a","b","c","d","e"],"limit":2,"nextToken":"` + encodeToken(4) + `"}`,
+			gets: map[string][]shared.Lpa{
+				"e": {{Uid: "e"}},
+			},
+			lpas: []string{"e"},
+		},
+		"filtered": {
+			body: `{"uids":["a","b","c","d","e"],"limit":2,"status":["registered"],"lpaType":["personal-welfare"]}`,
+			gets: map[string][]shared.Lpa{
+				"a,b": {
+					{Uid: "a", Status: shared.LpaStatusRegistered, LpaInit: shared.LpaInit{LpaType: shared.LpaTypePersonalWelfare}},
+					{Uid: "b", Status: shared.LpaStatusInProgress, LpaInit: shared.LpaInit{LpaType: shared.LpaTypePersonalWelfare}},
+				},
+				"c,d": {
+					{Uid: "c", Status: shared.LpaStatusRegistered, LpaInit: shared.LpaInit{LpaType: shared.LpaTypePropertyAndAffairs}},
+					{Uid: "d", Status: shared.LpaStatusRegistered, LpaInit: shared.LpaInit{LpaType: shared.LpaTypePersonalWelfare}},
+				},
+			},
+			lpas:      []string{"a", "d"},
+			nextToken: encodeToken(4),
+		},
+		"filtered to end": {
+			body: `{"uids":["a","b","c"],"limit":2,"channel":["paper"]}`,
+			gets: map[string][]shared.Lpa{
+				"a,b": {{Uid: "a", LpaInit: shared.LpaInit{Channel: shared.ChannelOnline}}},
+				"c":   {{Uid: "c", LpaInit: shared.LpaInit{Channel: shared.ChannelPaper}}},
+			},
+			lpas:     []string{"c"},
+			notFound: []string{"b"},
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			req := events.APIGatewayProxyRequest{Body: tc.body}
+
+			verifier := newMockVerifier(t)
+			verifier.EXPECT().
+				VerifyHeader(mock.Anything, req).
+				Return(siriusClaims, nil)
+
+			logger := newMockLogger(t)
+			logger.EXPECT().
+				DebugContext(mock.Anything, "Successfully parsed JWT from event header")
+
+			store := newMockStore(t)
+			for uids, lpas := range tc.gets {
+				store.EXPECT().
+					GetList(ctx, strings.Split(uids, ",")).
+					Return(lpas, nil).
+					Once()
+			}
+
+			lambda := &Lambda{
+				verifier: verifier,
+				logger:   logger,
+				store:    store,
+			}
+
+			resp, err := lambda.HandleEvent(ctx, req)
+			assert.Nil(t, err)
+			assert.Equal(t, 200, resp.StatusCode)
+
+			var body struct {
+				Lpas      []shared.Lpa
+				NotFound  []string
+				NextToken string
+			}
+			_ = json.Unmarshal([]byte(resp.Body), &body)
+
+			var uids []string
+			for _, lpa := range body.Lpas {
+				uids = append(uids, lpa.Uid)
+			}
+
+			assert.Equal(t, tc.lpas, uids)
+			assert.Equal(t, tc.notFound, body.NotFound)
+			assert.Equal(t, tc.nextToken, body.NextToken)
+		})
+	}
+}
+
+func TestLambdaHandleEventWhenFields(t *testing.T) {
+	req := events.APIGatewayProxyRequest{
+		Body: `{"uids":["my-uid"],"fields":["status","lpaType","missing"]}`,
+	}
+
+	verifier := newMockVerifier(t)
+	verifier.EXPECT().
+		VerifyHeader(mock.Anything, req).
+		Return(siriusClaims, nil)
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header")
+
+	store := newMockStore(t)
+	store.EXPECT().
+		GetList(ctx, []string{"my-uid"}).
+		Return([]shared.Lpa{{
+			Uid:     "my-uid",
+			Status:  shared.LpaStatusRegistered,
+			LpaInit: shared.LpaInit{LpaType: shared.LpaTypePersonalWelfare, Donor: shared.Donor{Person: shared.Person{FirstNames: "John"}}},
+		}}, nil)
+
+	lambda := &Lambda{
+		verifier: verifier,
+		logger:   logger,
+		store:    store,
+	}
+
+	resp, err := lambda.HandleEvent(ctx, req)
+	assert.Nil(t, err)
+	assert.Equal(t, events.APIGatewayProxyResponse{
+		StatusCode: 200,
+		Body:       `{"lpas":[{"lpaType":"personal-welfare","status":"registered","uid":"my-uid"}]}`,
+	}, resp)
+}
+
+func TestLambdaHandleEventWhenInvalidOptions(t *testing.T) {
+	req := events.APIGatewayProxyRequest{
+		Body: `{"uids":["my-uid"],"limit":101,"nextToken":"what","status":["nope"],"lpaType":["nope"],"channel":["nope"],"fields":[""]}`,
+	}
+
+	verifier := newMockVerifier(t)
+	verifier.EXPECT().
+		VerifyHeader(mock.Anything, req).
+		Return(siriusClaims, nil)
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header")
+
+	lambda := &Lambda{
+		verifier: verifier,
+		logger:   logger,
+	}
+
+	resp, err := lambda.HandleEvent(ctx, req)
+	assert.Nil(t, err)
+	assert.Equal(t, events.APIGatewayProxyResponse{
+		StatusCode: 400,
+		Headers:    map[string]string{"Content-Type": "application/problem+json"},
+		Body:       `{"type":"urn:opg:poas:lpa-store:problem:invalid-request","title":"Bad Request","status":400,"detail":"Invalid request","code":"INVALID_REQUEST","errors":[{"source":"/limit","detail":"must be between 1 and 100"},{"source":"/nextToken","detail":"invalid value"},{"source":"/status/0","detail":"invalid value"},{"source":"/lpaType/0","detail":"invalid value"},{"source":"/channel/0","detail":"invalid value"},{"source":"/fields/0","detail":"field is required"}]}`,
+	}, resp)
+}
+
 func TestLambdaHandleEventWhenPresignImages(t *testing.T) {
 	req := events.APIGatewayProxyRequest{
 		Body:                  `{"uids":["my-uid","another-uid"]}`,
@@ -66,16 +275,16 @@ func TestLambdaHandleEventWhenPresignImages(t *testing.T) {
 
 	lpas := []shared.Lpa{{Uid: "my-uid"}, {Uid: "another-uid"}}
 	presignedLpas := []shared.Lpa{{Uid: "my-uid2"}, {Uid: "another-uid2"}}
-	body, _ := json.Marshal(lpasResponse{Lpas: presignedLpas})
+	body, _ := json.Marshal(lpasResponse{Lpas: []any{presignedLpas[0], presignedLpas[1]}})
 
 	verifier := newMockVerifier(t)
 	verifier.EXPECT().
-		VerifyHeader(req).
-		Return(nil, nil)
+		VerifyHeader(mock.Anything, req).
+		Return(siriusClaims, nil)
 
 	logger := newMockLogger(t)
 	logger.EXPECT().
-		Debug("Successfully parsed JWT from event header")
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header")
 
 	store := newMockStore(t)
 	store.EXPECT().
@@ -117,14 +326,14 @@ func TestLambdaHandleEventWhenPresignImagesErrors(t *testing.T) {
 
 	verifier := newMockVerifier(t)
 	verifier.EXPECT().
-		VerifyHeader(req).
-		Return(nil, nil)
+		VerifyHeader(mock.Anything, req).
+		Return(siriusClaims, nil)
 
 	logger := newMockLogger(t)
 	logger.EXPECT().
-		Debug("Successfully parsed JWT from event header")
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header")
 	logger.EXPECT().
-		Error("error signing URL", mock.Anything)
+		ErrorContext(mock.Anything, "error signing URL", mock.Anything)
 
 	store := newMockStore(t)
 	store.EXPECT().
@@ -147,7 +356,8 @@ func TestLambdaHandleEventWhenPresignImagesErrors(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, events.APIGatewayProxyResponse{
 		StatusCode: 500,
-		Body:       `{"code":"INTERNAL_SERVER_ERROR","detail":"Internal server error"}`,
+		Headers:    map[string]string{"Content-Type": "application/problem+json"},
+		Body:       `{"type":"urn:opg:poas:lpa-store:problem:internal-server-error","title":"Internal Server Error","status":500,"detail":"Internal server error","code":"INTERNAL_SERVER_ERROR"}`,
 	}, resp)
 }
 
@@ -158,12 +368,12 @@ func TestLambdaHandleEventWhenUnauthorised(t *testing.T) {
 
 	verifier := newMockVerifier(t)
 	verifier.EXPECT().
-		VerifyHeader(req).
+		VerifyHeader(mock.Anything, req).
 		Return(nil, errors.New("hey"))
 
 	logger := newMockLogger(t)
 	logger.EXPECT().
-		Info("Unable to verify JWT from header")
+		InfoContext(mock.Anything, "Unable to verify JWT from header")
 
 	lambda := &Lambda{
 		verifier: verifier,
@@ -174,7 +384,8 @@ func TestLambdaHandleEventWhenUnauthorised(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, events.APIGatewayProxyResponse{
 		StatusCode: 401,
-		Body:       `{"code":"UNAUTHORISED","detail":"Invalid JWT"}`,
+		Headers:    map[string]string{"Content-Type": "application/problem+json"},
+		Body:       `{"type":"urn:opg:poas:lpa-store:problem:unauthorised","title":"Unauthorized","status":401,"detail":"Invalid JWT","code":"UNAUTHORISED"}`,
 	}, resp)
 }
 
@@ -185,14 +396,14 @@ func TestLambdaHandleEventWhenBadRequest(t *testing.T) {
 
 	verifier := newMockVerifier(t)
 	verifier.EXPECT().
-		VerifyHeader(req).
-		Return(nil, nil)
+		VerifyHeader(mock.Anything, req).
+		Return(siriusClaims, nil)
 
 	logger := newMockLogger(t)
 	logger.EXPECT().
-		Debug("Successfully parsed JWT from event header")
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header")
 	logger.EXPECT().
-		Error("error unmarshalling request", mock.Anything)
+		ErrorContext(mock.Anything, "error unmarshalling request", mock.Anything)
 
 	lambda := &Lambda{
 		verifier: verifier,
@@ -203,7 +414,8 @@ func TestLambdaHandleEventWhenBadRequest(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, events.APIGatewayProxyResponse{
 		StatusCode: 500,
-		Body:       `{"code":"INTERNAL_SERVER_ERROR","detail":"Internal server error"}`,
+		Headers:    map[string]string{"Content-Type": "application/problem+json"},
+		Body:       `{"type":"urn:opg:poas:lpa-store:problem:internal-server-error","title":"Internal Server Error","status":500,"detail":"Internal server error","code":"INTERNAL_SERVER_ERROR"}`,
 	}, resp)
 }
 
@@ -214,14 +426,14 @@ func TestLambdaHandleEventWhenStoreErrors(t *testing.T) {
 
 	verifier := newMockVerifier(t)
 	verifier.EXPECT().
-		VerifyHeader(req).
-		Return(nil, nil)
+		VerifyHeader(mock.Anything, req).
+		Return(siriusClaims, nil)
 
 	logger := newMockLogger(t)
 	logger.EXPECT().
-		Debug("Successfully parsed JWT from event header")
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header")
 	logger.EXPECT().
-		Error("error fetching LPAs", slog.Any("err", errExpected))
+		ErrorContext(mock.Anything, "error fetching LPAs", slog.Any("err", errExpected))
 
 	store := newMockStore(t)
 	store.EXPECT().
@@ -238,6 +450,7 @@ func TestLambdaHandleEventWhenStoreErrors(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, events.APIGatewayProxyResponse{
 		StatusCode: 500,
-		Body:       `{"code":"INTERNAL_SERVER_ERROR","detail":"Internal server error"}`,
+		Headers:    map[string]string{"Content-Type": "application/problem+json"},
+		Body:       `{"type":"urn:opg:poas:lpa-store:problem:internal-server-error","title":"Internal Server Error","status":500,"detail":"Internal server error","code":"INTERNAL_SERVER_ERROR"}`,
 	}, resp)
 }
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/validate"
+)
+
+const (
+	maxLimit = 100
+
+	// defaultLimit is used when the request has no limit. It is as large as
+	// allowed, as callers could previously get every LPA without paging.
+	defaultLimit = maxLimit
+)
+
+type lpasRequest struct {
+	UIDs      []string           `json:"uids"`
+	Limit     *int               `json:"limit,omitempty"`
+	NextToken string             `json:"nextToken,omitempty"`
+	Status    []shared.LpaStatus `json:"status,omitempty"`
+	LpaType   []shared.LpaType   `json:"lpaType,omitempty"`
+	Channel   []shared.Channel   `json:"channel,omitempty"`
+	Fields    []string           `json:"fields,omitempty"`
+}
+
+// validate checks the paging, filter and projection options, returning the
+// offset into UIDs that the requested page starts from.
+func (r lpasRequest) validate() (int, []shared.FieldError) {
+	offset, err := decodeToken(r.NextToken)
+
+	errs := validate.All(
+		validate.If(r.Limit != nil && (*r.Limit < 1 || *r.Limit > maxLimit), []shared.FieldError{{Source: "/limit", Detail: fmt.Sprintf("must be between 1 and %d", maxLimit)}}),
+		validate.If(err != nil || offset > len(r.UIDs), []shared.FieldError{{Source: "/nextToken", Detail: "invalid value"}}),
+		validateEach("/status", r.Status),
+		validateEach("/lpaType", r.LpaType),
+		validateEach("/channel", r.Channel),
+	)
+
+	for i, field := range r.Fields {
+		errs = append(errs, validate.WithSource(fmt.Sprintf("/fields/%d", i), field, validate.NotEmpty())...)
+	}
+
+	return offset, errs
+}
+
+// limit is the most LPAs to return in a page.
+func (r lpasRequest) limit() int {
+	if r.Limit == nil {
+		return defaultLimit
+	}
+
+	return *r.Limit
+}
+
+func validateEach[T any](source string, values []T) (errs []shared.FieldError) {
+	for i, value := range values {
+		errs = append(errs, validate.WithSource(fmt.Sprintf("%s/%d", source, i), value, validate.Valid())...)
+	}
+
+	return errs
+}
+
+// matches reports whether the LPA satisfies every filter in the request. An
+// empty filter matches everything.
+func (r lpasRequest) matches(lpa shared.Lpa) bool {
+	return (len(r.Status) == 0 || slices.Contains(r.Status, lpa.Status)) &&
+		(len(r.LpaType) == 0 || slices.Contains(r.LpaType, lpa.LpaType)) &&
+		(len(r.Channel) == 0 || slices.Contains(r.Channel, lpa.Channel))
+}
+
+// project reduces the LPA to the requested top-level fields. The uid is always
+// kept so that callers can tell the results apart.
+func (r lpasRequest) project(lpa shared.Lpa) (any, error) {
+	if len(r.Fields) == 0 {
+		return lpa, nil
+	}
+
+	data, err := json.Marshal(lpa)
+	if err != nil {
+		return nil, err
+	}
+
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+
+	projected := map[string]json.RawMessage{"uid": all["uid"]}
+	for _, field := range r.Fields {
+		if v, ok := all[field]; ok {
+			projected[field] = v
+		}
+	}
+
+	return projected, nil
+}
+
+// encodeToken and decodeToken convert between an offset into the requested
+// UIDs and the opaque nextToken given to callers.
+func encodeToken(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset)))
+}
+
+func decodeToken(token string) (int, error) {
+	if token == "" {
+		return 0, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, err
+	}
+
+	offset, err := strconv.Atoi(string(data))
+	if err != nil {
+		return 0, err
+	}
+
+	if offset < 0 {
+		return 0, fmt.Errorf("negative offset %d", offset)
+	}
+
+	return offset, nil
+}
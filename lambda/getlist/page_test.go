@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToken(t *testing.T) {
+	for _, offset := range []int{0, 1, 99, 1234} {
+		decoded, err := decodeToken(encodeToken(offset))
+		assert.Nil(t, err)
+		assert.Equal(t, offset, decoded)
+	}
+}
+
+func TestDecodeTokenWhenEmpty(t *testing.T) {
+	offset, err := decodeToken("")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, offset)
+}
+
+func TestDecodeTokenWhenInvalid(t *testing.T) {
+	for name, token := range map[string]string{
+		"not base64":   "!!",
+		"not a number": base64.RawURLEncoding.EncodeToString([]byte("abc")),
+		"negative":     base64.RawURLEncoding.EncodeToString([]byte("-1")),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := decodeToken(token)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLpasRequestLimit(t *testing.T) {
+	for body, limit := range map[string]int{
+		`{"uids":["a"]}`:           defaultLimit,
+		`{"uids":["a"],"limit":5}`: 5,
+	} {
+		t.Run(body, func(t *testing.T) {
+			var req lpasRequest
+			_ = json.Unmarshal([]byte(body), &req)
+
+			assert.Equal(t, limit, req.limit())
+		})
+	}
+}
+
+func TestLpasRequestValidateLimit(t *testing.T) {
+	for body, errs := range map[string][]shared.FieldError{
+		`{"uids":["a"]}`:             nil,
+		`{"uids":["a"],"limit":1}`:   nil,
+		`{"uids":["a"],"limit":100}`: nil,
+		`{"uids":["a"],"limit":0}`:   {{Source: "/limit", Detail: "must be between 1 and 100"}},
+		`{"uids":["a"],"limit":101}`: {{Source: "/limit", Detail: "must be between 1 and 100"}},
+	} {
+		t.Run(body, func(t *testing.T) {
+			var req lpasRequest
+			_ = json.Unmarshal([]byte(body), &req)
+
+			_, result := req.validate()
+			assert.Equal(t, errs, result)
+		})
+	}
+}
@@ -12,19 +12,21 @@ import (
   "github.com/aws/aws-lambda-go/lambda"
   "github.com/aws/aws-sdk-go-v2/aws"
   "github.com/aws/aws-sdk-go-v2/config"
+  "github.com/ministryofjustice/opg-data-lpa-store/internal/ddb"
   "github.com/ministryofjustice/opg-data-lpa-store/internal/objectstore"
   "github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+  "github.com/ministryofjustice/opg-data-lpa-store/internal/tracing"
   "github.com/ministryofjustice/opg-go-common/telemetry"
 )
 
 type Logger interface {
-  Error(string, ...any)
-  Info(string, ...any)
-  Debug(string, ...any)
+  ErrorContext(context.Context, string, ...any)
+  InfoContext(context.Context, string, ...any)
+  DebugContext(context.Context, string, ...any)
 }
 
 type Verifier interface {
-  VerifyHeader(events.APIGatewayProxyRequest) (*shared.LpaStoreClaims, error)
+  VerifyHeader(context.Context, events.APIGatewayProxyRequest) (*shared.LpaStoreClaims, error)
 }
 
 type S3Client interface {
@@ -38,13 +40,18 @@ type Lambda struct {
 }
 
 func (l *Lambda) HandleEvent(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-  _, err := l.verifier.VerifyHeader(event)
+  claims, err := l.verifier.VerifyHeader(ctx, event)
   if err != nil {
-    l.logger.Info("Unable to verify JWT from header")
-    return shared.ProblemUnauthorisedRequest.Respond()
+    l.logger.InfoContext(ctx, "Unable to verify JWT from header")
+    return shared.ProblemUnauthorisedRequest.Respond(ctx)
   }
 
-  l.logger.Debug("Successfully parsed JWT from event header")
+  l.logger.DebugContext(ctx, "Successfully parsed JWT from event header")
+
+  if !shared.Authorise(claims, shared.OperationGetStatic) {
+    l.logger.InfoContext(ctx, "JWT not permitted to make request")
+    return shared.ProblemForbidden.Respond(ctx)
+  }
 
   response := events.APIGatewayProxyResponse{
     StatusCode: 500,
@@ -54,16 +61,16 @@ func (l *Lambda) HandleEvent(ctx context.Context, event events.APIGatewayProxyRe
   objectKey := fmt.Sprintf("%s/donor-executed-lpa.json", event.PathParameters["uid"])
   data, err := l.staticLpaStorage.Get(ctx, objectKey)
   if err != nil {
-    l.logger.Error("error fetching static LPA", slog.Any("err", err))
+    l.logger.ErrorContext(ctx, "error fetching static LPA", slog.Any("err", err))
 
     var nsu *types.NoSuchUpload
     var nsk *types.NoSuchKey
 
     if errors.As(err, &nsu) || errors.As(err, &nsk) {
-      return shared.ProblemNotFoundRequest.Respond()
+      return shared.ProblemNotFoundRequest.Respond(ctx)
     }
 
-    return shared.ProblemInternalServerError.Respond()
+    return shared.ProblemInternalServerError.Respond(ctx)
   }
 
   response.StatusCode = 200
@@ -74,7 +81,7 @@ func (l *Lambda) HandleEvent(ctx context.Context, event events.APIGatewayProxyRe
 
 func main() {
   ctx := context.Background()
-  logger := telemetry.NewLogger("opg-data-lpa-store/getstatic")
+  logger := shared.NewRequestLogger(telemetry.NewLogger("opg-data-lpa-store/getstatic"))
 
   // set endpoint to "" outside dev to use default AWS resolver
   endpointURL := os.Getenv("AWS_BASE_URL")
@@ -88,14 +95,24 @@ func main() {
     cfg.BaseEndpoint = aws.String(endpointURL)
   }
 
+  tp, err := tracing.Start(ctx, "opg-data-lpa-store/getstatic")
+  if err != nil {
+    logger.Error("failed to start tracing", slog.Any("err", err))
+  }
+
+  verifier := shared.NewJWTVerifier(cfg, logger)
+  if tableName := os.Getenv("DDB_TABLE_NAME_TOKENS"); tableName != "" {
+    verifier.Tokens = ddb.NewTokenClient(cfg, tableName)
+  }
+
   l := &Lambda{
     logger: logger,
     staticLpaStorage: objectstore.NewS3Client(
       cfg,
       os.Getenv("S3_BUCKET_NAME_ORIGINAL"),
     ),
-    verifier: shared.NewJWTVerifier(cfg, logger),
+    verifier: verifier,
   }
 
-  lambda.Start(l.HandleEvent)
+  lambda.Start(tracing.WithTracing(tp, shared.WithRequestID(l.HandleEvent)))
 }
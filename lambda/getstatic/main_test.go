@@ -7,10 +7,17 @@ import (
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
+var siriusClaims = &shared.LpaStoreClaims{RegisteredClaims: jwt.RegisteredClaims{
+	Issuer:  "opg.poas.sirius",
+	Subject: "urn:opg:poas:sirius:users:1",
+}}
+
 var (
 	ctx        = context.WithValue(context.Background(), (*string)(nil), "testing")
 	errExample = errors.New("err")
@@ -23,12 +30,12 @@ func TestLambdaHandleEventHeadersNotVerified(t *testing.T) {
 
 	verifier := newMockVerifier(t)
 	verifier.EXPECT().
-		VerifyHeader(req).
+		VerifyHeader(mock.Anything, req).
 		Return(nil, errExample)
 
 	logger := newMockLogger(t)
 	logger.EXPECT().
-		Info("Unable to verify JWT from header")
+		InfoContext(mock.Anything, "Unable to verify JWT from header")
 
 	lambda := &Lambda{
 		verifier: verifier,
@@ -39,7 +46,8 @@ func TestLambdaHandleEventHeadersNotVerified(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, events.APIGatewayProxyResponse{
 		StatusCode: 401,
-		Body:       `{"code":"UNAUTHORISED","detail":"Invalid JWT"}`,
+		Headers:    map[string]string{"Content-Type": "application/problem+json"},
+		Body:       `{"type":"urn:opg:poas:lpa-store:problem:unauthorised","title":"Unauthorized","status":401,"detail":"Invalid JWT","code":"UNAUTHORISED"}`,
 	}, resp)
 }
 
@@ -48,17 +56,17 @@ func TestLambdaHandleEventStaticLpaNotFound(t *testing.T) {
 		"generic error, 500 response": {
 			"error":                errExample,
 			"expectedResponseCode": 500,
-			"expectedResponseBody": `{"code":"INTERNAL_SERVER_ERROR","detail":"Internal server error"}`,
+			"expectedResponseBody": `{"type":"urn:opg:poas:lpa-store:problem:internal-server-error","title":"Internal Server Error","status":500,"detail":"Internal server error","code":"INTERNAL_SERVER_ERROR"}`,
 		},
 		"NoSuchUpload error, 404 response": {
 			"error":                &types.NoSuchUpload{},
 			"expectedResponseCode": 404,
-			"expectedResponseBody": `{"code":"NOT_FOUND","detail":"Record not found"}`,
+			"expectedResponseBody": `{"type":"urn:opg:poas:lpa-store:problem:not-found","title":"Not Found","status":404,"detail":"Record not found","code":"NOT_FOUND"}`,
 		},
 		"NoSuchKey error, 404 response": {
 			"error":                &types.NoSuchKey{},
 			"expectedResponseCode": 404,
-			"expectedResponseBody": `{"code":"NOT_FOUND","detail":"Record not found"}`,
+			"expectedResponseBody": `{"type":"urn:opg:poas:lpa-store:problem:not-found","title":"Not Found","status":404,"detail":"Record not found","code":"NOT_FOUND"}`,
 		},
 	}
 
@@ -70,12 +78,12 @@ func TestLambdaHandleEventStaticLpaNotFound(t *testing.T) {
 
 			verifier := newMockVerifier(t)
 			verifier.EXPECT().
-				VerifyHeader(req).
-				Return(nil, nil)
+				VerifyHeader(mock.Anything, req).
+				Return(siriusClaims, nil)
 
 			logger := newMockLogger(t)
 			logger.EXPECT().
-				Debug("Successfully parsed JWT from event header")
+				DebugContext(mock.Anything, "Successfully parsed JWT from event header")
 
 			staticLpaStorage := newMockS3Client(t)
 			staticLpaStorage.EXPECT().
@@ -83,7 +91,7 @@ func TestLambdaHandleEventStaticLpaNotFound(t *testing.T) {
 				Return("", tc["error"].(error))
 
 			logger.EXPECT().
-				Error("error fetching static LPA", mock.Anything)
+				ErrorContext(mock.Anything, "error fetching static LPA", mock.Anything)
 
 			lambda := &Lambda{
 				verifier:         verifier,
@@ -95,6 +103,7 @@ func TestLambdaHandleEventStaticLpaNotFound(t *testing.T) {
 			assert.Nil(t, err)
 			assert.Equal(t, events.APIGatewayProxyResponse{
 				StatusCode: tc["expectedResponseCode"].(int),
+				Headers:    map[string]string{"Content-Type": "application/problem+json"},
 				Body:       tc["expectedResponseBody"].(string),
 			}, resp)
 		})
@@ -108,12 +117,12 @@ func TestLambdaHandleEventStaticLpaReturned(t *testing.T) {
 
 	verifier := newMockVerifier(t)
 	verifier.EXPECT().
-		VerifyHeader(req).
-		Return(nil, nil)
+		VerifyHeader(mock.Anything, req).
+		Return(siriusClaims, nil)
 
 	logger := newMockLogger(t)
 	logger.EXPECT().
-		Debug("Successfully parsed JWT from event header")
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header")
 
 	staticLpaStorage := newMockS3Client(t)
 	staticLpaStorage.EXPECT().
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/ddb"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/tracing"
+	"github.com/ministryofjustice/opg-go-common/telemetry"
+)
+
+type Logger interface {
+	ErrorContext(context.Context, string, ...any)
+	InfoContext(context.Context, string, ...any)
+	DebugContext(context.Context, string, ...any)
+}
+
+type Store interface {
+	Get(ctx context.Context, uid string) (shared.Lpa, error)
+}
+
+type Verifier interface {
+	VerifyHeader(context.Context, events.APIGatewayProxyRequest) (*shared.LpaStoreClaims, error)
+}
+
+type Lambda struct {
+	store    Store
+	verifier Verifier
+	logger   Logger
+}
+
+type response struct {
+	Status       shared.LpaStatus   `json:"status"`
+	NextStatuses []shared.LpaStatus `json:"nextStatuses"`
+}
+
+func (l *Lambda) HandleEvent(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	claims, err := l.verifier.VerifyHeader(ctx, event)
+	if err != nil {
+		l.logger.InfoContext(ctx, "Unable to verify JWT from header")
+		return shared.ProblemUnauthorisedRequest.Respond(ctx)
+	}
+
+	l.logger.DebugContext(ctx, "Successfully parsed JWT from event header")
+
+	if !shared.Authorise(claims, shared.OperationGetStatuses) {
+		l.logger.InfoContext(ctx, "JWT not permitted to make request")
+		return shared.ProblemForbidden.Respond(ctx)
+	}
+
+	lpa, err := l.store.Get(ctx, event.PathParameters["uid"])
+	if err != nil {
+		l.logger.ErrorContext(ctx, "error fetching LPA", slog.Any("err", err))
+		return shared.ProblemInternalServerError.Respond(ctx)
+	}
+
+	if lpa.Uid == "" {
+		l.logger.DebugContext(ctx, "Uid not found")
+		return shared.ProblemNotFoundRequest.Respond(ctx)
+	}
+
+	nextStatuses := lpa.Status.NextStatuses()
+	if nextStatuses == nil {
+		nextStatuses = []shared.LpaStatus{}
+	}
+
+	body, err := json.Marshal(response{
+		Status:       lpa.Status,
+		NextStatuses: nextStatuses,
+	})
+	if err != nil {
+		l.logger.ErrorContext(ctx, "error marshalling statuses", slog.Any("err", err))
+		return shared.ProblemInternalServerError.Respond(ctx)
+	}
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: 200,
+		Headers:    map[string]string{"ETag": lpa.ETag()},
+		Body:       string(body),
+	}, nil
+}
+
+func main() {
+	ctx := context.Background()
+	logger := shared.NewRequestLogger(telemetry.NewLogger("opg-data-lpa-store/getstatuses"))
+
+	// set endpoint to "" outside dev to use default AWS resolver
+	endpointURL := os.Getenv("AWS_BASE_URL")
+
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		logger.Error("failed to load aws config", slog.Any("err", err))
+	}
+
+	if endpointURL != "" {
+		cfg.BaseEndpoint = aws.String(endpointURL)
+	}
+
+	tp, err := tracing.Start(ctx, "opg-data-lpa-store/getstatuses")
+	if err != nil {
+		logger.Error("failed to start tracing", slog.Any("err", err))
+	}
+
+	verifier := shared.NewJWTVerifier(cfg, logger)
+	if tableName := os.Getenv("DDB_TABLE_NAME_TOKENS"); tableName != "" {
+		verifier.Tokens = ddb.NewTokenClient(cfg, tableName)
+	}
+
+	l := &Lambda{
+		store: ddb.New(
+			cfg,
+			os.Getenv("DDB_TABLE_NAME_DEEDS"),
+			os.Getenv("DDB_TABLE_NAME_CHANGES"),
+			os.Getenv("DDB_TABLE_NAME_ACTORS"),
+			os.Getenv("DDB_TABLE_NAME_IDEMPOTENCY"),
+			os.Getenv("DDB_TABLE_NAME_OUTBOX"),
+		),
+		verifier: verifier,
+		logger:   logger,
+	}
+
+	lambda.Start(tracing.WithTracing(tp, shared.WithRequestID(l.HandleEvent)))
+}
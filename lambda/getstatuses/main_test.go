@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var siriusClaims = &shared.LpaStoreClaims{RegisteredClaims: jwt.RegisteredClaims{
+	Issuer:  "opg.poas.sirius",
+	Subject: "urn:opg:poas:sirius:users:1",
+}}
+
+var (
+	ctx        = context.WithValue(context.Background(), (*string)(nil), "testing")
+	errExample = errors.New("err")
+	req        = events.APIGatewayProxyRequest{PathParameters: map[string]string{"uid": "M-1111-1111-1111"}}
+)
+
+func TestLambdaHandleEvent(t *testing.T) {
+	testcases := map[string]struct {
+		status shared.LpaStatus
+		body   string
+	}{
+		"in progress": {
+			status: shared.LpaStatusInProgress,
+			body:   `{"status":"in-progress","nextStatuses":["statutory-waiting-period","cannot-register","withdrawn","expired","donor-deceased"]}`,
+		},
+		"withdrawn": {
+			status: shared.LpaStatusWithdrawn,
+			body:   `{"status":"withdrawn","nextStatuses":["cannot-register"]}`,
+		},
+		"donor deceased": {
+			status: shared.LpaStatusDonorDeceased,
+			body:   `{"status":"donor-deceased","nextStatuses":[]}`,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			verifier := newMockVerifier(t)
+			verifier.EXPECT().
+				VerifyHeader(mock.Anything, req).
+				Return(siriusClaims, nil)
+
+			logger := newMockLogger(t)
+			logger.EXPECT().
+				DebugContext(mock.Anything, "Successfully parsed JWT from event header")
+
+			store := newMockStore(t)
+			store.EXPECT().
+				Get(ctx, "M-1111-1111-1111").
+				Return(shared.Lpa{Uid: "M-1111-1111-1111", Status: tc.status, Version: 2}, nil)
+
+			lambda := &Lambda{
+				verifier: verifier,
+				logger:   logger,
+				store:    store,
+			}
+
+			resp, err := lambda.HandleEvent(ctx, req)
+			assert.Nil(t, err)
+			assert.Equal(t, 200, resp.StatusCode)
+			assert.Equal(t, `"2"`, resp.Headers["ETag"])
+			assert.JSONEq(t, tc.body, resp.Body)
+		})
+	}
+}
+
+func TestLambdaHandleEventWhenNotVerified(t *testing.T) {
+	verifier := newMockVerifier(t)
+	verifier.EXPECT().
+		VerifyHeader(mock.Anything, req).
+		Return(nil, errExample)
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		InfoContext(mock.Anything, "Unable to verify JWT from header")
+
+	lambda := &Lambda{
+		verifier: verifier,
+		logger:   logger,
+	}
+
+	resp, err := lambda.HandleEvent(ctx, req)
+	assert.Nil(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+}
+
+func TestLambdaHandleEventWhenNotFound(t *testing.T) {
+	verifier := newMockVerifier(t)
+	verifier.EXPECT().
+		VerifyHeader(mock.Anything, req).
+		Return(siriusClaims, nil)
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header")
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Uid not found")
+
+	store := newMockStore(t)
+	store.EXPECT().
+		Get(ctx, "M-1111-1111-1111").
+		Return(shared.Lpa{}, nil)
+
+	lambda := &Lambda{
+		verifier: verifier,
+		logger:   logger,
+		store:    store,
+	}
+
+	resp, err := lambda.HandleEvent(ctx, req)
+	assert.Nil(t, err)
+	assert.Equal(t, 404, resp.StatusCode)
+}
+
+func TestLambdaHandleEventWhenStoreErrors(t *testing.T) {
+	verifier := newMockVerifier(t)
+	verifier.EXPECT().
+		VerifyHeader(mock.Anything, req).
+		Return(siriusClaims, nil)
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header")
+	logger.EXPECT().
+		ErrorContext(mock.Anything, "error fetching LPA", slog.Any("err", errExample))
+
+	store := newMockStore(t)
+	store.EXPECT().
+		Get(ctx, mock.Anything).
+		Return(shared.Lpa{}, errExample)
+
+	lambda := &Lambda{
+		verifier: verifier,
+		logger:   logger,
+		store:    store,
+	}
+
+	resp, err := lambda.HandleEvent(ctx, req)
+	assert.Nil(t, err)
+	assert.Equal(t, 500, resp.StatusCode)
+}
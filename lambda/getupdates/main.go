@@ -12,13 +12,14 @@ import (
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/ministryofjustice/opg-data-lpa-store/internal/ddb"
 	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/tracing"
 	"github.com/ministryofjustice/opg-go-common/telemetry"
 )
 
 type Logger interface {
-	Error(string, ...any)
-	Info(string, ...any)
-	Debug(string, ...any)
+	ErrorContext(context.Context, string, ...any)
+	InfoContext(context.Context, string, ...any)
+	DebugContext(context.Context, string, ...any)
 }
 
 type Store interface {
@@ -26,7 +27,7 @@ type Store interface {
 }
 
 type Verifier interface {
-	VerifyHeader(events.APIGatewayProxyRequest) (*shared.LpaStoreClaims, error)
+	VerifyHeader(context.Context, events.APIGatewayProxyRequest) (*shared.LpaStoreClaims, error)
 }
 
 type Lambda struct {
@@ -36,13 +37,18 @@ type Lambda struct {
 }
 
 func (l *Lambda) HandleEvent(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	_, err := l.verifier.VerifyHeader(event)
+	claims, err := l.verifier.VerifyHeader(ctx, event)
 	if err != nil {
-		l.logger.Info("Unable to verify JWT from header")
-		return shared.ProblemUnauthorisedRequest.Respond()
+		l.logger.InfoContext(ctx, "Unable to verify JWT from header")
+		return shared.ProblemUnauthorisedRequest.Respond(ctx)
 	}
 
-	l.logger.Debug("Successfully parsed JWT from event header")
+	l.logger.DebugContext(ctx, "Successfully parsed JWT from event header")
+
+	if !shared.Authorise(claims, shared.OperationGetUpdates) {
+		l.logger.InfoContext(ctx, "JWT not permitted to make request")
+		return shared.ProblemForbidden.Respond(ctx)
+	}
 
 	response := events.APIGatewayProxyResponse{
 		StatusCode: 500,
@@ -51,19 +57,19 @@ func (l *Lambda) HandleEvent(ctx context.Context, event events.APIGatewayProxyRe
 
 	changes, err := l.store.GetChanges(ctx, event.PathParameters["uid"])
 	if err != nil {
-		l.logger.Error("error fetching updates", slog.Any("err", err))
-		return shared.ProblemInternalServerError.Respond()
+		l.logger.ErrorContext(ctx, "error fetching updates", slog.Any("err", err))
+		return shared.ProblemInternalServerError.Respond(ctx)
 	}
 
 	if len(changes) == 0 {
-		l.logger.Debug("No updates found")
-		return shared.ProblemNotFoundRequest.Respond()
+		l.logger.DebugContext(ctx, "No updates found")
+		return shared.ProblemNotFoundRequest.Respond(ctx)
 	}
 
 	body, err := json.Marshal(changes)
 	if err != nil {
-		l.logger.Error("error marshalling changes", slog.Any("err", err))
-		return shared.ProblemInternalServerError.Respond()
+		l.logger.ErrorContext(ctx, "error marshalling changes", slog.Any("err", err))
+		return shared.ProblemInternalServerError.Respond(ctx)
 	}
 
 	response.StatusCode = 200
@@ -74,7 +80,7 @@ func (l *Lambda) HandleEvent(ctx context.Context, event events.APIGatewayProxyRe
 
 func main() {
 	ctx := context.Background()
-	logger := telemetry.NewLogger("opg-data-lpa-store/getupdates")
+	logger := shared.NewRequestLogger(telemetry.NewLogger("opg-data-lpa-store/getupdates"))
 
 	// set endpoint to "" outside dev to use default AWS resolver
 	endpointURL := os.Getenv("AWS_BASE_URL")
@@ -88,15 +94,28 @@ func main() {
 		cfg.BaseEndpoint = aws.String(endpointURL)
 	}
 
+	tp, err := tracing.Start(ctx, "opg-data-lpa-store/getupdates")
+	if err != nil {
+		logger.Error("failed to start tracing", slog.Any("err", err))
+	}
+
+	verifier := shared.NewJWTVerifier(cfg, logger)
+	if tableName := os.Getenv("DDB_TABLE_NAME_TOKENS"); tableName != "" {
+		verifier.Tokens = ddb.NewTokenClient(cfg, tableName)
+	}
+
 	l := &Lambda{
 		store: ddb.New(
 			cfg,
 			os.Getenv("DDB_TABLE_NAME_DEEDS"),
 			os.Getenv("DDB_TABLE_NAME_CHANGES"),
+			os.Getenv("DDB_TABLE_NAME_ACTORS"),
+			os.Getenv("DDB_TABLE_NAME_IDEMPOTENCY"),
+			os.Getenv("DDB_TABLE_NAME_OUTBOX"),
 		),
-		verifier: shared.NewJWTVerifier(cfg, logger),
+		verifier: verifier,
 		logger:   logger,
 	}
 
-	lambda.Start(l.HandleEvent)
+	lambda.Start(tracing.WithTracing(tp, shared.WithRequestID(l.HandleEvent)))
 }
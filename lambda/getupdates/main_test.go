@@ -8,10 +8,17 @@ import (
 	"testing"
 
 	"github.com/aws/aws-lambda-go/events"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
+var siriusClaims = &shared.LpaStoreClaims{RegisteredClaims: jwt.RegisteredClaims{
+	Issuer:  "opg.poas.sirius",
+	Subject: "urn:opg:poas:sirius:users:1",
+}}
+
 var (
 	ctx        = context.WithValue(context.Background(), (*string)(nil), "testing")
 	errExample = errors.New("err")
@@ -31,12 +38,12 @@ func TestLambdaHandleEvent(t *testing.T) {
 
 	verifier := newMockVerifier(t)
 	verifier.EXPECT().
-		VerifyHeader(req).
-		Return(nil, nil)
+		VerifyHeader(mock.Anything, req).
+		Return(siriusClaims, nil)
 
 	logger := newMockLogger(t)
 	logger.EXPECT().
-		Debug("Successfully parsed JWT from event header")
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header")
 
 	store := newMockStore(t)
 	store.EXPECT().
@@ -64,12 +71,12 @@ func TestLambdaHandleEventWhenUnauthorised(t *testing.T) {
 
 	verifier := newMockVerifier(t)
 	verifier.EXPECT().
-		VerifyHeader(req).
+		VerifyHeader(mock.Anything, req).
 		Return(nil, errExample)
 
 	logger := newMockLogger(t)
 	logger.EXPECT().
-		Info("Unable to verify JWT from header")
+		InfoContext(mock.Anything, "Unable to verify JWT from header")
 
 	lambda := &Lambda{
 		verifier: verifier,
@@ -80,7 +87,8 @@ func TestLambdaHandleEventWhenUnauthorised(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, events.APIGatewayProxyResponse{
 		StatusCode: 401,
-		Body:       `{"code":"UNAUTHORISED","detail":"Invalid JWT"}`,
+		Headers:    map[string]string{"Content-Type": "application/problem+json"},
+		Body:       `{"type":"urn:opg:poas:lpa-store:problem:unauthorised","title":"Unauthorized","status":401,"detail":"Invalid JWT","code":"UNAUTHORISED"}`,
 	}, resp)
 }
 
@@ -91,14 +99,14 @@ func TestLambdaHandleEventWhenNoChangesFound(t *testing.T) {
 
 	verifier := newMockVerifier(t)
 	verifier.EXPECT().
-		VerifyHeader(req).
-		Return(nil, nil)
+		VerifyHeader(mock.Anything, req).
+		Return(siriusClaims, nil)
 
 	logger := newMockLogger(t)
 	logger.EXPECT().
-		Debug("Successfully parsed JWT from event header")
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header")
 	logger.EXPECT().
-		Debug("No updates found")
+		DebugContext(mock.Anything, "No updates found")
 
 	store := newMockStore(t)
 	store.EXPECT().
@@ -115,7 +123,8 @@ func TestLambdaHandleEventWhenNoChangesFound(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, events.APIGatewayProxyResponse{
 		StatusCode: 404,
-		Body:       `{"code":"NOT_FOUND","detail":"Record not found"}`,
+		Headers:    map[string]string{"Content-Type": "application/problem+json"},
+		Body:       `{"type":"urn:opg:poas:lpa-store:problem:not-found","title":"Not Found","status":404,"detail":"Record not found","code":"NOT_FOUND"}`,
 	}, resp)
 }
 
@@ -126,14 +135,14 @@ func TestLambdaHandleEventWhenStoreErrors(t *testing.T) {
 
 	verifier := newMockVerifier(t)
 	verifier.EXPECT().
-		VerifyHeader(req).
-		Return(nil, nil)
+		VerifyHeader(mock.Anything, req).
+		Return(siriusClaims, nil)
 
 	logger := newMockLogger(t)
 	logger.EXPECT().
-		Debug("Successfully parsed JWT from event header")
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header")
 	logger.EXPECT().
-		Error("error fetching updates", slog.Any("err", errExample))
+		ErrorContext(mock.Anything, "error fetching updates", slog.Any("err", errExample))
 
 	store := newMockStore(t)
 	store.EXPECT().
@@ -150,6 +159,7 @@ func TestLambdaHandleEventWhenStoreErrors(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, events.APIGatewayProxyResponse{
 		StatusCode: 500,
-		Body:       `{"code":"INTERNAL_SERVER_ERROR","detail":"Internal server error"}`,
+		Headers:    map[string]string{"Content-Type": "application/problem+json"},
+		Body:       `{"type":"urn:opg:poas:lpa-store:problem:internal-server-error","title":"Internal Server Error","status":500,"detail":"Internal server error","code":"INTERNAL_SERVER_ERROR"}`,
 	}, resp)
 }
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/ddb"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/event"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/tracing"
+	"github.com/ministryofjustice/opg-go-common/telemetry"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// sweepAfter is how old an unsent outbox entry must be before the sweep sends
+// it, so that it does not race the stream for entries that are still being
+// relayed.
+const sweepAfter = 15 * time.Minute
+
+type EventClient interface {
+	SendLpaUpdated(ctx context.Context, event event.LpaUpdated, metrics []event.Metric) error
+}
+
+type MetricsSink interface {
+	SendMetrics(ctx context.Context, metrics []event.Metric) error
+}
+
+type Logger interface {
+	ErrorContext(context.Context, string, ...any)
+	WarnContext(context.Context, string, ...any)
+	InfoContext(context.Context, string, ...any)
+	DebugContext(context.Context, string, ...any)
+}
+
+type Store interface {
+	GetOutboxEntry(ctx context.Context, id string) (event.OutboxEntry, error)
+	MarkOutboxEntrySent(ctx context.Context, id string, sentAt time.Time) error
+	GetPendingOutboxIds(ctx context.Context, createdBefore time.Time) ([]string, error)
+}
+
+type Lambda struct {
+	eventClient EventClient
+	metricsSink MetricsSink // when nil metrics are sent with the event
+	store       Store
+	logger      Logger
+	now         func() time.Time
+}
+
+// HandleEvent sends the outbox entries inserted in the stream records. If an
+// entry cannot be sent then the records from it onwards are reported as failed,
+// so that they are retried in order.
+func (l *Lambda) HandleEvent(ctx context.Context, e events.DynamoDBEvent) (events.DynamoDBEventResponse, error) {
+	for _, record := range e.Records {
+		if record.EventName != string(events.DynamoDBOperationTypeInsert) {
+			continue
+		}
+
+		id := record.Change.Keys["id"].String()
+		ctx := shared.ContextWithCorrelationID(ctx, id)
+
+		if err := l.relay(ctx, id); err != nil {
+			l.logger.ErrorContext(ctx, "error sending outbox entry", slog.String("id", id), slog.Any("err", err))
+
+			return events.DynamoDBEventResponse{
+				BatchItemFailures: []events.DynamoDBBatchItemFailure{{ItemIdentifier: record.Change.SequenceNumber}},
+			}, nil
+		}
+	}
+
+	return events.DynamoDBEventResponse{}, nil
+}
+
+// Handle sweeps the outbox when invoked on a schedule, and otherwise handles the
+// payload as a batch of outbox stream records.
+func (l *Lambda) Handle(ctx context.Context, payload json.RawMessage) (any, error) {
+	var scheduled events.EventBridgeEvent
+	if err := json.Unmarshal(payload, &scheduled); err == nil && scheduled.DetailType == "Scheduled Event" {
+		return nil, l.Sweep(ctx)
+	}
+
+	var e events.DynamoDBEvent
+	if err := json.Unmarshal(payload, &e); err != nil {
+		return nil, err
+	}
+
+	return l.HandleEvent(ctx, e)
+}
+
+// Sweep sends the outbox entries written in this region that are still unsent
+// sweepAfter after they were created. These are entries whose stream records
+// were discarded after failing too many times, or that were never relayed. An
+// entry that cannot be sent is left for the next sweep.
+func (l *Lambda) Sweep(ctx context.Context) error {
+	ids, err := l.store.GetPendingOutboxIds(ctx, l.now().Add(-sweepAfter))
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		ctx := shared.ContextWithCorrelationID(ctx, id)
+
+		l.logger.WarnContext(ctx, "sending outbox entry missed by the stream", slog.String("id", id))
+
+		if err := l.relay(ctx, id); err != nil {
+			l.logger.ErrorContext(ctx, "error sending outbox entry", slog.String("id", id), slog.Any("err", err))
+		}
+	}
+
+	return nil
+}
+
+func (l *Lambda) relay(ctx context.Context, id string) error {
+	entry, err := l.store.GetOutboxEntry(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if entry.Id == "" {
+		l.logger.WarnContext(ctx, "outbox entry not found", slog.String("id", id))
+		return nil
+	}
+
+	if entry.Sent() {
+		l.logger.InfoContext(ctx, "outbox entry already sent", slog.String("id", id))
+		return nil
+	}
+
+	// continue the trace of the request that made the change
+	ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(entry.Event.TraceContext))
+
+	metrics := entry.AllMetrics()
+	if l.metricsSink != nil {
+		if err := l.eventClient.SendLpaUpdated(ctx, entry.Event, nil); err != nil {
+			return err
+		}
+
+		// metrics are not worth retrying the event for, and would be counted
+		// twice if it were
+		if len(metrics) > 0 {
+			if err := l.metricsSink.SendMetrics(ctx, metrics); err != nil {
+				l.logger.ErrorContext(ctx, "error sending metrics", slog.String("id", id), slog.Any("err", err))
+			}
+		}
+	} else if err := l.eventClient.SendLpaUpdated(ctx, entry.Event, metrics); err != nil {
+		return err
+	}
+
+	// the event has been sent, so a failure here only means it may be sent
+	// again if the record is retried
+	if err := l.store.MarkOutboxEntrySent(ctx, id, l.now()); err != nil {
+		l.logger.ErrorContext(ctx, "error marking outbox entry as sent", slog.String("id", id), slog.Any("err", err))
+	}
+
+	return nil
+}
+
+func main() {
+	ctx := context.Background()
+	logger := shared.NewRequestLogger(telemetry.NewLogger("opg-data-lpa-store/relay"))
+
+	// set endpoint to "" outside dev to use default AWS resolver
+	endpointURL := os.Getenv("AWS_BASE_URL")
+
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		logger.Error("failed to load aws config", slog.Any("err", err))
+	}
+
+	if endpointURL != "" {
+		cfg.BaseEndpoint = aws.String(endpointURL)
+	}
+
+	tp, err := tracing.Start(ctx, "opg-data-lpa-store/relay")
+	if err != nil {
+		logger.Error("failed to start tracing", slog.Any("err", err))
+	}
+
+	var eventClient EventClient = event.NewClient(cfg, os.Getenv("EVENT_BUS_NAME"))
+	if os.Getenv("EVENT_FORMAT") == "cloudevents" {
+		// EVENT_QUEUE_URL sends to an SQS queue instead of the event bus
+		eventClient = event.NewCloudEventsPublisher(event.NewTransport(cfg, os.Getenv("EVENT_BUS_NAME"), os.Getenv("EVENT_QUEUE_URL")))
+	}
+
+	l := &Lambda{
+		eventClient: eventClient,
+		store: ddb.New(
+			cfg,
+			os.Getenv("DDB_TABLE_NAME_DEEDS"),
+			os.Getenv("DDB_TABLE_NAME_CHANGES"),
+			os.Getenv("DDB_TABLE_NAME_ACTORS"),
+			os.Getenv("DDB_TABLE_NAME_IDEMPOTENCY"),
+			os.Getenv("DDB_TABLE_NAME_OUTBOX"),
+		),
+		logger: logger,
+		now:    time.Now,
+	}
+
+	// METRICS_SINK=emf writes metrics to the log for CloudWatch to extract,
+	// instead of putting them on the event bus
+	if os.Getenv("METRICS_SINK") == "emf" {
+		l.metricsSink = event.NewEMFSink(os.Stdout)
+	}
+
+	lambda.Start(func(ctx context.Context, payload json.RawMessage) (any, error) {
+		defer func() { _ = tp.ForceFlush(ctx) }()
+
+		return l.Handle(ctx, payload)
+	})
+}
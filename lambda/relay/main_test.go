@@ -0,0 +1,364 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/event"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var (
+	ctx         = context.WithValue(context.Background(), (*string)(nil), "testing")
+	recordCtx   = mock.MatchedBy(func(c context.Context) bool { return c.Value((*string)(nil)) == "testing" })
+	errExpected = errors.New("expect")
+	testNow     = time.Date(2024, time.January, 2, 12, 13, 14, 15, time.UTC)
+	testNowFn   = func() time.Time { return testNow }
+)
+
+func insertRecord(id, sequenceNumber string) events.DynamoDBEventRecord {
+	return events.DynamoDBEventRecord{
+		EventName: string(events.DynamoDBOperationTypeInsert),
+		Change: events.DynamoDBStreamRecord{
+			Keys:           map[string]events.DynamoDBAttributeValue{"id": events.NewStringAttribute(id)},
+			SequenceNumber: sequenceNumber,
+		},
+	}
+}
+
+func TestLambdaHandleEvent(t *testing.T) {
+	metrics := []event.Metric{{Project: "MRLPA"}}
+
+	store := newMockStore(t)
+	store.EXPECT().
+		GetOutboxEntry(recordCtx, "an-id").
+		Return(event.OutboxEntry{Id: "an-id", Event: event.LpaUpdated{Uid: "M-1", ChangeType: "CREATE"}, Metrics: metrics}, nil)
+	store.EXPECT().
+		MarkOutboxEntrySent(recordCtx, "an-id", testNow).
+		Return(nil)
+	store.EXPECT().
+		GetOutboxEntry(recordCtx, "another-id").
+		Return(event.OutboxEntry{Id: "another-id", Event: event.LpaUpdated{Uid: "M-1", ChangeType: "CORRECTION"}}, nil)
+	store.EXPECT().
+		MarkOutboxEntrySent(recordCtx, "another-id", testNow).
+		Return(nil)
+
+	eventClient := newMockEventClient(t)
+	eventClient.EXPECT().
+		SendLpaUpdated(recordCtx, event.LpaUpdated{Uid: "M-1", ChangeType: "CREATE"}, metrics).
+		Return(nil)
+	eventClient.EXPECT().
+		SendLpaUpdated(recordCtx, event.LpaUpdated{Uid: "M-1", ChangeType: "CORRECTION"}, ([]event.Metric)(nil)).
+		Return(nil)
+
+	l := &Lambda{
+		eventClient: eventClient,
+		store:       store,
+		now:         testNowFn,
+	}
+
+	resp, err := l.HandleEvent(ctx, events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{
+		insertRecord("an-id", "1"),
+		{EventName: string(events.DynamoDBOperationTypeModify)},
+		insertRecord("another-id", "3"),
+	}})
+	assert.Nil(t, err)
+	assert.Equal(t, events.DynamoDBEventResponse{}, resp)
+}
+
+func TestLambdaHandleEventWithMetricsSink(t *testing.T) {
+	metrics := []event.Metric{{Project: "MRLPA"}, {Project: "MRLPA"}}
+
+	store := newMockStore(t)
+	store.EXPECT().
+		GetOutboxEntry(recordCtx, "an-id").
+		Return(event.OutboxEntry{Id: "an-id", Event: event.LpaUpdated{Uid: "M-1"}, Metrics: metrics[1:], Metric: &metrics[0]}, nil)
+	store.EXPECT().
+		MarkOutboxEntrySent(recordCtx, "an-id", testNow).
+		Return(nil)
+
+	eventClient := newMockEventClient(t)
+	eventClient.EXPECT().
+		SendLpaUpdated(recordCtx, event.LpaUpdated{Uid: "M-1"}, ([]event.Metric)(nil)).
+		Return(nil)
+
+	metricsSink := newMockMetricsSink(t)
+	metricsSink.EXPECT().
+		SendMetrics(recordCtx, metrics).
+		Return(nil)
+
+	l := &Lambda{
+		eventClient: eventClient,
+		metricsSink: metricsSink,
+		store:       store,
+		now:         testNowFn,
+	}
+
+	resp, err := l.HandleEvent(ctx, events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{
+		insertRecord("an-id", "1"),
+	}})
+	assert.Nil(t, err)
+	assert.Equal(t, events.DynamoDBEventResponse{}, resp)
+}
+
+func TestLambdaHandleEventWhenMetricsSinkErrors(t *testing.T) {
+	store := newMockStore(t)
+	store.EXPECT().
+		GetOutboxEntry(recordCtx, "an-id").
+		Return(event.OutboxEntry{Id: "an-id", Metrics: []event.Metric{{Project: "MRLPA"}}}, nil)
+	store.EXPECT().
+		MarkOutboxEntrySent(recordCtx, "an-id", testNow).
+		Return(nil)
+
+	eventClient := newMockEventClient(t)
+	eventClient.EXPECT().
+		SendLpaUpdated(recordCtx, event.LpaUpdated{}, ([]event.Metric)(nil)).
+		Return(nil)
+
+	metricsSink := newMockMetricsSink(t)
+	metricsSink.EXPECT().
+		SendMetrics(recordCtx, []event.Metric{{Project: "MRLPA"}}).
+		Return(errExpected)
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		ErrorContext(mock.Anything, "error sending metrics", slog.String("id", "an-id"), slog.Any("err", errExpected))
+
+	l := &Lambda{
+		eventClient: eventClient,
+		metricsSink: metricsSink,
+		store:       store,
+		logger:      logger,
+		now:         testNowFn,
+	}
+
+	resp, err := l.HandleEvent(ctx, events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{
+		insertRecord("an-id", "1"),
+	}})
+	assert.Nil(t, err)
+	assert.Equal(t, events.DynamoDBEventResponse{}, resp)
+}
+
+func TestLambdaHandleEventWhenEntryNotFound(t *testing.T) {
+	store := newMockStore(t)
+	store.EXPECT().
+		GetOutboxEntry(recordCtx, "an-id").
+		Return(event.OutboxEntry{}, nil)
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		WarnContext(mock.Anything, "outbox entry not found", slog.String("id", "an-id"))
+
+	l := &Lambda{store: store, logger: logger}
+
+	resp, err := l.HandleEvent(ctx, events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{insertRecord("an-id", "1")}})
+	assert.Nil(t, err)
+	assert.Equal(t, events.DynamoDBEventResponse{}, resp)
+}
+
+func TestLambdaHandleEventWhenEntryAlreadySent(t *testing.T) {
+	store := newMockStore(t)
+	store.EXPECT().
+		GetOutboxEntry(recordCtx, "an-id").
+		Return(event.OutboxEntry{Id: "an-id", SentAt: &testNow}, nil)
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		InfoContext(mock.Anything, "outbox entry already sent", slog.String("id", "an-id"))
+
+	l := &Lambda{store: store, logger: logger}
+
+	resp, err := l.HandleEvent(ctx, events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{insertRecord("an-id", "1")}})
+	assert.Nil(t, err)
+	assert.Equal(t, events.DynamoDBEventResponse{}, resp)
+}
+
+func TestLambdaHandleEventWhenStoreErrors(t *testing.T) {
+	store := newMockStore(t)
+	store.EXPECT().
+		GetOutboxEntry(recordCtx, "an-id").
+		Return(event.OutboxEntry{}, errExpected)
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		ErrorContext(mock.Anything, "error sending outbox entry", slog.String("id", "an-id"), slog.Any("err", errExpected))
+
+	l := &Lambda{store: store, logger: logger}
+
+	resp, err := l.HandleEvent(ctx, events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{
+		insertRecord("an-id", "1"),
+		insertRecord("another-id", "2"),
+	}})
+	assert.Nil(t, err)
+	assert.Equal(t, events.DynamoDBEventResponse{
+		BatchItemFailures: []events.DynamoDBBatchItemFailure{{ItemIdentifier: "1"}},
+	}, resp)
+}
+
+func TestLambdaHandleEventWhenSendLpaUpdatedErrors(t *testing.T) {
+	store := newMockStore(t)
+	store.EXPECT().
+		GetOutboxEntry(recordCtx, "an-id").
+		Return(event.OutboxEntry{Id: "an-id"}, nil)
+	store.EXPECT().
+		GetOutboxEntry(recordCtx, "another-id").
+		Return(event.OutboxEntry{Id: "another-id"}, nil)
+
+	eventClient := newMockEventClient(t)
+	eventClient.EXPECT().
+		SendLpaUpdated(recordCtx, event.LpaUpdated{}, ([]event.Metric)(nil)).
+		Return(nil).
+		Once()
+	eventClient.EXPECT().
+		SendLpaUpdated(recordCtx, event.LpaUpdated{}, ([]event.Metric)(nil)).
+		Return(errExpected).
+		Once()
+	store.EXPECT().
+		MarkOutboxEntrySent(recordCtx, "an-id", testNow).
+		Return(nil)
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		ErrorContext(mock.Anything, "error sending outbox entry", slog.String("id", "another-id"), slog.Any("err", errExpected))
+
+	l := &Lambda{
+		eventClient: eventClient,
+		store:       store,
+		logger:      logger,
+		now:         testNowFn,
+	}
+
+	resp, err := l.HandleEvent(ctx, events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{
+		insertRecord("an-id", "1"),
+		insertRecord("another-id", "2"),
+		insertRecord("other-id", "3"),
+	}})
+	assert.Nil(t, err)
+	assert.Equal(t, events.DynamoDBEventResponse{
+		BatchItemFailures: []events.DynamoDBBatchItemFailure{{ItemIdentifier: "2"}},
+	}, resp)
+}
+
+func TestLambdaHandleEventWhenMarkOutboxEntrySentErrors(t *testing.T) {
+	store := newMockStore(t)
+	store.EXPECT().
+		GetOutboxEntry(recordCtx, "an-id").
+		Return(event.OutboxEntry{Id: "an-id"}, nil)
+	store.EXPECT().
+		MarkOutboxEntrySent(recordCtx, "an-id", testNow).
+		Return(errExpected)
+
+	eventClient := newMockEventClient(t)
+	eventClient.EXPECT().
+		SendLpaUpdated(recordCtx, event.LpaUpdated{}, ([]event.Metric)(nil)).
+		Return(nil)
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		ErrorContext(mock.Anything, "error marking outbox entry as sent", slog.String("id", "an-id"), slog.Any("err", errExpected))
+
+	l := &Lambda{
+		eventClient: eventClient,
+		store:       store,
+		logger:      logger,
+		now:         testNowFn,
+	}
+
+	resp, err := l.HandleEvent(ctx, events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{insertRecord("an-id", "1")}})
+	assert.Nil(t, err)
+	assert.Equal(t, events.DynamoDBEventResponse{}, resp)
+}
+
+func TestLambdaHandleWhenScheduled(t *testing.T) {
+	store := newMockStore(t)
+	store.EXPECT().
+		GetPendingOutboxIds(ctx, testNow.Add(-sweepAfter)).
+		Return(nil, nil)
+
+	l := &Lambda{store: store, now: testNowFn}
+
+	resp, err := l.Handle(ctx, json.RawMessage(`{"detail-type":"Scheduled Event","source":"aws.events"}`))
+	assert.Nil(t, err)
+	assert.Nil(t, resp)
+}
+
+func TestLambdaHandleWhenStreamRecords(t *testing.T) {
+	store := newMockStore(t)
+	store.EXPECT().
+		GetOutboxEntry(recordCtx, "an-id").
+		Return(event.OutboxEntry{Id: "an-id", SentAt: &testNow}, nil)
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		InfoContext(mock.Anything, "outbox entry already sent", slog.String("id", "an-id"))
+
+	l := &Lambda{store: store, logger: logger}
+
+	resp, err := l.Handle(ctx, json.RawMessage(`{"Records":[{"eventName":"INSERT","dynamodb":{"Keys":{"id":{"S":"an-id"}},"SequenceNumber":"1"}}]}`))
+	assert.Nil(t, err)
+	assert.Equal(t, events.DynamoDBEventResponse{}, resp)
+}
+
+func TestLambdaHandleWhenInvalidPayload(t *testing.T) {
+	l := &Lambda{}
+
+	_, err := l.Handle(ctx, json.RawMessage(`"not-an-event"`))
+	assert.Error(t, err)
+}
+
+func TestLambdaSweep(t *testing.T) {
+	store := newMockStore(t)
+	store.EXPECT().
+		GetPendingOutboxIds(ctx, testNow.Add(-sweepAfter)).
+		Return([]string{"an-id", "another-id"}, nil)
+	store.EXPECT().
+		GetOutboxEntry(recordCtx, "an-id").
+		Return(event.OutboxEntry{}, errExpected)
+	store.EXPECT().
+		GetOutboxEntry(recordCtx, "another-id").
+		Return(event.OutboxEntry{Id: "another-id", Event: event.LpaUpdated{Uid: "M-1"}}, nil)
+	store.EXPECT().
+		MarkOutboxEntrySent(recordCtx, "another-id", testNow).
+		Return(nil)
+
+	eventClient := newMockEventClient(t)
+	eventClient.EXPECT().
+		SendLpaUpdated(recordCtx, event.LpaUpdated{Uid: "M-1"}, ([]event.Metric)(nil)).
+		Return(nil)
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		WarnContext(mock.Anything, "sending outbox entry missed by the stream", slog.String("id", "an-id"))
+	logger.EXPECT().
+		ErrorContext(mock.Anything, "error sending outbox entry", slog.String("id", "an-id"), slog.Any("err", errExpected))
+	logger.EXPECT().
+		WarnContext(mock.Anything, "sending outbox entry missed by the stream", slog.String("id", "another-id"))
+
+	l := &Lambda{
+		eventClient: eventClient,
+		store:       store,
+		logger:      logger,
+		now:         testNowFn,
+	}
+
+	err := l.Sweep(ctx)
+	assert.Nil(t, err)
+}
+
+func TestLambdaSweepWhenStoreErrors(t *testing.T) {
+	store := newMockStore(t)
+	store.EXPECT().
+		GetPendingOutboxIds(ctx, testNow.Add(-sweepAfter)).
+		Return(nil, errExpected)
+
+	l := &Lambda{store: store, now: testNowFn}
+
+	err := l.Sweep(ctx)
+	assert.Equal(t, errExpected, err)
+}
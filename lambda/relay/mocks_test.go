@@ -0,0 +1,678 @@
+// Code generated by mockery; DO NOT EDIT.
+// github.com/vektra/mockery
+// template: testify
+
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/event"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// newMockEventClient creates a new instance of mockEventClient. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newMockEventClient(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *mockEventClient {
+	mock := &mockEventClient{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// mockEventClient is an autogenerated mock type for the EventClient type
+type mockEventClient struct {
+	mock.Mock
+}
+
+type mockEventClient_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *mockEventClient) EXPECT() *mockEventClient_Expecter {
+	return &mockEventClient_Expecter{mock: &_m.Mock}
+}
+
+// SendLpaUpdated provides a mock function for the type mockEventClient
+func (_mock *mockEventClient) SendLpaUpdated(ctx context.Context, event1 event.LpaUpdated, metrics []event.Metric) error {
+	ret := _mock.Called(ctx, event1, metrics)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SendLpaUpdated")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, event.LpaUpdated, []event.Metric) error); ok {
+		r0 = returnFunc(ctx, event1, metrics)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// mockEventClient_SendLpaUpdated_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SendLpaUpdated'
+type mockEventClient_SendLpaUpdated_Call struct {
+	*mock.Call
+}
+
+// SendLpaUpdated is a helper method to define mock.On call
+//   - ctx context.Context
+//   - event1 event.LpaUpdated
+//   - metrics []event.Metric
+func (_e *mockEventClient_Expecter) SendLpaUpdated(ctx interface{}, event1 interface{}, metrics interface{}) *mockEventClient_SendLpaUpdated_Call {
+	return &mockEventClient_SendLpaUpdated_Call{Call: _e.mock.On("SendLpaUpdated", ctx, event1, metrics)}
+}
+
+func (_c *mockEventClient_SendLpaUpdated_Call) Run(run func(ctx context.Context, event1 event.LpaUpdated, metrics []event.Metric)) *mockEventClient_SendLpaUpdated_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 event.LpaUpdated
+		if args[1] != nil {
+			arg1 = args[1].(event.LpaUpdated)
+		}
+		var arg2 []event.Metric
+		if args[2] != nil {
+			arg2 = args[2].([]event.Metric)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *mockEventClient_SendLpaUpdated_Call) Return(err error) *mockEventClient_SendLpaUpdated_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *mockEventClient_SendLpaUpdated_Call) RunAndReturn(run func(ctx context.Context, event1 event.LpaUpdated, metrics []event.Metric) error) *mockEventClient_SendLpaUpdated_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// newMockMetricsSink creates a new instance of mockMetricsSink. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newMockMetricsSink(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *mockMetricsSink {
+	mock := &mockMetricsSink{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// mockMetricsSink is an autogenerated mock type for the MetricsSink type
+type mockMetricsSink struct {
+	mock.Mock
+}
+
+type mockMetricsSink_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *mockMetricsSink) EXPECT() *mockMetricsSink_Expecter {
+	return &mockMetricsSink_Expecter{mock: &_m.Mock}
+}
+
+// SendMetrics provides a mock function for the type mockMetricsSink
+func (_mock *mockMetricsSink) SendMetrics(ctx context.Context, metrics []event.Metric) error {
+	ret := _mock.Called(ctx, metrics)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SendMetrics")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []event.Metric) error); ok {
+		r0 = returnFunc(ctx, metrics)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// mockMetricsSink_SendMetrics_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SendMetrics'
+type mockMetricsSink_SendMetrics_Call struct {
+	*mock.Call
+}
+
+// SendMetrics is a helper method to define mock.On call
+//   - ctx context.Context
+//   - metrics []event.Metric
+func (_e *mockMetricsSink_Expecter) SendMetrics(ctx interface{}, metrics interface{}) *mockMetricsSink_SendMetrics_Call {
+	return &mockMetricsSink_SendMetrics_Call{Call: _e.mock.On("SendMetrics", ctx, metrics)}
+}
+
+func (_c *mockMetricsSink_SendMetrics_Call) Run(run func(ctx context.Context, metrics []event.Metric)) *mockMetricsSink_SendMetrics_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 []event.Metric
+		if args[1] != nil {
+			arg1 = args[1].([]event.Metric)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *mockMetricsSink_SendMetrics_Call) Return(err error) *mockMetricsSink_SendMetrics_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *mockMetricsSink_SendMetrics_Call) RunAndReturn(run func(ctx context.Context, metrics []event.Metric) error) *mockMetricsSink_SendMetrics_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// newMockLogger creates a new instance of mockLogger. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newMockLogger(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *mockLogger {
+	mock := &mockLogger{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// mockLogger is an autogenerated mock type for the Logger type
+type mockLogger struct {
+	mock.Mock
+}
+
+type mockLogger_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *mockLogger) EXPECT() *mockLogger_Expecter {
+	return &mockLogger_Expecter{mock: &_m.Mock}
+}
+
+// DebugContext provides a mock function for the type mockLogger
+func (_mock *mockLogger) DebugContext(context1 context.Context, s string, vs ...any) {
+	var _ca []interface{}
+	_ca = append(_ca, context1, s)
+	_ca = append(_ca, vs...)
+	_mock.Called(_ca...)
+	return
+}
+
+// mockLogger_DebugContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DebugContext'
+type mockLogger_DebugContext_Call struct {
+	*mock.Call
+}
+
+// DebugContext is a helper method to define mock.On call
+//   - context1 context.Context
+//   - s string
+//   - vs ...any
+func (_e *mockLogger_Expecter) DebugContext(context1 interface{}, s interface{}, vs ...interface{}) *mockLogger_DebugContext_Call {
+	return &mockLogger_DebugContext_Call{Call: _e.mock.On("DebugContext",
+		append([]interface{}{context1, s}, vs...)...)}
+}
+
+func (_c *mockLogger_DebugContext_Call) Run(run func(context1 context.Context, s string, vs ...any)) *mockLogger_DebugContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 []any
+		variadicArgs := make([]any, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(any)
+			}
+		}
+		arg2 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2...,
+		)
+	})
+	return _c
+}
+
+func (_c *mockLogger_DebugContext_Call) Return() *mockLogger_DebugContext_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *mockLogger_DebugContext_Call) RunAndReturn(run func(context1 context.Context, s string, vs ...any)) *mockLogger_DebugContext_Call {
+	_c.Run(run)
+	return _c
+}
+
+// ErrorContext provides a mock function for the type mockLogger
+func (_mock *mockLogger) ErrorContext(context1 context.Context, s string, vs ...any) {
+	var _ca []interface{}
+	_ca = append(_ca, context1, s)
+	_ca = append(_ca, vs...)
+	_mock.Called(_ca...)
+	return
+}
+
+// mockLogger_ErrorContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ErrorContext'
+type mockLogger_ErrorContext_Call struct {
+	*mock.Call
+}
+
+// ErrorContext is a helper method to define mock.On call
+//   - context1 context.Context
+//   - s string
+//   - vs ...any
+func (_e *mockLogger_Expecter) ErrorContext(context1 interface{}, s interface{}, vs ...interface{}) *mockLogger_ErrorContext_Call {
+	return &mockLogger_ErrorContext_Call{Call: _e.mock.On("ErrorContext",
+		append([]interface{}{context1, s}, vs...)...)}
+}
+
+func (_c *mockLogger_ErrorContext_Call) Run(run func(context1 context.Context, s string, vs ...any)) *mockLogger_ErrorContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 []any
+		variadicArgs := make([]any, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(any)
+			}
+		}
+		arg2 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2...,
+		)
+	})
+	return _c
+}
+
+func (_c *mockLogger_ErrorContext_Call) Return() *mockLogger_ErrorContext_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *mockLogger_ErrorContext_Call) RunAndReturn(run func(context1 context.Context, s string, vs ...any)) *mockLogger_ErrorContext_Call {
+	_c.Run(run)
+	return _c
+}
+
+// InfoContext provides a mock function for the type mockLogger
+func (_mock *mockLogger) InfoContext(context1 context.Context, s string, vs ...any) {
+	var _ca []interface{}
+	_ca = append(_ca, context1, s)
+	_ca = append(_ca, vs...)
+	_mock.Called(_ca...)
+	return
+}
+
+// mockLogger_InfoContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'InfoContext'
+type mockLogger_InfoContext_Call struct {
+	*mock.Call
+}
+
+// InfoContext is a helper method to define mock.On call
+//   - context1 context.Context
+//   - s string
+//   - vs ...any
+func (_e *mockLogger_Expecter) InfoContext(context1 interface{}, s interface{}, vs ...interface{}) *mockLogger_InfoContext_Call {
+	return &mockLogger_InfoContext_Call{Call: _e.mock.On("InfoContext",
+		append([]interface{}{context1, s}, vs...)...)}
+}
+
+func (_c *mockLogger_InfoContext_Call) Run(run func(context1 context.Context, s string, vs ...any)) *mockLogger_InfoContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 []any
+		variadicArgs := make([]any, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(any)
+			}
+		}
+		arg2 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2...,
+		)
+	})
+	return _c
+}
+
+func (_c *mockLogger_InfoContext_Call) Return() *mockLogger_InfoContext_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *mockLogger_InfoContext_Call) RunAndReturn(run func(context1 context.Context, s string, vs ...any)) *mockLogger_InfoContext_Call {
+	_c.Run(run)
+	return _c
+}
+
+// WarnContext provides a mock function for the type mockLogger
+func (_mock *mockLogger) WarnContext(context1 context.Context, s string, vs ...any) {
+	var _ca []interface{}
+	_ca = append(_ca, context1, s)
+	_ca = append(_ca, vs...)
+	_mock.Called(_ca...)
+	return
+}
+
+// mockLogger_WarnContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'WarnContext'
+type mockLogger_WarnContext_Call struct {
+	*mock.Call
+}
+
+// WarnContext is a helper method to define mock.On call
+//   - context1 context.Context
+//   - s string
+//   - vs ...any
+func (_e *mockLogger_Expecter) WarnContext(context1 interface{}, s interface{}, vs ...interface{}) *mockLogger_WarnContext_Call {
+	return &mockLogger_WarnContext_Call{Call: _e.mock.On("WarnContext",
+		append([]interface{}{context1, s}, vs...)...)}
+}
+
+func (_c *mockLogger_WarnContext_Call) Run(run func(context1 context.Context, s string, vs ...any)) *mockLogger_WarnContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 []any
+		variadicArgs := make([]any, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(any)
+			}
+		}
+		arg2 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2...,
+		)
+	})
+	return _c
+}
+
+func (_c *mockLogger_WarnContext_Call) Return() *mockLogger_WarnContext_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *mockLogger_WarnContext_Call) RunAndReturn(run func(context1 context.Context, s string, vs ...any)) *mockLogger_WarnContext_Call {
+	_c.Run(run)
+	return _c
+}
+
+// newMockStore creates a new instance of mockStore. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newMockStore(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *mockStore {
+	mock := &mockStore{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// mockStore is an autogenerated mock type for the Store type
+type mockStore struct {
+	mock.Mock
+}
+
+type mockStore_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *mockStore) EXPECT() *mockStore_Expecter {
+	return &mockStore_Expecter{mock: &_m.Mock}
+}
+
+// GetOutboxEntry provides a mock function for the type mockStore
+func (_mock *mockStore) GetOutboxEntry(ctx context.Context, id string) (event.OutboxEntry, error) {
+	ret := _mock.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetOutboxEntry")
+	}
+
+	var r0 event.OutboxEntry
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (event.OutboxEntry, error)); ok {
+		return returnFunc(ctx, id)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) event.OutboxEntry); ok {
+		r0 = returnFunc(ctx, id)
+	} else {
+		r0 = ret.Get(0).(event.OutboxEntry)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockStore_GetOutboxEntry_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetOutboxEntry'
+type mockStore_GetOutboxEntry_Call struct {
+	*mock.Call
+}
+
+// GetOutboxEntry is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id string
+func (_e *mockStore_Expecter) GetOutboxEntry(ctx interface{}, id interface{}) *mockStore_GetOutboxEntry_Call {
+	return &mockStore_GetOutboxEntry_Call{Call: _e.mock.On("GetOutboxEntry", ctx, id)}
+}
+
+func (_c *mockStore_GetOutboxEntry_Call) Run(run func(ctx context.Context, id string)) *mockStore_GetOutboxEntry_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *mockStore_GetOutboxEntry_Call) Return(outboxEntry event.OutboxEntry, err error) *mockStore_GetOutboxEntry_Call {
+	_c.Call.Return(outboxEntry, err)
+	return _c
+}
+
+func (_c *mockStore_GetOutboxEntry_Call) RunAndReturn(run func(ctx context.Context, id string) (event.OutboxEntry, error)) *mockStore_GetOutboxEntry_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetPendingOutboxIds provides a mock function for the type mockStore
+func (_mock *mockStore) GetPendingOutboxIds(ctx context.Context, createdBefore time.Time) ([]string, error) {
+	ret := _mock.Called(ctx, createdBefore)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPendingOutboxIds")
+	}
+
+	var r0 []string
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, time.Time) ([]string, error)); ok {
+		return returnFunc(ctx, createdBefore)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, time.Time) []string); ok {
+		r0 = returnFunc(ctx, createdBefore)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = returnFunc(ctx, createdBefore)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockStore_GetPendingOutboxIds_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetPendingOutboxIds'
+type mockStore_GetPendingOutboxIds_Call struct {
+	*mock.Call
+}
+
+// GetPendingOutboxIds is a helper method to define mock.On call
+//   - ctx context.Context
+//   - createdBefore time.Time
+func (_e *mockStore_Expecter) GetPendingOutboxIds(ctx interface{}, createdBefore interface{}) *mockStore_GetPendingOutboxIds_Call {
+	return &mockStore_GetPendingOutboxIds_Call{Call: _e.mock.On("GetPendingOutboxIds", ctx, createdBefore)}
+}
+
+func (_c *mockStore_GetPendingOutboxIds_Call) Run(run func(ctx context.Context, createdBefore time.Time)) *mockStore_GetPendingOutboxIds_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 time.Time
+		if args[1] != nil {
+			arg1 = args[1].(time.Time)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *mockStore_GetPendingOutboxIds_Call) Return(strings []string, err error) *mockStore_GetPendingOutboxIds_Call {
+	_c.Call.Return(strings, err)
+	return _c
+}
+
+func (_c *mockStore_GetPendingOutboxIds_Call) RunAndReturn(run func(ctx context.Context, createdBefore time.Time) ([]string, error)) *mockStore_GetPendingOutboxIds_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// MarkOutboxEntrySent provides a mock function for the type mockStore
+func (_mock *mockStore) MarkOutboxEntrySent(ctx context.Context, id string, sentAt time.Time) error {
+	ret := _mock.Called(ctx, id, sentAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkOutboxEntrySent")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, time.Time) error); ok {
+		r0 = returnFunc(ctx, id, sentAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// mockStore_MarkOutboxEntrySent_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MarkOutboxEntrySent'
+type mockStore_MarkOutboxEntrySent_Call struct {
+	*mock.Call
+}
+
+// MarkOutboxEntrySent is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id string
+//   - sentAt time.Time
+func (_e *mockStore_Expecter) MarkOutboxEntrySent(ctx interface{}, id interface{}, sentAt interface{}) *mockStore_MarkOutboxEntrySent_Call {
+	return &mockStore_MarkOutboxEntrySent_Call{Call: _e.mock.On("MarkOutboxEntrySent", ctx, id, sentAt)}
+}
+
+func (_c *mockStore_MarkOutboxEntrySent_Call) Run(run func(ctx context.Context, id string, sentAt time.Time)) *mockStore_MarkOutboxEntrySent_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 time.Time
+		if args[2] != nil {
+			arg2 = args[2].(time.Time)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *mockStore_MarkOutboxEntrySent_Call) Return(err error) *mockStore_MarkOutboxEntrySent_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *mockStore_MarkOutboxEntrySent_Call) RunAndReturn(run func(ctx context.Context, id string, sentAt time.Time) error) *mockStore_MarkOutboxEntrySent_Call {
+	_c.Call.Return(run)
+	return _c
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/ddb"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/tracing"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/validate"
+	"github.com/ministryofjustice/opg-go-common/telemetry"
+)
+
+const (
+	defaultLimit = 25
+	maxLimit     = 100
+)
+
+type Logger interface {
+	ErrorContext(context.Context, string, ...any)
+	InfoContext(context.Context, string, ...any)
+	DebugContext(context.Context, string, ...any)
+}
+
+type Store interface {
+	SearchByDonor(ctx context.Context, searchKey string, limit int, startAfter string) ([]shared.Lpa, string, error)
+}
+
+type Verifier interface {
+	VerifyHeader(context.Context, events.APIGatewayProxyRequest) (*shared.LpaStoreClaims, error)
+}
+
+type Lambda struct {
+	store    Store
+	verifier Verifier
+	logger   Logger
+}
+
+type searchRequest struct {
+	LastName    string      `json:"lastName"`
+	DateOfBirth shared.Date `json:"dateOfBirth"`
+	Postcode    string      `json:"postcode"`
+	Limit       int         `json:"limit,omitempty"`
+	NextToken   string      `json:"nextToken,omitempty"`
+}
+
+type searchResponse struct {
+	Lpas      []lpaSummary `json:"lpas"`
+	NextToken string       `json:"nextToken,omitempty"`
+}
+
+type lpaSummary struct {
+	Uid              string           `json:"uid"`
+	Status           shared.LpaStatus `json:"status"`
+	LpaType          shared.LpaType   `json:"lpaType"`
+	Channel          shared.Channel   `json:"channel"`
+	Donor            donorSummary     `json:"donor"`
+	RegistrationDate *time.Time       `json:"registrationDate,omitempty"`
+	UpdatedAt        time.Time        `json:"updatedAt"`
+}
+
+type donorSummary struct {
+	FirstNames  string      `json:"firstNames"`
+	LastName    string      `json:"lastName"`
+	DateOfBirth shared.Date `json:"dateOfBirth"`
+	Postcode    string      `json:"postcode,omitempty"`
+}
+
+func (l *Lambda) HandleEvent(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	claims, err := l.verifier.VerifyHeader(ctx, event)
+	if err != nil {
+		l.logger.InfoContext(ctx, "Unable to verify JWT from header")
+		return shared.ProblemUnauthorisedRequest.Respond(ctx)
+	}
+
+	l.logger.DebugContext(ctx, "Successfully parsed JWT from event header")
+
+	if !shared.Authorise(claims, shared.OperationSearch) {
+		l.logger.InfoContext(ctx, "JWT not permitted to make request")
+		return shared.ProblemForbidden.Respond(ctx)
+	}
+
+	response := events.APIGatewayProxyResponse{
+		StatusCode: 500,
+		Body:       "{\"code\":\"INTERNAL_SERVER_ERROR\",\"detail\":\"Internal server error\"}",
+	}
+
+	var req searchRequest
+	if err := json.Unmarshal([]byte(event.Body), &req); err != nil {
+		l.logger.InfoContext(ctx, "invalid JSON in request", slog.Any("err", err))
+		return shared.ProblemInvalidRequest.Respond(ctx)
+	}
+
+	startAfter, errs := validateRequest(req)
+	if len(errs) > 0 {
+		problem := shared.ProblemInvalidRequest
+		problem.Errors = errs
+		return problem.Respond(ctx)
+	}
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultLimit
+	}
+
+	lpas, next, err := l.store.SearchByDonor(ctx, shared.DonorSearchKey(req.LastName, req.DateOfBirth, req.Postcode), limit, startAfter)
+	if err != nil {
+		l.logger.ErrorContext(ctx, "error searching LPAs", slog.Any("err", err))
+		return shared.ProblemInternalServerError.Respond(ctx)
+	}
+
+	result := searchResponse{Lpas: make([]lpaSummary, len(lpas))}
+	for i, lpa := range lpas {
+		result.Lpas[i] = summarise(lpa)
+	}
+
+	if next != "" {
+		result.NextToken = base64.RawURLEncoding.EncodeToString([]byte(next))
+	}
+
+	body, err := json.Marshal(result)
+	if err != nil {
+		l.logger.ErrorContext(ctx, "error marshalling LPAs", slog.Any("err", err))
+		return shared.ProblemInternalServerError.Respond(ctx)
+	}
+
+	response.StatusCode = 200
+	response.Body = string(body)
+
+	return response, nil
+}
+
+// validateRequest checks the search terms and paging options, returning the
+// UID that the search should start after.
+func validateRequest(req searchRequest) (string, []shared.FieldError) {
+	startAfter, err := base64.RawURLEncoding.DecodeString(req.NextToken)
+
+	return string(startAfter), validate.All(
+		validate.WithSource("/lastName", req.LastName, validate.NotEmpty()),
+		validate.WithSource("/dateOfBirth", req.DateOfBirth, validate.Date()),
+		validate.WithSource("/postcode", req.Postcode, validate.NotEmpty()),
+		validate.If(req.Limit < 0 || req.Limit > maxLimit, []shared.FieldError{{Source: "/limit", Detail: fmt.Sprintf("must be between 1 and %d", maxLimit)}}),
+		validate.If(err != nil, []shared.FieldError{{Source: "/nextToken", Detail: "invalid value"}}),
+	)
+}
+
+func summarise(lpa shared.Lpa) lpaSummary {
+	return lpaSummary{
+		Uid:     lpa.Uid,
+		Status:  lpa.Status,
+		LpaType: lpa.LpaType,
+		Channel: lpa.Channel,
+		Donor: donorSummary{
+			FirstNames:  lpa.Donor.FirstNames,
+			LastName:    lpa.Donor.LastName,
+			DateOfBirth: lpa.Donor.DateOfBirth,
+			Postcode:    lpa.Donor.Address.Postcode,
+		},
+		RegistrationDate: lpa.RegistrationDate,
+		UpdatedAt:        lpa.UpdatedAt,
+	}
+}
+
+func main() {
+	ctx := context.Background()
+	logger := shared.NewRequestLogger(telemetry.NewLogger("opg-data-lpa-store/search"))
+
+	// set endpoint to "" outside dev to use default AWS resolver
+	endpointURL := os.Getenv("AWS_BASE_URL")
+
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		logger.Error("failed to load aws config", slog.Any("err", err))
+	}
+
+	if endpointURL != "" {
+		cfg.BaseEndpoint = aws.String(endpointURL)
+	}
+
+	tp, err := tracing.Start(ctx, "opg-data-lpa-store/search")
+	if err != nil {
+		logger.Error("failed to start tracing", slog.Any("err", err))
+	}
+
+	verifier := shared.NewJWTVerifier(cfg, logger)
+	if tableName := os.Getenv("DDB_TABLE_NAME_TOKENS"); tableName != "" {
+		verifier.Tokens = ddb.NewTokenClient(cfg, tableName)
+	}
+
+	l := &Lambda{
+		store: ddb.New(
+			cfg,
+			os.Getenv("DDB_TABLE_NAME_DEEDS"),
+			os.Getenv("DDB_TABLE_NAME_CHANGES"),
+			os.Getenv("DDB_TABLE_NAME_ACTORS"),
+			os.Getenv("DDB_TABLE_NAME_IDEMPOTENCY"),
+			os.Getenv("DDB_TABLE_NAME_OUTBOX"),
+		),
+		verifier: verifier,
+		logger:   logger,
+	}
+
+	lambda.Start(tracing.WithTracing(tp, shared.WithRequestID(l.HandleEvent)))
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var siriusClaims = &shared.LpaStoreClaims{RegisteredClaims: jwt.RegisteredClaims{
+	Issuer:  "opg.poas.sirius",
+	Subject: "urn:opg:poas:sirius:users:1",
+}}
+
+var (
+	ctx        = context.WithValue(context.Background(), (*string)(nil), "testing")
+	errExample = errors.New("err")
+)
+
+func TestLambdaHandleEvent(t *testing.T) {
+	testcases := map[string]struct {
+		body       string
+		limit      int
+		startAfter string
+		next       string
+		nextToken  string
+	}{
+		"first page": {
+			body:      `{"lastName":"Smith","dateOfBirth":"1980-04-05","postcode":"b14 7ed"}`,
+			limit:     25,
+			next:      "M-1111-1111-1111",
+			nextToken: base64.RawURLEncoding.EncodeToString([]byte("M-1111-1111-1111")),
+		},
+		"next page": {
+			body:       `{"lastName":"Smith","dateOfBirth":"1980-04-05","postcode":"b14 7ed","limit":10,"nextToken":"` + base64.RawURLEncoding.EncodeToString([]byte("M-1111-1111-1111")) + `"}`,
+			limit:      10,
+			startAfter: "M-1111-1111-1111",
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			req := events.APIGatewayProxyRequest{Body: tc.body}
+
+			var donor shared.Donor
+			_ = json.Unmarshal([]byte(`{"firstNames":"John","lastName":"Smith","dateOfBirth":"1980-04-05","address":{"line1":"a","postcode":"B14 7ED"},"email":"john@example.com"}`), &donor)
+
+			verifier := newMockVerifier(t)
+			verifier.EXPECT().
+				VerifyHeader(mock.Anything, req).
+				Return(siriusClaims, nil)
+
+			logger := newMockLogger(t)
+			logger.EXPECT().
+				DebugContext(mock.Anything, "Successfully parsed JWT from event header")
+
+			store := newMockStore(t)
+			store.EXPECT().
+				SearchByDonor(ctx, "smith#1980-04-05#B147ED", tc.limit, tc.startAfter).
+				Return([]shared.Lpa{{
+					Uid:     "M-1111-1111-1111",
+					Status:  shared.LpaStatusRegistered,
+					LpaInit: shared.LpaInit{LpaType: shared.LpaTypePersonalWelfare, Channel: shared.ChannelOnline, Donor: donor},
+				}}, tc.next, nil)
+
+			lambda := &Lambda{
+				verifier: verifier,
+				logger:   logger,
+				store:    store,
+			}
+
+			nextToken := ""
+			if tc.nextToken != "" {
+				nextToken = `,"nextToken":"` + tc.nextToken + `"`
+			}
+
+			resp, err := lambda.HandleEvent(ctx, req)
+			assert.Nil(t, err)
+			assert.Equal(t, events.APIGatewayProxyResponse{
+				StatusCode: 200,
+				Body:       `{"lpas":[{"uid":"M-1111-1111-1111","status":"registered","lpaType":"personal-welfare","channel":"online","donor":{"firstNames":"John","lastName":"Smith","dateOfBirth":"1980-04-05","postcode":"B14 7ED"},"updatedAt":"0001-01-01T00:00:00Z"}]` + nextToken + `}`,
+			}, resp)
+		})
+	}
+}
+
+func TestLambdaHandleEventWhenUnauthorised(t *testing.T) {
+	req := events.APIGatewayProxyRequest{Body: `{}`}
+
+	verifier := newMockVerifier(t)
+	verifier.EXPECT().
+		VerifyHeader(mock.Anything, req).
+		Return(nil, errExample)
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		InfoContext(mock.Anything, "Unable to verify JWT from header")
+
+	lambda := &Lambda{
+		verifier: verifier,
+		logger:   logger,
+	}
+
+	resp, err := lambda.HandleEvent(ctx, req)
+	assert.Nil(t, err)
+	assert.Equal(t, events.APIGatewayProxyResponse{
+		StatusCode: 401,
+		Headers:    map[string]string{"Content-Type": "application/problem+json"},
+		Body:       `{"type":"urn:opg:poas:lpa-store:problem:unauthorised","title":"Unauthorized","status":401,"detail":"Invalid JWT","code":"UNAUTHORISED"}`,
+	}, resp)
+}
+
+func TestLambdaHandleEventWhenBadRequest(t *testing.T) {
+	req := events.APIGatewayProxyRequest{Body: `{`}
+
+	verifier := newMockVerifier(t)
+	verifier.EXPECT().
+		VerifyHeader(mock.Anything, req).
+		Return(siriusClaims, nil)
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header")
+	logger.EXPECT().
+		InfoContext(mock.Anything, "invalid JSON in request", mock.Anything)
+
+	lambda := &Lambda{
+		verifier: verifier,
+		logger:   logger,
+	}
+
+	resp, err := lambda.HandleEvent(ctx, req)
+	assert.Nil(t, err)
+	assert.Equal(t, events.APIGatewayProxyResponse{
+		StatusCode: 400,
+		Headers:    map[string]string{"Content-Type": "application/problem+json"},
+		Body:       `{"type":"urn:opg:poas:lpa-store:problem:invalid-request","title":"Bad Request","status":400,"detail":"Invalid request","code":"INVALID_REQUEST"}`,
+	}, resp)
+}
+
+func TestLambdaHandleEventWhenInvalid(t *testing.T) {
+	req := events.APIGatewayProxyRequest{
+		Body: `{"lastName":"","dateOfBirth":"5 April 1980","limit":101,"nextToken":"!!"}`,
+	}
+
+	verifier := newMockVerifier(t)
+	verifier.EXPECT().
+		VerifyHeader(mock.Anything, req).
+		Return(siriusClaims, nil)
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header")
+
+	lambda := &Lambda{
+		verifier: verifier,
+		logger:   logger,
+	}
+
+	resp, err := lambda.HandleEvent(ctx, req)
+	assert.Nil(t, err)
+	assert.Equal(t, events.APIGatewayProxyResponse{
+		StatusCode: 400,
+		Headers:    map[string]string{"Content-Type": "application/problem+json"},
+		Body:       `{"type":"urn:opg:poas:lpa-store:problem:invalid-request","title":"Bad Request","status":400,"detail":"Invalid request","code":"INVALID_REQUEST","errors":[{"source":"/lastName","detail":"field is required"},{"source":"/dateOfBirth","detail":"invalid format"},{"source":"/postcode","detail":"field is required"},{"source":"/limit","detail":"must be between 1 and 100"},{"source":"/nextToken","detail":"invalid value"}]}`,
+	}, resp)
+}
+
+func TestLambdaHandleEventWhenStoreErrors(t *testing.T) {
+	req := events.APIGatewayProxyRequest{
+		Body: `{"lastName":"Smith","dateOfBirth":"1980-04-05","postcode":"B14 7ED"}`,
+	}
+
+	verifier := newMockVerifier(t)
+	verifier.EXPECT().
+		VerifyHeader(mock.Anything, req).
+		Return(siriusClaims, nil)
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header")
+	logger.EXPECT().
+		ErrorContext(mock.Anything, "error searching LPAs", slog.Any("err", errExample))
+
+	store := newMockStore(t)
+	store.EXPECT().
+		SearchByDonor(ctx, mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, "", errExample)
+
+	lambda := &Lambda{
+		verifier: verifier,
+		logger:   logger,
+		store:    store,
+	}
+
+	resp, err := lambda.HandleEvent(ctx, req)
+	assert.Nil(t, err)
+	assert.Equal(t, events.APIGatewayProxyResponse{
+		StatusCode: 500,
+		Headers:    map[string]string{"Content-Type": "application/problem+json"},
+		Body:       `{"type":"urn:opg:poas:lpa-store:problem:internal-server-error","title":"Internal Server Error","status":500,"detail":"Internal server error","code":"INTERNAL_SERVER_ERROR"}`,
+	}, resp)
+}
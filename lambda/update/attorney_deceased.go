@@ -0,0 +1,150 @@
+package main
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/validate"
+	"github.com/ministryofjustice/opg-data-lpa-store/lambda/update/parse"
+)
+
+type AttorneyDeceased struct {
+	Attorneys []DeceasedAttorney
+}
+
+type DeceasedAttorney struct {
+	Index       int
+	DateOfDeath shared.Date
+}
+
+func (a AttorneyDeceased) Apply(lpa *shared.Lpa) []shared.FieldError {
+	if lpa.Status.IsFinal() {
+		return []shared.FieldError{{Source: "/type", Detail: "attorney cannot be recorded as deceased for an lpa that is " + string(lpa.Status)}}
+	}
+
+	activeDied := false
+
+	for _, deceased := range a.Attorneys {
+		source := "/attorneys/" + strconv.Itoa(deceased.Index) + "/dateOfDeath"
+		attorney := &lpa.Attorneys[deceased.Index]
+
+		if attorney.Status == shared.AttorneyStatusRemoved {
+			return []shared.FieldError{{Source: source, Detail: "attorney has already been removed"}}
+		}
+
+		if deceased.DateOfDeath.Time().After(time.Now()) {
+			return []shared.FieldError{{Source: source, Detail: "date of death cannot be in the future"}}
+		}
+
+		if deceased.DateOfDeath.Time().Before(attorney.DateOfBirth.Time()) {
+			return []shared.FieldError{{Source: source, Detail: "date of death cannot be before date of birth"}}
+		}
+
+		if attorney.Status == shared.AttorneyStatusActive {
+			activeDied = true
+		}
+
+		attorney.DateOfDeath = &deceased.DateOfDeath
+		attorney.Status = shared.AttorneyStatusRemoved
+
+		attorneyDeceasedNote := shared.Note{
+			Type:     "ATTORNEY_DECEASED_V1",
+			Datetime: time.Now().Format(time.RFC3339),
+			Values: map[string]string{
+				"fullName":    attorney.FirstNames + " " + attorney.LastName,
+				"dateOfDeath": deceased.DateOfDeath.DateOnlyText(),
+			},
+		}
+
+		lpa.AddNote(attorneyDeceasedNote)
+	}
+
+	if activeDied && replacementsStepIn(lpa) {
+		activateReplacements(lpa)
+	}
+
+	return nil
+}
+
+// replacementsStepIn reports whether, now that an active attorney can no
+// longer act, the replacement attorneys should act in their place. When the
+// donor described another way for them to step in this is left to a
+// caseworker.
+func replacementsStepIn(lpa *shared.Lpa) bool {
+	if lpa.HowReplacementAttorneysStepIn == shared.HowStepInAnotherWay {
+		return false
+	}
+
+	if lpa.HowAttorneysMakeDecisions == shared.HowMakeDecisionsJointly {
+		return true
+	}
+
+	if lpa.HowReplacementAttorneysStepIn == shared.HowStepInOneCanNoLongerAct {
+		return true
+	}
+
+	actives, _ := shared.CountAttorneys(lpa.Attorneys, lpa.TrustCorporations)
+	return actives == 0
+}
+
+func activateReplacements(lpa *shared.Lpa) {
+	for i, attorney := range lpa.Attorneys {
+		if attorney.AppointmentType == shared.AppointmentTypeReplacement && attorney.Status == shared.AttorneyStatusInactive {
+			lpa.Attorneys[i].Status = shared.AttorneyStatusActive
+
+			lpa.AddNote(shared.Note{
+				Type:     "REPLACEMENT_ATTORNEY_ENABLED_V1",
+				Datetime: time.Now().Format(time.RFC3339),
+				Values: map[string]string{
+					"fullName": attorney.FirstNames + " " + attorney.LastName,
+				},
+			})
+		}
+	}
+
+	for i, trustCorporation := range lpa.TrustCorporations {
+		if trustCorporation.AppointmentType == shared.AppointmentTypeReplacement && trustCorporation.Status == shared.AttorneyStatusInactive {
+			lpa.TrustCorporations[i].Status = shared.AttorneyStatusActive
+
+			lpa.AddNote(shared.Note{
+				Type:     "REPLACEMENT_ATTORNEY_ENABLED_V1",
+				Datetime: time.Now().Format(time.RFC3339),
+				Values: map[string]string{
+					"fullName": trustCorporation.Name,
+				},
+			})
+		}
+	}
+}
+
+func validateAttorneyDeceased(changes []shared.Change, lpa *shared.Lpa) (AttorneyDeceased, []shared.FieldError) {
+	var data AttorneyDeceased
+
+	if len(changes) == 0 {
+		return data, []shared.FieldError{{Source: "/changes", Detail: "no changes provided"}}
+	}
+
+	errors := parse.Changes(changes).
+		Prefix("/attorneys", func(p *parse.Parser) []shared.FieldError {
+			return p.
+				EachKey(func(key string, p *parse.Parser) []shared.FieldError {
+					attorneyIdx, ok := lpa.FindAttorneyIndex(key)
+					if !ok {
+						return p.OutOfRange()
+					}
+
+					deceased := DeceasedAttorney{Index: attorneyIdx}
+					errors := p.
+						Field("/dateOfDeath", &deceased.DateOfDeath, parse.Validate(validate.Date())).
+						Consumed()
+
+					data.Attorneys = append(data.Attorneys, deceased)
+					return errors
+				}).
+				Consumed()
+		}).
+		Consumed()
+
+	return data, errors
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateAttorneyDeceased(t *testing.T) {
+	lpa := &shared.Lpa{LpaInit: shared.LpaInit{
+		Attorneys: []shared.Attorney{
+			{Person: shared.Person{UID: "a"}},
+			{Person: shared.Person{UID: "8a6f8a43-1c4e-4b3e-9d3f-0e0a2f6d7b11"}},
+		},
+	}}
+
+	data, errors := validateAttorneyDeceased([]shared.Change{
+		{Key: "/attorneys/8a6f8a43-1c4e-4b3e-9d3f-0e0a2f6d7b11/dateOfDeath", Old: jsonNull, New: json.RawMessage(`"2024-01-02"`)},
+	}, lpa)
+
+	assert.Nil(t, errors)
+	assert.Equal(t, AttorneyDeceased{Attorneys: []DeceasedAttorney{{Index: 1, DateOfDeath: createDate("2024-01-02")}}}, data)
+}
+
+func TestValidateAttorneyDeceasedWhenInvalid(t *testing.T) {
+	testcases := map[string]struct {
+		changes []shared.Change
+		errors  []shared.FieldError
+	}{
+		"no changes": {
+			errors: []shared.FieldError{{Source: "/changes", Detail: "no changes provided"}},
+		},
+		"unknown attorney": {
+			changes: []shared.Change{{Key: "/attorneys/5/dateOfDeath", Old: jsonNull, New: json.RawMessage(`"2024-01-02"`)}},
+			errors:  []shared.FieldError{{Source: "/changes/0/key", Detail: "index out of range"}},
+		},
+		"malformed": {
+			changes: []shared.Change{{Key: "/attorneys/0/dateOfDeath", Old: jsonNull, New: json.RawMessage(`"2 January"`)}},
+			errors:  []shared.FieldError{{Source: "/changes/0/new", Detail: "invalid format"}},
+		},
+		"other field": {
+			changes: []shared.Change{{Key: "/attorneys/0/status", Old: jsonNull, New: json.RawMessage(`"removed"`)}},
+			errors: []shared.FieldError{
+				{Source: "/changes", Detail: "missing /attorneys/0/dateOfDeath"},
+				{Source: "/changes/0", Detail: "unexpected change provided"},
+			},
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			_, errors := validateAttorneyDeceased(tc.changes, &shared.Lpa{LpaInit: shared.LpaInit{Attorneys: []shared.Attorney{{}}}})
+			assert.Equal(t, tc.errors, errors)
+		})
+	}
+}
+
+func TestAttorneyDeceasedApply(t *testing.T) {
+	lpa := &shared.Lpa{Status: shared.LpaStatusRegistered, LpaInit: shared.LpaInit{
+		Attorneys: []shared.Attorney{
+			{Person: shared.Person{FirstNames: "Arun", LastName: "Brar"}, Status: shared.AttorneyStatusActive, AppointmentType: shared.AppointmentTypeOriginal},
+			{Person: shared.Person{FirstNames: "Charles", LastName: "Dent"}, Status: shared.AttorneyStatusActive, AppointmentType: shared.AppointmentTypeOriginal},
+			{Person: shared.Person{FirstNames: "Eve", LastName: "Ford"}, Status: shared.AttorneyStatusInactive, AppointmentType: shared.AppointmentTypeReplacement},
+		},
+		HowAttorneysMakeDecisions:     shared.HowMakeDecisionsJointlyAndSeverally,
+		HowReplacementAttorneysStepIn: shared.HowStepInAllCanNoLongerAct,
+	}}
+
+	errors := AttorneyDeceased{Attorneys: []DeceasedAttorney{{Index: 0, DateOfDeath: createDate("2024-01-02")}}}.Apply(lpa)
+
+	assert.Nil(t, errors)
+	assert.Equal(t, shared.AttorneyStatusRemoved, lpa.Attorneys[0].Status)
+	assert.Equal(t, createDate("2024-01-02"), *lpa.Attorneys[0].DateOfDeath)
+	assert.Equal(t, shared.AttorneyStatusActive, lpa.Attorneys[1].Status)
+	assert.Equal(t, shared.AttorneyStatusInactive, lpa.Attorneys[2].Status)
+	assert.Len(t, lpa.Notes, 1)
+	assert.Equal(t, "ATTORNEY_DECEASED_V1", lpa.Notes[0].Type)
+	assert.Equal(t, map[string]string{"fullName": "Arun Brar", "dateOfDeath": "2024-01-02"}, lpa.Notes[0].Values)
+}
+
+func TestAttorneyDeceasedApplyStepsInReplacements(t *testing.T) {
+	testcases := map[string]struct {
+		howMakeDecisions shared.HowMakeDecisions
+		howStepIn        shared.HowStepIn
+		otherStatus      shared.AttorneyStatus
+		stepIn           bool
+	}{
+		"one can no longer act": {
+			howMakeDecisions: shared.HowMakeDecisionsJointlyAndSeverally,
+			howStepIn:        shared.HowStepInOneCanNoLongerAct,
+			otherStatus:      shared.AttorneyStatusActive,
+			stepIn:           true,
+		},
+		"all can no longer act when others remain": {
+			howMakeDecisions: shared.HowMakeDecisionsJointlyAndSeverally,
+			howStepIn:        shared.HowStepInAllCanNoLongerAct,
+			otherStatus:      shared.AttorneyStatusActive,
+		},
+		"all can no longer act when none remain": {
+			howMakeDecisions: shared.HowMakeDecisionsJointlyAndSeverally,
+			howStepIn:        shared.HowStepInAllCanNoLongerAct,
+			otherStatus:      shared.AttorneyStatusRemoved,
+			stepIn:           true,
+		},
+		"another way": {
+			howMakeDecisions: shared.HowMakeDecisionsJointlyAndSeverally,
+			howStepIn:        shared.HowStepInAnotherWay,
+			otherStatus:      shared.AttorneyStatusRemoved,
+		},
+		"jointly": {
+			howMakeDecisions: shared.HowMakeDecisionsJointly,
+			otherStatus:      shared.AttorneyStatusActive,
+			stepIn:           true,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			lpa := &shared.Lpa{Status: shared.LpaStatusRegistered, LpaInit: shared.LpaInit{
+				Attorneys: []shared.Attorney{
+					{Status: shared.AttorneyStatusActive, AppointmentType: shared.AppointmentTypeOriginal},
+					{Status: tc.otherStatus, AppointmentType: shared.AppointmentTypeOriginal},
+					{Person: shared.Person{FirstNames: "Eve", LastName: "Ford"}, Status: shared.AttorneyStatusInactive, AppointmentType: shared.AppointmentTypeReplacement},
+				},
+				TrustCorporations: []shared.TrustCorporation{
+					{Name: "Trusty", Status: shared.AttorneyStatusInactive, AppointmentType: shared.AppointmentTypeReplacement},
+				},
+				HowAttorneysMakeDecisions:     tc.howMakeDecisions,
+				HowReplacementAttorneysStepIn: tc.howStepIn,
+			}}
+
+			errors := AttorneyDeceased{Attorneys: []DeceasedAttorney{{Index: 0, DateOfDeath: createDate("2024-01-02")}}}.Apply(lpa)
+			assert.Nil(t, errors)
+
+			if tc.stepIn {
+				assert.Equal(t, shared.AttorneyStatusActive, lpa.Attorneys[2].Status)
+				assert.Equal(t, shared.AttorneyStatusActive, lpa.TrustCorporations[0].Status)
+				assert.Len(t, lpa.Notes, 3)
+				assert.Equal(t, "REPLACEMENT_ATTORNEY_ENABLED_V1", lpa.Notes[1].Type)
+				assert.Equal(t, map[string]string{"fullName": "Eve Ford"}, lpa.Notes[1].Values)
+				assert.Equal(t, map[string]string{"fullName": "Trusty"}, lpa.Notes[2].Values)
+			} else {
+				assert.Equal(t, shared.AttorneyStatusInactive, lpa.Attorneys[2].Status)
+				assert.Equal(t, shared.AttorneyStatusInactive, lpa.TrustCorporations[0].Status)
+				assert.Len(t, lpa.Notes, 1)
+			}
+		})
+	}
+}
+
+func TestAttorneyDeceasedApplyWhenReplacementDies(t *testing.T) {
+	lpa := &shared.Lpa{Status: shared.LpaStatusRegistered, LpaInit: shared.LpaInit{
+		Attorneys: []shared.Attorney{
+			{Status: shared.AttorneyStatusActive, AppointmentType: shared.AppointmentTypeOriginal},
+			{Status: shared.AttorneyStatusInactive, AppointmentType: shared.AppointmentTypeReplacement},
+			{Status: shared.AttorneyStatusInactive, AppointmentType: shared.AppointmentTypeReplacement},
+		},
+		HowReplacementAttorneysStepIn: shared.HowStepInOneCanNoLongerAct,
+	}}
+
+	errors := AttorneyDeceased{Attorneys: []DeceasedAttorney{{Index: 1, DateOfDeath: createDate("2024-01-02")}}}.Apply(lpa)
+
+	assert.Nil(t, errors)
+	assert.Equal(t, shared.AttorneyStatusRemoved, lpa.Attorneys[1].Status)
+	assert.Equal(t, shared.AttorneyStatusInactive, lpa.Attorneys[2].Status)
+}
+
+func TestAttorneyDeceasedApplyWhenInvalid(t *testing.T) {
+	testcases := map[string]struct {
+		attorney    shared.Attorney
+		dateOfDeath string
+		error       shared.FieldError
+	}{
+		"already removed": {
+			attorney:    shared.Attorney{Status: shared.AttorneyStatusRemoved},
+			dateOfDeath: "2024-01-02",
+			error:       shared.FieldError{Source: "/attorneys/0/dateOfDeath", Detail: "attorney has already been removed"},
+		},
+		"in the future": {
+			attorney:    shared.Attorney{Status: shared.AttorneyStatusActive},
+			dateOfDeath: "2999-01-02",
+			error:       shared.FieldError{Source: "/attorneys/0/dateOfDeath", Detail: "date of death cannot be in the future"},
+		},
+		"before birth": {
+			attorney:    shared.Attorney{Status: shared.AttorneyStatusActive, DateOfBirth: createDate("1950-01-02")},
+			dateOfDeath: "1949-01-02",
+			error:       shared.FieldError{Source: "/attorneys/0/dateOfDeath", Detail: "date of death cannot be before date of birth"},
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			lpa := &shared.Lpa{Status: shared.LpaStatusRegistered, LpaInit: shared.LpaInit{Attorneys: []shared.Attorney{tc.attorney}}}
+
+			errors := AttorneyDeceased{Attorneys: []DeceasedAttorney{{Index: 0, DateOfDeath: createDate(tc.dateOfDeath)}}}.Apply(lpa)
+
+			assert.Equal(t, []shared.FieldError{tc.error}, errors)
+			assert.Nil(t, lpa.Attorneys[0].DateOfDeath)
+		})
+	}
+}
+
+func TestAttorneyDeceasedApplyWhenLpaIsFinal(t *testing.T) {
+	for _, status := range []shared.LpaStatus{shared.LpaStatusCannotRegister, shared.LpaStatusWithdrawn, shared.LpaStatusCancelled, shared.LpaStatusExpired, shared.LpaStatusDonorDeceased} {
+		t.Run(string(status), func(t *testing.T) {
+			lpa := &shared.Lpa{Status: status, LpaInit: shared.LpaInit{
+				Attorneys: []shared.Attorney{
+					{Status: shared.AttorneyStatusActive, AppointmentType: shared.AppointmentTypeOriginal},
+					{Status: shared.AttorneyStatusInactive, AppointmentType: shared.AppointmentTypeReplacement},
+				},
+				HowAttorneysMakeDecisions: shared.HowMakeDecisionsJointly,
+			}}
+
+			errors := AttorneyDeceased{Attorneys: []DeceasedAttorney{{Index: 0, DateOfDeath: createDate("2024-01-02")}}}.Apply(lpa)
+
+			assert.Equal(t, []shared.FieldError{{Source: "/type", Detail: "attorney cannot be recorded as deceased for an lpa that is " + string(status)}}, errors)
+			assert.Nil(t, lpa.Attorneys[0].DateOfDeath)
+			assert.Equal(t, shared.AttorneyStatusActive, lpa.Attorneys[0].Status)
+			assert.Equal(t, shared.AttorneyStatusInactive, lpa.Attorneys[1].Status)
+			assert.Empty(t, lpa.Notes)
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/event"
 	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
 	"github.com/ministryofjustice/opg-data-lpa-store/internal/validate"
 )
@@ -24,6 +25,10 @@ func (c AttorneyOptOut) Apply(lpa *shared.Lpa) []shared.FieldError {
 	return []shared.FieldError{{Source: "/type", Detail: "attorney not found"}}
 }
 
+func (AttorneyOptOut) Measurements(*shared.Lpa) []event.Measurement {
+	return []event.Measurement{event.Count(event.SubcategoryOptOut, event.MeasureAttorneyOptOut)}
+}
+
 func validateAttorneyOptOut(update shared.Update) (AttorneyOptOut, []shared.FieldError) {
 	if len(update.Changes) > 0 {
 		return AttorneyOptOut{}, []shared.FieldError{{Source: "/changes", Detail: "expected empty"}}
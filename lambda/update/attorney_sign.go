@@ -3,6 +3,7 @@ package main
 import (
 	"time"
 
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/event"
 	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
 	"github.com/ministryofjustice/opg-data-lpa-store/internal/validate"
 	"github.com/ministryofjustice/opg-data-lpa-store/lambda/update/parse"
@@ -31,6 +32,10 @@ func (a AttorneySign) Apply(lpa *shared.Lpa) []shared.FieldError {
 	return nil
 }
 
+func (a AttorneySign) Measurements(*shared.Lpa) []event.Measurement {
+	return []event.Measurement{event.FunnelCompletion(event.PartyAttorney, a.Channel)}
+}
+
 func validateAttorneySign(changes []shared.Change, lpa *shared.Lpa) (AttorneySign, []shared.FieldError) {
 	var data AttorneySign
 
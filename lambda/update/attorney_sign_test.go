@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/event"
 	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
 	"github.com/stretchr/testify/assert"
 )
@@ -44,6 +45,13 @@ func TestAttorneySignApplyWhenAlreadySigned(t *testing.T) {
 	assert.Equal(t, errors, []shared.FieldError{{Source: "/type", Detail: "attorney cannot sign again"}})
 }
 
+func TestAttorneySignMeasurements(t *testing.T) {
+	assert.Equal(t, []event.Measurement{{Subcategory: event.SubcategoryFunnelCompletionRate, Name: "ONLINEATTORNEY", Value: 1}},
+		AttorneySign{Channel: shared.ChannelOnline}.Measurements(&shared.Lpa{}))
+	assert.Equal(t, []event.Measurement{{Subcategory: event.SubcategoryFunnelCompletionRate, Name: "PAPERATTORNEY", Value: 1}},
+		AttorneySign{Channel: shared.ChannelPaper}.Measurements(&shared.Lpa{}))
+}
+
 func TestValidateUpdateAttorneySign(t *testing.T) {
 	now := time.Now()
 	yesterday := time.Now()
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+)
+
+// maxBatchUpdates limits the size of a batch. Each update is saved with its
+// change and outbox entry in a single DynamoDB transaction, along with the LPA,
+// its actor mappings and any idempotency record, so a batch within this limit
+// can still be rejected if the LPA has many actors.
+const maxBatchUpdates = 20
+
+type batchRequest struct {
+	Updates []shared.Update `json:"updates"`
+}
+
+func (r batchRequest) validate() []shared.FieldError {
+	if len(r.Updates) == 0 || len(r.Updates) > maxBatchUpdates {
+		return []shared.FieldError{{Source: "/updates", Detail: fmt.Sprintf("must contain between 1 and %d updates", maxBatchUpdates)}}
+	}
+
+	return nil
+}
+
+// batchResource is the API Gateway resource of the batch endpoint, which
+// applies several updates at once.
+const batchResource = "/lpas/{uid}/updates/batch"
+
+// isBatch reports whether the request was made to the batch endpoint.
+func isBatch(req events.APIGatewayProxyRequest) bool {
+	return req.Resource == batchResource
+}
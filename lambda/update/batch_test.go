@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchRequestValidate(t *testing.T) {
+	assert.Nil(t, batchRequest{Updates: make([]shared.Update, 1)}.validate())
+	assert.Nil(t, batchRequest{Updates: make([]shared.Update, maxBatchUpdates)}.validate())
+}
+
+func TestBatchRequestValidateWhenInvalid(t *testing.T) {
+	testcases := map[string][]shared.Update{
+		"none":     nil,
+		"too many": make([]shared.Update, maxBatchUpdates+1),
+	}
+
+	for name, updates := range testcases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, []shared.FieldError{{Source: "/updates", Detail: "must contain between 1 and 20 updates"}},
+				batchRequest{Updates: updates}.validate())
+		})
+	}
+}
+
+func TestIsBatch(t *testing.T) {
+	assert.True(t, isBatch(events.APIGatewayProxyRequest{Resource: "/lpas/{uid}/updates/batch", Path: "/lpas/M-1111-2222-3333/updates/batch"}))
+	assert.False(t, isBatch(events.APIGatewayProxyRequest{Resource: "/lpas/{uid}/updates", Path: "/lpas/M-1111-2222-3333/updates"}))
+	assert.False(t, isBatch(events.APIGatewayProxyRequest{}))
+}
@@ -1,6 +1,9 @@
 package main
 
-import "github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+import (
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/event"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+)
 
 type CertificateProviderOptOut struct{}
 
@@ -9,11 +12,19 @@ func (c CertificateProviderOptOut) Apply(lpa *shared.Lpa) []shared.FieldError {
 		return []shared.FieldError{{Source: "/type", Detail: "certificate provider cannot opt out after providing certificate"}}
 	}
 
+	if lpa.Status.IsFinal() || !lpa.Status.CanTransitionTo(shared.LpaStatusCannotRegister) {
+		return []shared.FieldError{{Source: "/type", Detail: "certificate provider cannot opt out of an lpa that is " + string(lpa.Status)}}
+	}
+
 	lpa.Status = shared.LpaStatusCannotRegister
 
 	return nil
 }
 
+func (CertificateProviderOptOut) Measurements(*shared.Lpa) []event.Measurement {
+	return []event.Measurement{event.Count(event.SubcategoryOptOut, event.MeasureCertificateProviderOptOut)}
+}
+
 func validateCertificateProviderOptOut(changes []shared.Change) (CertificateProviderOptOut, []shared.FieldError) {
 	if len(changes) > 0 {
 		return CertificateProviderOptOut{}, []shared.FieldError{{Source: "/changes", Detail: "expected empty"}}
@@ -32,6 +32,15 @@ func TestCertificateProviderOptOutApplyWhenCertificateProvided(t *testing.T) {
 	assert.Equal(t, certificateProvider, lpa.CertificateProvider)
 }
 
+func TestCertificateProviderOptOutApplyWhenStatusFinal(t *testing.T) {
+	lpa := &shared.Lpa{Status: shared.LpaStatusWithdrawn}
+
+	errors := CertificateProviderOptOut{}.Apply(lpa)
+
+	assert.Equal(t, []shared.FieldError{{Source: "/type", Detail: "certificate provider cannot opt out of an lpa that is withdrawn"}}, errors)
+	assert.Equal(t, shared.LpaStatusWithdrawn, lpa.Status)
+}
+
 func TestValidateUpdateCertificateProviderOptOut(t *testing.T) {
 	testcases := map[string]struct {
 		update shared.Update
@@ -3,6 +3,7 @@ package main
 import (
 	"time"
 
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/event"
 	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
 	"github.com/ministryofjustice/opg-data-lpa-store/internal/validate"
 	"github.com/ministryofjustice/opg-data-lpa-store/lambda/update/parse"
@@ -30,6 +31,10 @@ func (c CertificateProviderSign) Apply(lpa *shared.Lpa) []shared.FieldError {
 	return nil
 }
 
+func (c CertificateProviderSign) Measurements(*shared.Lpa) []event.Measurement {
+	return []event.Measurement{event.FunnelCompletion(event.PartyCertificateProvider, c.Channel)}
+}
+
 func validateCertificateProviderSign(changes []shared.Change, lpa *shared.Lpa) (CertificateProviderSign, []shared.FieldError) {
 	data := CertificateProviderSign{
 		Address:                   lpa.CertificateProvider.Address,
@@ -4,6 +4,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/event"
 	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
 	"github.com/ministryofjustice/opg-data-lpa-store/internal/validate"
 	"github.com/ministryofjustice/opg-data-lpa-store/lambda/update/parse"
@@ -323,6 +324,10 @@ func (c Correction) Apply(lpa *shared.Lpa) []shared.FieldError {
 	return nil
 }
 
+func (Correction) Measurements(*shared.Lpa) []event.Measurement {
+	return []event.Measurement{event.Count(event.SubcategoryCorrection, event.MeasurePreRegistrationCorrection)}
+}
+
 func validateCorrection(changes []shared.Change, lpa *shared.Lpa) (Correction, []shared.FieldError) {
 	var data Correction
 
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"time"
+
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/validate"
+	"github.com/ministryofjustice/opg-data-lpa-store/lambda/update/parse"
+)
+
+type DonorDeceased struct {
+	DateOfDeath shared.Date
+}
+
+func (d DonorDeceased) Apply(lpa *shared.Lpa) []shared.FieldError {
+	if !lpa.Status.CanTransitionTo(shared.LpaStatusDonorDeceased) {
+		return []shared.FieldError{{Source: "/type", Detail: "donor cannot be recorded as deceased for an lpa that is " + string(lpa.Status)}}
+	}
+
+	if d.DateOfDeath.Time().After(time.Now()) {
+		return []shared.FieldError{{Source: "/changes/0/new", Detail: "date of death cannot be in the future"}}
+	}
+
+	if d.DateOfDeath.Time().Before(lpa.Donor.DateOfBirth.Time()) {
+		return []shared.FieldError{{Source: "/changes/0/new", Detail: "date of death cannot be before date of birth"}}
+	}
+
+	lpa.Donor.DateOfDeath = &d.DateOfDeath
+	lpa.Status = shared.LpaStatusDonorDeceased
+
+	donorDeceasedNote := shared.Note{
+		Type:     "DONOR_DECEASED_V1",
+		Datetime: time.Now().Format(time.RFC3339),
+		Values: map[string]string{
+			"fullName":    lpa.Donor.FirstNames + " " + lpa.Donor.LastName,
+			"dateOfDeath": d.DateOfDeath.DateOnlyText(),
+		},
+	}
+
+	lpa.AddNote(donorDeceasedNote)
+
+	return nil
+}
+
+func validateDonorDeceased(changes []shared.Change) (DonorDeceased, []shared.FieldError) {
+	var data DonorDeceased
+
+	errors := parse.Changes(changes).
+		Field("/donor/dateOfDeath", &data.DateOfDeath, parse.Validate(validate.Date())).
+		Consumed()
+
+	return data, errors
+}
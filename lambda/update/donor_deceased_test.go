@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateDonorDeceased(t *testing.T) {
+	data, errors := validateDonorDeceased([]shared.Change{
+		{Key: "/donor/dateOfDeath", Old: jsonNull, New: json.RawMessage(`"2024-01-02"`)},
+	})
+
+	assert.Nil(t, errors)
+	assert.Equal(t, DonorDeceased{DateOfDeath: createDate("2024-01-02")}, data)
+}
+
+func TestValidateDonorDeceasedWhenInvalid(t *testing.T) {
+	testcases := map[string]struct {
+		changes []shared.Change
+		errors  []shared.FieldError
+	}{
+		"missing": {
+			errors: []shared.FieldError{{Source: "/changes", Detail: "missing /donor/dateOfDeath"}},
+		},
+		"malformed": {
+			changes: []shared.Change{{Key: "/donor/dateOfDeath", Old: jsonNull, New: json.RawMessage(`"2 January"`)}},
+			errors:  []shared.FieldError{{Source: "/changes/0/new", Detail: "invalid format"}},
+		},
+		"extra": {
+			changes: []shared.Change{
+				{Key: "/donor/dateOfDeath", Old: jsonNull, New: json.RawMessage(`"2024-01-02"`)},
+				{Key: "/status", Old: jsonNull, New: json.RawMessage(`"donor-deceased"`)},
+			},
+			errors: []shared.FieldError{{Source: "/changes/1", Detail: "unexpected change provided"}},
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			_, errors := validateDonorDeceased(tc.changes)
+			assert.Equal(t, tc.errors, errors)
+		})
+	}
+}
+
+func TestDonorDeceasedApply(t *testing.T) {
+	lpa := &shared.Lpa{
+		Status: shared.LpaStatusRegistered,
+		LpaInit: shared.LpaInit{
+			Donor: shared.Donor{
+				Person:      shared.Person{FirstNames: "Sam", LastName: "Smith"},
+				DateOfBirth: createDate("1950-01-02"),
+			},
+		},
+	}
+
+	errors := DonorDeceased{DateOfDeath: createDate("2024-01-02")}.Apply(lpa)
+
+	assert.Nil(t, errors)
+	assert.Equal(t, shared.LpaStatusDonorDeceased, lpa.Status)
+	assert.Equal(t, createDate("2024-01-02"), *lpa.Donor.DateOfDeath)
+	assert.Len(t, lpa.Notes, 1)
+	assert.Equal(t, "DONOR_DECEASED_V1", lpa.Notes[0].Type)
+	assert.Equal(t, map[string]string{"fullName": "Sam Smith", "dateOfDeath": "2024-01-02"}, lpa.Notes[0].Values)
+}
+
+func TestDonorDeceasedApplyWhenInvalid(t *testing.T) {
+	testcases := map[string]struct {
+		lpa         shared.Lpa
+		dateOfDeath string
+		error       shared.FieldError
+	}{
+		"already deceased": {
+			lpa:         shared.Lpa{Status: shared.LpaStatusDonorDeceased},
+			dateOfDeath: "2024-01-02",
+			error:       shared.FieldError{Source: "/type", Detail: "donor cannot be recorded as deceased for an lpa that is donor-deceased"},
+		},
+		"in the future": {
+			lpa:         shared.Lpa{Status: shared.LpaStatusInProgress},
+			dateOfDeath: "2999-01-02",
+			error:       shared.FieldError{Source: "/changes/0/new", Detail: "date of death cannot be in the future"},
+		},
+		"before birth": {
+			lpa:         shared.Lpa{Status: shared.LpaStatusInProgress, LpaInit: shared.LpaInit{Donor: shared.Donor{DateOfBirth: createDate("1950-01-02")}}},
+			dateOfDeath: "1949-01-02",
+			error:       shared.FieldError{Source: "/changes/0/new", Detail: "date of death cannot be before date of birth"},
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			errors := DonorDeceased{DateOfDeath: createDate(tc.dateOfDeath)}.Apply(&tc.lpa)
+
+			assert.Equal(t, []shared.FieldError{tc.error}, errors)
+			assert.Nil(t, tc.lpa.Donor.DateOfDeath)
+			assert.Empty(t, tc.lpa.Notes)
+		})
+	}
+}
@@ -1,22 +1,15 @@
 package main
 
 import (
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/event"
 	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
 )
 
 type DonorWithdrawLpa struct{}
 
 func (d DonorWithdrawLpa) Apply(lpa *shared.Lpa) []shared.FieldError {
-	if lpa.Status == shared.LpaStatusWithdrawn {
-		return []shared.FieldError{{Source: "/type", Detail: "lpa has already been withdrawn"}}
-	}
-
-	if lpa.Status == shared.LpaStatusRegistered {
-		return []shared.FieldError{{Source: "/type", Detail: "cannot withdraw a registered lpa"}}
-	}
-
-	if lpa.Status == shared.LpaStatusCannotRegister {
-		return []shared.FieldError{{Source: "/type", Detail: "cannot withdraw an unregisterable lpa"}}
+	if !lpa.Status.CanTransitionTo(shared.LpaStatusWithdrawn) {
+		return []shared.FieldError{{Source: "/type", Detail: "cannot withdraw an lpa that is " + string(lpa.Status)}}
 	}
 
 	lpa.Status = shared.LpaStatusWithdrawn
@@ -24,6 +17,10 @@ func (d DonorWithdrawLpa) Apply(lpa *shared.Lpa) []shared.FieldError {
 	return nil
 }
 
+func (DonorWithdrawLpa) Measurements(*shared.Lpa) []event.Measurement {
+	return []event.Measurement{event.Count(event.SubcategoryWithdrawal, event.MeasureWithdrawn)}
+}
+
 func validateDonorWithdrawLPA(changes []shared.Change) (DonorWithdrawLpa, []shared.FieldError) {
 	if len(changes) > 0 {
 		return DonorWithdrawLpa{}, []shared.FieldError{{Source: "/changes", Detail: "expected empty"}}
@@ -3,6 +3,7 @@ package main
 import (
 	"testing"
 
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/event"
 	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
 	"github.com/stretchr/testify/assert"
 )
@@ -18,20 +19,25 @@ func TestValidateDonorWithdrawLPAWithChanges(t *testing.T) {
 }
 
 func TestDonorWithdrawLPA(t *testing.T) {
-	lpa := &shared.Lpa{
-		Status: shared.LpaStatusInProgress,
-	}
+	for _, status := range []shared.LpaStatus{shared.LpaStatusInProgress, shared.LpaStatusExpired, shared.LpaStatusCancelled} {
+		t.Run(string(status), func(t *testing.T) {
+			lpa := &shared.Lpa{
+				Status: status,
+			}
 
-	errors := DonorWithdrawLpa{}.Apply(lpa)
-	assert.Nil(t, errors)
-	assert.Equal(t, shared.LpaStatusWithdrawn, lpa.Status)
+			errors := DonorWithdrawLpa{}.Apply(lpa)
+			assert.Nil(t, errors)
+			assert.Equal(t, shared.LpaStatusWithdrawn, lpa.Status)
+		})
+	}
 }
 
 func TestDonorWithdrawLPAInvalidStatuses(t *testing.T) {
 	testcases := map[shared.LpaStatus]string{
-		shared.LpaStatusWithdrawn:      "lpa has already been withdrawn",
-		shared.LpaStatusRegistered:     "cannot withdraw a registered lpa",
-		shared.LpaStatusCannotRegister: "cannot withdraw an unregisterable lpa",
+		shared.LpaStatusWithdrawn:      "cannot withdraw an lpa that is withdrawn",
+		shared.LpaStatusRegistered:     "cannot withdraw an lpa that is registered",
+		shared.LpaStatusCannotRegister: "cannot withdraw an lpa that is cannot-register",
+		shared.LpaStatusDonorDeceased:  "cannot withdraw an lpa that is donor-deceased",
 	}
 
 	for status, expectedError := range testcases {
@@ -42,3 +48,8 @@ func TestDonorWithdrawLPAInvalidStatuses(t *testing.T) {
 		})
 	}
 }
+
+func TestDonorWithdrawLPAMeasurements(t *testing.T) {
+	assert.Equal(t, []event.Measurement{{Subcategory: event.SubcategoryWithdrawal, Name: event.MeasureWithdrawn, Value: 1}},
+		DonorWithdrawLpa{}.Measurements(&shared.Lpa{}))
+}
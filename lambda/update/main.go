@@ -3,6 +3,8 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"os"
 	"strconv"
@@ -15,167 +17,465 @@ import (
 	"github.com/google/uuid"
 	"github.com/ministryofjustice/opg-data-lpa-store/internal/ddb"
 	"github.com/ministryofjustice/opg-data-lpa-store/internal/event"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/idempotency"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/jsondoc"
 	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/tracing"
 	"github.com/ministryofjustice/opg-go-common/telemetry"
 )
 
-type EventClient interface {
-	SendLpaUpdated(ctx context.Context, event event.LpaUpdated, metric *event.Metric) error
-}
+// maxAttempts is how many times an update is applied when the LPA keeps
+// changing underneath it.
+const maxAttempts = 2
 
 type Logger interface {
-	Error(string, ...any)
-	Warn(string, ...any)
-	Info(string, ...any)
-	Debug(string, ...any)
+	ErrorContext(context.Context, string, ...any)
+	WarnContext(context.Context, string, ...any)
+	InfoContext(context.Context, string, ...any)
+	DebugContext(context.Context, string, ...any)
 }
 
 type Store interface {
-	PutChanges(ctx context.Context, data any, update shared.Update) error
-	Get(ctx context.Context, uid string) (shared.Lpa, error)
+	PutChanges(ctx context.Context, lpa shared.Lpa, updates []shared.Update, previousActorUIDs []string, record *idempotency.Record, outbox []event.OutboxEntry) error
+	GetForUpdate(ctx context.Context, uid string) (shared.Lpa, error)
+	GetIdempotencyRecord(ctx context.Context, key string) (idempotency.Record, error)
+	GetUpdate(ctx context.Context, uid, id string) (shared.Update, error)
 }
 
 type Verifier interface {
-	VerifyHeader(events.APIGatewayProxyRequest) (*shared.LpaStoreClaims, error)
+	VerifyHeader(context.Context, events.APIGatewayProxyRequest) (*shared.LpaStoreClaims, error)
 }
 
 type Lambda struct {
-	eventClient EventClient
-	store       Store
-	verifier    Verifier
-	environment string
-	logger      Logger
-	now         func() time.Time
+	store          Store
+	verifier       Verifier
+	environment    string
+	logger         Logger
+	now            func() time.Time
+	idempotencyTTL time.Duration
 }
 
 func (l *Lambda) HandleEvent(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	claims, err := l.verifier.VerifyHeader(req)
+	claims, err := l.verifier.VerifyHeader(ctx, req)
 	if err != nil {
-		l.logger.Info("Unable to verify JWT from header")
-		return shared.ProblemUnauthorisedRequest.Respond()
+		l.logger.InfoContext(ctx, "Unable to verify JWT from header")
+		return shared.ProblemUnauthorisedRequest.Respond(ctx)
 	}
 
-	l.logger.Debug("Successfully parsed JWT from event header")
+	l.logger.DebugContext(ctx, "Successfully parsed JWT from event header")
+
+	if !shared.Authorise(claims, shared.OperationUpdate) {
+		l.logger.InfoContext(ctx, "JWT not permitted to make request")
+		return shared.ProblemForbidden.Respond(ctx)
+	}
 
 	response := events.APIGatewayProxyResponse{
 		StatusCode: 500,
 		Body:       "{\"code\":\"INTERNAL_SERVER_ERROR\",\"detail\":\"Internal server error\"}",
 	}
 
-	var update shared.Update
-	if err = json.Unmarshal([]byte(req.Body), &update); err != nil {
-		l.logger.Error("error unmarshalling request", slog.Any("err", err))
-		return shared.ProblemInternalServerError.Respond()
+	batch := isBatch(req)
+
+	var updates []shared.Update
+	if batch {
+		var input batchRequest
+		if err = json.Unmarshal([]byte(req.Body), &input); err != nil {
+			l.logger.ErrorContext(ctx, "error unmarshalling request", slog.Any("err", err))
+			return shared.ProblemInternalServerError.Respond(ctx)
+		}
+
+		if errs := input.validate(); len(errs) > 0 {
+			problem := shared.ProblemInvalidRequest
+			problem.Errors = errs
+			return problem.Respond(ctx)
+		}
+
+		updates = input.Updates
+	} else {
+		var update shared.Update
+		if err = json.Unmarshal([]byte(req.Body), &update); err != nil {
+			l.logger.ErrorContext(ctx, "error unmarshalling request", slog.Any("err", err))
+			return shared.ProblemInternalServerError.Respond(ctx)
+		}
+
+		updates = []shared.Update{update}
+	}
+
+	if errs := forbiddenTypeErrors(claims, updates, batch); len(errs) > 0 {
+		l.logger.InfoContext(ctx, "JWT not permitted to make update")
+		problem := shared.ProblemForbidden
+		problem.Errors = errs
+		return problem.Respond(ctx)
+	}
+
+	subject, _ := claims.GetSubject()
+	for i := range updates {
+		updates[i].Author = shared.URN(subject)
+	}
+
+	ifMatch := shared.GetEventHeader("If-Match", req)
+
+	var dryRun bool
+	if v, ok := req.QueryStringParameters["dryRun"]; ok {
+		if dryRun, err = strconv.ParseBool(v); err != nil {
+			problem := shared.ProblemInvalidRequest
+			problem.Errors = []shared.FieldError{{Source: "/dryRun", Detail: "invalid value"}}
+			return problem.Respond(ctx)
+		}
+	}
+
+	// a dry run changes nothing, so there is no response to keep for retries
+	var idempotencyKey string
+	if !dryRun {
+		operation := "update"
+		if batch {
+			operation = "batch"
+		}
+
+		idempotencyKey = idempotency.Key(req, operation, subject)
+	}
+
+	var lpa shared.Lpa
+
+	// if another request changes the LPA between reading and writing it then
+	// the updates are validated again against the new state before giving up
+	for attempt := 1; ; attempt++ {
+		record, problem := l.getIdempotencyRecord(ctx, idempotencyKey, req)
+		if problem != nil {
+			return problem.Respond(ctx)
+		}
+		if record != nil {
+			l.logger.InfoContext(ctx, "replaying response for Idempotency-Key", slog.String("uid", req.PathParameters["uid"]), slog.String("updateId", record.UpdateId))
+			return record.Replay(), nil
+		}
+
+		lpa, problem = l.getLpa(ctx, req.PathParameters["uid"], ifMatch)
+		if problem != nil {
+			return problem.Respond(ctx)
+		}
+
+		actorUIDs := lpa.ActorUIDs()
+
+		original, err := json.Marshal(lpa)
+		if err != nil {
+			l.logger.ErrorContext(ctx, "error marshalling LPA", slog.Any("err", err))
+			return shared.ProblemInternalServerError.Respond(ctx)
+		}
+
+		originalStatus := lpa.Status
+
+		measurements, applied, problem := l.applyUpdates(ctx, &lpa, updates, batch)
+		if problem != nil {
+			return problem.Respond(ctx)
+		}
+
+		if dryRun {
+			diff, err := diffLpa(original, lpa)
+			if err != nil {
+				l.logger.ErrorContext(ctx, "error calculating changes", slog.Any("err", err))
+				return shared.ProblemInternalServerError.Respond(ctx)
+			}
+
+			return l.respondDryRun(ctx, lpa, diff)
+		}
+
+		body, err := json.Marshal(lpa)
+		if err != nil {
+			l.logger.ErrorContext(ctx, "error marshalling LPA", slog.Any("err", err))
+			return shared.ProblemInternalServerError.Respond(ctx)
+		}
+
+		response.StatusCode = 201
+		response.Headers = map[string]string{"ETag": lpa.ETag()}
+		response.Body = string(body)
+
+		if idempotencyKey != "" {
+			newRecord := idempotency.NewRecord(idempotencyKey, req, applied[len(applied)-1].Id, response, l.now(), l.idempotencyTTL)
+			record = &newRecord
+		}
+
+		// the lpa-updated events are sent by the outbox relay once saved
+		outbox := make([]event.OutboxEntry, len(applied))
+		status := originalStatus
+		for i, update := range applied {
+			lpaUpdated := event.NewLpaUpdated(update, status, lpa)
+			status = lpaUpdated.StatusAfter
+
+			outbox[i] = event.NewOutboxEntry(ctx, update.Id, lpaUpdated, event.NewMetrics(l.environment, l.now(), measurements[i]...), l.now())
+		}
+
+		err = l.store.PutChanges(ctx, lpa, applied, actorUIDs, record, outbox)
+		if err == nil {
+			break
+		}
+
+		if errors.Is(err, ddb.ErrTooManyItems) {
+			l.logger.InfoContext(ctx, "too many changes to save at once", slog.String("uid", lpa.Uid))
+			problem := shared.ProblemInvalidRequest
+			problem.Detail = "Too many changes to save at once, send fewer updates"
+			return problem.Respond(ctx)
+		}
+
+		if !errors.Is(err, ddb.ErrConditionFailed) {
+			l.logger.ErrorContext(ctx, "error saving changes", slog.Any("err", err))
+			return shared.ProblemInternalServerError.Respond(ctx)
+		}
+
+		if attempt == maxAttempts {
+			l.logger.InfoContext(ctx, "LPA was changed by another request", slog.String("uid", lpa.Uid))
+			return shared.ProblemConflict.Respond(ctx)
+		}
+
+		l.logger.InfoContext(ctx, "LPA was changed by another request, retrying", slog.String("uid", lpa.Uid))
+	}
+
+	return response, nil
+}
+
+type dryRunResponse struct {
+	Lpa  shared.Lpa      `json:"lpa"`
+	Diff []shared.Change `json:"diff"`
+}
+
+// respondDryRun returns the LPA as it would be if the update was saved, and
+// the changes the update would make to it.
+func (l *Lambda) respondDryRun(ctx context.Context, lpa shared.Lpa, diff []shared.Change) (events.APIGatewayProxyResponse, error) {
+	if diff == nil {
+		diff = []shared.Change{}
+	}
+
+	body, err := json.Marshal(dryRunResponse{Lpa: lpa, Diff: diff})
+	if err != nil {
+		l.logger.ErrorContext(ctx, "error marshalling LPA", slog.Any("err", err))
+		return shared.ProblemInternalServerError.Respond(ctx)
+	}
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: 200,
+		Body:       string(body),
+	}, nil
+}
+
+// diffLpa lists every change between the LPA as marshalled in before and lpa.
+func diffLpa(before []byte, lpa shared.Lpa) ([]shared.Change, error) {
+	after, err := json.Marshal(lpa)
+	if err != nil {
+		return nil, err
 	}
 
-	lpa, err := l.store.Get(ctx, req.PathParameters["uid"])
+	return jsondoc.Diff(before, after)
+}
+
+// getIdempotencyRecord returns the record of the response already given for
+// the Idempotency-Key, if there is one. A problem is returned if the key was
+// used for a different request.
+func (l *Lambda) getIdempotencyRecord(ctx context.Context, key string, req events.APIGatewayProxyRequest) (*idempotency.Record, *shared.Problem) {
+	if key == "" {
+		return nil, nil
+	}
+
+	record, err := l.store.GetIdempotencyRecord(ctx, key)
 	if err != nil {
-		l.logger.Error("error fetching LPA", slog.Any("err", err))
-		return shared.ProblemInternalServerError.Respond()
+		l.logger.ErrorContext(ctx, "error fetching idempotency record", slog.Any("err", err))
+		return nil, &shared.ProblemInternalServerError
+	}
+
+	if !record.Live(l.now()) {
+		return nil, nil
+	}
+
+	if !record.Matches(req) {
+		l.logger.InfoContext(ctx, "Idempotency-Key reused for a different request", slog.String("uid", req.PathParameters["uid"]))
+		return nil, &shared.ProblemIdempotencyKeyReused
+	}
+
+	return &record, nil
+}
+
+// getLpa fetches the LPA, returning a problem if it cannot be found or does not
+// match the If-Match header values. The read is consistent, so that a retry
+// sees the change that caused it.
+func (l *Lambda) getLpa(ctx context.Context, uid string, ifMatch []string) (shared.Lpa, *shared.Problem) {
+	lpa, err := l.store.GetForUpdate(ctx, uid)
+	if err != nil {
+		l.logger.ErrorContext(ctx, "error fetching LPA", slog.Any("err", err))
+		return lpa, &shared.ProblemInternalServerError
 	}
 	if lpa.Uid == "" {
-		l.logger.Debug("Uid not found")
-		return shared.ProblemNotFoundRequest.Respond()
+		l.logger.DebugContext(ctx, "Uid not found")
+		return lpa, &shared.ProblemNotFoundRequest
 	}
 
-	subject, _ := claims.GetSubject()
-	update.Author = shared.URN(subject)
+	if len(ifMatch) > 0 && !lpa.MatchesETag(ifMatch) {
+		l.logger.InfoContext(ctx, "LPA does not match If-Match header", slog.String("uid", lpa.Uid))
+		return lpa, &shared.ProblemPreconditionFailed
+	}
+
+	return lpa, nil
+}
+
+// applyUpdates applies each update to the LPA in turn, returning them with
+// the details they are recorded with and the measurements each emits. The
+// LPA's version is increased once for all of the updates. For a batch, any
+// problem has its error sources prefixed with the position of the update that
+// caused it.
+func (l *Lambda) applyUpdates(ctx context.Context, lpa *shared.Lpa, updates []shared.Update, batch bool) ([][]event.Measurement, []shared.Update, *shared.Problem) {
+	measurements := make([][]event.Measurement, len(updates))
+	applied := make([]shared.Update, len(updates))
+
+	for i, update := range updates {
+		before, err := json.Marshal(lpa)
+		if err != nil {
+			l.logger.ErrorContext(ctx, "error marshalling LPA", slog.Any("err", err))
+			return nil, nil, &shared.ProblemInternalServerError
+		}
+
+		applyable, problem := l.applyUpdate(ctx, lpa, update)
+		if problem != nil {
+			if batch {
+				problem = prefixErrors(problem, fmt.Sprintf("/updates/%d", i))
+			}
+
+			return nil, nil, problem
+		}
+
+		if i == len(updates)-1 {
+			lpa.Version++
+		}
+
+		update.Id = uuid.NewString()
+		update.Uid = lpa.Uid
+
+		if update.Diff, err = diffLpa(before, *lpa); err != nil {
+			l.logger.ErrorContext(ctx, "error calculating changes", slog.Any("err", err))
+			return nil, nil, &shared.ProblemInternalServerError
+		}
+
+		if emitter, ok := applyable.(event.MetricsEmitter); ok {
+			measurements[i] = emitter.Measurements(lpa)
+		}
+		applied[i] = update
+	}
+
+	// each update in a batch is recorded a nanosecond apart so that they have
+	// distinct keys, in the order they were applied
+	now := l.now().UTC()
+	for i := range applied {
+		applied[i].Applied = now.Add(time.Duration(i)).Format(shared.AppliedFormat)
+	}
+
+	return measurements, applied, nil
+}
+
+// forbiddenTypeErrors returns an error for each update with a type that the
+// claims are not permitted to make.
+func forbiddenTypeErrors(claims *shared.LpaStoreClaims, updates []shared.Update, batch bool) []shared.FieldError {
+	var errs []shared.FieldError
+	for i, update := range updates {
+		if !shared.Authorise(claims, shared.OperationUpdate, update.Type) {
+			source := "/type"
+			if batch {
+				source = fmt.Sprintf("/updates/%d/type", i)
+			}
+
+			errs = append(errs, shared.FieldError{Source: source, Detail: "not permitted"})
+		}
+	}
+
+	return errs
+}
+
+// prefixErrors returns a copy of the problem with prefix added to the source of
+// each of its errors.
+func prefixErrors(problem *shared.Problem, prefix string) *shared.Problem {
+	prefixed := *problem
+	prefixed.Errors = make([]shared.FieldError, len(problem.Errors))
 
+	for i, fieldError := range problem.Errors {
+		prefixed.Errors[i] = shared.FieldError{Source: prefix + fieldError.Source, Detail: fieldError.Detail}
+	}
+
+	return &prefixed
+}
+
+// applyUpdate applies update to the LPA, returning a problem if the update is
+// not valid for it.
+func (l *Lambda) applyUpdate(ctx context.Context, lpa *shared.Lpa, update shared.Update) (Applyable, *shared.Problem) {
 	redundantErrors, err := redundantChangeErrors(update.Changes)
 	if err != nil {
-		l.logger.Error("error evaluating redundant changes", slog.Any("err", err))
-		return shared.ProblemInternalServerError.Respond()
+		l.logger.ErrorContext(ctx, "error evaluating redundant changes", slog.Any("err", err))
+		return nil, &shared.ProblemInternalServerError
 	}
 
 	if len(redundantErrors) > 0 {
 		problem := shared.ProblemInvalidRequest
 		problem.Errors = redundantErrors
 
-		return problem.Respond()
+		return nil, &problem
+	}
+
+	var (
+		applyable Applyable
+		errors    []shared.FieldError
+	)
+	if update.Type == "REVERT" {
+		var problem *shared.Problem
+		if applyable, errors, problem = l.validateRevert(ctx, lpa.Uid, update.Changes); problem != nil {
+			return nil, problem
+		}
+	} else {
+		applyable, errors = validateUpdate(update, lpa)
 	}
 
-	applyable, errors := validateUpdate(update, &lpa)
 	if len(errors) > 0 {
 		problem := shared.ProblemInvalidRequest
 		problem.Errors = errors
 
-		return problem.Respond()
+		return nil, &problem
 	}
 
-	if errors := applyable.Apply(&lpa); len(errors) > 0 {
+	status := lpa.Status
+	if errors := applyable.Apply(lpa); len(errors) > 0 {
 		problem := shared.ProblemInvalidRequest
 		problem.Errors = errors
 
-		return problem.Respond()
+		return nil, &problem
 	}
 
-	update.Id = uuid.NewString()
-	update.Uid = lpa.Uid
-	update.Applied = l.now().UTC().Format(time.RFC3339)
+	if lpa.Status != status && !status.CanTransitionTo(lpa.Status) {
+		problem := shared.ProblemInvalidRequest
+		problem.Errors = []shared.FieldError{{Source: "/type", Detail: fmt.Sprintf("lpa status cannot be changed from %s to %s", status, lpa.Status)}}
 
-	if err := l.store.PutChanges(ctx, lpa, update); err != nil {
-		l.logger.Error("error saving changes", slog.Any("err", err))
-		return shared.ProblemInternalServerError.Respond()
+		return nil, &problem
 	}
 
-	var measureName string
-	switch v := applyable.(type) {
-	case AttorneySign:
-		if v.Channel == shared.ChannelOnline {
-			measureName = "ONLINEATTORNEY"
-		} else {
-			measureName = "PAPERATTORNEY"
-		}
-	case CertificateProviderSign:
-		if v.Channel == shared.ChannelOnline {
-			measureName = "ONLINECERTIFICATEPROVIDER"
-		} else {
-			measureName = "PAPERCERTIFICATEPROVIDER"
-		}
-	case TrustCorporationSign:
-		if v.Channel == shared.ChannelOnline {
-			measureName = "ONLINETRUSTCORPORATION"
-		} else {
-			measureName = "PAPERTRUSTCORPORATION"
-		}
-	}
+	return applyable, nil
+}
 
-	var metric *event.Metric
-	if measureName != "" {
-		metric = &event.Metric{
-			Project:          "MRLPA",
-			Category:         "metric",
-			Subcategory:      "FunnelCompletionRate",
-			Environment:      l.environment,
-			MeasureName:      measureName,
-			MeasureValue:     "1",
-			MeasureValueType: "BIGINT",
-			Time:             strconv.FormatInt(l.now().UnixMilli(), 10),
-		}
+// validateRevert fetches the update to be reverted, as it is needed to know
+// what the revert will change.
+func (l *Lambda) validateRevert(ctx context.Context, uid string, changes []shared.Change) (Applyable, []shared.FieldError, *shared.Problem) {
+	updateId, errors := validateRevert(changes)
+	if len(errors) > 0 {
+		return nil, errors, nil
 	}
 
-	body, err := json.Marshal(lpa)
+	original, err := l.store.GetUpdate(ctx, uid, updateId)
 	if err != nil {
-		l.logger.Error("error marshalling LPA", slog.Any("err", err))
-		return shared.ProblemInternalServerError.Respond()
+		l.logger.ErrorContext(ctx, "error fetching update", slog.Any("err", err))
+		return nil, nil, &shared.ProblemInternalServerError
 	}
 
-	if err := l.eventClient.SendLpaUpdated(ctx, event.LpaUpdated{
-		Uid:        lpa.Uid,
-		ChangeType: update.Type,
-	}, metric); err != nil {
-		l.logger.Error("unexpected error occurred", slog.Any("err", err))
+	if original.Id == "" {
+		return nil, []shared.FieldError{{Source: "/changes/0/new", Detail: "update not found"}}, nil
 	}
 
-	response.StatusCode = 201
-	response.Body = string(body)
-
-	return response, nil
+	return Revert{Original: original}, nil, nil
 }
 
 func main() {
 	ctx := context.Background()
-	logger := telemetry.NewLogger("opg-data-lpa-store/update")
+	logger := shared.NewRequestLogger(telemetry.NewLogger("opg-data-lpa-store/update"))
 
 	// set endpoint to "" outside dev to use default AWS resolver
 	endpointURL := os.Getenv("AWS_BASE_URL")
@@ -189,18 +489,36 @@ func main() {
 		cfg.BaseEndpoint = aws.String(endpointURL)
 	}
 
+	idempotencyTTL, err := idempotency.ParseTTL(os.Getenv("IDEMPOTENCY_KEY_TTL"))
+	if err != nil {
+		logger.Error("invalid idempotency key TTL, using default", slog.Any("err", err))
+	}
+
+	tp, err := tracing.Start(ctx, "opg-data-lpa-store/update")
+	if err != nil {
+		logger.Error("failed to start tracing", slog.Any("err", err))
+	}
+
+	verifier := shared.NewJWTVerifier(cfg, logger)
+	if tableName := os.Getenv("DDB_TABLE_NAME_TOKENS"); tableName != "" {
+		verifier.Tokens = ddb.NewTokenClient(cfg, tableName)
+	}
+
 	l := &Lambda{
-		eventClient: event.NewClient(cfg, os.Getenv("EVENT_BUS_NAME")),
 		store: ddb.New(
 			cfg,
 			os.Getenv("DDB_TABLE_NAME_DEEDS"),
 			os.Getenv("DDB_TABLE_NAME_CHANGES"),
+			os.Getenv("DDB_TABLE_NAME_ACTORS"),
+			os.Getenv("DDB_TABLE_NAME_IDEMPOTENCY"),
+			os.Getenv("DDB_TABLE_NAME_OUTBOX"),
 		),
-		verifier:    shared.NewJWTVerifier(cfg, logger),
-		environment: os.Getenv("ENVIRONMENT"),
-		logger:      logger,
-		now:         time.Now,
+		verifier:       verifier,
+		environment:    os.Getenv("ENVIRONMENT"),
+		logger:         logger,
+		now:            time.Now,
+		idempotencyTTL: idempotencyTTL,
 	}
 
-	lambda.Start(l.HandleEvent)
+	lambda.Start(tracing.WithTracing(tp, shared.WithRequestID(l.HandleEvent)))
 }
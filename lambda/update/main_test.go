@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"errors"
 	"log/slog"
-	"regexp"
 	"strconv"
 	"testing"
 	"time"
@@ -13,12 +12,19 @@ import (
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/ddb"
 	"github.com/ministryofjustice/opg-data-lpa-store/internal/event"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/idempotency"
 	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
+var siriusClaims = &shared.LpaStoreClaims{RegisteredClaims: jwt.RegisteredClaims{
+	Issuer:  "opg.poas.sirius",
+	Subject: "urn:opg:poas:sirius:users:1",
+}}
+
 var (
 	errExpected = errors.New("expected")
 	jsonNull    = json.RawMessage("null")
@@ -30,8 +36,8 @@ var (
 func newAllowedMockVerifier(t *testing.T) *mockVerifier {
 	verifier := newMockVerifier(t)
 	verifier.EXPECT().
-		VerifyHeader(mock.Anything).
-		Return(&shared.LpaStoreClaims{}, nil)
+		VerifyHeader(mock.Anything, mock.Anything).
+		Return(siriusClaims, nil)
 	return verifier
 }
 
@@ -40,14 +46,15 @@ func TestHandleEvent(t *testing.T) {
 
 	logger := newMockLogger(t)
 	logger.EXPECT().
-		Debug("Successfully parsed JWT from event header", mock.Anything)
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header", mock.Anything)
 
 	store := newMockStore(t)
 	store.EXPECT().
-		Get(mock.Anything, mock.Anything).
+		GetForUpdate(mock.Anything, mock.Anything).
 		Return(shared.Lpa{
 			Uid: "1",
 			LpaInit: shared.LpaInit{
+				Donor: shared.Donor{Person: shared.Person{UID: "donor-uid"}},
 				CertificateProvider: shared.CertificateProvider{
 					Email:   "a@example.com",
 					Channel: shared.ChannelPaper,
@@ -56,8 +63,10 @@ func TestHandleEvent(t *testing.T) {
 		}, nil)
 	store.EXPECT().
 		PutChanges(mock.Anything, shared.Lpa{
-			Uid: "1",
+			Uid:     "1",
+			Version: 1,
 			LpaInit: shared.LpaInit{
+				Donor: shared.Donor{Person: shared.Person{UID: "donor-uid"}},
 				CertificateProvider: shared.CertificateProvider{
 					SignedAt:                  &signedAt,
 					ContactLanguagePreference: shared.LangEn,
@@ -65,17 +74,22 @@ func TestHandleEvent(t *testing.T) {
 					Email:                     "b@example.com",
 				},
 			},
-		}, mock.MatchedBy(func(update shared.Update) bool {
+		}, mock.MatchedBy(func(updates []shared.Update) bool {
+			if !assert.Len(t, updates, 1) {
+				return false
+			}
+
+			update := updates[0]
 			id := update.Id
 			applied := update.Applied
 			update.Id = ""
 			update.Applied = ""
 
 			return assert.NoError(t, uuid.Validate(id)) &&
-				assert.Regexp(t, regexp.MustCompile(`\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}Z`), applied) &&
+				assert.Equal(t, "2024-01-02T12:13:14.000000015Z", applied) &&
 				assert.Equal(t, shared.Update{
 					Uid:    "1",
-					Author: "1234",
+					Author: "urn:opg:poas:sirius:users:1234",
 					Type:   "CERTIFICATE_PROVIDER_SIGN",
 					Changes: []shared.Change{
 						{
@@ -99,38 +113,57 @@ func TestHandleEvent(t *testing.T) {
 							New: json.RawMessage(`"online"`),
 						},
 					},
+					Diff: []shared.Change{
+						{Key: "/certificateProvider/channel", Old: json.RawMessage(`"paper"`), New: json.RawMessage(`"online"`)},
+						{Key: "/certificateProvider/contactLanguagePreference", Old: jsonNull, New: json.RawMessage(`"en"`)},
+						{Key: "/certificateProvider/email", Old: json.RawMessage(`"a@example.com"`), New: json.RawMessage(`"b@example.com"`)},
+						{Key: "/certificateProvider/signedAt", Old: jsonNull, New: json.RawMessage(`"2022-01-02T12:13:14.000000006Z"`)},
+						{Key: "/version", Old: json.RawMessage(`0`), New: json.RawMessage(`1`)},
+					},
 				}, update)
+		}), []string{"donor-uid"}, (*idempotency.Record)(nil), mock.MatchedBy(func(outbox []event.OutboxEntry) bool {
+			return assert.Len(t, outbox, 1) &&
+				assert.NoError(t, uuid.Validate(outbox[0].Id)) &&
+				assert.Equal(t, event.LpaUpdated{
+					Uid:           "1",
+					ChangeType:    "CERTIFICATE_PROVIDER_SIGN",
+					SchemaVersion: event.LpaUpdatedSchemaVersion,
+					UpdateId:      outbox[0].Id,
+					Author:        "urn:opg:poas:sirius:users:1234",
+					Applied:       "2024-01-02T12:13:14.000000015Z",
+					ChangedKeys: []string{
+						"/certificateProvider/channel",
+						"/certificateProvider/contactLanguagePreference",
+						"/certificateProvider/email",
+						"/certificateProvider/signedAt",
+						"/version",
+					},
+				}, outbox[0].Event) &&
+				assert.Equal(t, []event.Metric{{
+					Project:          "MRLPA",
+					Category:         "metric",
+					Subcategory:      "FunnelCompletionRate",
+					Environment:      "ENVIRONMENT",
+					MeasureName:      "ONLINECERTIFICATEPROVIDER",
+					MeasureValue:     "1",
+					MeasureValueType: "BIGINT",
+					Time:             strconv.FormatInt(testNow.UnixMilli(), 10),
+				}}, outbox[0].Metrics) &&
+				assert.Equal(t, testNow, outbox[0].CreatedAt)
 		})).
 		Return(nil)
 
-	eventClient := newMockEventClient(t)
-	eventClient.EXPECT().
-		SendLpaUpdated(mock.Anything, event.LpaUpdated{
-			Uid:        "1",
-			ChangeType: "CERTIFICATE_PROVIDER_SIGN",
-		}, &event.Metric{
-			Project:          "MRLPA",
-			Category:         "metric",
-			Subcategory:      "FunnelCompletionRate",
-			Environment:      "ENVIRONMENT",
-			MeasureName:      "ONLINECERTIFICATEPROVIDER",
-			MeasureValue:     "1",
-			MeasureValueType: "BIGINT",
-			Time:             strconv.FormatInt(testNow.UnixMilli(), 10),
-		}).
-		Return(nil)
-
 	verifier := newMockVerifier(t)
 	verifier.EXPECT().
-		VerifyHeader(mock.Anything).
+		VerifyHeader(mock.Anything, mock.Anything).
 		Return(&shared.LpaStoreClaims{
 			RegisteredClaims: jwt.RegisteredClaims{
-				Subject: "1234",
+				Issuer:  "opg.poas.sirius",
+				Subject: "urn:opg:poas:sirius:users:1234",
 			},
 		}, nil)
 
 	l := Lambda{
-		eventClient: eventClient,
 		store:       store,
 		verifier:    verifier,
 		environment: "ENVIRONMENT",
@@ -144,6 +177,7 @@ func TestHandleEvent(t *testing.T) {
 
 	assert.Nil(t, err)
 	assert.Equal(t, 201, resp.StatusCode)
+	assert.Equal(t, map[string]string{"ETag": `"1"`}, resp.Headers)
 	assert.Contains(t, resp.Body, `"2022-01-02T12:13:14.000000006Z"`)
 	assert.Contains(t, resp.Body, `"en"`)
 }
@@ -151,28 +185,28 @@ func TestHandleEvent(t *testing.T) {
 func TestHandleEventWhenAllChangesRedundant(t *testing.T) {
 	logger := newMockLogger(t)
 	logger.EXPECT().
-		Debug("Successfully parsed JWT from event header", mock.Anything)
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header", mock.Anything)
 
 	store := newMockStore(t)
 	store.EXPECT().
-		Get(mock.Anything, mock.Anything).
+		GetForUpdate(mock.Anything, mock.Anything).
 		Return(shared.Lpa{Uid: "1", Status: shared.LpaStatusCannotRegister}, nil)
 
 	verifier := newMockVerifier(t)
 	verifier.EXPECT().
-		VerifyHeader(mock.Anything).
+		VerifyHeader(mock.Anything, mock.Anything).
 		Return(&shared.LpaStoreClaims{
 			RegisteredClaims: jwt.RegisteredClaims{
-				Subject: "subject",
+				Issuer:  "opg.poas.sirius",
+				Subject: "urn:opg:poas:sirius:users:subject",
 			},
 		}, nil)
 
 	l := Lambda{
-		eventClient: newMockEventClient(t),
-		store:       store,
-		verifier:    verifier,
-		logger:      logger,
-		now:         testNowFn,
+		store:    store,
+		verifier: verifier,
+		logger:   logger,
+		now:      testNowFn,
 	}
 
 	resp, err := l.HandleEvent(context.Background(), events.APIGatewayProxyRequest{
@@ -181,34 +215,34 @@ func TestHandleEventWhenAllChangesRedundant(t *testing.T) {
 
 	assert.Nil(t, err)
 	assert.Equal(t, 400, resp.StatusCode)
-	assert.JSONEq(t, `{"code":"INVALID_REQUEST","detail":"Invalid request","errors":[{"source":"/changes/0","detail":"redundant change for /status"}]}`, resp.Body)
+	assert.JSONEq(t, `{"type":"urn:opg:poas:lpa-store:problem:invalid-request","title":"Bad Request","status":400,"detail":"Invalid request","code":"INVALID_REQUEST","errors":[{"source":"/changes/0","detail":"redundant change for /status"}]}`, resp.Body)
 }
 
 func TestHandleEventWhenAnyChangeRedundant(t *testing.T) {
 	logger := newMockLogger(t)
 	logger.EXPECT().
-		Debug("Successfully parsed JWT from event header", mock.Anything)
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header", mock.Anything)
 
 	store := newMockStore(t)
 	store.EXPECT().
-		Get(mock.Anything, mock.Anything).
+		GetForUpdate(mock.Anything, mock.Anything).
 		Return(shared.Lpa{Uid: "1"}, nil)
 
 	verifier := newMockVerifier(t)
 	verifier.EXPECT().
-		VerifyHeader(mock.Anything).
+		VerifyHeader(mock.Anything, mock.Anything).
 		Return(&shared.LpaStoreClaims{
 			RegisteredClaims: jwt.RegisteredClaims{
-				Subject: "subject",
+				Issuer:  "opg.poas.sirius",
+				Subject: "urn:opg:poas:sirius:users:subject",
 			},
 		}, nil)
 
 	l := Lambda{
-		eventClient: newMockEventClient(t),
-		store:       store,
-		verifier:    verifier,
-		logger:      logger,
-		now:         testNowFn,
+		store:    store,
+		verifier: verifier,
+		logger:   logger,
+		now:      testNowFn,
 	}
 
 	resp, err := l.HandleEvent(context.Background(), events.APIGatewayProxyRequest{
@@ -217,24 +251,23 @@ func TestHandleEventWhenAnyChangeRedundant(t *testing.T) {
 
 	assert.Nil(t, err)
 	assert.Equal(t, 400, resp.StatusCode)
-	assert.JSONEq(t, `{"code":"INVALID_REQUEST","detail":"Invalid request","errors":[{"source":"/changes/0","detail":"redundant change for /certificateProvider/email"}]}`, resp.Body)
+	assert.JSONEq(t, `{"type":"urn:opg:poas:lpa-store:problem:invalid-request","title":"Bad Request","status":400,"detail":"Invalid request","code":"INVALID_REQUEST","errors":[{"source":"/changes/0","detail":"redundant change for /certificateProvider/email"}]}`, resp.Body)
 }
 
 func TestHandleEventWhenUnknownType(t *testing.T) {
 	logger := newMockLogger(t)
 	logger.EXPECT().
-		Debug("Successfully parsed JWT from event header", mock.Anything)
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header", mock.Anything)
 
 	store := newMockStore(t)
 	store.EXPECT().
-		Get(mock.Anything, mock.Anything).
+		GetForUpdate(mock.Anything, mock.Anything).
 		Return(shared.Lpa{Uid: "1"}, nil)
 
 	l := Lambda{
-		store:       store,
-		verifier:    newAllowedMockVerifier(t),
-		logger:      logger,
-		eventClient: newMockEventClient(t),
+		store:    store,
+		verifier: newAllowedMockVerifier(t),
+		logger:   logger,
 	}
 
 	resp, err := l.HandleEvent(context.Background(), events.APIGatewayProxyRequest{
@@ -242,17 +275,17 @@ func TestHandleEventWhenUnknownType(t *testing.T) {
 	})
 	assert.Nil(t, err)
 	assert.Equal(t, 400, resp.StatusCode)
-	assert.JSONEq(t, `{"code":"INVALID_REQUEST","detail":"Invalid request","errors":[{"source":"/type","detail":"invalid value"}]}`, resp.Body)
+	assert.JSONEq(t, `{"type":"urn:opg:poas:lpa-store:problem:invalid-request","title":"Bad Request","status":400,"detail":"Invalid request","code":"INVALID_REQUEST","errors":[{"source":"/type","detail":"invalid value"}]}`, resp.Body)
 }
 
 func TestHandleEventWhenUpdateInvalid(t *testing.T) {
 	logger := newMockLogger(t)
 	logger.EXPECT().
-		Debug("Successfully parsed JWT from event header", mock.Anything)
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header", mock.Anything)
 
 	store := newMockStore(t)
 	store.EXPECT().
-		Get(mock.Anything, mock.Anything).
+		GetForUpdate(mock.Anything, mock.Anything).
 		Return(shared.Lpa{Uid: "1"}, nil)
 
 	l := Lambda{
@@ -266,19 +299,19 @@ func TestHandleEventWhenUpdateInvalid(t *testing.T) {
 	})
 	assert.Nil(t, err)
 	assert.Equal(t, 400, resp.StatusCode)
-	assert.JSONEq(t, `{"code":"INVALID_REQUEST","detail":"Invalid request","errors":[{"source":"/changes","detail":"missing /certificateProvider/signedAt"}]}`, resp.Body)
+	assert.JSONEq(t, `{"type":"urn:opg:poas:lpa-store:problem:invalid-request","title":"Bad Request","status":400,"detail":"Invalid request","code":"INVALID_REQUEST","errors":[{"source":"/changes","detail":"missing /certificateProvider/signedAt"}]}`, resp.Body)
 }
 
 func TestHandleEventWhenLpaNotFound(t *testing.T) {
 	logger := newMockLogger(t)
 	logger.EXPECT().
-		Debug("Successfully parsed JWT from event header", mock.Anything)
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header", mock.Anything)
 	logger.EXPECT().
-		Debug("Uid not found", mock.Anything)
+		DebugContext(mock.Anything, "Uid not found", mock.Anything)
 
 	store := newMockStore(t)
 	store.EXPECT().
-		Get(mock.Anything, mock.Anything).
+		GetForUpdate(mock.Anything, mock.Anything).
 		Return(shared.Lpa{}, nil)
 
 	l := Lambda{
@@ -292,19 +325,19 @@ func TestHandleEventWhenLpaNotFound(t *testing.T) {
 	})
 	assert.Nil(t, err)
 	assert.Equal(t, 404, resp.StatusCode)
-	assert.JSONEq(t, `{"code":"NOT_FOUND","detail":"Record not found"}`, resp.Body)
+	assert.JSONEq(t, `{"type":"urn:opg:poas:lpa-store:problem:not-found","title":"Not Found","status":404,"detail":"Record not found","code":"NOT_FOUND"}`, resp.Body)
 }
 
 func TestHandleEventWhenStoreGetError(t *testing.T) {
 	logger := newMockLogger(t)
 	logger.EXPECT().
-		Debug("Successfully parsed JWT from event header", mock.Anything)
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header", mock.Anything)
 	logger.EXPECT().
-		Error("error fetching LPA", slog.Any("err", errExpected))
+		ErrorContext(mock.Anything, "error fetching LPA", slog.Any("err", errExpected))
 
 	store := newMockStore(t)
 	store.EXPECT().
-		Get(mock.Anything, mock.Anything).
+		GetForUpdate(mock.Anything, mock.Anything).
 		Return(shared.Lpa{}, errExpected)
 
 	l := Lambda{
@@ -318,15 +351,15 @@ func TestHandleEventWhenStoreGetError(t *testing.T) {
 	})
 	assert.Nil(t, err)
 	assert.Equal(t, 500, resp.StatusCode)
-	assert.JSONEq(t, `{"code":"INTERNAL_SERVER_ERROR","detail":"Internal server error"}`, resp.Body)
+	assert.JSONEq(t, `{"type":"urn:opg:poas:lpa-store:problem:internal-server-error","title":"Internal Server Error","status":500,"detail":"Internal server error","code":"INTERNAL_SERVER_ERROR"}`, resp.Body)
 }
 
 func TestHandleEventWhenRequestBodyNotJSON(t *testing.T) {
 	logger := newMockLogger(t)
 	logger.EXPECT().
-		Debug("Successfully parsed JWT from event header", mock.Anything)
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header", mock.Anything)
 	logger.EXPECT().
-		Error("error unmarshalling request", mock.Anything)
+		ErrorContext(mock.Anything, "error unmarshalling request", mock.Anything)
 
 	l := Lambda{
 		verifier: newAllowedMockVerifier(t),
@@ -336,17 +369,17 @@ func TestHandleEventWhenRequestBodyNotJSON(t *testing.T) {
 	resp, err := l.HandleEvent(context.Background(), events.APIGatewayProxyRequest{})
 	assert.Nil(t, err)
 	assert.Equal(t, 500, resp.StatusCode)
-	assert.JSONEq(t, `{"code":"INTERNAL_SERVER_ERROR","detail":"Internal server error"}`, resp.Body)
+	assert.JSONEq(t, `{"type":"urn:opg:poas:lpa-store:problem:internal-server-error","title":"Internal Server Error","status":500,"detail":"Internal server error","code":"INTERNAL_SERVER_ERROR"}`, resp.Body)
 }
 
 func TestHandleEventWhenHeaderNotVerified(t *testing.T) {
 	logger := newMockLogger(t)
 	logger.EXPECT().
-		Info("Unable to verify JWT from header", mock.Anything)
+		InfoContext(mock.Anything, "Unable to verify JWT from header", mock.Anything)
 
 	verifier := newMockVerifier(t)
 	verifier.EXPECT().
-		VerifyHeader(mock.Anything).
+		VerifyHeader(mock.Anything, mock.Anything).
 		Return(nil, errors.New("Invalid JWT"))
 
 	l := Lambda{
@@ -357,50 +390,884 @@ func TestHandleEventWhenHeaderNotVerified(t *testing.T) {
 	resp, err := l.HandleEvent(context.Background(), events.APIGatewayProxyRequest{})
 	assert.Nil(t, err)
 	assert.Equal(t, 401, resp.StatusCode)
-	assert.JSONEq(t, `{"code":"UNAUTHORISED","detail":"Invalid JWT"}`, resp.Body)
+	assert.JSONEq(t, `{"type":"urn:opg:poas:lpa-store:problem:unauthorised","title":"Unauthorized","status":401,"detail":"Invalid JWT","code":"UNAUTHORISED"}`, resp.Body)
+}
+
+func TestHandleEventWhenForbidden(t *testing.T) {
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header", mock.Anything)
+	logger.EXPECT().
+		InfoContext(mock.Anything, "JWT not permitted to make request")
+
+	verifier := newMockVerifier(t)
+	verifier.EXPECT().
+		VerifyHeader(mock.Anything, mock.Anything).
+		Return(&shared.LpaStoreClaims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:  "opg.poas.use",
+				Subject: "urn:opg:poas:use:users:1234",
+			},
+		}, nil)
+
+	l := Lambda{
+		verifier: verifier,
+		logger:   logger,
+	}
+
+	resp, err := l.HandleEvent(context.Background(), events.APIGatewayProxyRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, 403, resp.StatusCode)
+	assert.JSONEq(t, `{"type":"urn:opg:poas:lpa-store:problem:forbidden","title":"Forbidden","status":403,"detail":"Not permitted to make this request","code":"FORBIDDEN"}`, resp.Body)
 }
 
-func TestHandleEventWhenSendLpaUpdatedFailed(t *testing.T) {
+func TestHandleEventWhenUpdateTypeForbidden(t *testing.T) {
 	logger := newMockLogger(t)
 	logger.EXPECT().
-		Debug("Successfully parsed JWT from event header", mock.Anything)
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header", mock.Anything)
 	logger.EXPECT().
-		Error("unexpected error occurred", slog.Any("err", errors.New("Update failed")))
+		InfoContext(mock.Anything, "JWT not permitted to make update")
 
 	verifier := newMockVerifier(t)
 	verifier.EXPECT().
-		VerifyHeader(mock.Anything).
+		VerifyHeader(mock.Anything, mock.Anything).
 		Return(&shared.LpaStoreClaims{
 			RegisteredClaims: jwt.RegisteredClaims{
-				Subject: "1234",
+				Issuer:  "opg.poas.makeregister",
+				Subject: "urn:opg:poas:makeregister:users:1234",
 			},
 		}, nil)
 
+	l := Lambda{
+		verifier: verifier,
+		logger:   logger,
+	}
+
+	resp, err := l.HandleEvent(context.Background(), events.APIGatewayProxyRequest{
+		PathParameters: map[string]string{"uid": "1"},
+		Body:           `{"type":"CORRECTION","changes":[{"key":"/donor/lastName","old":"","new":"Smith"}]}`,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 403, resp.StatusCode)
+	assert.JSONEq(t, `{"type":"urn:opg:poas:lpa-store:problem:forbidden","title":"Forbidden","status":403,"detail":"Not permitted to make this request","code":"FORBIDDEN","errors":[{"source":"/type","detail":"not permitted"}]}`, resp.Body)
+}
+
+func TestHandleEventWhenLpaChangedByAnotherRequest(t *testing.T) {
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header", mock.Anything)
+	logger.EXPECT().
+		InfoContext(mock.Anything, "LPA was changed by another request, retrying", slog.String("uid", "1"))
+
 	store := newMockStore(t)
 	store.EXPECT().
-		Get(mock.Anything, mock.Anything).
+		GetForUpdate(mock.Anything, "1").
+		Return(shared.Lpa{Uid: "1", Version: 3}, nil).
+		Once()
+	store.EXPECT().
+		PutChanges(mock.Anything, mock.MatchedBy(func(lpa shared.Lpa) bool { return lpa.Version == 4 }), mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(ddb.ErrConditionFailed).
+		Once()
+	store.EXPECT().
+		GetForUpdate(mock.Anything, "1").
+		Return(shared.Lpa{Uid: "1", Version: 4}, nil).
+		Once()
+	store.EXPECT().
+		PutChanges(mock.Anything, mock.MatchedBy(func(lpa shared.Lpa) bool { return lpa.Version == 5 }), mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(nil).
+		Once()
+
+	l := Lambda{
+		store:    store,
+		verifier: newAllowedMockVerifier(t),
+		logger:   logger,
+		now:      testNowFn,
+	}
+
+	resp, err := l.HandleEvent(context.Background(), events.APIGatewayProxyRequest{
+		PathParameters: map[string]string{"uid": "1"},
+		Body:           `{"type":"CERTIFICATE_PROVIDER_SIGN","changes":[{"key":"/certificateProvider/signedAt","old":null,"new":"2022-01-02T12:13:14.000000006Z"},{"key":"/certificateProvider/contactLanguagePreference","old":null,"new":"en"}]}`,
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 201, resp.StatusCode)
+	assert.Contains(t, resp.Body, `"version":5`)
+}
+
+func TestHandleEventWhenLpaKeepsChanging(t *testing.T) {
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header", mock.Anything)
+	logger.EXPECT().
+		InfoContext(mock.Anything, "LPA was changed by another request, retrying", slog.String("uid", "1"))
+	logger.EXPECT().
+		InfoContext(mock.Anything, "LPA was changed by another request", slog.String("uid", "1"))
+
+	store := newMockStore(t)
+	store.EXPECT().
+		GetForUpdate(mock.Anything, "1").
+		Return(shared.Lpa{Uid: "1"}, nil).
+		Twice()
+	store.EXPECT().
+		PutChanges(mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(ddb.ErrConditionFailed).
+		Twice()
+
+	l := Lambda{
+		store:    store,
+		verifier: newAllowedMockVerifier(t),
+		logger:   logger,
+		now:      testNowFn,
+	}
+
+	resp, err := l.HandleEvent(context.Background(), events.APIGatewayProxyRequest{
+		PathParameters: map[string]string{"uid": "1"},
+		Body:           `{"type":"CERTIFICATE_PROVIDER_SIGN","changes":[{"key":"/certificateProvider/signedAt","old":null,"new":"2022-01-02T12:13:14.000000006Z"},{"key":"/certificateProvider/contactLanguagePreference","old":null,"new":"en"}]}`,
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 409, resp.StatusCode)
+	assert.JSONEq(t, `{"type":"urn:opg:poas:lpa-store:problem:conflict","title":"Conflict","status":409,"detail":"Record has been changed by another request","code":"CONFLICT"}`, resp.Body)
+}
+
+func TestHandleEventWhenPutChangesErrors(t *testing.T) {
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header", mock.Anything)
+	logger.EXPECT().
+		ErrorContext(mock.Anything, "error saving changes", slog.Any("err", errExpected))
+
+	store := newMockStore(t)
+	store.EXPECT().
+		GetForUpdate(mock.Anything, mock.Anything).
 		Return(shared.Lpa{Uid: "1"}, nil)
 	store.EXPECT().
-		PutChanges(mock.Anything, mock.Anything, mock.Anything).
+		PutChanges(mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(errExpected)
+
+	l := Lambda{
+		store:    store,
+		verifier: newAllowedMockVerifier(t),
+		logger:   logger,
+		now:      testNowFn,
+	}
+
+	resp, err := l.HandleEvent(context.Background(), events.APIGatewayProxyRequest{
+		Body: `{"type":"CERTIFICATE_PROVIDER_SIGN","changes":[{"key":"/certificateProvider/signedAt","old":null,"new":"2022-01-02T12:13:14.000000006Z"},{"key":"/certificateProvider/contactLanguagePreference","old":null,"new":"en"}]}`,
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 500, resp.StatusCode)
+}
+
+func TestHandleEventWhenPutChangesHasTooManyItems(t *testing.T) {
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header", mock.Anything)
+	logger.EXPECT().
+		InfoContext(mock.Anything, "too many changes to save at once", slog.String("uid", "1"))
+
+	store := newMockStore(t)
+	store.EXPECT().
+		GetForUpdate(mock.Anything, mock.Anything).
+		Return(shared.Lpa{Uid: "1"}, nil)
+	store.EXPECT().
+		PutChanges(mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(ddb.ErrTooManyItems)
+
+	l := Lambda{
+		store:    store,
+		verifier: newAllowedMockVerifier(t),
+		logger:   logger,
+		now:      testNowFn,
+	}
+
+	resp, err := l.HandleEvent(context.Background(), events.APIGatewayProxyRequest{
+		Body: `{"type":"CERTIFICATE_PROVIDER_SIGN","changes":[{"key":"/certificateProvider/signedAt","old":null,"new":"2022-01-02T12:13:14.000000006Z"},{"key":"/certificateProvider/contactLanguagePreference","old":null,"new":"en"}]}`,
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+	assert.JSONEq(t, `{"type":"urn:opg:poas:lpa-store:problem:invalid-request","title":"Bad Request","status":400,"detail":"Too many changes to save at once, send fewer updates","code":"INVALID_REQUEST"}`, resp.Body)
+}
+
+func TestHandleEventWhenIfMatchMatches(t *testing.T) {
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header", mock.Anything)
+
+	store := newMockStore(t)
+	store.EXPECT().
+		GetForUpdate(mock.Anything, "1").
+		Return(shared.Lpa{Uid: "1", Version: 3}, nil)
+	store.EXPECT().
+		PutChanges(mock.Anything, mock.MatchedBy(func(lpa shared.Lpa) bool { return lpa.Version == 4 }), mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 		Return(nil)
 
-	client := newMockEventClient(t)
-	client.EXPECT().
-		SendLpaUpdated(mock.Anything, mock.Anything, mock.Anything).
-		Return(errors.New("Update failed"))
+	l := Lambda{
+		store:    store,
+		verifier: newAllowedMockVerifier(t),
+		logger:   logger,
+		now:      testNowFn,
+	}
+
+	resp, err := l.HandleEvent(context.Background(), events.APIGatewayProxyRequest{
+		PathParameters:    map[string]string{"uid": "1"},
+		MultiValueHeaders: map[string][]string{"If-Match": {`"3"`}},
+		Body:              `{"type":"CERTIFICATE_PROVIDER_SIGN","changes":[{"key":"/certificateProvider/signedAt","old":null,"new":"2022-01-02T12:13:14.000000006Z"},{"key":"/certificateProvider/contactLanguagePreference","old":null,"new":"en"}]}`,
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 201, resp.StatusCode)
+	assert.Equal(t, map[string]string{"ETag": `"4"`}, resp.Headers)
+}
+
+func TestHandleEventWhenIfMatchDoesNotMatch(t *testing.T) {
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header", mock.Anything)
+	logger.EXPECT().
+		InfoContext(mock.Anything, "LPA does not match If-Match header", slog.String("uid", "1"))
+
+	store := newMockStore(t)
+	store.EXPECT().
+		GetForUpdate(mock.Anything, "1").
+		Return(shared.Lpa{Uid: "1", Version: 3}, nil)
 
 	l := Lambda{
-		eventClient: client,
-		store:       store,
-		verifier:    verifier,
-		logger:      logger,
-		now:         testNowFn,
+		store:    store,
+		verifier: newAllowedMockVerifier(t),
+		logger:   logger,
+	}
+
+	resp, err := l.HandleEvent(context.Background(), events.APIGatewayProxyRequest{
+		PathParameters:    map[string]string{"uid": "1"},
+		MultiValueHeaders: map[string][]string{"if-match": {`"2"`}},
+		Body:              `{"type":"CERTIFICATE_PROVIDER_SIGN","changes":[{"key":"/certificateProvider/email","old":"a@example.com","new":"b@example.com"}]}`,
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 412, resp.StatusCode)
+	assert.JSONEq(t, `{"type":"urn:opg:poas:lpa-store:problem:precondition-failed","title":"Precondition Failed","status":412,"detail":"Record has been changed since it was retrieved","code":"PRECONDITION_FAILED"}`, resp.Body)
+}
+
+func TestHandleEventWhenDryRun(t *testing.T) {
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header", mock.Anything)
+
+	store := newMockStore(t)
+	store.EXPECT().
+		GetForUpdate(mock.Anything, "1").
+		Return(shared.Lpa{Uid: "1", Version: 3, LpaInit: shared.LpaInit{
+			CertificateProvider: shared.CertificateProvider{Email: "a@example.com"},
+		}}, nil)
+
+	l := Lambda{
+		store:    store,
+		verifier: newAllowedMockVerifier(t),
+		logger:   logger,
+		now:      testNowFn,
 	}
 
 	resp, err := l.HandleEvent(context.Background(), events.APIGatewayProxyRequest{
-		Body: `{"type":"CERTIFICATE_PROVIDER_SIGN","changes":[{"key":"/certificateProvider/signedAt","old":null,"new":"2022-01-02T12:13:14.000000006Z"},{"key":"/certificateProvider/contactLanguagePreference","old":null,"new":"en"},{"key":"/certificateProvider/email","old":null,"new":"a@example.com"}]}`,
+		PathParameters:        map[string]string{"uid": "1"},
+		QueryStringParameters: map[string]string{"dryRun": "true"},
+		MultiValueHeaders:     map[string][]string{"Idempotency-Key": {"a-key"}},
+		Body:                  `{"type":"CERTIFICATE_PROVIDER_SIGN","changes":[{"key":"/certificateProvider/signedAt","old":null,"new":"2022-01-02T12:13:14.000000006Z"},{"key":"/certificateProvider/contactLanguagePreference","old":null,"new":"en"},{"key":"/certificateProvider/email","old":"a@example.com","new":"b@example.com"}]}`,
 	})
+	assert.Nil(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Nil(t, resp.Headers)
+
+	var body dryRunResponse
+	_ = json.Unmarshal([]byte(resp.Body), &body)
+	assert.Equal(t, "b@example.com", body.Lpa.CertificateProvider.Email)
+	assert.Equal(t, 4, body.Lpa.Version)
+	assert.Equal(t, []shared.Change{
+		{Key: "/certificateProvider/contactLanguagePreference", Old: jsonNull, New: json.RawMessage(`"en"`)},
+		{Key: "/certificateProvider/email", Old: json.RawMessage(`"a@example.com"`), New: json.RawMessage(`"b@example.com"`)},
+		{Key: "/certificateProvider/signedAt", Old: jsonNull, New: json.RawMessage(`"2022-01-02T12:13:14.000000006Z"`)},
+		{Key: "/version", Old: json.RawMessage(`3`), New: json.RawMessage(`4`)},
+	}, body.Diff)
+}
+
+func TestHandleEventWhenDryRunInvalid(t *testing.T) {
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header", mock.Anything)
+
+	l := Lambda{
+		verifier: newAllowedMockVerifier(t),
+		logger:   logger,
+	}
+
+	resp, err := l.HandleEvent(context.Background(), events.APIGatewayProxyRequest{
+		PathParameters:        map[string]string{"uid": "1"},
+		QueryStringParameters: map[string]string{"dryRun": "maybe"},
+		Body:                  `{"type":"CERTIFICATE_PROVIDER_SIGN","changes":[]}`,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+	assert.JSONEq(t, `{"type":"urn:opg:poas:lpa-store:problem:invalid-request","title":"Bad Request","status":400,"detail":"Invalid request","code":"INVALID_REQUEST","errors":[{"source":"/dryRun","detail":"invalid value"}]}`, resp.Body)
+}
+
+func TestHandleEventWhenDryRunUpdateInvalid(t *testing.T) {
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header", mock.Anything)
+
+	store := newMockStore(t)
+	store.EXPECT().
+		GetForUpdate(mock.Anything, "1").
+		Return(shared.Lpa{Uid: "1"}, nil)
 
+	l := Lambda{
+		store:    store,
+		verifier: newAllowedMockVerifier(t),
+		logger:   logger,
+	}
+
+	resp, err := l.HandleEvent(context.Background(), events.APIGatewayProxyRequest{
+		PathParameters:        map[string]string{"uid": "1"},
+		QueryStringParameters: map[string]string{"dryRun": "true"},
+		Body:                  `{"type":"NOT_A_TYPE","changes":[]}`,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+}
+
+func TestHandleEventWithIdempotencyKey(t *testing.T) {
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header", mock.Anything)
+
+	store := newMockStore(t)
+	store.EXPECT().
+		GetIdempotencyRecord(mock.Anything, "update#1#urn:opg:poas:sirius:users:1#a-key").
+		Return(idempotency.Record{Key: "update#1#urn:opg:poas:sirius:users:1#a-key", RequestHash: "other", ExpiresAt: testNow.Unix()}, nil)
+	store.EXPECT().
+		GetForUpdate(mock.Anything, "1").
+		Return(shared.Lpa{Uid: "1", Version: 3}, nil)
+
+	var saved *idempotency.Record
+	store.EXPECT().
+		PutChanges(mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		RunAndReturn(func(_ context.Context, _ shared.Lpa, updates []shared.Update, _ []string, record *idempotency.Record, _ []event.OutboxEntry) error {
+			assert.Equal(t, updates[0].Id, record.UpdateId)
+			saved = record
+			return nil
+		})
+
+	l := Lambda{
+		store:          store,
+		verifier:       newAllowedMockVerifier(t),
+		logger:         logger,
+		now:            testNowFn,
+		idempotencyTTL: time.Hour,
+	}
+
+	req := events.APIGatewayProxyRequest{
+		PathParameters:    map[string]string{"uid": "1"},
+		MultiValueHeaders: map[string][]string{"Idempotency-Key": {"a-key"}},
+		Body:              `{"type":"CERTIFICATE_PROVIDER_SIGN","changes":[{"key":"/certificateProvider/signedAt","old":null,"new":"2022-01-02T12:13:14.000000006Z"},{"key":"/certificateProvider/contactLanguagePreference","old":null,"new":"en"}]}`,
+	}
+
+	resp, err := l.HandleEvent(context.Background(), req)
 	assert.Nil(t, err)
 	assert.Equal(t, 201, resp.StatusCode)
+
+	if assert.NotNil(t, saved) {
+		assert.Equal(t, "update#1#urn:opg:poas:sirius:users:1#a-key", saved.Key)
+		assert.True(t, saved.Matches(req))
+		assert.Equal(t, resp.StatusCode, saved.StatusCode)
+		assert.Equal(t, resp.Headers, saved.Headers)
+		assert.Equal(t, resp.Body, saved.Body)
+		assert.Equal(t, testNow.Add(time.Hour).Unix(), saved.ExpiresAt)
+	}
+}
+
+func TestHandleEventWhenIdempotencyKeyReplayed(t *testing.T) {
+	req := events.APIGatewayProxyRequest{
+		PathParameters:    map[string]string{"uid": "1"},
+		MultiValueHeaders: map[string][]string{"Idempotency-Key": {"a-key"}},
+		Body:              `{"type":"CERTIFICATE_PROVIDER_SIGN","changes":[]}`,
+	}
+
+	record := idempotency.NewRecord("update#1#urn:opg:poas:sirius:users:1#a-key", req, "an-id", events.APIGatewayProxyResponse{
+		StatusCode: 201,
+		Headers:    map[string]string{"ETag": `"1"`},
+		Body:       `{"uid":"1"}`,
+	}, testNow, time.Hour)
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header", mock.Anything)
+	logger.EXPECT().
+		InfoContext(mock.Anything, "replaying response for Idempotency-Key", slog.String("uid", "1"), slog.String("updateId", "an-id"))
+
+	store := newMockStore(t)
+	store.EXPECT().
+		GetIdempotencyRecord(mock.Anything, "update#1#urn:opg:poas:sirius:users:1#a-key").
+		Return(record, nil)
+
+	l := Lambda{
+		store:    store,
+		verifier: newAllowedMockVerifier(t),
+		logger:   logger,
+		now:      testNowFn,
+	}
+
+	resp, err := l.HandleEvent(context.Background(), req)
+	assert.Nil(t, err)
+	assert.Equal(t, events.APIGatewayProxyResponse{
+		StatusCode: 201,
+		Headers:    map[string]string{"ETag": `"1"`, "Idempotent-Replayed": "true"},
+		Body:       `{"uid":"1"}`,
+	}, resp)
+}
+
+func TestHandleEventWhenIdempotencyKeyReused(t *testing.T) {
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header", mock.Anything)
+	logger.EXPECT().
+		InfoContext(mock.Anything, "Idempotency-Key reused for a different request", slog.String("uid", "1"))
+
+	store := newMockStore(t)
+	store.EXPECT().
+		GetIdempotencyRecord(mock.Anything, mock.Anything).
+		Return(idempotency.Record{Key: "update#1#urn:opg:poas:sirius:users:1#a-key", RequestHash: "other", ExpiresAt: testNow.Add(time.Minute).Unix()}, nil)
+
+	l := Lambda{
+		store:    store,
+		verifier: newAllowedMockVerifier(t),
+		logger:   logger,
+		now:      testNowFn,
+	}
+
+	resp, err := l.HandleEvent(context.Background(), events.APIGatewayProxyRequest{
+		PathParameters:    map[string]string{"uid": "1"},
+		MultiValueHeaders: map[string][]string{"Idempotency-Key": {"a-key"}},
+		Body:              `{"type":"CERTIFICATE_PROVIDER_SIGN","changes":[]}`,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 422, resp.StatusCode)
+	assert.JSONEq(t, `{"type":"urn:opg:poas:lpa-store:problem:idempotency-key-reused","title":"Unprocessable Entity","status":422,"detail":"Idempotency-Key has already been used for a different request","code":"IDEMPOTENCY_KEY_REUSED"}`, resp.Body)
+}
+
+func TestHandleEventWhenGetIdempotencyRecordErrors(t *testing.T) {
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header", mock.Anything)
+	logger.EXPECT().
+		ErrorContext(mock.Anything, "error fetching idempotency record", slog.Any("err", errExpected))
+
+	store := newMockStore(t)
+	store.EXPECT().
+		GetIdempotencyRecord(mock.Anything, mock.Anything).
+		Return(idempotency.Record{}, errExpected)
+
+	l := Lambda{
+		store:    store,
+		verifier: newAllowedMockVerifier(t),
+		logger:   logger,
+		now:      testNowFn,
+	}
+
+	resp, err := l.HandleEvent(context.Background(), events.APIGatewayProxyRequest{
+		PathParameters:    map[string]string{"uid": "1"},
+		MultiValueHeaders: map[string][]string{"Idempotency-Key": {"a-key"}},
+		Body:              `{"type":"CERTIFICATE_PROVIDER_SIGN","changes":[]}`,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 500, resp.StatusCode)
+}
+
+func TestHandleEventWhenIdempotencyKeySavedByAnotherRequest(t *testing.T) {
+	req := events.APIGatewayProxyRequest{
+		PathParameters:    map[string]string{"uid": "1"},
+		MultiValueHeaders: map[string][]string{"Idempotency-Key": {"a-key"}},
+		Body:              `{"type":"CERTIFICATE_PROVIDER_SIGN","changes":[{"key":"/certificateProvider/signedAt","old":null,"new":"2022-01-02T12:13:14.000000006Z"},{"key":"/certificateProvider/contactLanguagePreference","old":null,"new":"en"}]}`,
+	}
+
+	record := idempotency.NewRecord("update#1#urn:opg:poas:sirius:users:1#a-key", req, "an-id", events.APIGatewayProxyResponse{StatusCode: 201, Body: `{"uid":"1"}`}, testNow, time.Hour)
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header", mock.Anything)
+	logger.EXPECT().
+		InfoContext(mock.Anything, "LPA was changed by another request, retrying", slog.String("uid", "1"))
+	logger.EXPECT().
+		InfoContext(mock.Anything, "replaying response for Idempotency-Key", slog.String("uid", "1"), slog.String("updateId", "an-id"))
+
+	store := newMockStore(t)
+	store.EXPECT().
+		GetIdempotencyRecord(mock.Anything, "update#1#urn:opg:poas:sirius:users:1#a-key").
+		Return(idempotency.Record{}, nil).
+		Once()
+	store.EXPECT().
+		GetForUpdate(mock.Anything, "1").
+		Return(shared.Lpa{Uid: "1"}, nil).
+		Once()
+	store.EXPECT().
+		PutChanges(mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(ddb.ErrConditionFailed).
+		Once()
+	store.EXPECT().
+		GetIdempotencyRecord(mock.Anything, "update#1#urn:opg:poas:sirius:users:1#a-key").
+		Return(record, nil).
+		Once()
+
+	l := Lambda{
+		store:          store,
+		verifier:       newAllowedMockVerifier(t),
+		logger:         logger,
+		now:            testNowFn,
+		idempotencyTTL: time.Hour,
+	}
+
+	resp, err := l.HandleEvent(context.Background(), req)
+	assert.Nil(t, err)
+	assert.Equal(t, 201, resp.StatusCode)
+	assert.Equal(t, "true", resp.Headers["Idempotent-Replayed"])
+}
+
+func TestHandleEventBatch(t *testing.T) {
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header", mock.Anything)
+
+	store := newMockStore(t)
+	store.EXPECT().
+		GetForUpdate(mock.Anything, "1").
+		Return(shared.Lpa{Uid: "1", Version: 3}, nil)
+	store.EXPECT().
+		PutChanges(mock.Anything, mock.MatchedBy(func(lpa shared.Lpa) bool {
+			return assert.Equal(t, 4, lpa.Version) &&
+				assert.Equal(t, "Smith", lpa.Donor.LastName) &&
+				assert.NotNil(t, lpa.CertificateProvider.SignedAt)
+		}), mock.MatchedBy(func(updates []shared.Update) bool {
+			return assert.Len(t, updates, 2) &&
+				assert.Equal(t, "CERTIFICATE_PROVIDER_SIGN", updates[0].Type) &&
+				assert.Equal(t, "2024-01-02T12:13:14.000000015Z", updates[0].Applied) &&
+				assert.NotContains(t, updates[0].Diff, shared.Change{Key: "/version", Old: json.RawMessage(`3`), New: json.RawMessage(`4`)}) &&
+				assert.Equal(t, "CORRECTION", updates[1].Type) &&
+				assert.Equal(t, "2024-01-02T12:13:14.000000016Z", updates[1].Applied) &&
+				assert.Contains(t, updates[1].Diff, shared.Change{Key: "/version", Old: json.RawMessage(`3`), New: json.RawMessage(`4`)}) &&
+				assert.NotEqual(t, updates[0].Id, updates[1].Id)
+		}), mock.Anything, (*idempotency.Record)(nil), mock.MatchedBy(func(outbox []event.OutboxEntry) bool {
+			return assert.Len(t, outbox, 2) &&
+				assert.Equal(t, "CERTIFICATE_PROVIDER_SIGN", outbox[0].Event.ChangeType) &&
+				assert.Equal(t, []string{"/certificateProvider/contactLanguagePreference", "/certificateProvider/signedAt"}, outbox[0].Event.ChangedKeys) &&
+				assert.Len(t, outbox[0].Metrics, 1) &&
+				assert.Equal(t, "PAPERCERTIFICATEPROVIDER", outbox[0].Metrics[0].MeasureName) &&
+				assert.Equal(t, "CORRECTION", outbox[1].Event.ChangeType) &&
+				assert.Len(t, outbox[1].Metrics, 1) &&
+				assert.Equal(t, "PREREGISTRATION", outbox[1].Metrics[0].MeasureName) &&
+				assert.NotEqual(t, outbox[0].Id, outbox[1].Id)
+		})).
+		Return(nil)
+
+	l := Lambda{
+		store:    store,
+		verifier: newAllowedMockVerifier(t),
+		logger:   logger,
+		now:      testNowFn,
+	}
+
+	resp, err := l.HandleEvent(context.Background(), events.APIGatewayProxyRequest{
+		Resource:       "/lpas/{uid}/updates/batch",
+		PathParameters: map[string]string{"uid": "1"},
+		Body: `{"updates":[
+			{"type":"CERTIFICATE_PROVIDER_SIGN","changes":[{"key":"/certificateProvider/signedAt","old":null,"new":"2022-01-02T12:13:14.000000006Z"},{"key":"/certificateProvider/contactLanguagePreference","old":null,"new":"en"}]},
+			{"type":"CORRECTION","changes":[{"key":"/donor/lastName","old":"","new":"Smith"}]}
+		]}`,
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 201, resp.StatusCode)
+	assert.Equal(t, `"4"`, resp.Headers["ETag"])
+	assert.Contains(t, resp.Body, `"version":4`)
+}
+
+func TestHandleEventBatchTracksStatusInEvents(t *testing.T) {
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header", mock.Anything)
+
+	store := newMockStore(t)
+	store.EXPECT().
+		GetForUpdate(mock.Anything, "1").
+		Return(shared.Lpa{Uid: "1", Version: 3, Status: shared.LpaStatusInProgress, LpaInit: shared.LpaInit{
+			Donor: shared.Donor{Person: shared.Person{UID: "donor-uid"}},
+		}}, nil)
+	store.EXPECT().
+		PutChanges(mock.Anything, mock.Anything, mock.Anything, mock.Anything, (*idempotency.Record)(nil), mock.MatchedBy(func(outbox []event.OutboxEntry) bool {
+			return assert.Len(t, outbox, 2) &&
+				assert.Equal(t, shared.LpaStatusInProgress, outbox[0].Event.StatusBefore) &&
+				assert.Equal(t, shared.LpaStatusInProgress, outbox[0].Event.StatusAfter) &&
+				assert.Equal(t, []string{"donor-uid"}, outbox[0].Event.ActorUIDs) &&
+				assert.Equal(t, shared.LpaStatusInProgress, outbox[1].Event.StatusBefore) &&
+				assert.Equal(t, shared.LpaStatusCannotRegister, outbox[1].Event.StatusAfter) &&
+				assert.Equal(t, []string{"donor-uid"}, outbox[1].Event.ActorUIDs)
+		})).
+		Return(nil)
+
+	l := Lambda{
+		store:    store,
+		verifier: newAllowedMockVerifier(t),
+		logger:   logger,
+		now:      testNowFn,
+	}
+
+	resp, err := l.HandleEvent(context.Background(), events.APIGatewayProxyRequest{
+		Resource:       "/lpas/{uid}/updates/batch",
+		PathParameters: map[string]string{"uid": "1"},
+		Body: `{"updates":[
+			{"type":"CORRECTION","changes":[{"key":"/donor/lastName","old":"","new":"Smith"}]},
+			{"type":"OPG_STATUS_CHANGE","changes":[{"key":"/status","old":"in-progress","new":"cannot-register"}]}
+		]}`,
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 201, resp.StatusCode)
+}
+
+func TestHandleEventBatchWhenUpdateInvalid(t *testing.T) {
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header", mock.Anything)
+
+	store := newMockStore(t)
+	store.EXPECT().
+		GetForUpdate(mock.Anything, "1").
+		Return(shared.Lpa{Uid: "1"}, nil)
+
+	l := Lambda{
+		store:    store,
+		verifier: newAllowedMockVerifier(t),
+		logger:   logger,
+		now:      testNowFn,
+	}
+
+	resp, err := l.HandleEvent(context.Background(), events.APIGatewayProxyRequest{
+		Resource:       "/lpas/{uid}/updates/batch",
+		PathParameters: map[string]string{"uid": "1"},
+		Body: `{"updates":[
+			{"type":"CORRECTION","changes":[{"key":"/donor/lastName","old":"","new":"Smith"}]},
+			{"type":"CORRECTION","changes":[{"key":"/donor/lastName","old":"Jones","new":"Smyth"}]}
+		]}`,
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+	assert.JSONEq(t, `{"type":"urn:opg:poas:lpa-store:problem:invalid-request","title":"Bad Request","status":400,"detail":"Invalid request","code":"INVALID_REQUEST","errors":[{"source":"/updates/1/changes/0/old","detail":"does not match existing value"}]}`, resp.Body)
+}
+
+func TestHandleEventBatchWhenUpdateTypeForbidden(t *testing.T) {
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header", mock.Anything)
+	logger.EXPECT().
+		InfoContext(mock.Anything, "JWT not permitted to make update")
+
+	verifier := newMockVerifier(t)
+	verifier.EXPECT().
+		VerifyHeader(mock.Anything, mock.Anything).
+		Return(&shared.LpaStoreClaims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:  "opg.poas.makeregister",
+				Subject: "urn:opg:poas:makeregister:users:1234",
+			},
+		}, nil)
+
+	l := Lambda{
+		verifier: verifier,
+		logger:   logger,
+	}
+
+	resp, err := l.HandleEvent(context.Background(), events.APIGatewayProxyRequest{
+		Resource:       "/lpas/{uid}/updates/batch",
+		PathParameters: map[string]string{"uid": "1"},
+		Body: `{"updates":[
+			{"type":"PERFECT","changes":[]},
+			{"type":"CORRECTION","changes":[{"key":"/donor/lastName","old":"","new":"Smith"}]}
+		]}`,
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 403, resp.StatusCode)
+	assert.JSONEq(t, `{"type":"urn:opg:poas:lpa-store:problem:forbidden","title":"Forbidden","status":403,"detail":"Not permitted to make this request","code":"FORBIDDEN","errors":[{"source":"/updates/1/type","detail":"not permitted"}]}`, resp.Body)
+}
+
+func TestHandleEventBatchWhenEmpty(t *testing.T) {
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header", mock.Anything)
+
+	l := Lambda{
+		verifier: newAllowedMockVerifier(t),
+		logger:   logger,
+	}
+
+	resp, err := l.HandleEvent(context.Background(), events.APIGatewayProxyRequest{
+		Resource:       "/lpas/{uid}/updates/batch",
+		PathParameters: map[string]string{"uid": "1"},
+		Body:           `{"updates":[]}`,
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+	assert.JSONEq(t, `{"type":"urn:opg:poas:lpa-store:problem:invalid-request","title":"Bad Request","status":400,"detail":"Invalid request","code":"INVALID_REQUEST","errors":[{"source":"/updates","detail":"must contain between 1 and 20 updates"}]}`, resp.Body)
+}
+
+func TestHandleEventBatchWhenDryRun(t *testing.T) {
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header", mock.Anything)
+
+	store := newMockStore(t)
+	store.EXPECT().
+		GetForUpdate(mock.Anything, "1").
+		Return(shared.Lpa{Uid: "1", Version: 3}, nil)
+
+	l := Lambda{
+		store:    store,
+		verifier: newAllowedMockVerifier(t),
+		logger:   logger,
+		now:      testNowFn,
+	}
+
+	resp, err := l.HandleEvent(context.Background(), events.APIGatewayProxyRequest{
+		Resource:              "/lpas/{uid}/updates/batch",
+		PathParameters:        map[string]string{"uid": "1"},
+		QueryStringParameters: map[string]string{"dryRun": "true"},
+		Body: `{"updates":[
+			{"type":"CORRECTION","changes":[{"key":"/donor/lastName","old":"","new":"Smith"}]},
+			{"type":"CORRECTION","changes":[{"key":"/donor/lastName","old":"Smith","new":"Smyth"}]}
+		]}`,
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var body dryRunResponse
+	_ = json.Unmarshal([]byte(resp.Body), &body)
+	assert.Equal(t, "Smyth", body.Lpa.Donor.LastName)
+	assert.Contains(t, body.Diff, shared.Change{Key: "/donor/lastName", Old: json.RawMessage(`""`), New: json.RawMessage(`"Smyth"`)})
+	assert.Contains(t, body.Diff, shared.Change{Key: "/version", Old: json.RawMessage(`3`), New: json.RawMessage(`4`)})
+}
+
+func TestHandleEventWhenRevert(t *testing.T) {
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header", mock.Anything)
+
+	store := newMockStore(t)
+	store.EXPECT().
+		GetForUpdate(mock.Anything, "1").
+		Return(shared.Lpa{Uid: "1", Version: 4, LpaInit: shared.LpaInit{
+			Donor: shared.Donor{Person: shared.Person{LastName: "Smith"}},
+		}}, nil)
+	store.EXPECT().
+		GetUpdate(mock.Anything, "1", "an-id").
+		Return(shared.Update{Id: "an-id", Type: "CORRECTION", Diff: []shared.Change{
+			{Key: "/donor/lastName", Old: json.RawMessage(`""`), New: json.RawMessage(`"Smith"`)},
+			{Key: "/version", Old: json.RawMessage(`3`), New: json.RawMessage(`4`)},
+		}}, nil)
+	store.EXPECT().
+		PutChanges(mock.Anything, mock.MatchedBy(func(lpa shared.Lpa) bool {
+			return assert.Equal(t, 5, lpa.Version) &&
+				assert.Equal(t, "", lpa.Donor.LastName)
+		}), mock.MatchedBy(func(updates []shared.Update) bool {
+			return assert.Len(t, updates, 1) &&
+				assert.Equal(t, "REVERT", updates[0].Type) &&
+				assert.Equal(t, []shared.Change{{Key: "/updateId", Old: jsonNull, New: json.RawMessage(`"an-id"`)}}, updates[0].Changes) &&
+				assert.Equal(t, []shared.Change{
+					{Key: "/donor/lastName", Old: json.RawMessage(`"Smith"`), New: json.RawMessage(`""`)},
+					{Key: "/version", Old: json.RawMessage(`4`), New: json.RawMessage(`5`)},
+				}, updates[0].Diff)
+		}), mock.Anything, (*idempotency.Record)(nil), mock.MatchedBy(func(outbox []event.OutboxEntry) bool {
+			return assert.Len(t, outbox, 1) &&
+				assert.Equal(t, "REVERT", outbox[0].Event.ChangeType) &&
+				assert.Equal(t, []string{"/donor/lastName", "/version"}, outbox[0].Event.ChangedKeys) &&
+				assert.Nil(t, outbox[0].Metrics)
+		})).
+		Return(nil)
+
+	l := Lambda{
+		store:    store,
+		verifier: newAllowedMockVerifier(t),
+		logger:   logger,
+		now:      testNowFn,
+	}
+
+	resp, err := l.HandleEvent(context.Background(), events.APIGatewayProxyRequest{
+		PathParameters: map[string]string{"uid": "1"},
+		Body:           `{"type":"REVERT","changes":[{"key":"/updateId","old":null,"new":"an-id"}]}`,
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 201, resp.StatusCode)
+	assert.Equal(t, `"5"`, resp.Headers["ETag"])
+}
+
+func TestHandleEventWhenRevertUpdateNotFound(t *testing.T) {
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header", mock.Anything)
+
+	store := newMockStore(t)
+	store.EXPECT().
+		GetForUpdate(mock.Anything, "1").
+		Return(shared.Lpa{Uid: "1"}, nil)
+	store.EXPECT().
+		GetUpdate(mock.Anything, "1", "an-id").
+		Return(shared.Update{}, nil)
+
+	l := Lambda{
+		store:    store,
+		verifier: newAllowedMockVerifier(t),
+		logger:   logger,
+	}
+
+	resp, err := l.HandleEvent(context.Background(), events.APIGatewayProxyRequest{
+		PathParameters: map[string]string{"uid": "1"},
+		Body:           `{"type":"REVERT","changes":[{"key":"/updateId","old":null,"new":"an-id"}]}`,
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+	assert.JSONEq(t, `{"type":"urn:opg:poas:lpa-store:problem:invalid-request","title":"Bad Request","status":400,"detail":"Invalid request","code":"INVALID_REQUEST","errors":[{"source":"/changes/0/new","detail":"update not found"}]}`, resp.Body)
+}
+
+func TestHandleEventWhenRevertGetUpdateErrors(t *testing.T) {
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		DebugContext(mock.Anything, "Successfully parsed JWT from event header", mock.Anything)
+	logger.EXPECT().
+		ErrorContext(mock.Anything, "error fetching update", slog.Any("err", errExpected))
+
+	store := newMockStore(t)
+	store.EXPECT().
+		GetForUpdate(mock.Anything, "1").
+		Return(shared.Lpa{Uid: "1"}, nil)
+	store.EXPECT().
+		GetUpdate(mock.Anything, "1", "an-id").
+		Return(shared.Update{}, errExpected)
+
+	l := Lambda{
+		store:    store,
+		verifier: newAllowedMockVerifier(t),
+		logger:   logger,
+	}
+
+	resp, err := l.HandleEvent(context.Background(), events.APIGatewayProxyRequest{
+		PathParameters: map[string]string{"uid": "1"},
+		Body:           `{"type":"REVERT","changes":[{"key":"/updateId","old":null,"new":"an-id"}]}`,
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 500, resp.StatusCode)
 }
@@ -9,100 +9,11 @@ import (
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/ministryofjustice/opg-data-lpa-store/internal/event"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/idempotency"
 	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
 	mock "github.com/stretchr/testify/mock"
 )
 
-// newMockEventClient creates a new instance of mockEventClient. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
-// The first argument is typically a *testing.T value.
-func newMockEventClient(t interface {
-	mock.TestingT
-	Cleanup(func())
-}) *mockEventClient {
-	mock := &mockEventClient{}
-	mock.Mock.Test(t)
-
-	t.Cleanup(func() { mock.AssertExpectations(t) })
-
-	return mock
-}
-
-// mockEventClient is an autogenerated mock type for the EventClient type
-type mockEventClient struct {
-	mock.Mock
-}
-
-type mockEventClient_Expecter struct {
-	mock *mock.Mock
-}
-
-func (_m *mockEventClient) EXPECT() *mockEventClient_Expecter {
-	return &mockEventClient_Expecter{mock: &_m.Mock}
-}
-
-// SendLpaUpdated provides a mock function for the type mockEventClient
-func (_mock *mockEventClient) SendLpaUpdated(ctx context.Context, event1 event.LpaUpdated, metric *event.Metric) error {
-	ret := _mock.Called(ctx, event1, metric)
-
-	if len(ret) == 0 {
-		panic("no return value specified for SendLpaUpdated")
-	}
-
-	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, event.LpaUpdated, *event.Metric) error); ok {
-		r0 = returnFunc(ctx, event1, metric)
-	} else {
-		r0 = ret.Error(0)
-	}
-	return r0
-}
-
-// mockEventClient_SendLpaUpdated_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SendLpaUpdated'
-type mockEventClient_SendLpaUpdated_Call struct {
-	*mock.Call
-}
-
-// SendLpaUpdated is a helper method to define mock.On call
-//   - ctx context.Context
-//   - event1 event.LpaUpdated
-//   - metric *event.Metric
-func (_e *mockEventClient_Expecter) SendLpaUpdated(ctx interface{}, event1 interface{}, metric interface{}) *mockEventClient_SendLpaUpdated_Call {
-	return &mockEventClient_SendLpaUpdated_Call{Call: _e.mock.On("SendLpaUpdated", ctx, event1, metric)}
-}
-
-func (_c *mockEventClient_SendLpaUpdated_Call) Run(run func(ctx context.Context, event1 event.LpaUpdated, metric *event.Metric)) *mockEventClient_SendLpaUpdated_Call {
-	_c.Call.Run(func(args mock.Arguments) {
-		var arg0 context.Context
-		if args[0] != nil {
-			arg0 = args[0].(context.Context)
-		}
-		var arg1 event.LpaUpdated
-		if args[1] != nil {
-			arg1 = args[1].(event.LpaUpdated)
-		}
-		var arg2 *event.Metric
-		if args[2] != nil {
-			arg2 = args[2].(*event.Metric)
-		}
-		run(
-			arg0,
-			arg1,
-			arg2,
-		)
-	})
-	return _c
-}
-
-func (_c *mockEventClient_SendLpaUpdated_Call) Return(err error) *mockEventClient_SendLpaUpdated_Call {
-	_c.Call.Return(err)
-	return _c
-}
-
-func (_c *mockEventClient_SendLpaUpdated_Call) RunAndReturn(run func(ctx context.Context, event1 event.LpaUpdated, metric *event.Metric) error) *mockEventClient_SendLpaUpdated_Call {
-	_c.Call.Return(run)
-	return _c
-}
-
 // newMockLogger creates a new instance of mockLogger. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func newMockLogger(t interface {
@@ -130,218 +41,242 @@ func (_m *mockLogger) EXPECT() *mockLogger_Expecter {
 	return &mockLogger_Expecter{mock: &_m.Mock}
 }
 
-// Debug provides a mock function for the type mockLogger
-func (_mock *mockLogger) Debug(s string, vs ...any) {
+// DebugContext provides a mock function for the type mockLogger
+func (_mock *mockLogger) DebugContext(context1 context.Context, s string, vs ...any) {
 	var _ca []interface{}
-	_ca = append(_ca, s)
+	_ca = append(_ca, context1, s)
 	_ca = append(_ca, vs...)
 	_mock.Called(_ca...)
 	return
 }
 
-// mockLogger_Debug_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Debug'
-type mockLogger_Debug_Call struct {
+// mockLogger_DebugContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DebugContext'
+type mockLogger_DebugContext_Call struct {
 	*mock.Call
 }
 
-// Debug is a helper method to define mock.On call
+// DebugContext is a helper method to define mock.On call
+//   - context1 context.Context
 //   - s string
 //   - vs ...any
-func (_e *mockLogger_Expecter) Debug(s interface{}, vs ...interface{}) *mockLogger_Debug_Call {
-	return &mockLogger_Debug_Call{Call: _e.mock.On("Debug",
-		append([]interface{}{s}, vs...)...)}
+func (_e *mockLogger_Expecter) DebugContext(context1 interface{}, s interface{}, vs ...interface{}) *mockLogger_DebugContext_Call {
+	return &mockLogger_DebugContext_Call{Call: _e.mock.On("DebugContext",
+		append([]interface{}{context1, s}, vs...)...)}
 }
 
-func (_c *mockLogger_Debug_Call) Run(run func(s string, vs ...any)) *mockLogger_Debug_Call {
+func (_c *mockLogger_DebugContext_Call) Run(run func(context1 context.Context, s string, vs ...any)) *mockLogger_DebugContext_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		var arg0 string
+		var arg0 context.Context
 		if args[0] != nil {
-			arg0 = args[0].(string)
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
 		}
-		var arg1 []any
-		variadicArgs := make([]any, len(args)-1)
-		for i, a := range args[1:] {
+		var arg2 []any
+		variadicArgs := make([]any, len(args)-2)
+		for i, a := range args[2:] {
 			if a != nil {
 				variadicArgs[i] = a.(any)
 			}
 		}
-		arg1 = variadicArgs
+		arg2 = variadicArgs
 		run(
 			arg0,
-			arg1...,
+			arg1,
+			arg2...,
 		)
 	})
 	return _c
 }
 
-func (_c *mockLogger_Debug_Call) Return() *mockLogger_Debug_Call {
+func (_c *mockLogger_DebugContext_Call) Return() *mockLogger_DebugContext_Call {
 	_c.Call.Return()
 	return _c
 }
 
-func (_c *mockLogger_Debug_Call) RunAndReturn(run func(s string, vs ...any)) *mockLogger_Debug_Call {
+func (_c *mockLogger_DebugContext_Call) RunAndReturn(run func(context1 context.Context, s string, vs ...any)) *mockLogger_DebugContext_Call {
 	_c.Run(run)
 	return _c
 }
 
-// Error provides a mock function for the type mockLogger
-func (_mock *mockLogger) Error(s string, vs ...any) {
+// ErrorContext provides a mock function for the type mockLogger
+func (_mock *mockLogger) ErrorContext(context1 context.Context, s string, vs ...any) {
 	var _ca []interface{}
-	_ca = append(_ca, s)
+	_ca = append(_ca, context1, s)
 	_ca = append(_ca, vs...)
 	_mock.Called(_ca...)
 	return
 }
 
-// mockLogger_Error_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Error'
-type mockLogger_Error_Call struct {
+// mockLogger_ErrorContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ErrorContext'
+type mockLogger_ErrorContext_Call struct {
 	*mock.Call
 }
 
-// Error is a helper method to define mock.On call
+// ErrorContext is a helper method to define mock.On call
+//   - context1 context.Context
 //   - s string
 //   - vs ...any
-func (_e *mockLogger_Expecter) Error(s interface{}, vs ...interface{}) *mockLogger_Error_Call {
-	return &mockLogger_Error_Call{Call: _e.mock.On("Error",
-		append([]interface{}{s}, vs...)...)}
+func (_e *mockLogger_Expecter) ErrorContext(context1 interface{}, s interface{}, vs ...interface{}) *mockLogger_ErrorContext_Call {
+	return &mockLogger_ErrorContext_Call{Call: _e.mock.On("ErrorContext",
+		append([]interface{}{context1, s}, vs...)...)}
 }
 
-func (_c *mockLogger_Error_Call) Run(run func(s string, vs ...any)) *mockLogger_Error_Call {
+func (_c *mockLogger_ErrorContext_Call) Run(run func(context1 context.Context, s string, vs ...any)) *mockLogger_ErrorContext_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		var arg0 string
+		var arg0 context.Context
 		if args[0] != nil {
-			arg0 = args[0].(string)
+			arg0 = args[0].(context.Context)
 		}
-		var arg1 []any
-		variadicArgs := make([]any, len(args)-1)
-		for i, a := range args[1:] {
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 []any
+		variadicArgs := make([]any, len(args)-2)
+		for i, a := range args[2:] {
 			if a != nil {
 				variadicArgs[i] = a.(any)
 			}
 		}
-		arg1 = variadicArgs
+		arg2 = variadicArgs
 		run(
 			arg0,
-			arg1...,
+			arg1,
+			arg2...,
 		)
 	})
 	return _c
 }
 
-func (_c *mockLogger_Error_Call) Return() *mockLogger_Error_Call {
+func (_c *mockLogger_ErrorContext_Call) Return() *mockLogger_ErrorContext_Call {
 	_c.Call.Return()
 	return _c
 }
 
-func (_c *mockLogger_Error_Call) RunAndReturn(run func(s string, vs ...any)) *mockLogger_Error_Call {
+func (_c *mockLogger_ErrorContext_Call) RunAndReturn(run func(context1 context.Context, s string, vs ...any)) *mockLogger_ErrorContext_Call {
 	_c.Run(run)
 	return _c
 }
 
-// Info provides a mock function for the type mockLogger
-func (_mock *mockLogger) Info(s string, vs ...any) {
+// InfoContext provides a mock function for the type mockLogger
+func (_mock *mockLogger) InfoContext(context1 context.Context, s string, vs ...any) {
 	var _ca []interface{}
-	_ca = append(_ca, s)
+	_ca = append(_ca, context1, s)
 	_ca = append(_ca, vs...)
 	_mock.Called(_ca...)
 	return
 }
 
-// mockLogger_Info_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Info'
-type mockLogger_Info_Call struct {
+// mockLogger_InfoContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'InfoContext'
+type mockLogger_InfoContext_Call struct {
 	*mock.Call
 }
 
-// Info is a helper method to define mock.On call
+// InfoContext is a helper method to define mock.On call
+//   - context1 context.Context
 //   - s string
 //   - vs ...any
-func (_e *mockLogger_Expecter) Info(s interface{}, vs ...interface{}) *mockLogger_Info_Call {
-	return &mockLogger_Info_Call{Call: _e.mock.On("Info",
-		append([]interface{}{s}, vs...)...)}
+func (_e *mockLogger_Expecter) InfoContext(context1 interface{}, s interface{}, vs ...interface{}) *mockLogger_InfoContext_Call {
+	return &mockLogger_InfoContext_Call{Call: _e.mock.On("InfoContext",
+		append([]interface{}{context1, s}, vs...)...)}
 }
 
-func (_c *mockLogger_Info_Call) Run(run func(s string, vs ...any)) *mockLogger_Info_Call {
+func (_c *mockLogger_InfoContext_Call) Run(run func(context1 context.Context, s string, vs ...any)) *mockLogger_InfoContext_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		var arg0 string
+		var arg0 context.Context
 		if args[0] != nil {
-			arg0 = args[0].(string)
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
 		}
-		var arg1 []any
-		variadicArgs := make([]any, len(args)-1)
-		for i, a := range args[1:] {
+		var arg2 []any
+		variadicArgs := make([]any, len(args)-2)
+		for i, a := range args[2:] {
 			if a != nil {
 				variadicArgs[i] = a.(any)
 			}
 		}
-		arg1 = variadicArgs
+		arg2 = variadicArgs
 		run(
 			arg0,
-			arg1...,
+			arg1,
+			arg2...,
 		)
 	})
 	return _c
 }
 
-func (_c *mockLogger_Info_Call) Return() *mockLogger_Info_Call {
+func (_c *mockLogger_InfoContext_Call) Return() *mockLogger_InfoContext_Call {
 	_c.Call.Return()
 	return _c
 }
 
-func (_c *mockLogger_Info_Call) RunAndReturn(run func(s string, vs ...any)) *mockLogger_Info_Call {
+func (_c *mockLogger_InfoContext_Call) RunAndReturn(run func(context1 context.Context, s string, vs ...any)) *mockLogger_InfoContext_Call {
 	_c.Run(run)
 	return _c
 }
 
-// Warn provides a mock function for the type mockLogger
-func (_mock *mockLogger) Warn(s string, vs ...any) {
+// WarnContext provides a mock function for the type mockLogger
+func (_mock *mockLogger) WarnContext(context1 context.Context, s string, vs ...any) {
 	var _ca []interface{}
-	_ca = append(_ca, s)
+	_ca = append(_ca, context1, s)
 	_ca = append(_ca, vs...)
 	_mock.Called(_ca...)
 	return
 }
 
-// mockLogger_Warn_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Warn'
-type mockLogger_Warn_Call struct {
+// mockLogger_WarnContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'WarnContext'
+type mockLogger_WarnContext_Call struct {
 	*mock.Call
 }
 
-// Warn is a helper method to define mock.On call
+// WarnContext is a helper method to define mock.On call
+//   - context1 context.Context
 //   - s string
 //   - vs ...any
-func (_e *mockLogger_Expecter) Warn(s interface{}, vs ...interface{}) *mockLogger_Warn_Call {
-	return &mockLogger_Warn_Call{Call: _e.mock.On("Warn",
-		append([]interface{}{s}, vs...)...)}
+func (_e *mockLogger_Expecter) WarnContext(context1 interface{}, s interface{}, vs ...interface{}) *mockLogger_WarnContext_Call {
+	return &mockLogger_WarnContext_Call{Call: _e.mock.On("WarnContext",
+		append([]interface{}{context1, s}, vs...)...)}
 }
 
-func (_c *mockLogger_Warn_Call) Run(run func(s string, vs ...any)) *mockLogger_Warn_Call {
+func (_c *mockLogger_WarnContext_Call) Run(run func(context1 context.Context, s string, vs ...any)) *mockLogger_WarnContext_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		var arg0 string
+		var arg0 context.Context
 		if args[0] != nil {
-			arg0 = args[0].(string)
+			arg0 = args[0].(context.Context)
 		}
-		var arg1 []any
-		variadicArgs := make([]any, len(args)-1)
-		for i, a := range args[1:] {
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 []any
+		variadicArgs := make([]any, len(args)-2)
+		for i, a := range args[2:] {
 			if a != nil {
 				variadicArgs[i] = a.(any)
 			}
 		}
-		arg1 = variadicArgs
+		arg2 = variadicArgs
 		run(
 			arg0,
-			arg1...,
+			arg1,
+			arg2...,
 		)
 	})
 	return _c
 }
 
-func (_c *mockLogger_Warn_Call) Return() *mockLogger_Warn_Call {
+func (_c *mockLogger_WarnContext_Call) Return() *mockLogger_WarnContext_Call {
 	_c.Call.Return()
 	return _c
 }
 
-func (_c *mockLogger_Warn_Call) RunAndReturn(run func(s string, vs ...any)) *mockLogger_Warn_Call {
+func (_c *mockLogger_WarnContext_Call) RunAndReturn(run func(context1 context.Context, s string, vs ...any)) *mockLogger_WarnContext_Call {
 	_c.Run(run)
 	return _c
 }
@@ -373,12 +308,12 @@ func (_m *mockStore) EXPECT() *mockStore_Expecter {
 	return &mockStore_Expecter{mock: &_m.Mock}
 }
 
-// Get provides a mock function for the type mockStore
-func (_mock *mockStore) Get(ctx context.Context, uid string) (shared.Lpa, error) {
+// GetForUpdate provides a mock function for the type mockStore
+func (_mock *mockStore) GetForUpdate(ctx context.Context, uid string) (shared.Lpa, error) {
 	ret := _mock.Called(ctx, uid)
 
 	if len(ret) == 0 {
-		panic("no return value specified for Get")
+		panic("no return value specified for GetForUpdate")
 	}
 
 	var r0 shared.Lpa
@@ -399,19 +334,19 @@ func (_mock *mockStore) Get(ctx context.Context, uid string) (shared.Lpa, error)
 	return r0, r1
 }
 
-// mockStore_Get_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Get'
-type mockStore_Get_Call struct {
+// mockStore_GetForUpdate_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetForUpdate'
+type mockStore_GetForUpdate_Call struct {
 	*mock.Call
 }
 
-// Get is a helper method to define mock.On call
+// GetForUpdate is a helper method to define mock.On call
 //   - ctx context.Context
 //   - uid string
-func (_e *mockStore_Expecter) Get(ctx interface{}, uid interface{}) *mockStore_Get_Call {
-	return &mockStore_Get_Call{Call: _e.mock.On("Get", ctx, uid)}
+func (_e *mockStore_Expecter) GetForUpdate(ctx interface{}, uid interface{}) *mockStore_GetForUpdate_Call {
+	return &mockStore_GetForUpdate_Call{Call: _e.mock.On("GetForUpdate", ctx, uid)}
 }
 
-func (_c *mockStore_Get_Call) Run(run func(ctx context.Context, uid string)) *mockStore_Get_Call {
+func (_c *mockStore_GetForUpdate_Call) Run(run func(ctx context.Context, uid string)) *mockStore_GetForUpdate_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -429,27 +364,165 @@ func (_c *mockStore_Get_Call) Run(run func(ctx context.Context, uid string)) *mo
 	return _c
 }
 
-func (_c *mockStore_Get_Call) Return(lpa shared.Lpa, err error) *mockStore_Get_Call {
+func (_c *mockStore_GetForUpdate_Call) Return(lpa shared.Lpa, err error) *mockStore_GetForUpdate_Call {
 	_c.Call.Return(lpa, err)
 	return _c
 }
 
-func (_c *mockStore_Get_Call) RunAndReturn(run func(ctx context.Context, uid string) (shared.Lpa, error)) *mockStore_Get_Call {
+func (_c *mockStore_GetForUpdate_Call) RunAndReturn(run func(ctx context.Context, uid string) (shared.Lpa, error)) *mockStore_GetForUpdate_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetIdempotencyRecord provides a mock function for the type mockStore
+func (_mock *mockStore) GetIdempotencyRecord(ctx context.Context, key string) (idempotency.Record, error) {
+	ret := _mock.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetIdempotencyRecord")
+	}
+
+	var r0 idempotency.Record
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (idempotency.Record, error)); ok {
+		return returnFunc(ctx, key)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) idempotency.Record); ok {
+		r0 = returnFunc(ctx, key)
+	} else {
+		r0 = ret.Get(0).(idempotency.Record)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockStore_GetIdempotencyRecord_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetIdempotencyRecord'
+type mockStore_GetIdempotencyRecord_Call struct {
+	*mock.Call
+}
+
+// GetIdempotencyRecord is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+func (_e *mockStore_Expecter) GetIdempotencyRecord(ctx interface{}, key interface{}) *mockStore_GetIdempotencyRecord_Call {
+	return &mockStore_GetIdempotencyRecord_Call{Call: _e.mock.On("GetIdempotencyRecord", ctx, key)}
+}
+
+func (_c *mockStore_GetIdempotencyRecord_Call) Run(run func(ctx context.Context, key string)) *mockStore_GetIdempotencyRecord_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *mockStore_GetIdempotencyRecord_Call) Return(record idempotency.Record, err error) *mockStore_GetIdempotencyRecord_Call {
+	_c.Call.Return(record, err)
+	return _c
+}
+
+func (_c *mockStore_GetIdempotencyRecord_Call) RunAndReturn(run func(ctx context.Context, key string) (idempotency.Record, error)) *mockStore_GetIdempotencyRecord_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetUpdate provides a mock function for the type mockStore
+func (_mock *mockStore) GetUpdate(ctx context.Context, uid string, id string) (shared.Update, error) {
+	ret := _mock.Called(ctx, uid, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUpdate")
+	}
+
+	var r0 shared.Update
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) (shared.Update, error)); ok {
+		return returnFunc(ctx, uid, id)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) shared.Update); ok {
+		r0 = returnFunc(ctx, uid, id)
+	} else {
+		r0 = ret.Get(0).(shared.Update)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = returnFunc(ctx, uid, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockStore_GetUpdate_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUpdate'
+type mockStore_GetUpdate_Call struct {
+	*mock.Call
+}
+
+// GetUpdate is a helper method to define mock.On call
+//   - ctx context.Context
+//   - uid string
+//   - id string
+func (_e *mockStore_Expecter) GetUpdate(ctx interface{}, uid interface{}, id interface{}) *mockStore_GetUpdate_Call {
+	return &mockStore_GetUpdate_Call{Call: _e.mock.On("GetUpdate", ctx, uid, id)}
+}
+
+func (_c *mockStore_GetUpdate_Call) Run(run func(ctx context.Context, uid string, id string)) *mockStore_GetUpdate_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *mockStore_GetUpdate_Call) Return(update shared.Update, err error) *mockStore_GetUpdate_Call {
+	_c.Call.Return(update, err)
+	return _c
+}
+
+func (_c *mockStore_GetUpdate_Call) RunAndReturn(run func(ctx context.Context, uid string, id string) (shared.Update, error)) *mockStore_GetUpdate_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
 // PutChanges provides a mock function for the type mockStore
-func (_mock *mockStore) PutChanges(ctx context.Context, data any, update shared.Update) error {
-	ret := _mock.Called(ctx, data, update)
+func (_mock *mockStore) PutChanges(ctx context.Context, lpa shared.Lpa, updates []shared.Update, previousActorUIDs []string, record *idempotency.Record, outbox []event.OutboxEntry) error {
+	ret := _mock.Called(ctx, lpa, updates, previousActorUIDs, record, outbox)
 
 	if len(ret) == 0 {
 		panic("no return value specified for PutChanges")
 	}
 
 	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, any, shared.Update) error); ok {
-		r0 = returnFunc(ctx, data, update)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, shared.Lpa, []shared.Update, []string, *idempotency.Record, []event.OutboxEntry) error); ok {
+		r0 = returnFunc(ctx, lpa, updates, previousActorUIDs, record, outbox)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -463,30 +536,48 @@ type mockStore_PutChanges_Call struct {
 
 // PutChanges is a helper method to define mock.On call
 //   - ctx context.Context
-//   - data any
-//   - update shared.Update
-func (_e *mockStore_Expecter) PutChanges(ctx interface{}, data interface{}, update interface{}) *mockStore_PutChanges_Call {
-	return &mockStore_PutChanges_Call{Call: _e.mock.On("PutChanges", ctx, data, update)}
+//   - lpa shared.Lpa
+//   - updates []shared.Update
+//   - previousActorUIDs []string
+//   - record *idempotency.Record
+//   - outbox []event.OutboxEntry
+func (_e *mockStore_Expecter) PutChanges(ctx interface{}, lpa interface{}, updates interface{}, previousActorUIDs interface{}, record interface{}, outbox interface{}) *mockStore_PutChanges_Call {
+	return &mockStore_PutChanges_Call{Call: _e.mock.On("PutChanges", ctx, lpa, updates, previousActorUIDs, record, outbox)}
 }
 
-func (_c *mockStore_PutChanges_Call) Run(run func(ctx context.Context, data any, update shared.Update)) *mockStore_PutChanges_Call {
+func (_c *mockStore_PutChanges_Call) Run(run func(ctx context.Context, lpa shared.Lpa, updates []shared.Update, previousActorUIDs []string, record *idempotency.Record, outbox []event.OutboxEntry)) *mockStore_PutChanges_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
 			arg0 = args[0].(context.Context)
 		}
-		var arg1 any
+		var arg1 shared.Lpa
 		if args[1] != nil {
-			arg1 = args[1].(any)
+			arg1 = args[1].(shared.Lpa)
 		}
-		var arg2 shared.Update
+		var arg2 []shared.Update
 		if args[2] != nil {
-			arg2 = args[2].(shared.Update)
+			arg2 = args[2].([]shared.Update)
+		}
+		var arg3 []string
+		if args[3] != nil {
+			arg3 = args[3].([]string)
+		}
+		var arg4 *idempotency.Record
+		if args[4] != nil {
+			arg4 = args[4].(*idempotency.Record)
+		}
+		var arg5 []event.OutboxEntry
+		if args[5] != nil {
+			arg5 = args[5].([]event.OutboxEntry)
 		}
 		run(
 			arg0,
 			arg1,
 			arg2,
+			arg3,
+			arg4,
+			arg5,
 		)
 	})
 	return _c
@@ -497,7 +588,7 @@ func (_c *mockStore_PutChanges_Call) Return(err error) *mockStore_PutChanges_Cal
 	return _c
 }
 
-func (_c *mockStore_PutChanges_Call) RunAndReturn(run func(ctx context.Context, data any, update shared.Update) error) *mockStore_PutChanges_Call {
+func (_c *mockStore_PutChanges_Call) RunAndReturn(run func(ctx context.Context, lpa shared.Lpa, updates []shared.Update, previousActorUIDs []string, record *idempotency.Record, outbox []event.OutboxEntry) error) *mockStore_PutChanges_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -530,8 +621,8 @@ func (_m *mockVerifier) EXPECT() *mockVerifier_Expecter {
 }
 
 // VerifyHeader provides a mock function for the type mockVerifier
-func (_mock *mockVerifier) VerifyHeader(aPIGatewayProxyRequest events.APIGatewayProxyRequest) (*shared.LpaStoreClaims, error) {
-	ret := _mock.Called(aPIGatewayProxyRequest)
+func (_mock *mockVerifier) VerifyHeader(context1 context.Context, aPIGatewayProxyRequest events.APIGatewayProxyRequest) (*shared.LpaStoreClaims, error) {
+	ret := _mock.Called(context1, aPIGatewayProxyRequest)
 
 	if len(ret) == 0 {
 		panic("no return value specified for VerifyHeader")
@@ -539,18 +630,18 @@ func (_mock *mockVerifier) VerifyHeader(aPIGatewayProxyRequest events.APIGateway
 
 	var r0 *shared.LpaStoreClaims
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(events.APIGatewayProxyRequest) (*shared.LpaStoreClaims, error)); ok {
-		return returnFunc(aPIGatewayProxyRequest)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, events.APIGatewayProxyRequest) (*shared.LpaStoreClaims, error)); ok {
+		return returnFunc(context1, aPIGatewayProxyRequest)
 	}
-	if returnFunc, ok := ret.Get(0).(func(events.APIGatewayProxyRequest) *shared.LpaStoreClaims); ok {
-		r0 = returnFunc(aPIGatewayProxyRequest)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, events.APIGatewayProxyRequest) *shared.LpaStoreClaims); ok {
+		r0 = returnFunc(context1, aPIGatewayProxyRequest)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*shared.LpaStoreClaims)
 		}
 	}
-	if returnFunc, ok := ret.Get(1).(func(events.APIGatewayProxyRequest) error); ok {
-		r1 = returnFunc(aPIGatewayProxyRequest)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, events.APIGatewayProxyRequest) error); ok {
+		r1 = returnFunc(context1, aPIGatewayProxyRequest)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -563,19 +654,25 @@ type mockVerifier_VerifyHeader_Call struct {
 }
 
 // VerifyHeader is a helper method to define mock.On call
+//   - context1 context.Context
 //   - aPIGatewayProxyRequest events.APIGatewayProxyRequest
-func (_e *mockVerifier_Expecter) VerifyHeader(aPIGatewayProxyRequest interface{}) *mockVerifier_VerifyHeader_Call {
-	return &mockVerifier_VerifyHeader_Call{Call: _e.mock.On("VerifyHeader", aPIGatewayProxyRequest)}
+func (_e *mockVerifier_Expecter) VerifyHeader(context1 interface{}, aPIGatewayProxyRequest interface{}) *mockVerifier_VerifyHeader_Call {
+	return &mockVerifier_VerifyHeader_Call{Call: _e.mock.On("VerifyHeader", context1, aPIGatewayProxyRequest)}
 }
 
-func (_c *mockVerifier_VerifyHeader_Call) Run(run func(aPIGatewayProxyRequest events.APIGatewayProxyRequest)) *mockVerifier_VerifyHeader_Call {
+func (_c *mockVerifier_VerifyHeader_Call) Run(run func(context1 context.Context, aPIGatewayProxyRequest events.APIGatewayProxyRequest)) *mockVerifier_VerifyHeader_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		var arg0 events.APIGatewayProxyRequest
+		var arg0 context.Context
 		if args[0] != nil {
-			arg0 = args[0].(events.APIGatewayProxyRequest)
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 events.APIGatewayProxyRequest
+		if args[1] != nil {
+			arg1 = args[1].(events.APIGatewayProxyRequest)
 		}
 		run(
 			arg0,
+			arg1,
 		)
 	})
 	return _c
@@ -586,7 +683,7 @@ func (_c *mockVerifier_VerifyHeader_Call) Return(lpaStoreClaims *shared.LpaStore
 	return _c
 }
 
-func (_c *mockVerifier_VerifyHeader_Call) RunAndReturn(run func(aPIGatewayProxyRequest events.APIGatewayProxyRequest) (*shared.LpaStoreClaims, error)) *mockVerifier_VerifyHeader_Call {
+func (_c *mockVerifier_VerifyHeader_Call) RunAndReturn(run func(context1 context.Context, aPIGatewayProxyRequest events.APIGatewayProxyRequest) (*shared.LpaStoreClaims, error)) *mockVerifier_VerifyHeader_Call {
 	_c.Call.Return(run)
 	return _c
 }
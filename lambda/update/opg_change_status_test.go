@@ -9,16 +9,20 @@ import (
 )
 
 func TestOpgChangeStatusToCannotRegisterApply(t *testing.T) {
-	lpa := &shared.Lpa{
-		Status: shared.LpaStatusInProgress,
-	}
-	c := OpgChangeStatus{
-		Status: shared.LpaStatusCannotRegister,
+	for _, status := range []shared.LpaStatus{shared.LpaStatusInProgress, shared.LpaStatusWithdrawn, shared.LpaStatusExpired} {
+		t.Run(string(status), func(t *testing.T) {
+			lpa := &shared.Lpa{
+				Status: status,
+			}
+			c := OpgChangeStatus{
+				Status: shared.LpaStatusCannotRegister,
+			}
+
+			errors := c.Apply(lpa)
+			assert.Empty(t, errors)
+			assert.Equal(t, c.Status, lpa.Status)
+		})
 	}
-
-	errors := c.Apply(lpa)
-	assert.Empty(t, errors)
-	assert.Equal(t, c.Status, lpa.Status)
 }
 
 func TestOpgChangeStatusToCancelledApply(t *testing.T) {
@@ -81,7 +85,7 @@ func TestOpgChangeStatusToCannotRegisterIncorrectExistingStatus(t *testing.T) {
 	}
 
 	errors := c.Apply(lpa)
-	assert.Equal(t, errors, []shared.FieldError{{Source: "/status", Detail: "Lpa status cannot be registered while changing to cannot register"}})
+	assert.Equal(t, errors, []shared.FieldError{{Source: "/status", Detail: "Lpa status cannot be changed from registered to cannot-register"}})
 }
 
 func TestOpgChangeStatusToCancelledIncorrectExistingStatus(t *testing.T) {
@@ -93,7 +97,7 @@ func TestOpgChangeStatusToCancelledIncorrectExistingStatus(t *testing.T) {
 	}
 
 	errors := c.Apply(lpa)
-	assert.Equal(t, errors, []shared.FieldError{{Source: "/status", Detail: "Lpa status has to be registered while changing to cancelled"}})
+	assert.Equal(t, errors, []shared.FieldError{{Source: "/status", Detail: "Lpa status cannot be changed from in-progress to cancelled"}})
 }
 
 func TestOpgChangeStatusToDoNotRegisterIncorrectExistingStatus(t *testing.T) {
@@ -105,7 +109,7 @@ func TestOpgChangeStatusToDoNotRegisterIncorrectExistingStatus(t *testing.T) {
 	}
 
 	errors := c.Apply(lpa)
-	assert.Equal(t, errors, []shared.FieldError{{Source: "/status", Detail: "Lpa status has to be statutory waiting period while changing to do not register"}})
+	assert.Equal(t, errors, []shared.FieldError{{Source: "/status", Detail: "Lpa status cannot be changed from in-progress to do-not-register"}})
 }
 
 func TestOpgChangeStatusToExpiredIncorrectExistingStatus(t *testing.T) {
@@ -117,7 +121,7 @@ func TestOpgChangeStatusToExpiredIncorrectExistingStatus(t *testing.T) {
 	}
 
 	errors := c.Apply(lpa)
-	assert.Equal(t, errors, []shared.FieldError{{Source: "/status", Detail: "Lpa status has to be in progress, statutory waiting period or do not register while changing to expired"}})
+	assert.Equal(t, errors, []shared.FieldError{{Source: "/status", Detail: "Lpa status cannot be changed from registered to expired"}})
 }
 
 func TestValidateUpdateOPGChangeStatus(t *testing.T) {
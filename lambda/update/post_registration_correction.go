@@ -1,6 +1,7 @@
 package main
 
 import (
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/event"
 	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
 	"github.com/ministryofjustice/opg-data-lpa-store/internal/validate"
 	"github.com/ministryofjustice/opg-data-lpa-store/lambda/update/parse"
@@ -125,6 +126,10 @@ func (c PostRegistrationCorrection) Apply(lpa *shared.Lpa) []shared.FieldError {
 	return nil
 }
 
+func (PostRegistrationCorrection) Measurements(*shared.Lpa) []event.Measurement {
+	return []event.Measurement{event.Count(event.SubcategoryCorrection, event.MeasurePostRegistrationCorrection)}
+}
+
 func validatePostRegistrationCorrection(changes []shared.Change, lpa *shared.Lpa) (PostRegistrationCorrection, []shared.FieldError) {
 	var data PostRegistrationCorrection
 
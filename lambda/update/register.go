@@ -3,13 +3,14 @@ package main
 import (
 	"time"
 
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/event"
 	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
 )
 
 type Register struct{}
 
 func (r Register) Apply(lpa *shared.Lpa) []shared.FieldError {
-	if lpa.Status != shared.LpaStatusStatutoryWaitingPeriod {
+	if !lpa.Status.CanTransitionTo(shared.LpaStatusRegistered) {
 		return []shared.FieldError{{Source: "/type", Detail: "status must be statutory-waiting-period to register"}}
 	}
 
@@ -20,6 +21,22 @@ func (r Register) Apply(lpa *shared.Lpa) []shared.FieldError {
 	return nil
 }
 
+// Measurements counts the registration, and how long it took from the donor
+// signing the LPA.
+func (Register) Measurements(lpa *shared.Lpa) []event.Measurement {
+	measurements := []event.Measurement{event.Count(event.SubcategoryRegistration, event.MeasureRegistered)}
+
+	if lpa.RegistrationDate != nil && !lpa.SignedAt.IsZero() {
+		measurements = append(measurements, event.Measurement{
+			Subcategory: event.SubcategoryRegistration,
+			Name:        event.MeasureTimeToRegister,
+			Value:       int64(lpa.RegistrationDate.Sub(lpa.SignedAt) / time.Second),
+		})
+	}
+
+	return measurements
+}
+
 func validateRegister(changes []shared.Change) (Register, []shared.FieldError) {
 	if len(changes) > 0 {
 		return Register{}, []shared.FieldError{{Source: "/changes", Detail: "expected empty"}}
@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/event"
 	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
 	"github.com/stretchr/testify/assert"
 )
@@ -39,3 +40,23 @@ func TestValidateRegisterWhenChanges(t *testing.T) {
 	_, errors := validateRegister([]shared.Change{{}})
 	assert.Equal(t, []shared.FieldError{{Source: "/changes", Detail: "expected empty"}}, errors)
 }
+
+func TestRegisterMeasurements(t *testing.T) {
+	signedAt := time.Date(2024, time.January, 2, 12, 0, 0, 0, time.UTC)
+	registeredAt := signedAt.Add(20*24*time.Hour + time.Minute)
+
+	lpa := &shared.Lpa{LpaInit: shared.LpaInit{SignedAt: signedAt}, RegistrationDate: &registeredAt}
+
+	assert.Equal(t, []event.Measurement{
+		{Subcategory: event.SubcategoryRegistration, Name: event.MeasureRegistered, Value: 1},
+		{Subcategory: event.SubcategoryRegistration, Name: event.MeasureTimeToRegister, Value: 1728060},
+	}, Register{}.Measurements(lpa))
+}
+
+func TestRegisterMeasurementsWhenNotSigned(t *testing.T) {
+	registeredAt := time.Now()
+
+	assert.Equal(t, []event.Measurement{
+		{Subcategory: event.SubcategoryRegistration, Name: event.MeasureRegistered, Value: 1},
+	}, Register{}.Measurements(&shared.Lpa{RegistrationDate: &registeredAt}))
+}
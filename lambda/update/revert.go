@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/jsondoc"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/validate"
+	"github.com/ministryofjustice/opg-data-lpa-store/lambda/update/parse"
+)
+
+// Revert undoes a previously applied update, by setting each value it changed
+// back to what it was before.
+type Revert struct {
+	Original shared.Update
+}
+
+func (r Revert) Apply(lpa *shared.Lpa) []shared.FieldError {
+	if r.Original.Type == "CREATE" {
+		return []shared.FieldError{{Source: "/changes/0/new", Detail: "cannot revert the creation of an lpa"}}
+	}
+
+	changes := r.changes()
+	if len(changes) == 0 {
+		return []shared.FieldError{{Source: "/changes/0/new", Detail: "update made no changes"}}
+	}
+
+	doc, err := json.Marshal(lpa)
+	if err != nil {
+		return []shared.FieldError{{Source: "/changes/0/new", Detail: "could not revert update"}}
+	}
+
+	conflicts, err := jsondoc.Conflicts(doc, changes)
+	if err != nil {
+		return []shared.FieldError{{Source: "/changes/0/new", Detail: "could not revert update"}}
+	}
+
+	if len(conflicts) > 0 {
+		errors := make([]shared.FieldError, len(conflicts))
+		for i, key := range conflicts {
+			errors[i] = shared.FieldError{Source: "/changes/0/new", Detail: fmt.Sprintf("%s has changed since the update", key)}
+		}
+
+		return errors
+	}
+
+	reverted, err := jsondoc.Revert(doc, changes)
+	if err != nil {
+		return []shared.FieldError{{Source: "/changes/0/new", Detail: "could not revert update"}}
+	}
+
+	var result shared.Lpa
+	if err := json.Unmarshal(reverted, &result); err != nil {
+		return []shared.FieldError{{Source: "/changes/0/new", Detail: "could not revert update"}}
+	}
+
+	result.Version = lpa.Version
+	*lpa = result
+
+	return nil
+}
+
+// changes returns what the original update did to the LPA, without the
+// version which only ever increases.
+func (r Revert) changes() []shared.Change {
+	source := r.Original.Diff
+	if len(source) == 0 {
+		source = r.Original.Changes
+	}
+
+	var changes []shared.Change
+	for _, change := range source {
+		if change.Key != "/version" {
+			changes = append(changes, change)
+		}
+	}
+
+	return changes
+}
+
+// validateRevert returns the id of the update to be reverted.
+func validateRevert(changes []shared.Change) (string, []shared.FieldError) {
+	var updateId string
+
+	errors := parse.Changes(changes).
+		Field("/updateId", &updateId, parse.Validate(validate.NotEmpty())).
+		Consumed()
+
+	return updateId, errors
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateRevert(t *testing.T) {
+	updateId, errors := validateRevert([]shared.Change{
+		{Key: "/updateId", Old: jsonNull, New: json.RawMessage(`"an-id"`)},
+	})
+
+	assert.Nil(t, errors)
+	assert.Equal(t, "an-id", updateId)
+}
+
+func TestValidateRevertWhenInvalid(t *testing.T) {
+	testcases := map[string]struct {
+		changes []shared.Change
+		errors  []shared.FieldError
+	}{
+		"missing": {
+			errors: []shared.FieldError{{Source: "/changes", Detail: "missing /updateId"}},
+		},
+		"empty": {
+			changes: []shared.Change{{Key: "/updateId", Old: jsonNull, New: json.RawMessage(`""`)}},
+			errors:  []shared.FieldError{{Source: "/changes/0/new", Detail: "field is required"}},
+		},
+		"extra": {
+			changes: []shared.Change{
+				{Key: "/updateId", Old: jsonNull, New: json.RawMessage(`"an-id"`)},
+				{Key: "/donor/lastName", Old: jsonNull, New: json.RawMessage(`"Smith"`)},
+			},
+			errors: []shared.FieldError{{Source: "/changes/1", Detail: "unexpected change provided"}},
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			_, errors := validateRevert(tc.changes)
+			assert.Equal(t, tc.errors, errors)
+		})
+	}
+}
+
+func TestRevertApply(t *testing.T) {
+	lpa := &shared.Lpa{Uid: "1", Version: 4, LpaInit: shared.LpaInit{
+		Donor: shared.Donor{Person: shared.Person{FirstNames: "John", LastName: "Smith"}},
+	}}
+
+	errors := Revert{Original: shared.Update{Type: "CORRECTION", Diff: []shared.Change{
+		{Key: "/donor/lastName", Old: json.RawMessage(`"Jones"`), New: json.RawMessage(`"Smith"`)},
+		{Key: "/version", Old: json.RawMessage(`3`), New: json.RawMessage(`4`)},
+	}}}.Apply(lpa)
+
+	assert.Nil(t, errors)
+	assert.Equal(t, "Jones", lpa.Donor.LastName)
+	assert.Equal(t, "John", lpa.Donor.FirstNames)
+	assert.Equal(t, 4, lpa.Version)
+}
+
+func TestRevertApplyWhenNoDiff(t *testing.T) {
+	lpa := &shared.Lpa{LpaInit: shared.LpaInit{
+		Donor: shared.Donor{Person: shared.Person{LastName: "Smith"}},
+	}}
+
+	errors := Revert{Original: shared.Update{Type: "CORRECTION", Changes: []shared.Change{
+		{Key: "/donor/lastName", Old: json.RawMessage(`"Jones"`), New: json.RawMessage(`"Smith"`)},
+	}}}.Apply(lpa)
+
+	assert.Nil(t, errors)
+	assert.Equal(t, "Jones", lpa.Donor.LastName)
+}
+
+func TestRevertApplyWhenChangedSince(t *testing.T) {
+	lpa := &shared.Lpa{LpaInit: shared.LpaInit{
+		Donor: shared.Donor{Person: shared.Person{LastName: "Taylor"}},
+	}}
+
+	errors := Revert{Original: shared.Update{Type: "CORRECTION", Diff: []shared.Change{
+		{Key: "/donor/lastName", Old: json.RawMessage(`"Jones"`), New: json.RawMessage(`"Smith"`)},
+	}}}.Apply(lpa)
+
+	assert.Equal(t, []shared.FieldError{{Source: "/changes/0/new", Detail: "/donor/lastName has changed since the update"}}, errors)
+	assert.Equal(t, "Taylor", lpa.Donor.LastName)
+}
+
+func TestRevertApplyWhenCreate(t *testing.T) {
+	errors := Revert{Original: shared.Update{Type: "CREATE"}}.Apply(&shared.Lpa{})
+
+	assert.Equal(t, []shared.FieldError{{Source: "/changes/0/new", Detail: "cannot revert the creation of an lpa"}}, errors)
+}
+
+func TestRevertApplyWhenNoChanges(t *testing.T) {
+	errors := Revert{Original: shared.Update{Type: "CORRECTION", Diff: []shared.Change{
+		{Key: "/version", Old: json.RawMessage(`3`), New: json.RawMessage(`4`)},
+	}}}.Apply(&shared.Lpa{})
+
+	assert.Equal(t, []shared.FieldError{{Source: "/changes/0/new", Detail: "update made no changes"}}, errors)
+}
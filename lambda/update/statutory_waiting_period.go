@@ -7,7 +7,7 @@ import (
 type StatutoryWaitingPeriod struct{}
 
 func (r StatutoryWaitingPeriod) Apply(lpa *shared.Lpa) []shared.FieldError {
-	if lpa.Status != shared.LpaStatusInProgress {
+	if !lpa.Status.CanTransitionTo(shared.LpaStatusStatutoryWaitingPeriod) {
 		return []shared.FieldError{{Source: "/type", Detail: "status must be in-progress to enter statutory-waiting-period"}}
 	}
 
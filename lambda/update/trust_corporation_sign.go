@@ -1,6 +1,7 @@
 package main
 
 import (
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/event"
 	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
 	"github.com/ministryofjustice/opg-data-lpa-store/internal/validate"
 	"github.com/ministryofjustice/opg-data-lpa-store/lambda/update/parse"
@@ -36,6 +37,10 @@ func (a TrustCorporationSign) Apply(lpa *shared.Lpa) []shared.FieldError {
 	return nil
 }
 
+func (a TrustCorporationSign) Measurements(*shared.Lpa) []event.Measurement {
+	return []event.Measurement{event.FunnelCompletion(event.PartyTrustCorporation, a.Channel)}
+}
+
 func validateTrustCorporationSign(changes []shared.Change, lpa *shared.Lpa) (TrustCorporationSign, []shared.FieldError) {
 	var data TrustCorporationSign
 
@@ -28,6 +28,10 @@ func validateUpdate(update shared.Update, lpa *shared.Lpa) (Applyable, []shared.
 		return validateDonorConfirmIdentity(update.Changes, lpa)
 	case "CERTIFICATE_PROVIDER_CONFIRM_IDENTITY":
 		return validateCertificateProviderConfirmIdentity(update.Changes, lpa)
+	case "DONOR_DECEASED":
+		return validateDonorDeceased(update.Changes)
+	case "ATTORNEY_DECEASED":
+		return validateAttorneyDeceased(update.Changes, lpa)
 	case "DONOR_WITHDRAW_LPA":
 		return validateDonorWithdrawLPA(update.Changes)
 	case "ATTORNEY_OPT_OUT":
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/ddb"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/tracing"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/webhook"
+	"github.com/ministryofjustice/opg-go-common/telemetry"
+)
+
+// deadLetterReserve is how long before the invocation deadline deliveries are
+// stopped, so that there is time to record dead letters for them.
+const deadLetterReserve = 5 * time.Second
+
+type Dispatcher interface {
+	Deliver(ctx context.Context, subscription webhook.Subscription, payload webhook.Payload) (int, error)
+}
+
+type Logger interface {
+	ErrorContext(context.Context, string, ...any)
+	WarnContext(context.Context, string, ...any)
+	InfoContext(context.Context, string, ...any)
+	DebugContext(context.Context, string, ...any)
+}
+
+type Store interface {
+	Get(ctx context.Context, uid string) (shared.Lpa, error)
+}
+
+type SubscriptionStore interface {
+	GetSubscriptions(ctx context.Context) ([]webhook.Subscription, error)
+	PutDeadLetter(ctx context.Context, deadLetter webhook.DeadLetter) error
+}
+
+type Lambda struct {
+	dispatcher    Dispatcher
+	store         Store
+	subscriptions SubscriptionStore
+	logger        Logger
+	now           func() time.Time
+}
+
+// HandleEvent delivers the updates inserted in the stream records to the
+// subscriptions that match them. Deliveries that fail after retrying, or that
+// are cut short by the invocation deadline, are recorded as dead letters; if
+// that cannot be done then the records from it onwards are reported as failed,
+// so that they are retried in order. Records that there is no time left for
+// are also reported as failed.
+func (l *Lambda) HandleEvent(ctx context.Context, e events.DynamoDBEvent) (events.DynamoDBEventResponse, error) {
+	var subscriptions []webhook.Subscription
+
+	deliverCtx, cancel := deliveryContext(ctx)
+	defer cancel()
+
+	for i, record := range e.Records {
+		if record.EventName != string(events.DynamoDBOperationTypeInsert) {
+			continue
+		}
+
+		ctx := shared.ContextWithCorrelationID(ctx, record.Change.SequenceNumber)
+
+		if deliverCtx.Err() != nil {
+			l.logger.WarnContext(ctx, "no time left to deliver webhooks", slog.String("sequenceNumber", record.Change.SequenceNumber))
+
+			return failedFrom(e.Records[i:]), nil
+		}
+
+		if subscriptions == nil {
+			var err error
+			subscriptions, err = l.subscriptions.GetSubscriptions(ctx)
+			if err != nil {
+				l.logger.ErrorContext(ctx, "error getting subscriptions", slog.Any("err", err))
+
+				return failedFrom(e.Records[i:]), nil
+			}
+
+			if len(subscriptions) == 0 {
+				return events.DynamoDBEventResponse{}, nil
+			}
+		}
+
+		deliverCtx := shared.ContextWithCorrelationID(deliverCtx, record.Change.SequenceNumber)
+
+		if err := l.deliver(ctx, deliverCtx, subscriptions, record.Change.NewImage); err != nil {
+			l.logger.ErrorContext(ctx, "error delivering webhooks", slog.String("sequenceNumber", record.Change.SequenceNumber), slog.Any("err", err))
+
+			return failedFrom(e.Records[i:]), nil
+		}
+	}
+
+	return events.DynamoDBEventResponse{}, nil
+}
+
+// deliver sends the update to the matching subscriptions using deliverCtx,
+// which ends before ctx so that dead letters can still be recorded using ctx.
+func (l *Lambda) deliver(ctx, deliverCtx context.Context, subscriptions []webhook.Subscription, image map[string]events.DynamoDBAttributeValue) error {
+	update, err := ddb.UnmarshalUpdate(image)
+	if err != nil {
+		return err
+	}
+
+	status, err := l.statusAfter(ctx, update)
+	if err != nil {
+		return err
+	}
+
+	for _, subscription := range subscriptions {
+		if !subscription.Matches(update.Type, status) {
+			continue
+		}
+
+		payload := webhook.NewPayload(subscription, update, status)
+
+		attempts, deliverErr := l.dispatcher.Deliver(deliverCtx, subscription, payload)
+		if deliverErr == nil {
+			continue
+		}
+
+		l.logger.WarnContext(ctx, "webhook delivery failed", slog.String("deliveryId", payload.DeliveryId), slog.Int("attempts", attempts), slog.Any("err", deliverErr))
+
+		body, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+
+		if err := l.subscriptions.PutDeadLetter(ctx, webhook.DeadLetter{
+			DeliveryId:     payload.DeliveryId,
+			SubscriptionId: subscription.Id,
+			CallbackURL:    subscription.CallbackURL,
+			Payload:        body,
+			Attempts:       attempts,
+			LastError:      deliverErr.Error(),
+			FailedAt:       l.now().UTC(),
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// statusAfter returns the status the update left the LPA in. This is taken
+// from the update if it changed the status, as the LPA may have been updated
+// again since. The diff is used when there is one, as the status is often
+// changed by the type of update rather than by a requested change.
+func (l *Lambda) statusAfter(ctx context.Context, update shared.Update) (shared.LpaStatus, error) {
+	changes := update.Diff
+	if len(changes) == 0 {
+		changes = update.Changes
+	}
+
+	for _, change := range changes {
+		if change.Key == "/status" {
+			var status shared.LpaStatus
+			if err := json.Unmarshal(change.New, &status); err == nil {
+				return status, nil
+			}
+		}
+	}
+
+	lpa, err := l.store.Get(ctx, update.Uid)
+	if err != nil {
+		return "", err
+	}
+
+	return lpa.Status, nil
+}
+
+// deliveryContext returns a context that ends deadLetterReserve before ctx.
+func deliveryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if deadline, ok := ctx.Deadline(); ok {
+		return context.WithDeadline(ctx, deadline.Add(-deadLetterReserve))
+	}
+
+	return context.WithCancel(ctx)
+}
+
+func failedFrom(records []events.DynamoDBEventRecord) events.DynamoDBEventResponse {
+	return events.DynamoDBEventResponse{
+		BatchItemFailures: []events.DynamoDBBatchItemFailure{{ItemIdentifier: records[0].Change.SequenceNumber}},
+	}
+}
+
+func main() {
+	ctx := context.Background()
+	logger := shared.NewRequestLogger(telemetry.NewLogger("opg-data-lpa-store/webhooks"))
+
+	// set endpoint to "" outside dev to use default AWS resolver
+	endpointURL := os.Getenv("AWS_BASE_URL")
+
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		logger.Error("failed to load aws config", slog.Any("err", err))
+	}
+
+	if endpointURL != "" {
+		cfg.BaseEndpoint = aws.String(endpointURL)
+	}
+
+	tp, err := tracing.Start(ctx, "opg-data-lpa-store/webhooks")
+	if err != nil {
+		logger.Error("failed to start tracing", slog.Any("err", err))
+	}
+
+	l := &Lambda{
+		dispatcher: webhook.NewDispatcher(nil, secretsmanager.NewFromConfig(cfg)),
+		store: ddb.New(
+			cfg,
+			os.Getenv("DDB_TABLE_NAME_DEEDS"),
+			os.Getenv("DDB_TABLE_NAME_CHANGES"),
+			os.Getenv("DDB_TABLE_NAME_ACTORS"),
+			os.Getenv("DDB_TABLE_NAME_IDEMPOTENCY"),
+			os.Getenv("DDB_TABLE_NAME_OUTBOX"),
+		),
+		subscriptions: ddb.NewSubscriptionClient(
+			cfg,
+			os.Getenv("DDB_TABLE_NAME_SUBSCRIPTIONS"),
+			os.Getenv("DDB_TABLE_NAME_WEBHOOK_DEAD_LETTERS"),
+		),
+		logger: logger,
+		now:    time.Now,
+	}
+
+	lambda.Start(func(ctx context.Context, e events.DynamoDBEvent) (events.DynamoDBEventResponse, error) {
+		defer func() { _ = tp.ForceFlush(ctx) }()
+
+		return l.HandleEvent(ctx, e)
+	})
+}
@@ -0,0 +1,407 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const (
+	testSecret    = "a-secret-that-is-long-enough-to-use"
+	testSecretARN = "arn:aws:secretsmanager:eu-west-1:123456789012:secret:webhooks/a-subscription-AbCdEf"
+)
+
+var (
+	ctx         = context.WithValue(context.Background(), (*string)(nil), "testing")
+	recordCtx   = mock.MatchedBy(func(c context.Context) bool { return c.Value((*string)(nil)) == "testing" })
+	errExpected = errors.New("expect")
+	testNow     = time.Date(2024, time.January, 2, 12, 13, 14, 0, time.UTC)
+)
+
+type testSecrets struct{}
+
+func (testSecrets) GetSecretValue(context.Context, *secretsmanager.GetSecretValueInput, ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+	return &secretsmanager.GetSecretValueOutput{SecretString: aws.String(testSecret)}, nil
+}
+
+func insertRecord(uid, updateType, sequenceNumber string, changes ...events.DynamoDBAttributeValue) events.DynamoDBEventRecord {
+	return events.DynamoDBEventRecord{
+		EventName: string(events.DynamoDBOperationTypeInsert),
+		Change: events.DynamoDBStreamRecord{
+			NewImage: map[string]events.DynamoDBAttributeValue{
+				"id":      events.NewStringAttribute("update-" + sequenceNumber),
+				"uid":     events.NewStringAttribute(uid),
+				"applied": events.NewStringAttribute("2024-01-02T12:13:14.000000015Z"),
+				"author":  events.NewStringAttribute("urn:opg:poas:sirius:users:1"),
+				"type":    events.NewStringAttribute(updateType),
+				"changes": events.NewListAttribute(changes),
+			},
+			SequenceNumber: sequenceNumber,
+		},
+	}
+}
+
+func statusChange(status shared.LpaStatus) events.DynamoDBAttributeValue {
+	return events.NewMapAttribute(map[string]events.DynamoDBAttributeValue{
+		"Key": events.NewStringAttribute("/status"),
+		"Old": events.NewBinaryAttribute([]byte(`"in-progress"`)),
+		"New": events.NewBinaryAttribute([]byte(`"` + status + `"`)),
+	})
+}
+
+func TestLambdaHandleEvent(t *testing.T) {
+	registered := webhook.Subscription{Id: "registered", Statuses: []shared.LpaStatus{shared.LpaStatusRegistered}}
+	corrections := webhook.Subscription{Id: "corrections", UpdateTypes: []string{"CORRECTION"}}
+
+	subscriptions := newMockSubscriptionStore(t)
+	subscriptions.EXPECT().
+		GetSubscriptions(recordCtx).
+		Return([]webhook.Subscription{registered, corrections}, nil).
+		Once()
+
+	store := newMockStore(t)
+	store.EXPECT().
+		Get(recordCtx, "M-2").
+		Return(shared.Lpa{Uid: "M-2", Status: shared.LpaStatusRegistered}, nil)
+
+	dispatcher := newMockDispatcher(t)
+	dispatcher.EXPECT().
+		Deliver(recordCtx, registered, mock.MatchedBy(func(payload webhook.Payload) bool {
+			return payload.DeliveryId == "update-1/registered" && payload.Status == shared.LpaStatusRegistered
+		})).
+		Return(1, nil)
+	dispatcher.EXPECT().
+		Deliver(recordCtx, registered, mock.MatchedBy(func(payload webhook.Payload) bool {
+			return payload.DeliveryId == "update-3/registered"
+		})).
+		Return(1, nil)
+	dispatcher.EXPECT().
+		Deliver(recordCtx, corrections, mock.MatchedBy(func(payload webhook.Payload) bool {
+			return payload.DeliveryId == "update-3/corrections" && payload.Update.Uid == "M-2"
+		})).
+		Return(1, nil)
+
+	l := &Lambda{
+		dispatcher:    dispatcher,
+		store:         store,
+		subscriptions: subscriptions,
+		logger:        newMockLogger(t),
+	}
+
+	resp, err := l.HandleEvent(ctx, events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{
+		insertRecord("M-1", "REGISTER", "1", statusChange(shared.LpaStatusRegistered)),
+		{EventName: string(events.DynamoDBOperationTypeRemove)},
+		insertRecord("M-2", "CORRECTION", "3"),
+	}})
+	assert.Nil(t, err)
+	assert.Empty(t, resp.BatchItemFailures)
+}
+
+func TestLambdaHandleEventWhenStatusChangedInDiff(t *testing.T) {
+	registered := webhook.Subscription{Id: "registered", Statuses: []shared.LpaStatus{shared.LpaStatusRegistered}}
+
+	subscriptions := newMockSubscriptionStore(t)
+	subscriptions.EXPECT().
+		GetSubscriptions(recordCtx).
+		Return([]webhook.Subscription{registered}, nil)
+
+	dispatcher := newMockDispatcher(t)
+	dispatcher.EXPECT().
+		Deliver(recordCtx, registered, mock.MatchedBy(func(payload webhook.Payload) bool {
+			return payload.DeliveryId == "update-1/registered" && payload.Status == shared.LpaStatusRegistered
+		})).
+		Return(1, nil)
+
+	record := insertRecord("M-1", "REGISTER", "1")
+	record.Change.NewImage["diff"] = events.NewListAttribute([]events.DynamoDBAttributeValue{statusChange(shared.LpaStatusRegistered)})
+
+	l := &Lambda{
+		dispatcher:    dispatcher,
+		store:         newMockStore(t),
+		subscriptions: subscriptions,
+		logger:        newMockLogger(t),
+	}
+
+	resp, err := l.HandleEvent(ctx, events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{record}})
+	assert.Nil(t, err)
+	assert.Empty(t, resp.BatchItemFailures)
+}
+
+func TestLambdaHandleEventWhenNoSubscriptions(t *testing.T) {
+	subscriptions := newMockSubscriptionStore(t)
+	subscriptions.EXPECT().
+		GetSubscriptions(recordCtx).
+		Return([]webhook.Subscription{}, nil)
+
+	l := &Lambda{subscriptions: subscriptions}
+
+	resp, err := l.HandleEvent(ctx, events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{
+		insertRecord("M-1", "REGISTER", "1"),
+		insertRecord("M-2", "CORRECTION", "2"),
+	}})
+	assert.Nil(t, err)
+	assert.Empty(t, resp.BatchItemFailures)
+}
+
+func TestLambdaHandleEventWhenGetSubscriptionsErrors(t *testing.T) {
+	subscriptions := newMockSubscriptionStore(t)
+	subscriptions.EXPECT().
+		GetSubscriptions(recordCtx).
+		Return(nil, errExpected)
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		ErrorContext(mock.Anything, "error getting subscriptions", slog.Any("err", errExpected))
+
+	l := &Lambda{subscriptions: subscriptions, logger: logger}
+
+	resp, err := l.HandleEvent(ctx, events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{
+		{EventName: string(events.DynamoDBOperationTypeModify), Change: events.DynamoDBStreamRecord{SequenceNumber: "1"}},
+		insertRecord("M-1", "REGISTER", "2"),
+	}})
+	assert.Nil(t, err)
+	assert.Equal(t, []events.DynamoDBBatchItemFailure{{ItemIdentifier: "2"}}, resp.BatchItemFailures)
+}
+
+func TestLambdaHandleEventWhenDeliveryFails(t *testing.T) {
+	subscription := webhook.Subscription{Id: "a-subscription", CallbackURL: "https://example.com/hook"}
+
+	subscriptions := newMockSubscriptionStore(t)
+	subscriptions.EXPECT().
+		GetSubscriptions(recordCtx).
+		Return([]webhook.Subscription{subscription}, nil)
+	subscriptions.EXPECT().
+		PutDeadLetter(recordCtx, mock.MatchedBy(func(deadLetter webhook.DeadLetter) bool {
+			var payload webhook.Payload
+			_ = json.Unmarshal(deadLetter.Payload, &payload)
+
+			return assert.Equal(t, "update-1/a-subscription", deadLetter.DeliveryId) &&
+				assert.Equal(t, "a-subscription", deadLetter.SubscriptionId) &&
+				assert.Equal(t, "https://example.com/hook", deadLetter.CallbackURL) &&
+				assert.Equal(t, 4, deadLetter.Attempts) &&
+				assert.Equal(t, "expect", deadLetter.LastError) &&
+				assert.Equal(t, testNow, deadLetter.FailedAt) &&
+				assert.Equal(t, "M-1", payload.Update.Uid)
+		})).
+		Return(nil)
+
+	store := newMockStore(t)
+	store.EXPECT().
+		Get(recordCtx, "M-1").
+		Return(shared.Lpa{Status: shared.LpaStatusInProgress}, nil)
+
+	dispatcher := newMockDispatcher(t)
+	dispatcher.EXPECT().
+		Deliver(recordCtx, subscription, mock.Anything).
+		Return(4, errExpected)
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		WarnContext(mock.Anything, "webhook delivery failed", slog.String("deliveryId", "update-1/a-subscription"), slog.Int("attempts", 4), slog.Any("err", errExpected))
+
+	l := &Lambda{
+		dispatcher:    dispatcher,
+		store:         store,
+		subscriptions: subscriptions,
+		logger:        logger,
+		now:           func() time.Time { return testNow },
+	}
+
+	resp, err := l.HandleEvent(ctx, events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{
+		insertRecord("M-1", "CORRECTION", "1"),
+	}})
+	assert.Nil(t, err)
+	assert.Empty(t, resp.BatchItemFailures)
+}
+
+func TestLambdaHandleEventWhenDeliveryTimesOut(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	subscription := webhook.Subscription{Id: "a-subscription", CallbackURL: server.URL + "/hook", SecretARN: testSecretARN}
+
+	ctx, cancel := context.WithTimeout(ctx, deadLetterReserve+100*time.Millisecond)
+	defer cancel()
+
+	subscriptions := newMockSubscriptionStore(t)
+	subscriptions.EXPECT().
+		GetSubscriptions(recordCtx).
+		Return([]webhook.Subscription{subscription}, nil)
+	subscriptions.EXPECT().
+		PutDeadLetter(mock.MatchedBy(func(c context.Context) bool { return c.Value((*string)(nil)) == "testing" && c.Err() == nil }), mock.MatchedBy(func(deadLetter webhook.DeadLetter) bool {
+			return deadLetter.DeliveryId == "update-1/a-subscription" && deadLetter.Attempts == 1 && strings.Contains(deadLetter.LastError, context.DeadlineExceeded.Error())
+		})).
+		Return(nil)
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		WarnContext(mock.Anything, "webhook delivery failed", slog.String("deliveryId", "update-1/a-subscription"), slog.Int("attempts", 1), mock.Anything)
+	logger.EXPECT().
+		WarnContext(mock.Anything, "no time left to deliver webhooks", slog.String("sequenceNumber", "2"))
+
+	l := &Lambda{
+		dispatcher:    webhook.NewDispatcher(server.Client(), testSecrets{}),
+		subscriptions: subscriptions,
+		logger:        logger,
+		now:           time.Now,
+	}
+
+	resp, err := l.HandleEvent(ctx, events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{
+		insertRecord("M-1", "REGISTER", "1", statusChange(shared.LpaStatusRegistered)),
+		insertRecord("M-2", "REGISTER", "2", statusChange(shared.LpaStatusRegistered)),
+	}})
+	assert.Nil(t, err)
+	assert.Equal(t, []events.DynamoDBBatchItemFailure{{ItemIdentifier: "2"}}, resp.BatchItemFailures)
+}
+
+func TestLambdaHandleEventWhenPutDeadLetterErrors(t *testing.T) {
+	subscription := webhook.Subscription{Id: "a-subscription"}
+
+	subscriptions := newMockSubscriptionStore(t)
+	subscriptions.EXPECT().
+		GetSubscriptions(recordCtx).
+		Return([]webhook.Subscription{subscription}, nil)
+	subscriptions.EXPECT().
+		PutDeadLetter(recordCtx, mock.Anything).
+		Return(errExpected)
+
+	store := newMockStore(t)
+	store.EXPECT().
+		Get(recordCtx, mock.Anything).
+		Return(shared.Lpa{}, nil)
+
+	dispatcher := newMockDispatcher(t)
+	dispatcher.EXPECT().
+		Deliver(recordCtx, subscription, mock.Anything).
+		Return(1, errors.New("callback responded 400"))
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		WarnContext(mock.Anything, "webhook delivery failed", mock.Anything, mock.Anything, mock.Anything)
+	logger.EXPECT().
+		ErrorContext(mock.Anything, "error delivering webhooks", slog.String("sequenceNumber", "1"), slog.Any("err", errExpected))
+
+	l := &Lambda{
+		dispatcher:    dispatcher,
+		store:         store,
+		subscriptions: subscriptions,
+		logger:        logger,
+		now:           time.Now,
+	}
+
+	resp, err := l.HandleEvent(ctx, events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{
+		insertRecord("M-1", "CORRECTION", "1"),
+		insertRecord("M-2", "CORRECTION", "2"),
+	}})
+	assert.Nil(t, err)
+	assert.Equal(t, []events.DynamoDBBatchItemFailure{{ItemIdentifier: "1"}}, resp.BatchItemFailures)
+}
+
+func TestLambdaHandleEventWhenStoreErrors(t *testing.T) {
+	subscriptions := newMockSubscriptionStore(t)
+	subscriptions.EXPECT().
+		GetSubscriptions(recordCtx).
+		Return([]webhook.Subscription{{Id: "a-subscription"}}, nil)
+
+	store := newMockStore(t)
+	store.EXPECT().
+		Get(recordCtx, "M-1").
+		Return(shared.Lpa{}, errExpected)
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		ErrorContext(mock.Anything, "error delivering webhooks", slog.String("sequenceNumber", "1"), slog.Any("err", errExpected))
+
+	l := &Lambda{store: store, subscriptions: subscriptions, logger: logger}
+
+	resp, err := l.HandleEvent(ctx, events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{
+		insertRecord("M-1", "CORRECTION", "1"),
+	}})
+	assert.Nil(t, err)
+	assert.Equal(t, []events.DynamoDBBatchItemFailure{{ItemIdentifier: "1"}}, resp.BatchItemFailures)
+}
+
+func TestLambdaHandleEventDeliversToReceiver(t *testing.T) {
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+
+	var deliveries []received
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		deliveries = append(deliveries, received{header: r.Header, body: body})
+
+		if r.URL.Path == "/reject" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	accepting := webhook.Subscription{Id: "accepting", CallbackURL: server.URL + "/hook", SecretARN: testSecretARN}
+	rejecting := webhook.Subscription{Id: "rejecting", CallbackURL: server.URL + "/reject", SecretARN: testSecretARN, Statuses: []shared.LpaStatus{shared.LpaStatusRegistered}}
+
+	subscriptions := newMockSubscriptionStore(t)
+	subscriptions.EXPECT().
+		GetSubscriptions(recordCtx).
+		Return([]webhook.Subscription{accepting, rejecting}, nil)
+	subscriptions.EXPECT().
+		PutDeadLetter(recordCtx, mock.MatchedBy(func(deadLetter webhook.DeadLetter) bool {
+			return deadLetter.DeliveryId == "update-1/rejecting" && deadLetter.Attempts == 1 && deadLetter.LastError == "callback responded 400"
+		})).
+		Return(nil)
+
+	logger := newMockLogger(t)
+	logger.EXPECT().
+		WarnContext(mock.Anything, "webhook delivery failed", slog.String("deliveryId", "update-1/rejecting"), slog.Int("attempts", 1), mock.Anything)
+
+	l := &Lambda{
+		dispatcher:    webhook.NewDispatcher(server.Client(), testSecrets{}),
+		subscriptions: subscriptions,
+		logger:        logger,
+		now:           time.Now,
+	}
+
+	resp, err := l.HandleEvent(ctx, events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{
+		insertRecord("M-1", "REGISTER", "1", statusChange(shared.LpaStatusRegistered)),
+	}})
+	assert.Nil(t, err)
+	assert.Empty(t, resp.BatchItemFailures)
+
+	if assert.Len(t, deliveries, 2) {
+		delivery := deliveries[0]
+		timestamp, _ := strconv.ParseInt(delivery.header.Get(webhook.TimestampHeader), 10, 64)
+
+		assert.Equal(t, "update-1/accepting", delivery.header.Get(webhook.DeliveryHeader))
+		assert.True(t, webhook.Verify(testSecret, timestamp, delivery.body, delivery.header.Get(webhook.SignatureHeader)))
+
+		var payload webhook.Payload
+		assert.Nil(t, json.Unmarshal(delivery.body, &payload))
+		assert.Equal(t, "accepting", payload.SubscriptionId)
+		assert.Equal(t, shared.LpaStatusRegistered, payload.Status)
+		assert.Equal(t, "M-1", payload.Update.Uid)
+		assert.Equal(t, "REGISTER", payload.Update.Type)
+	}
+}
@@ -0,0 +1,618 @@
+// Code generated by mockery; DO NOT EDIT.
+// github.com/vektra/mockery
+// template: testify
+
+package main
+
+import (
+	"context"
+
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/webhook"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// newMockDispatcher creates a new instance of mockDispatcher. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newMockDispatcher(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *mockDispatcher {
+	mock := &mockDispatcher{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// mockDispatcher is an autogenerated mock type for the Dispatcher type
+type mockDispatcher struct {
+	mock.Mock
+}
+
+type mockDispatcher_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *mockDispatcher) EXPECT() *mockDispatcher_Expecter {
+	return &mockDispatcher_Expecter{mock: &_m.Mock}
+}
+
+// Deliver provides a mock function for the type mockDispatcher
+func (_mock *mockDispatcher) Deliver(ctx context.Context, subscription webhook.Subscription, payload webhook.Payload) (int, error) {
+	ret := _mock.Called(ctx, subscription, payload)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Deliver")
+	}
+
+	var r0 int
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, webhook.Subscription, webhook.Payload) (int, error)); ok {
+		return returnFunc(ctx, subscription, payload)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, webhook.Subscription, webhook.Payload) int); ok {
+		r0 = returnFunc(ctx, subscription, payload)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, webhook.Subscription, webhook.Payload) error); ok {
+		r1 = returnFunc(ctx, subscription, payload)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockDispatcher_Deliver_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Deliver'
+type mockDispatcher_Deliver_Call struct {
+	*mock.Call
+}
+
+// Deliver is a helper method to define mock.On call
+//   - ctx context.Context
+//   - subscription webhook.Subscription
+//   - payload webhook.Payload
+func (_e *mockDispatcher_Expecter) Deliver(ctx interface{}, subscription interface{}, payload interface{}) *mockDispatcher_Deliver_Call {
+	return &mockDispatcher_Deliver_Call{Call: _e.mock.On("Deliver", ctx, subscription, payload)}
+}
+
+func (_c *mockDispatcher_Deliver_Call) Run(run func(ctx context.Context, subscription webhook.Subscription, payload webhook.Payload)) *mockDispatcher_Deliver_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 webhook.Subscription
+		if args[1] != nil {
+			arg1 = args[1].(webhook.Subscription)
+		}
+		var arg2 webhook.Payload
+		if args[2] != nil {
+			arg2 = args[2].(webhook.Payload)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *mockDispatcher_Deliver_Call) Return(n int, err error) *mockDispatcher_Deliver_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *mockDispatcher_Deliver_Call) RunAndReturn(run func(ctx context.Context, subscription webhook.Subscription, payload webhook.Payload) (int, error)) *mockDispatcher_Deliver_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// newMockLogger creates a new instance of mockLogger. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newMockLogger(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *mockLogger {
+	mock := &mockLogger{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// mockLogger is an autogenerated mock type for the Logger type
+type mockLogger struct {
+	mock.Mock
+}
+
+type mockLogger_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *mockLogger) EXPECT() *mockLogger_Expecter {
+	return &mockLogger_Expecter{mock: &_m.Mock}
+}
+
+// DebugContext provides a mock function for the type mockLogger
+func (_mock *mockLogger) DebugContext(context1 context.Context, s string, vs ...any) {
+	var _ca []interface{}
+	_ca = append(_ca, context1, s)
+	_ca = append(_ca, vs...)
+	_mock.Called(_ca...)
+	return
+}
+
+// mockLogger_DebugContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DebugContext'
+type mockLogger_DebugContext_Call struct {
+	*mock.Call
+}
+
+// DebugContext is a helper method to define mock.On call
+//   - context1 context.Context
+//   - s string
+//   - vs ...any
+func (_e *mockLogger_Expecter) DebugContext(context1 interface{}, s interface{}, vs ...interface{}) *mockLogger_DebugContext_Call {
+	return &mockLogger_DebugContext_Call{Call: _e.mock.On("DebugContext",
+		append([]interface{}{context1, s}, vs...)...)}
+}
+
+func (_c *mockLogger_DebugContext_Call) Run(run func(context1 context.Context, s string, vs ...any)) *mockLogger_DebugContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 []any
+		variadicArgs := make([]any, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(any)
+			}
+		}
+		arg2 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2...,
+		)
+	})
+	return _c
+}
+
+func (_c *mockLogger_DebugContext_Call) Return() *mockLogger_DebugContext_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *mockLogger_DebugContext_Call) RunAndReturn(run func(context1 context.Context, s string, vs ...any)) *mockLogger_DebugContext_Call {
+	_c.Run(run)
+	return _c
+}
+
+// ErrorContext provides a mock function for the type mockLogger
+func (_mock *mockLogger) ErrorContext(context1 context.Context, s string, vs ...any) {
+	var _ca []interface{}
+	_ca = append(_ca, context1, s)
+	_ca = append(_ca, vs...)
+	_mock.Called(_ca...)
+	return
+}
+
+// mockLogger_ErrorContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ErrorContext'
+type mockLogger_ErrorContext_Call struct {
+	*mock.Call
+}
+
+// ErrorContext is a helper method to define mock.On call
+//   - context1 context.Context
+//   - s string
+//   - vs ...any
+func (_e *mockLogger_Expecter) ErrorContext(context1 interface{}, s interface{}, vs ...interface{}) *mockLogger_ErrorContext_Call {
+	return &mockLogger_ErrorContext_Call{Call: _e.mock.On("ErrorContext",
+		append([]interface{}{context1, s}, vs...)...)}
+}
+
+func (_c *mockLogger_ErrorContext_Call) Run(run func(context1 context.Context, s string, vs ...any)) *mockLogger_ErrorContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 []any
+		variadicArgs := make([]any, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(any)
+			}
+		}
+		arg2 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2...,
+		)
+	})
+	return _c
+}
+
+func (_c *mockLogger_ErrorContext_Call) Return() *mockLogger_ErrorContext_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *mockLogger_ErrorContext_Call) RunAndReturn(run func(context1 context.Context, s string, vs ...any)) *mockLogger_ErrorContext_Call {
+	_c.Run(run)
+	return _c
+}
+
+// InfoContext provides a mock function for the type mockLogger
+func (_mock *mockLogger) InfoContext(context1 context.Context, s string, vs ...any) {
+	var _ca []interface{}
+	_ca = append(_ca, context1, s)
+	_ca = append(_ca, vs...)
+	_mock.Called(_ca...)
+	return
+}
+
+// mockLogger_InfoContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'InfoContext'
+type mockLogger_InfoContext_Call struct {
+	*mock.Call
+}
+
+// InfoContext is a helper method to define mock.On call
+//   - context1 context.Context
+//   - s string
+//   - vs ...any
+func (_e *mockLogger_Expecter) InfoContext(context1 interface{}, s interface{}, vs ...interface{}) *mockLogger_InfoContext_Call {
+	return &mockLogger_InfoContext_Call{Call: _e.mock.On("InfoContext",
+		append([]interface{}{context1, s}, vs...)...)}
+}
+
+func (_c *mockLogger_InfoContext_Call) Run(run func(context1 context.Context, s string, vs ...any)) *mockLogger_InfoContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 []any
+		variadicArgs := make([]any, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(any)
+			}
+		}
+		arg2 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2...,
+		)
+	})
+	return _c
+}
+
+func (_c *mockLogger_InfoContext_Call) Return() *mockLogger_InfoContext_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *mockLogger_InfoContext_Call) RunAndReturn(run func(context1 context.Context, s string, vs ...any)) *mockLogger_InfoContext_Call {
+	_c.Run(run)
+	return _c
+}
+
+// WarnContext provides a mock function for the type mockLogger
+func (_mock *mockLogger) WarnContext(context1 context.Context, s string, vs ...any) {
+	var _ca []interface{}
+	_ca = append(_ca, context1, s)
+	_ca = append(_ca, vs...)
+	_mock.Called(_ca...)
+	return
+}
+
+// mockLogger_WarnContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'WarnContext'
+type mockLogger_WarnContext_Call struct {
+	*mock.Call
+}
+
+// WarnContext is a helper method to define mock.On call
+//   - context1 context.Context
+//   - s string
+//   - vs ...any
+func (_e *mockLogger_Expecter) WarnContext(context1 interface{}, s interface{}, vs ...interface{}) *mockLogger_WarnContext_Call {
+	return &mockLogger_WarnContext_Call{Call: _e.mock.On("WarnContext",
+		append([]interface{}{context1, s}, vs...)...)}
+}
+
+func (_c *mockLogger_WarnContext_Call) Run(run func(context1 context.Context, s string, vs ...any)) *mockLogger_WarnContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 []any
+		variadicArgs := make([]any, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(any)
+			}
+		}
+		arg2 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2...,
+		)
+	})
+	return _c
+}
+
+func (_c *mockLogger_WarnContext_Call) Return() *mockLogger_WarnContext_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *mockLogger_WarnContext_Call) RunAndReturn(run func(context1 context.Context, s string, vs ...any)) *mockLogger_WarnContext_Call {
+	_c.Run(run)
+	return _c
+}
+
+// newMockStore creates a new instance of mockStore. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newMockStore(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *mockStore {
+	mock := &mockStore{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// mockStore is an autogenerated mock type for the Store type
+type mockStore struct {
+	mock.Mock
+}
+
+type mockStore_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *mockStore) EXPECT() *mockStore_Expecter {
+	return &mockStore_Expecter{mock: &_m.Mock}
+}
+
+// Get provides a mock function for the type mockStore
+func (_mock *mockStore) Get(ctx context.Context, uid string) (shared.Lpa, error) {
+	ret := _mock.Called(ctx, uid)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Get")
+	}
+
+	var r0 shared.Lpa
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (shared.Lpa, error)); ok {
+		return returnFunc(ctx, uid)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) shared.Lpa); ok {
+		r0 = returnFunc(ctx, uid)
+	} else {
+		r0 = ret.Get(0).(shared.Lpa)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, uid)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockStore_Get_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Get'
+type mockStore_Get_Call struct {
+	*mock.Call
+}
+
+// Get is a helper method to define mock.On call
+//   - ctx context.Context
+//   - uid string
+func (_e *mockStore_Expecter) Get(ctx interface{}, uid interface{}) *mockStore_Get_Call {
+	return &mockStore_Get_Call{Call: _e.mock.On("Get", ctx, uid)}
+}
+
+func (_c *mockStore_Get_Call) Run(run func(ctx context.Context, uid string)) *mockStore_Get_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *mockStore_Get_Call) Return(lpa shared.Lpa, err error) *mockStore_Get_Call {
+	_c.Call.Return(lpa, err)
+	return _c
+}
+
+func (_c *mockStore_Get_Call) RunAndReturn(run func(ctx context.Context, uid string) (shared.Lpa, error)) *mockStore_Get_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// newMockSubscriptionStore creates a new instance of mockSubscriptionStore. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newMockSubscriptionStore(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *mockSubscriptionStore {
+	mock := &mockSubscriptionStore{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// mockSubscriptionStore is an autogenerated mock type for the SubscriptionStore type
+type mockSubscriptionStore struct {
+	mock.Mock
+}
+
+type mockSubscriptionStore_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *mockSubscriptionStore) EXPECT() *mockSubscriptionStore_Expecter {
+	return &mockSubscriptionStore_Expecter{mock: &_m.Mock}
+}
+
+// GetSubscriptions provides a mock function for the type mockSubscriptionStore
+func (_mock *mockSubscriptionStore) GetSubscriptions(ctx context.Context) ([]webhook.Subscription, error) {
+	ret := _mock.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSubscriptions")
+	}
+
+	var r0 []webhook.Subscription
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context) ([]webhook.Subscription, error)); ok {
+		return returnFunc(ctx)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context) []webhook.Subscription); ok {
+		r0 = returnFunc(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]webhook.Subscription)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = returnFunc(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockSubscriptionStore_GetSubscriptions_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetSubscriptions'
+type mockSubscriptionStore_GetSubscriptions_Call struct {
+	*mock.Call
+}
+
+// GetSubscriptions is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *mockSubscriptionStore_Expecter) GetSubscriptions(ctx interface{}) *mockSubscriptionStore_GetSubscriptions_Call {
+	return &mockSubscriptionStore_GetSubscriptions_Call{Call: _e.mock.On("GetSubscriptions", ctx)}
+}
+
+func (_c *mockSubscriptionStore_GetSubscriptions_Call) Run(run func(ctx context.Context)) *mockSubscriptionStore_GetSubscriptions_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *mockSubscriptionStore_GetSubscriptions_Call) Return(subscriptions []webhook.Subscription, err error) *mockSubscriptionStore_GetSubscriptions_Call {
+	_c.Call.Return(subscriptions, err)
+	return _c
+}
+
+func (_c *mockSubscriptionStore_GetSubscriptions_Call) RunAndReturn(run func(ctx context.Context) ([]webhook.Subscription, error)) *mockSubscriptionStore_GetSubscriptions_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PutDeadLetter provides a mock function for the type mockSubscriptionStore
+func (_mock *mockSubscriptionStore) PutDeadLetter(ctx context.Context, deadLetter webhook.DeadLetter) error {
+	ret := _mock.Called(ctx, deadLetter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PutDeadLetter")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, webhook.DeadLetter) error); ok {
+		r0 = returnFunc(ctx, deadLetter)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// mockSubscriptionStore_PutDeadLetter_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PutDeadLetter'
+type mockSubscriptionStore_PutDeadLetter_Call struct {
+	*mock.Call
+}
+
+// PutDeadLetter is a helper method to define mock.On call
+//   - ctx context.Context
+//   - deadLetter webhook.DeadLetter
+func (_e *mockSubscriptionStore_Expecter) PutDeadLetter(ctx interface{}, deadLetter interface{}) *mockSubscriptionStore_PutDeadLetter_Call {
+	return &mockSubscriptionStore_PutDeadLetter_Call{Call: _e.mock.On("PutDeadLetter", ctx, deadLetter)}
+}
+
+func (_c *mockSubscriptionStore_PutDeadLetter_Call) Run(run func(ctx context.Context, deadLetter webhook.DeadLetter)) *mockSubscriptionStore_PutDeadLetter_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 webhook.DeadLetter
+		if args[1] != nil {
+			arg1 = args[1].(webhook.DeadLetter)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *mockSubscriptionStore_PutDeadLetter_Call) Return(err error) *mockSubscriptionStore_PutDeadLetter_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *mockSubscriptionStore_PutDeadLetter_Call) RunAndReturn(run func(ctx context.Context, deadLetter webhook.DeadLetter) error) *mockSubscriptionStore_PutDeadLetter_Call {
+	_c.Call.Return(run)
+	return _c
+}
@@ -35,16 +35,62 @@ create_bucket "opg-lpa-store-static-eu-west-1"
 # DynamoDB
 awslocal dynamodb create-table \
     --table-name deeds \
-    --attribute-definitions AttributeName=uid,AttributeType=S \
+    --attribute-definitions AttributeName=uid,AttributeType=S AttributeName=searchKey,AttributeType=S \
     --key-schema AttributeName=uid,KeyType=HASH \
+    --global-secondary-indexes '[{"IndexName":"searchKey","KeySchema":[{"AttributeName":"searchKey","KeyType":"HASH"},{"AttributeName":"uid","KeyType":"RANGE"}],"Projection":{"ProjectionType":"ALL"}}]' \
     --billing-mode PAY_PER_REQUEST
 
 awslocal dynamodb create-table \
     --table-name changes \
     --attribute-definitions AttributeName=uid,AttributeType=S AttributeName=applied,AttributeType=S \
     --key-schema AttributeName=uid,KeyType=HASH AttributeName=applied,KeyType=RANGE \
+    --stream-specification StreamEnabled=true,StreamViewType=NEW_AND_OLD_IMAGES \
     --billing-mode PAY_PER_REQUEST
 
+awslocal dynamodb create-table \
+    --table-name actors \
+    --attribute-definitions AttributeName=actorUid,AttributeType=S AttributeName=lpaUid,AttributeType=S \
+    --key-schema AttributeName=actorUid,KeyType=HASH AttributeName=lpaUid,KeyType=RANGE \
+    --billing-mode PAY_PER_REQUEST
+
+awslocal dynamodb create-table \
+    --table-name idempotency \
+    --attribute-definitions AttributeName=key,AttributeType=S \
+    --key-schema AttributeName=key,KeyType=HASH \
+    --billing-mode PAY_PER_REQUEST
+
+awslocal dynamodb update-time-to-live \
+    --table-name idempotency \
+    --time-to-live-specification Enabled=true,AttributeName=expiresAt
+
+awslocal dynamodb create-table \
+    --table-name outbox \
+    --attribute-definitions AttributeName=id,AttributeType=S AttributeName=pendingRegion,AttributeType=S AttributeName=createdAt,AttributeType=S \
+    --key-schema AttributeName=id,KeyType=HASH \
+    --global-secondary-indexes '[{"IndexName":"pending","KeySchema":[{"AttributeName":"pendingRegion","KeyType":"HASH"},{"AttributeName":"createdAt","KeyType":"RANGE"}],"Projection":{"ProjectionType":"KEYS_ONLY"}}]' \
+    --stream-specification StreamEnabled=true,StreamViewType=NEW_AND_OLD_IMAGES \
+    --billing-mode PAY_PER_REQUEST
+
+awslocal dynamodb update-time-to-live \
+    --table-name outbox \
+    --time-to-live-specification Enabled=true,AttributeName=expiresAt
+
+awslocal dynamodb create-table \
+    --table-name webhook-subscriptions \
+    --attribute-definitions AttributeName=id,AttributeType=S \
+    --key-schema AttributeName=id,KeyType=HASH \
+    --billing-mode PAY_PER_REQUEST
+
+awslocal dynamodb create-table \
+    --table-name webhook-dead-letters \
+    --attribute-definitions AttributeName=deliveryId,AttributeType=S \
+    --key-schema AttributeName=deliveryId,KeyType=HASH \
+    --billing-mode PAY_PER_REQUEST
+
+awslocal dynamodb update-time-to-live \
+    --table-name webhook-dead-letters \
+    --time-to-live-specification Enabled=true,AttributeName=expiresAt
+
 # Secrets Manager
 awslocal secretsmanager create-secret --name local/jwt-key \
     --description "JWT secret for service authentication" \
@@ -18,13 +18,18 @@ import (
 
 var LPAPath = regexp.MustCompile("^/lpas/(M(?:-[0-9A-Z]{4}){3})$")
 var UpdatePath = regexp.MustCompile("^/lpas/(M(?:-[0-9A-Z]{4}){3})/updates$")
+var BatchUpdatePath = regexp.MustCompile("^/lpas/(M(?:-[0-9A-Z]{4}){3})/updates/batch$")
 var GetStaticPath = regexp.MustCompile("^/lpas/(M(?:-[0-9A-Z]{4}){3})/static$")
+var StatusesPath = regexp.MustCompile("^/lpas/(M(?:-[0-9A-Z]{4}){3})/statuses$")
+var ActorLpasPath = regexp.MustCompile("^/actors/([0-9a-fA-F-]+)/lpas$")
 
 var uidMap = map[string]string{}
 
 func delegateHandler(w http.ResponseWriter, r *http.Request) {
 	lambdaName := ""
 	uid := ""
+	actorUid := ""
+	resource := ""
 
 	if r.URL.Path == "/_pact_state" {
 		err := handlePactState(r)
@@ -44,17 +49,29 @@ func delegateHandler(w http.ResponseWriter, r *http.Request) {
 	if LPAPath.MatchString(r.URL.Path) && r.Method == http.MethodPut {
 		uid = LPAPath.FindStringSubmatch(r.URL.Path)[1]
 		lambdaName = "create"
+		resource = "/lpas/{uid}"
 	} else if LPAPath.MatchString(r.URL.Path) && r.Method == http.MethodGet {
 		uid = LPAPath.FindStringSubmatch(r.URL.Path)[1]
 		lambdaName = "get"
+		resource = "/lpas/{uid}"
 	} else if UpdatePath.MatchString(r.URL.Path) && r.Method == http.MethodPost {
 		uid = UpdatePath.FindStringSubmatch(r.URL.Path)[1]
 		lambdaName = "update"
+		resource = "/lpas/{uid}/updates"
+	} else if BatchUpdatePath.MatchString(r.URL.Path) && r.Method == http.MethodPost {
+		uid = BatchUpdatePath.FindStringSubmatch(r.URL.Path)[1]
+		lambdaName = "update"
+		resource = "/lpas/{uid}/updates/batch"
 	} else if UpdatePath.MatchString(r.URL.Path) && r.Method == http.MethodGet {
 		uid = UpdatePath.FindStringSubmatch(r.URL.Path)[1]
 		lambdaName = "getupdates"
+		resource = "/lpas/{uid}/updates"
+	} else if r.URL.Path == "/lpas/search" && r.Method == http.MethodPost {
+		lambdaName = "search"
+		resource = "/lpas/search"
 	} else if r.URL.Path == "/lpas" && r.Method == http.MethodPost {
 		lambdaName = "getlist"
+		resource = "/lpas"
 		bs := reqBody.Bytes()
 		for oldUID, newUID := range uidMap {
 			bs = bytes.ReplaceAll(bs, []byte(oldUID), []byte(newUID))
@@ -63,6 +80,15 @@ func delegateHandler(w http.ResponseWriter, r *http.Request) {
 	} else if GetStaticPath.MatchString(r.URL.Path) && r.Method == http.MethodGet {
 		uid = GetStaticPath.FindStringSubmatch(r.URL.Path)[1]
 		lambdaName = "getstatic"
+		resource = "/lpas/{uid}/static"
+	} else if StatusesPath.MatchString(r.URL.Path) && r.Method == http.MethodGet {
+		uid = StatusesPath.FindStringSubmatch(r.URL.Path)[1]
+		lambdaName = "getstatuses"
+		resource = "/lpas/{uid}/statuses"
+	} else if ActorLpasPath.MatchString(r.URL.Path) && r.Method == http.MethodGet {
+		actorUid = ActorLpasPath.FindStringSubmatch(r.URL.Path)[1]
+		lambdaName = "getbyactor"
+		resource = "/actors/{actorUid}/lpas"
 	}
 
 	if newUID, ok := uidMap[uid]; ok {
@@ -83,6 +109,7 @@ func delegateHandler(w http.ResponseWriter, r *http.Request) {
 
 	body := events.APIGatewayProxyRequest{
 		Body:                  reqBody.String(),
+		Resource:              resource,
 		Path:                  r.URL.Path,
 		HTTPMethod:            r.Method,
 		MultiValueHeaders:     r.Header,
@@ -95,6 +122,12 @@ func delegateHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if actorUid != "" {
+		body.PathParameters = map[string]string{
+			"actorUid": actorUid,
+		}
+	}
+
 	encodedBody, _ := json.Marshal(body)
 
 	proxyReq, err := http.NewRequest("POST", url, io.NopCloser(strings.NewReader(string(encodedBody))))
@@ -119,6 +152,9 @@ func delegateHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	for k, v := range respBody.Headers {
+		w.Header().Set(k, v)
+	}
 	w.WriteHeader(respBody.StatusCode)
 	_, err = w.Write([]byte(respBody.Body))
 
@@ -0,0 +1,58 @@
+// backfill writes the actor mappings and donor searchKey of LPAs saved before
+// they were introduced, so that the LPAs can be found by actor and by donor
+// without waiting for them to be changed. It can be run again safely, for
+// example:
+//
+//	go run ./scripts/backfill -table deeds -actors-table actors
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/ddb"
+)
+
+func main() {
+	var (
+		tableName       = flag.String("table", envOr("DDB_TABLE_NAME_DEEDS", "deeds"), "deeds table name")
+		actorsTableName = flag.String("actors-table", envOr("DDB_TABLE_NAME_ACTORS", "actors"), "actors table name")
+	)
+	flag.Parse()
+
+	ctx := context.Background()
+
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		fail(err)
+	}
+
+	if endpointURL := os.Getenv("AWS_BASE_URL"); endpointURL != "" {
+		cfg.BaseEndpoint = aws.String(endpointURL)
+	}
+
+	client := ddb.New(cfg, *tableName, "", *actorsTableName, "", "")
+
+	count, err := client.Backfill(ctx)
+	fmt.Printf("backfilled %d LPAs\n", count)
+	if err != nil {
+		fail(err)
+	}
+}
+
+func envOr(name, fallback string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+
+	return fallback
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
@@ -11,8 +11,8 @@ func main() {
 	secretKey := "mysupersecrettestkeythatis128bits"
 
 	claims := jwt.MapClaims{
-		"exp": time.Now().Add(time.Hour * 24).Unix(),
-		"iat": time.Now().Add(time.Hour * -24).Unix(),
+		"exp": time.Now().Add(time.Hour).Unix(),
+		"iat": time.Now().Unix(),
 		"iss": "opg.poas.sirius",
 		"sub": "urn:opg:sirius:users:34",
 	}
@@ -0,0 +1,99 @@
+// subscribe registers or removes a webhook subscription. The secret deliveries
+// are signed with must be created in Secrets Manager first, named
+// <environment>/webhooks/<id> so that the webhooks lambda can read it. The
+// callback URL must use https, and its host must only resolve to public
+// addresses. For example:
+//
+//	go run ./scripts/subscribe -id my-service -url https://my-service/hooks/lpa -secret-arn "$SECRET_ARN" -update-types REGISTER -statuses registered
+//	go run ./scripts/subscribe -id my-service -delete
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/ddb"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/shared"
+	"github.com/ministryofjustice/opg-data-lpa-store/internal/webhook"
+)
+
+func main() {
+	var (
+		tableName   = flag.String("table", envOr("DDB_TABLE_NAME_SUBSCRIPTIONS", "webhook-subscriptions"), "subscriptions table name")
+		id          = flag.String("id", "", "subscription id")
+		callbackURL = flag.String("url", "", "callback url")
+		secretARN   = flag.String("secret-arn", "", "arn of the secrets manager secret used to sign deliveries")
+		updateTypes = flag.String("update-types", "", "comma separated update types to deliver, or all if empty")
+		statuses    = flag.String("statuses", "", "comma separated statuses to deliver, or all if empty")
+		remove      = flag.Bool("delete", false, "delete the subscription")
+	)
+	flag.Parse()
+
+	ctx := context.Background()
+
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		fail(err)
+	}
+
+	if endpointURL := os.Getenv("AWS_BASE_URL"); endpointURL != "" {
+		cfg.BaseEndpoint = aws.String(endpointURL)
+	}
+
+	client := ddb.NewSubscriptionClient(cfg, *tableName, "")
+
+	if *remove {
+		if err := client.DeleteSubscription(ctx, *id); err != nil {
+			fail(err)
+		}
+		return
+	}
+
+	subscription := webhook.Subscription{
+		Id:          *id,
+		CallbackURL: *callbackURL,
+		UpdateTypes: split(*updateTypes),
+		SecretARN:   *secretARN,
+		CreatedAt:   time.Now().UTC(),
+	}
+
+	for _, status := range split(*statuses) {
+		subscription.Statuses = append(subscription.Statuses, shared.LpaStatus(status))
+	}
+
+	if err := subscription.ValidateCallbackHost(ctx, net.DefaultResolver); err != nil {
+		fail(err)
+	}
+
+	if err := client.PutSubscription(ctx, subscription); err != nil {
+		fail(err)
+	}
+}
+
+func split(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(s, ",")
+}
+
+func envOr(name, fallback string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+
+	return fallback
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
@@ -15,6 +15,18 @@ resource "aws_dynamodb_table" "deeds_table" {
     type = "S"
   }
 
+  attribute {
+    name = "searchKey"
+    type = "S"
+  }
+
+  global_secondary_index {
+    name            = "searchKey"
+    hash_key        = "searchKey"
+    range_key       = "uid"
+    projection_type = "ALL"
+  }
+
   point_in_time_recovery {
     enabled = true
   }
@@ -72,3 +84,230 @@ resource "aws_dynamodb_table_replica" "changes_table" {
   point_in_time_recovery = true
   provider               = aws.eu_west_2
 }
+
+resource "aws_dynamodb_table" "actors_table" {
+  name                        = "actors-${local.environment_name}"
+  billing_mode                = "PAY_PER_REQUEST"
+  deletion_protection_enabled = local.environment.is_production
+  stream_enabled              = true
+  stream_view_type            = "NEW_AND_OLD_IMAGES"
+  hash_key                    = "actorUid"
+  range_key                   = "lpaUid"
+
+  server_side_encryption {
+    enabled = true
+  }
+
+  attribute {
+    name = "actorUid"
+    type = "S"
+  }
+
+  attribute {
+    name = "lpaUid"
+    type = "S"
+  }
+
+  point_in_time_recovery {
+    enabled = true
+  }
+
+  lifecycle {
+    ignore_changes = [replica]
+  }
+
+  provider = aws.eu_west_1
+}
+
+resource "aws_dynamodb_table_replica" "actors_table" {
+  global_table_arn       = aws_dynamodb_table.actors_table.arn
+  point_in_time_recovery = true
+  provider               = aws.eu_west_2
+}
+
+resource "aws_dynamodb_table" "idempotency_table" {
+  name                        = "idempotency-${local.environment_name}"
+  billing_mode                = "PAY_PER_REQUEST"
+  deletion_protection_enabled = local.environment.is_production
+  stream_enabled              = true
+  stream_view_type            = "NEW_AND_OLD_IMAGES"
+  hash_key                    = "key"
+
+  server_side_encryption {
+    enabled = true
+  }
+
+  attribute {
+    name = "key"
+    type = "S"
+  }
+
+  ttl {
+    attribute_name = "expiresAt"
+    enabled        = true
+  }
+
+  point_in_time_recovery {
+    enabled = true
+  }
+
+  lifecycle {
+    ignore_changes = [replica]
+  }
+
+  provider = aws.eu_west_1
+}
+
+resource "aws_dynamodb_table_replica" "idempotency_table" {
+  global_table_arn       = aws_dynamodb_table.idempotency_table.arn
+  point_in_time_recovery = true
+  provider               = aws.eu_west_2
+}
+
+resource "aws_dynamodb_table" "tokens_table" {
+  name                        = "tokens-${local.environment_name}"
+  billing_mode                = "PAY_PER_REQUEST"
+  deletion_protection_enabled = local.environment.is_production
+  stream_enabled              = true
+  stream_view_type            = "NEW_AND_OLD_IMAGES"
+  hash_key                    = "id"
+
+  server_side_encryption {
+    enabled = true
+  }
+
+  attribute {
+    name = "id"
+    type = "S"
+  }
+
+  ttl {
+    attribute_name = "expiresAt"
+    enabled        = true
+  }
+
+  point_in_time_recovery {
+    enabled = true
+  }
+
+  lifecycle {
+    ignore_changes = [replica]
+  }
+
+  provider = aws.eu_west_1
+}
+
+resource "aws_dynamodb_table_replica" "tokens_table" {
+  global_table_arn       = aws_dynamodb_table.tokens_table.arn
+  point_in_time_recovery = true
+  provider               = aws.eu_west_2
+}
+
+resource "aws_dynamodb_table" "outbox_table" {
+  name                        = "outbox-${local.environment_name}"
+  billing_mode                = "PAY_PER_REQUEST"
+  deletion_protection_enabled = local.environment.is_production
+  stream_enabled              = true
+  stream_view_type            = "NEW_AND_OLD_IMAGES"
+  hash_key                    = "id"
+
+  server_side_encryption {
+    enabled = true
+  }
+
+  attribute {
+    name = "id"
+    type = "S"
+  }
+
+  attribute {
+    name = "pendingRegion"
+    type = "S"
+  }
+
+  attribute {
+    name = "createdAt"
+    type = "S"
+  }
+
+  # pendingRegion is removed when an entry is sent, so this only holds the
+  # entries the relay sweep may need to send
+  global_secondary_index {
+    name            = "pending"
+    hash_key        = "pendingRegion"
+    range_key       = "createdAt"
+    projection_type = "KEYS_ONLY"
+  }
+
+  ttl {
+    attribute_name = "expiresAt"
+    enabled        = true
+  }
+
+  point_in_time_recovery {
+    enabled = true
+  }
+
+  lifecycle {
+    ignore_changes = [replica]
+  }
+
+  provider = aws.eu_west_1
+}
+
+resource "aws_dynamodb_table_replica" "outbox_table" {
+  global_table_arn       = aws_dynamodb_table.outbox_table.arn
+  point_in_time_recovery = true
+  provider               = aws.eu_west_2
+}
+
+# webhooks are only delivered from eu-west-1, where the changes stream is read,
+# so the webhook tables are not global tables and do not need streams
+resource "aws_dynamodb_table" "webhook_subscriptions_table" {
+  name                        = "webhook-subscriptions-${local.environment_name}"
+  billing_mode                = "PAY_PER_REQUEST"
+  deletion_protection_enabled = local.environment.is_production
+  hash_key                    = "id"
+
+  server_side_encryption {
+    enabled = true
+  }
+
+  attribute {
+    name = "id"
+    type = "S"
+  }
+
+  point_in_time_recovery {
+    enabled = true
+  }
+
+  provider = aws.eu_west_1
+}
+
+resource "aws_dynamodb_table" "webhook_dead_letters_table" {
+  name                        = "webhook-dead-letters-${local.environment_name}"
+  billing_mode                = "PAY_PER_REQUEST"
+  deletion_protection_enabled = local.environment.is_production
+  hash_key                    = "deliveryId"
+
+  server_side_encryption {
+    enabled = true
+  }
+
+  attribute {
+    name = "deliveryId"
+    type = "S"
+  }
+
+  ttl {
+    attribute_name = "expiresAt"
+    enabled        = true
+  }
+
+  point_in_time_recovery {
+    enabled = true
+  }
+
+  provider = aws.eu_west_1
+}
@@ -1,12 +1,15 @@
 locals {
   stage_name = "current"
   template_file = templatefile("../../docs/openapi/openapi-aws.compiled.yaml", {
-    lambda_create_invoke_arn     = module.lambda["create"].invoke_arn
-    lambda_get_invoke_arn        = module.lambda["get"].invoke_arn
-    lambda_update_invoke_arn     = module.lambda["update"].invoke_arn
-    lambda_getupdates_invoke_arn = module.lambda["getupdates"].invoke_arn
-    lambda_getlist_invoke_arn    = module.lambda["getlist"].invoke_arn
-    lambda_getstatic_invoke_arn  = module.lambda["getstatic"].invoke_arn
+    lambda_create_invoke_arn      = module.lambda["create"].invoke_arn
+    lambda_get_invoke_arn         = module.lambda["get"].invoke_arn
+    lambda_update_invoke_arn      = module.lambda["update"].invoke_arn
+    lambda_getupdates_invoke_arn  = module.lambda["getupdates"].invoke_arn
+    lambda_getlist_invoke_arn     = module.lambda["getlist"].invoke_arn
+    lambda_getstatic_invoke_arn   = module.lambda["getstatic"].invoke_arn
+    lambda_getstatuses_invoke_arn = module.lambda["getstatuses"].invoke_arn
+    lambda_getbyactor_invoke_arn  = module.lambda["getbyactor"].invoke_arn
+    lambda_search_invoke_arn      = module.lambda["search"].invoke_arn
   })
 }
 
@@ -207,7 +210,7 @@ locals {
 }
 
 resource "aws_lambda_permission" "api_gateway_invoke" {
-  for_each      = module.lambda
+  for_each      = { for name in local.api_functions : name => module.lambda[name] }
   statement_id  = "AllowLambdaAPIGatewayInvocation"
   action        = "lambda:InvokeFunction"
   function_name = each.value.function_name
@@ -52,6 +52,11 @@ data "aws_secretsmanager_secret" "jwt_secret_key" {
   provider = aws.management
 }
 
+data "aws_secretsmanager_secret" "jwt_keys" {
+  name     = "${data.aws_default_tags.default.tags.application}/${data.aws_default_tags.default.tags.account}/jwt-keys"
+  provider = aws.management
+}
+
 data "aws_kms_alias" "jwt_key" {
   name     = "alias/${data.aws_default_tags.default.tags.application}/${data.aws_default_tags.default.tags.account}/jwt-key"
   provider = aws.management
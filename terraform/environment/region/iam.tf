@@ -15,14 +15,79 @@ data "aws_iam_policy_document" "lambda_dynamodb_policy" {
   statement {
     sid       = "allowDynamoDB"
     effect    = "Allow"
-    resources = [var.dynamodb_arn, var.dynamodb_arn_changes]
+    resources = [var.dynamodb_arn, "${var.dynamodb_arn}/index/*", var.dynamodb_arn_changes, var.dynamodb_arn_actors, var.dynamodb_arn_idempotency, var.dynamodb_arn_outbox, "${var.dynamodb_arn_outbox}/index/*", var.dynamodb_arn_tokens, var.dynamodb_arn_webhook_dead_letters, var.dynamodb_arn_webhook_subscriptions]
     actions = [
       "dynamodb:PutItem",
+      "dynamodb:DeleteItem",
       "dynamodb:GetItem",
       "dynamodb:Query",
       "dynamodb:BatchGetItem",
+      "dynamodb:UpdateItem",
     ]
   }
+
+  statement {
+    sid       = "allowDynamoDBScanWebhookSubscriptions"
+    effect    = "Allow"
+    resources = [var.dynamodb_arn_webhook_subscriptions]
+    actions   = ["dynamodb:Scan"]
+  }
+}
+
+resource "aws_iam_role_policy" "lambda_dynamodb_stream" {
+  for_each = local.worker_functions
+  name     = "LambdaAllowDynamoDBStream"
+  role     = module.lambda[each.key].iam_role.id
+  policy   = data.aws_iam_policy_document.lambda_dynamodb_stream_policy.json
+  provider = aws.region
+}
+
+data "aws_iam_policy_document" "lambda_dynamodb_stream_policy" {
+  statement {
+    sid       = "allowDynamoDBStream"
+    effect    = "Allow"
+    resources = ["${var.dynamodb_arn_outbox}/stream/*", "${var.dynamodb_arn_changes}/stream/*"]
+    actions = [
+      "dynamodb:DescribeStream",
+      "dynamodb:GetRecords",
+      "dynamodb:GetShardIterator",
+      "dynamodb:ListStreams",
+    ]
+  }
+}
+
+resource "aws_iam_role_policy" "lambda_webhook_secrets" {
+  name     = "LambdaAllowWebhookSecrets"
+  role     = module.lambda["webhooks"].iam_role.id
+  policy   = data.aws_iam_policy_document.lambda_webhook_secrets_policy.json
+  provider = aws.region
+}
+
+# subscriptions' signing secrets are kept under this prefix, encrypted with the
+# default Secrets Manager key
+data "aws_iam_policy_document" "lambda_webhook_secrets_policy" {
+  statement {
+    sid       = "allowReadWebhookSecrets"
+    effect    = "Allow"
+    resources = ["arn:aws:secretsmanager:${data.aws_region.current.region}:${data.aws_caller_identity.current.account_id}:secret:${var.environment_name}/webhooks/*"]
+    actions   = ["secretsmanager:GetSecretValue"]
+  }
+}
+
+resource "aws_iam_role_policy" "lambda_relay_failures" {
+  name     = "LambdaAllowRelayFailures"
+  role     = module.lambda["relay"].iam_role.id
+  policy   = data.aws_iam_policy_document.lambda_relay_failures_policy.json
+  provider = aws.region
+}
+
+data "aws_iam_policy_document" "lambda_relay_failures_policy" {
+  statement {
+    sid       = "allowSendRelayFailures"
+    effect    = "Allow"
+    resources = [aws_sqs_queue.relay_failures.arn]
+    actions   = ["sqs:SendMessage"]
+  }
 }
 
 resource "aws_iam_role_policy" "lambda_s3_policy" {
@@ -102,9 +167,12 @@ resource "aws_iam_role_policy" "lambda_secrets_policy" {
 
 data "aws_iam_policy_document" "lambda_secrets_policy" {
   statement {
-    sid       = "allowReadJwtSecret"
-    effect    = "Allow"
-    resources = [data.aws_secretsmanager_secret.jwt_secret_key.arn]
+    sid    = "allowReadJwtSecret"
+    effect = "Allow"
+    resources = [
+      data.aws_secretsmanager_secret.jwt_secret_key.arn,
+      data.aws_secretsmanager_secret.jwt_keys.arn,
+    ]
     actions = [
       "secretsmanager:GetSecretValue"
     ]
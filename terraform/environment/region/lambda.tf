@@ -1,13 +1,32 @@
 locals {
   # we could make maps of these functions to associate a specific iam role to specific functions
-  functions = toset([
+  api_functions = toset([
     "create",
     "get",
+    "getbyactor",
     "getlist",
     "getstatic",
+    "getstatuses",
     "getupdates",
+    "search",
     "update",
   ])
+
+  # functions that are not invoked through API Gateway
+  worker_functions = toset([
+    "changeevents",
+    "relay",
+    "webhooks",
+  ])
+
+  functions = setunion(local.api_functions, local.worker_functions)
+
+  # functions that need longer than the default 5 seconds. A webhook delivery
+  # can take about 45 seconds when the receiver times out on every attempt, so
+  # this allows a few of those; records there is no time for are retried.
+  function_timeouts = {
+    webhooks = 180
+  }
 }
 
 module "lambda" {
@@ -16,18 +35,32 @@ module "lambda" {
 
   environment_name      = var.environment_name
   lambda_name           = each.key
+  timeout               = lookup(local.function_timeouts, each.key, 5)
   ecr_image_uri         = "${data.aws_ecr_repository.lambda[each.key].repository_url}:${var.app_version}"
   cloudwatch_kms_key_id = aws_kms_key.cloudwatch.arn
   subnet_ids            = data.aws_subnets.application.ids
   vpc_id                = data.aws_vpc.main.id
 
   environment_variables = {
-    DDB_TABLE_NAME_DEEDS    = var.dynamodb_name
-    DDB_TABLE_NAME_CHANGES  = var.dynamodb_name_changes
-    EVENT_BUS_NAME          = var.event_bus.name
-    S3_BUCKET_NAME_ORIGINAL = var.lpa_store_static_bucket.bucket
-    JWT_SECRET_KEY_ARN      = data.aws_secretsmanager_secret.jwt_secret_key.arn
-    ENVIRONMENT             = var.environment_name
+    DDB_TABLE_NAME_DEEDS                = var.dynamodb_name
+    DDB_TABLE_NAME_CHANGES              = var.dynamodb_name_changes
+    DDB_TABLE_NAME_ACTORS               = var.dynamodb_name_actors
+    DDB_TABLE_NAME_IDEMPOTENCY          = var.dynamodb_name_idempotency
+    DDB_TABLE_NAME_OUTBOX               = var.dynamodb_name_outbox
+    DDB_TABLE_NAME_TOKENS               = var.environment.jwt.replay_detection ? var.dynamodb_name_tokens : ""
+    DDB_TABLE_NAME_SUBSCRIPTIONS        = var.dynamodb_name_webhook_subscriptions
+    DDB_TABLE_NAME_WEBHOOK_DEAD_LETTERS = var.dynamodb_name_webhook_dead_letters
+    EVENT_BUS_NAME                      = var.event_bus.name
+    EVENT_FORMAT                        = var.environment.cloud_events ? "cloudevents" : ""
+    METRICS_SINK                        = var.environment.emf_metrics ? "emf" : ""
+    S3_BUCKET_NAME_ORIGINAL             = var.lpa_store_static_bucket.bucket
+    JWT_SECRET_KEY_ARN                  = data.aws_secretsmanager_secret.jwt_secret_key.arn
+    JWT_KEYS_ARN                        = data.aws_secretsmanager_secret.jwt_keys.arn
+    JWT_AUDIENCE                        = var.environment.jwt.audience
+    JWT_MAX_LIFETIME                    = var.environment.jwt.max_lifetime
+    JWT_CLOCK_SKEW                      = var.environment.jwt.clock_skew
+    ENVIRONMENT                         = var.environment_name
+    IDEMPOTENCY_KEY_TTL                 = var.idempotency_key_ttl
   }
 
   providers = {
@@ -40,3 +73,138 @@ data "aws_ecr_repository" "lambda" {
   name     = "lpa-store/lambda/api-${each.key}"
   provider = aws.management
 }
+
+data "aws_dynamodb_table" "outbox" {
+  name     = var.dynamodb_name_outbox
+  provider = aws.region
+}
+
+resource "aws_lambda_event_source_mapping" "relay" {
+  event_source_arn                   = data.aws_dynamodb_table.outbox.stream_arn
+  function_name                      = module.lambda["relay"].function_name
+  starting_position                  = "TRIM_HORIZON"
+  batch_size                         = 10
+  maximum_batching_window_in_seconds = 1
+  maximum_retry_attempts             = 10
+  bisect_batch_on_function_error     = true
+  function_response_types            = ["ReportBatchItemFailures"]
+
+  # records that are still failing are dropped from the stream, so keep a
+  # note of them; the entries themselves are sent by the sweep
+  destination_config {
+    on_failure {
+      destination_arn = aws_sqs_queue.relay_failures.arn
+    }
+  }
+
+  # the outbox is a global table, so only relay entries written in this region
+  filter_criteria {
+    filter {
+      pattern = jsonencode({
+        eventName = ["INSERT"]
+        dynamodb = {
+          NewImage = {
+            region = { S = [data.aws_region.current.region] }
+          }
+        }
+      })
+    }
+  }
+
+  provider = aws.region
+}
+
+resource "aws_sqs_queue" "relay_failures" {
+  name                      = "${var.environment_name}-relay-failures"
+  sqs_managed_sse_enabled   = true
+  message_retention_seconds = 1209600
+
+  provider = aws.region
+}
+
+# sends outbox entries that are still unsent some time after they were created,
+# such as those whose stream records were dropped
+resource "aws_cloudwatch_event_rule" "relay_sweep" {
+  name                = "${var.environment_name}-relay-sweep"
+  schedule_expression = "rate(5 minutes)"
+
+  provider = aws.region
+}
+
+resource "aws_cloudwatch_event_target" "relay_sweep" {
+  rule = aws_cloudwatch_event_rule.relay_sweep.name
+  arn  = module.lambda["relay"].arn
+
+  provider = aws.region
+}
+
+resource "aws_lambda_permission" "relay_sweep" {
+  statement_id  = "AllowExecutionFromRelaySweep"
+  action        = "lambda:InvokeFunction"
+  function_name = module.lambda["relay"].function_name
+  principal     = "events.amazonaws.com"
+  source_arn    = aws_cloudwatch_event_rule.relay_sweep.arn
+
+  provider = aws.region
+}
+
+data "aws_dynamodb_table" "changes" {
+  name     = var.dynamodb_name_changes
+  provider = aws.region
+}
+
+resource "aws_lambda_event_source_mapping" "changeevents" {
+  count                              = var.changes_stream_enabled ? 1 : 0
+  event_source_arn                   = data.aws_dynamodb_table.changes.stream_arn
+  function_name                      = module.lambda["changeevents"].function_name
+  starting_position                  = "TRIM_HORIZON"
+  batch_size                         = 10
+  maximum_batching_window_in_seconds = 1
+  maximum_retry_attempts             = 10
+  bisect_batch_on_function_error     = true
+  function_response_types            = ["ReportBatchItemFailures"]
+
+  filter_criteria {
+    filter {
+      pattern = jsonencode({
+        eventName = ["INSERT"]
+      })
+    }
+  }
+
+  provider = aws.region
+}
+
+resource "aws_lambda_event_source_mapping" "webhooks" {
+  count                              = var.changes_stream_enabled ? 1 : 0
+  event_source_arn                   = data.aws_dynamodb_table.changes.stream_arn
+  function_name                      = module.lambda["webhooks"].function_name
+  starting_position                  = "TRIM_HORIZON"
+  batch_size                         = 10
+  maximum_batching_window_in_seconds = 1
+  maximum_retry_attempts             = 10
+  bisect_batch_on_function_error     = true
+  function_response_types            = ["ReportBatchItemFailures"]
+
+  filter_criteria {
+    filter {
+      pattern = jsonencode({
+        eventName = ["INSERT"]
+      })
+    }
+  }
+
+  provider = aws.region
+}
+
+# webhooks are delivered to callback URLs outside of the VPC
+resource "aws_security_group_rule" "webhooks_to_internet" {
+  type              = "egress"
+  protocol          = "tcp"
+  from_port         = 443
+  to_port           = 443
+  security_group_id = module.lambda["webhooks"].security_group_id
+  cidr_blocks       = ["0.0.0.0/0"]
+  description       = "Deliver webhooks to subscribers"
+  provider          = aws.region
+}
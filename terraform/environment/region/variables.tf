@@ -9,6 +9,14 @@ variable "environment" {
     account_name          = string
     allowed_arns          = list(string)
     allowed_wildcard_arns = optional(list(string), [])
+    cloud_events          = bool
+    emf_metrics           = bool
+    jwt = object({
+      audience         = string
+      max_lifetime     = string
+      clock_skew       = string
+      replay_detection = bool
+    })
   })
 }
 
@@ -18,6 +26,11 @@ variable "dns_weighting" {
   default     = 50
 }
 
+variable "changes_stream_enabled" {
+  description = "Whether to send change events and webhooks from the changes table stream. Replicated writes appear on the stream in every region, so only one region should"
+  type        = bool
+}
+
 variable "dynamodb_arn" {
   description = "ARN of DynamoDB table"
   type        = string
@@ -38,6 +51,66 @@ variable "dynamodb_name_changes" {
   type        = string
 }
 
+variable "dynamodb_arn_actors" {
+  description = "ARN of DynamoDB table mapping actors to LPAs"
+  type        = string
+}
+
+variable "dynamodb_name_actors" {
+  description = "Name of DynamoDB table mapping actors to LPAs"
+  type        = string
+}
+
+variable "dynamodb_arn_idempotency" {
+  description = "ARN of DynamoDB table storing responses to requests with an Idempotency-Key"
+  type        = string
+}
+
+variable "dynamodb_name_idempotency" {
+  description = "Name of DynamoDB table storing responses to requests with an Idempotency-Key"
+  type        = string
+}
+
+variable "dynamodb_arn_outbox" {
+  description = "ARN of DynamoDB table storing lpa-updated events waiting to be sent"
+  type        = string
+}
+
+variable "dynamodb_name_outbox" {
+  description = "Name of DynamoDB table storing lpa-updated events waiting to be sent"
+  type        = string
+}
+
+variable "dynamodb_arn_tokens" {
+  description = "ARN of DynamoDB table recording the IDs of JWTs that have been used"
+  type        = string
+}
+
+variable "dynamodb_name_tokens" {
+  description = "Name of DynamoDB table recording the IDs of JWTs that have been used"
+  type        = string
+}
+
+variable "dynamodb_arn_webhook_dead_letters" {
+  description = "ARN of DynamoDB table recording webhook deliveries that could not be made"
+  type        = string
+}
+
+variable "dynamodb_name_webhook_dead_letters" {
+  description = "Name of DynamoDB table recording webhook deliveries that could not be made"
+  type        = string
+}
+
+variable "dynamodb_arn_webhook_subscriptions" {
+  description = "ARN of DynamoDB table storing webhook subscriptions"
+  type        = string
+}
+
+variable "dynamodb_name_webhook_subscriptions" {
+  description = "Name of DynamoDB table storing webhook subscriptions"
+  type        = string
+}
+
 variable "environment_name" {
   description = "The name of the environment the region is deployed to"
   type        = string
@@ -54,6 +127,11 @@ variable "has_fixtures" {
   default     = false
 }
 
+variable "idempotency_key_ttl" {
+  description = "How long responses to requests with an Idempotency-Key are kept for, as a Go duration"
+  type        = string
+}
+
 variable "lpa_store_static_bucket" {
   description = "LPA Store Static bucket object for the region"
   type        = any
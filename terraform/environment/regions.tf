@@ -1,18 +1,32 @@
 module "eu_west_1" {
   source = "./region"
 
-  app_version                     = var.app_version
-  dns_weighting                   = 100
-  dynamodb_arn                    = aws_dynamodb_table.deeds_table.arn
-  dynamodb_arn_changes            = aws_dynamodb_table.changes_table.arn
-  dynamodb_name                   = aws_dynamodb_table.deeds_table.name
-  dynamodb_name_changes           = aws_dynamodb_table.changes_table.name
-  environment                     = local.environment
-  environment_name                = local.environment_name
-  event_bus                       = aws_cloudwatch_event_bus.main
-  has_fixtures                    = local.environment.has_fixtures
-  lpa_store_static_bucket         = module.s3_lpa_store_static_eu_west_1.bucket
-  lpa_store_static_bucket_kms_key = module.s3_lpa_store_static_eu_west_1.encryption_kms_key
+  app_version                         = var.app_version
+  changes_stream_enabled              = true
+  dns_weighting                       = 100
+  dynamodb_arn                        = aws_dynamodb_table.deeds_table.arn
+  dynamodb_arn_actors                 = aws_dynamodb_table.actors_table.arn
+  dynamodb_arn_changes                = aws_dynamodb_table.changes_table.arn
+  dynamodb_arn_idempotency            = aws_dynamodb_table.idempotency_table.arn
+  dynamodb_arn_outbox                 = aws_dynamodb_table.outbox_table.arn
+  dynamodb_arn_tokens                 = aws_dynamodb_table.tokens_table.arn
+  dynamodb_arn_webhook_dead_letters   = aws_dynamodb_table.webhook_dead_letters_table.arn
+  dynamodb_arn_webhook_subscriptions  = aws_dynamodb_table.webhook_subscriptions_table.arn
+  dynamodb_name                       = aws_dynamodb_table.deeds_table.name
+  dynamodb_name_actors                = aws_dynamodb_table.actors_table.name
+  dynamodb_name_changes               = aws_dynamodb_table.changes_table.name
+  dynamodb_name_idempotency           = aws_dynamodb_table.idempotency_table.name
+  dynamodb_name_outbox                = aws_dynamodb_table.outbox_table.name
+  dynamodb_name_tokens                = aws_dynamodb_table.tokens_table.name
+  dynamodb_name_webhook_dead_letters  = aws_dynamodb_table.webhook_dead_letters_table.name
+  dynamodb_name_webhook_subscriptions = aws_dynamodb_table.webhook_subscriptions_table.name
+  environment                         = local.environment
+  environment_name                    = local.environment_name
+  event_bus                           = aws_cloudwatch_event_bus.main
+  has_fixtures                        = local.environment.has_fixtures
+  idempotency_key_ttl                 = local.environment.idempotency_key_ttl
+  lpa_store_static_bucket             = module.s3_lpa_store_static_eu_west_1.bucket
+  lpa_store_static_bucket_kms_key     = module.s3_lpa_store_static_eu_west_1.encryption_kms_key
 
   providers = {
     aws.global     = aws.global
@@ -24,18 +38,32 @@ module "eu_west_1" {
 module "eu_west_2" {
   source = "./region"
 
-  app_version                     = var.app_version
-  dns_weighting                   = 0
-  dynamodb_arn                    = aws_dynamodb_table_replica.deeds_table.arn
-  dynamodb_arn_changes            = aws_dynamodb_table_replica.changes_table.arn
-  dynamodb_name                   = aws_dynamodb_table.deeds_table.name
-  dynamodb_name_changes           = aws_dynamodb_table.changes_table.name
-  environment                     = local.environment
-  environment_name                = local.environment_name
-  event_bus                       = aws_cloudwatch_event_bus.main
-  has_fixtures                    = false
-  lpa_store_static_bucket         = module.s3_lpa_store_static_eu_west_2.bucket
-  lpa_store_static_bucket_kms_key = module.s3_lpa_store_static_eu_west_2.encryption_kms_key
+  app_version                         = var.app_version
+  changes_stream_enabled              = false
+  dns_weighting                       = 0
+  dynamodb_arn                        = aws_dynamodb_table_replica.deeds_table.arn
+  dynamodb_arn_actors                 = aws_dynamodb_table_replica.actors_table.arn
+  dynamodb_arn_changes                = aws_dynamodb_table_replica.changes_table.arn
+  dynamodb_arn_idempotency            = aws_dynamodb_table_replica.idempotency_table.arn
+  dynamodb_arn_outbox                 = aws_dynamodb_table_replica.outbox_table.arn
+  dynamodb_arn_tokens                 = aws_dynamodb_table_replica.tokens_table.arn
+  dynamodb_arn_webhook_dead_letters   = aws_dynamodb_table.webhook_dead_letters_table.arn
+  dynamodb_arn_webhook_subscriptions  = aws_dynamodb_table.webhook_subscriptions_table.arn
+  dynamodb_name                       = aws_dynamodb_table.deeds_table.name
+  dynamodb_name_actors                = aws_dynamodb_table.actors_table.name
+  dynamodb_name_changes               = aws_dynamodb_table.changes_table.name
+  dynamodb_name_idempotency           = aws_dynamodb_table.idempotency_table.name
+  dynamodb_name_outbox                = aws_dynamodb_table.outbox_table.name
+  dynamodb_name_tokens                = aws_dynamodb_table.tokens_table.name
+  dynamodb_name_webhook_dead_letters  = aws_dynamodb_table.webhook_dead_letters_table.name
+  dynamodb_name_webhook_subscriptions = aws_dynamodb_table.webhook_subscriptions_table.name
+  environment                         = local.environment
+  environment_name                    = local.environment_name
+  event_bus                           = aws_cloudwatch_event_bus.main
+  has_fixtures                        = false
+  idempotency_key_ttl                 = local.environment.idempotency_key_ttl
+  lpa_store_static_bucket             = module.s3_lpa_store_static_eu_west_2.bucket
+  lpa_store_static_bucket_kms_key     = module.s3_lpa_store_static_eu_west_2.encryption_kms_key
 
   providers = {
     aws.global     = aws.global
@@ -33,6 +33,15 @@ variable "environments" {
       allowed_arns          = list(string)
       allowed_wildcard_arns = optional(list(string), [])
       target_event_buses    = map(string)
+      idempotency_key_ttl   = optional(string, "24h")
+      cloud_events          = optional(bool, false)
+      emf_metrics           = optional(bool, false)
+      jwt = optional(object({
+        audience         = optional(string, "")
+        max_lifetime     = optional(string, "1h")
+        clock_skew       = optional(string, "30s")
+        replay_detection = optional(bool, false)
+      }), {})
     })
   )
 }
@@ -8,7 +8,7 @@ resource "aws_lambda_function" "main" {
   image_uri     = var.ecr_image_uri
   package_type  = "Image"
   role          = aws_iam_role.lambda.arn
-  timeout       = 5
+  timeout       = var.timeout
   depends_on    = [aws_cloudwatch_log_group.lambda]
 
   tracing_config {
@@ -8,7 +8,17 @@ output "invoke_arn" {
   value       = aws_lambda_function.main.invoke_arn
 }
 
+output "arn" {
+  description = "ARN of Lambda function"
+  value       = aws_lambda_function.main.arn
+}
+
 output "function_name" {
   description = "Name of Lambda function"
   value       = aws_lambda_function.main.function_name
 }
+
+output "security_group_id" {
+  description = "ID of the security group attached to the Lambda Function"
+  value       = aws_security_group.lambda.id
+}
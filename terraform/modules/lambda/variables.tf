@@ -17,6 +17,12 @@ variable "cloudwatch_kms_key_id" {
   type        = string
 }
 
+variable "timeout" {
+  description = "The number of seconds the Lambda Function can run for"
+  type        = number
+  default     = 5
+}
+
 variable "environment_variables" {
   description = "A map that defines environment variables for the Lambda Function"
   type        = map(string)